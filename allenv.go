@@ -0,0 +1,244 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// environmentFileExtensions are the input formats recognized in an --all-environments directory
+var environmentFileExtensions = map[string]bool{".csv": true, ".yaml": true, ".yml": true, ".json": true, ".env": true}
+
+// environmentTarget is one environment of an --all-environments run and the file that defines it
+type environmentTarget struct {
+	Environment string
+	File        string
+	Remote      []Variable
+	Diff        DiffResult
+}
+
+// discoverEnvironmentFiles maps each input file in dir to the environment named by its base
+// name: vars/production.csv syncs the production environment
+func discoverEnvironmentFiles(dir string) ([]environmentTarget, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := []environmentTarget{}
+	files := map[string]string{}
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || !environmentFileExtensions[ext] || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		environment := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+		if other, ok := files[environment]; ok {
+			return nil, fmt.Errorf("%s and %s both define environment '%s'", other, entry.Name(), environment)
+		}
+		files[environment] = entry.Name()
+		targets = append(targets, environmentTarget{Environment: environment, File: filepath.Join(dir, entry.Name())})
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no .csv, .yaml, .json, or .env files in %s", dir)
+	}
+
+	sort.Slice(targets, func(i, j int) bool { return targets[i].Environment < targets[j].Environment })
+	return targets, nil
+}
+
+// handleAllEnvironments syncs every file in dir to its matching environment: all diffs are
+// shown first, one confirmation covers every environment, and a combined report ends the run
+func handleAllEnvironments(token, owner, repo, dir string) {
+	switch {
+	case *sourceSpec != "":
+		fatal(exitFailure, "--all-environments reads its files from %s and can't be combined with --source", dir)
+	case *pullMode || *mergeMode || *backupMode || *tuiMode || *strategy != StrategyLocalWins:
+		fatal(exitFailure, "--all-environments can't be combined with --pull, --merge, --backup, --tui, or --strategy")
+	}
+
+	targets, err := discoverEnvironmentFiles(dir)
+	if err != nil {
+		fatal(exitValidation, "Error: --all-environments: %v", err)
+	}
+	fmt.Printf("🎯 Target: %d environment(s) in %s/%s from %s\n", len(targets), owner, repo, dir)
+
+	// Environments in GitHub without a file are left alone; say so, since a typo'd file name looks the same
+	if existing, err := ListEnvironments(token, owner, repo); err == nil {
+		for _, name := range existing {
+			if !hasEnvironmentTarget(targets, name) {
+				fmt.Printf("ℹ️  Environment '%s' has no file in %s and is not changed\n", name, dir)
+			}
+		}
+	}
+
+	var fleet *FleetState
+	if !*diffMode {
+		fleet = fleetResumeState(FleetAllEnvironments, owner, repo, dir)
+		targets = unfinishedEnvironmentTargets(fleet, owner, repo, targets)
+	}
+
+	needWrite := !*diffMode
+	for i := range targets {
+		t := &targets[i]
+		fmt.Printf("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n🌐 %s ← %s\n", t.Environment, t.File)
+
+		ensureEnvironment(token, owner, repo, t.Environment, needWrite)
+		if needWrite {
+			err = AcquireTargetLock(token, owner, repo, t.Environment)
+			if err != nil {
+				fatal(lockExitCode(err), "%v", err)
+			}
+		}
+
+		*inputFile = t.File
+		variables, source, err := LoadDesiredVariables(token, owner, repo, t.Environment)
+		if err != nil {
+			fatal(exitValidation, "Error: %s: %v", t.Environment, err)
+		}
+		checkSecretLeaks(source, variables)
+
+		t.Remote, err = FetchGitHubVariables(token, owner, repo, t.Environment)
+		if err != nil {
+			fatal(exitFailure, "Error fetching variables of %s: %v", t.Environment, err)
+		}
+		t.Diff = scopeToTeam(CompareSets(variables, t.Remote))
+		emitDiffComputed(owner, repo, t.Environment, t.Diff)
+		DisplayDiffSummary(t.Diff)
+		DisplayDetailedDiff(t.Diff)
+		enforceSchema(owner, repo, t.Environment, variables, t.Remote)
+		enforcePolicy(owner, repo, t.Environment, variables, t.Diff)
+	}
+
+	if *diffMode {
+		reports := []*SyncReport{}
+		for _, t := range targets {
+			report := driftReport(owner, repo, t.Environment, t.Diff)
+			reports = append(reports, report)
+			if report.HasDrift() {
+				sendNotifications(report)
+			}
+		}
+		displayMultiTargetReport(reports, targets)
+		fmt.Println("ℹ️  Diff mode: No changes were made")
+		exit(0)
+	}
+
+	pending := 0
+	for _, t := range targets {
+		pending += len(t.Diff.New) + len(t.Diff.Updated)
+	}
+	if pending == 0 {
+		fleet.Finish(true)
+		fmt.Println("\n✅ No changes to sync. All environments are up to date!")
+		exit(0)
+	}
+	for _, t := range targets {
+		if len(t.Diff.New)+len(t.Diff.Updated) > 0 {
+			runPreSyncHooks(owner, repo, t.Environment, t.Diff)
+			requireApproval(token, owner, repo, t.Environment, syncPlan(t.Diff, nil))
+		}
+	}
+	if !askYesNo(fmt.Sprintf("\n⚠️  Sync %d variable(s) across %d environment(s) of %s/%s?", pending, len(targets), owner, repo)) {
+		fmt.Println("\n❌ Sync cancelled by user")
+		exit(exitCancelled)
+	}
+
+	fmt.Print("\n🚀 Starting sync...\n")
+	client := newRESTClient(token)
+	reports := []*SyncReport{}
+	for _, t := range targets {
+		if len(t.Diff.New)+len(t.Diff.Updated) == 0 {
+			reports = append(reports, newSyncReport(owner, repo, t.Environment, "sync"))
+			fleet.MarkCompleted(targetName(owner, repo, t.Environment))
+			continue
+		}
+		fmt.Printf("\n🌐 %s\n", t.Environment)
+
+		if !*noBackup {
+			backupFile, err := BackupGitHubVariables(token, owner, repo, t.Environment)
+			if err != nil {
+				fatal(exitFailure, "Backup of %s failed, stopping before it is changed: %v", t.Environment, err)
+			}
+			fmt.Printf("💾 Backup saved: %s\n", backupFile)
+		}
+		err := VerifyRemoteUnchanged(token, owner, repo, t.Environment, t.Remote)
+		if err != nil {
+			fatal(exitFailure, "Sync of %s aborted: %v", t.Environment, err)
+		}
+
+		report := applyDiff(client, newSyncReport(owner, repo, t.Environment, "sync"), t.Diff, func(format string, args ...interface{}) {
+			fmt.Printf(format+"\n", args...)
+		}, nil)
+		fmt.Printf("✅ Created %d, Updated %d, Failed %d\n", len(report.Created), len(report.Updated), len(report.Failed))
+		writeSyncManifest(token, report)
+		sendNotifications(report)
+		runPostSyncHooks(report)
+		reports = append(reports, report)
+		if len(report.Failed)+len(report.NotSynced) == 0 {
+			fleet.MarkCompleted(targetName(owner, repo, t.Environment))
+		}
+	}
+
+	displayMultiTargetReport(reports, targets)
+	complete := true
+	for _, report := range reports {
+		complete = complete && len(report.Failed)+len(report.NotSynced) == 0
+	}
+	fleet.Finish(complete)
+	if !complete {
+		runError = "some variables failed to sync"
+		exit(exitPartial)
+	}
+}
+
+// unfinishedEnvironmentTargets leaves out the environments an earlier run already synced
+func unfinishedEnvironmentTargets(fleet *FleetState, owner, repo string, targets []environmentTarget) []environmentTarget {
+	remaining := []environmentTarget{}
+	for _, t := range targets {
+		if fleet.Done(targetName(owner, repo, t.Environment)) {
+			fmt.Printf("⏭️  %s was synced by the earlier run; skipping\n", t.Environment)
+			continue
+		}
+		remaining = append(remaining, t)
+	}
+	if len(remaining) == 0 {
+		fleet.Finish(true)
+		fmt.Println("\n✅ Nothing left to resume")
+		exit(0)
+	}
+	return remaining
+}
+
+func hasEnvironmentTarget(targets []environmentTarget, environment string) bool {
+	for _, t := range targets {
+		if t.Environment == environment {
+			return true
+		}
+	}
+	return false
+}
+
+// displayMultiTargetReport prints one row per environment with its counts
+func displayMultiTargetReport(reports []*SyncReport, targets []environmentTarget) {
+	verb := "Created"
+	if len(reports) > 0 && reports[0].Mode == "diff" {
+		verb = "New"
+	}
+
+	fmt.Println("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Println("📊 ALL ENVIRONMENTS")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Printf("%-24s %8s %8s %8s %15s\n", "Environment", verb, "Updated", "Failed", "Only in GitHub")
+	totals := [4]int{}
+	for i, r := range reports {
+		counts := [4]int{len(r.Created), len(r.Updated), len(r.Failed), len(targets[i].Diff.Deleted)}
+		fmt.Printf("%-24s %8d %8d %8d %15d\n", r.Environment, counts[0], counts[1], counts[2], counts[3])
+		for i, n := range counts {
+			totals[i] += n
+		}
+	}
+	fmt.Printf("%-24s %8d %8d %8d %15d\n", "Total", totals[0], totals[1], totals[2], totals[3])
+}
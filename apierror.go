@@ -0,0 +1,161 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// APIError is an error response from the GitHub API, explained: what went wrong for the
+// common statuses, and how to fix it, instead of the raw JSON body
+type APIError struct {
+	Status      int
+	Message     string // GitHub's "message", or the body when it isn't JSON
+	Explanation string
+	Fix         string // Empty when there's nothing specific to suggest
+}
+
+func (e *APIError) Error() string {
+	s := fmt.Sprintf("%s (status %d)", e.Explanation, e.Status)
+	if e.Fix != "" {
+		s += "\n   Fix: " + e.Fix
+	}
+	return s
+}
+
+// variablesPathPattern finds the target in a variables API path; repositoryPathPattern the
+// repository in any other
+var (
+	variablesPathPattern  = regexp.MustCompile(`^/(?:repos/([^/]+)/([^/]+)/(?:environments/([^/]+)|actions)|orgs/([^/]+)/actions)/variables(?:/([^/]+))?`)
+	repositoryPathPattern = regexp.MustCompile(`^/repos/([^/]+)/([^/]+)`)
+)
+
+// newAPIError explains an unexpected GitHub API response. The target and variable name come
+// from the request URL, so the message can say which environment or repository is missing.
+func newAPIError(resp *http.Response, body []byte) *APIError {
+	e := &APIError{Status: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+	var parsed struct {
+		Message string `json:"message"`
+		Errors  []struct {
+			Field   string `json:"field"`
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	details := []string{}
+	if json.Unmarshal(body, &parsed) == nil && parsed.Message != "" {
+		e.Message = parsed.Message
+		for _, fieldErr := range parsed.Errors {
+			switch {
+			case fieldErr.Message != "":
+				details = append(details, fieldErr.Message)
+			case fieldErr.Field != "":
+				details = append(details, fieldErr.Field+" "+fieldErr.Code)
+			}
+		}
+	}
+
+	var owner, repo, environment, org, name string
+	variables := false // Variable-specific advice only fits the variables endpoints
+	if resp.Request != nil {
+		path := apiPath(resp.Request)
+		if m := variablesPathPattern.FindStringSubmatch(path); m != nil {
+			owner, repo, environment, org, name = m[1], m[2], m[3], m[4], m[5]
+			variables = true
+		} else if m := repositoryPathPattern.FindStringSubmatch(path); m != nil {
+			owner, repo = m[1], m[2]
+		}
+	}
+	token := ""
+	if resp.Request != nil {
+		token = strings.TrimPrefix(resp.Request.Header.Get("Authorization"), "Bearer ")
+	}
+
+	switch resp.StatusCode {
+	case 401:
+		e.Explanation = "GitHub rejected the token: it's invalid, expired, or revoked"
+		e.Fix = "create a new token (or run login again) and check that GITHUB_TOKEN holds the whole of it"
+	case 403:
+		sso, ssoBlocked := ssoRequired(resp, body)
+		switch {
+		case ssoBlocked:
+			if owner == "" {
+				owner = org
+			}
+			e.Explanation, e.Fix = sso.Explain(owner)
+		case resp.Header.Get("X-RateLimit-Remaining") == "0" || strings.Contains(strings.ToLower(e.Message), "rate limit"):
+			e.Explanation = "GitHub's API rate limit is exhausted"
+			if reset := resp.Header.Get("X-RateLimit-Reset"); reset != "" {
+				e.Explanation += " until " + reset + " (Unix time)"
+			}
+			e.Fix = "wait for the limit to reset, or slow down with --throttle"
+		case org != "":
+			e.Explanation = fmt.Sprintf("the token isn't allowed to manage variables of organization %s: %s", org, e.Message)
+			e.Fix = "use an organization admin's token with the 'admin:org' scope, or the organization permission Variables"
+		default:
+			permission := "Variables: Read and write"
+			if environment != "" {
+				permission = "Environments: Read and write"
+			}
+			e.Explanation = "the token isn't allowed to do this: " + e.Message
+			if variables || resp.Header.Get("X-Accepted-GitHub-Permissions") != "" {
+				e.Fix = strings.TrimPrefix(missingPermissionHint(resp, token, "repo", permission), "   Fix: ")
+			}
+		}
+	case 404:
+		switch {
+		case environment != "" && name == "":
+			e.Explanation = fmt.Sprintf("environment '%s' doesn't exist in %s/%s, or the token can't see it", environment, owner, repo)
+			e.Fix = "create it under Settings → Environments (or with --create-environment), or check the name's spelling and case"
+		case name != "":
+			e.Explanation = fmt.Sprintf("variable %s, or its %s, doesn't exist (or the token can't see it)", name, targetKindName(owner, repo, environment, org))
+		case org != "":
+			e.Explanation = fmt.Sprintf("organization %s doesn't exist, or the token can't see its variables", org)
+		case owner != "":
+			e.Explanation = fmt.Sprintf("repository %s/%s doesn't exist, or the token has no access to it", owner, repo)
+			e.Fix = strings.TrimPrefix(missingPermissionHint(resp, token, "repo", "Metadata: Read-only"), "   Fix: ")
+		default:
+			e.Explanation = "GitHub API returned 404: " + e.Message
+		}
+	case 422:
+		e.Explanation = "GitHub rejected the request: " + e.Message
+		if len(details) > 0 {
+			e.Explanation += ": " + strings.Join(details, "; ")
+		}
+		if variables {
+			e.Explanation = strings.Replace(e.Explanation, "the request", "the variable", 1)
+			e.Fix = "names may only contain letters, digits, and underscores, mustn't start with a digit or GITHUB_, and values are limited to 48 KB"
+		}
+	default:
+		e.Explanation = "GitHub API error: " + e.Message
+		if len(details) > 0 {
+			e.Explanation += ": " + strings.Join(details, "; ")
+		}
+	}
+	return e
+}
+
+// apiPath is a request's path relative to the API root, which has a prefix on GHES (/api/v3)
+func apiPath(req *http.Request) string {
+	path := req.URL.Path
+	if i := strings.Index(path, "/repos/"); i >= 0 {
+		return path[i:]
+	}
+	if i := strings.Index(path, "/orgs/"); i >= 0 {
+		return path[i:]
+	}
+	return path
+}
+
+// targetKindName names what a variable lives in, for error messages
+func targetKindName(owner, repo, environment, org string) string {
+	switch {
+	case org != "":
+		return "organization " + org
+	case environment != "":
+		return fmt.Sprintf("environment %s in %s/%s", environment, owner, repo)
+	}
+	return fmt.Sprintf("repository %s/%s", owner, repo)
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// appTokenRefreshMargin refreshes installation tokens this long before they expire
+const appTokenRefreshMargin = 5 * time.Minute
+
+// appTokenProvider mints and refreshes GitHub App installation tokens (valid for one hour)
+type appTokenProvider struct {
+	appID          string
+	installationID string
+	key            *rsa.PrivateKey
+	base           http.RoundTripper // Transport used to mint tokens (bypasses appAuthTransport)
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// newAppTokenProviderFromEnv configures GitHub App auth from GITHUB_APP_ID,
+// GITHUB_APP_INSTALLATION_ID, and GITHUB_APP_PRIVATE_KEY(_FILE). It returns nil
+// when App auth isn't configured.
+func newAppTokenProviderFromEnv() (*appTokenProvider, error) {
+	appID := os.Getenv("GITHUB_APP_ID")
+	installationID := os.Getenv("GITHUB_APP_INSTALLATION_ID")
+	if appID == "" && installationID == "" {
+		return nil, nil
+	}
+	if appID == "" || installationID == "" {
+		return nil, fmt.Errorf("both GITHUB_APP_ID and GITHUB_APP_INSTALLATION_ID must be set for GitHub App auth")
+	}
+
+	keyPEM := []byte(os.Getenv("GITHUB_APP_PRIVATE_KEY"))
+	if path := os.Getenv("GITHUB_APP_PRIVATE_KEY_FILE"); path != "" {
+		var err error
+		keyPEM, err = os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read GitHub App private key: %w", err)
+		}
+	}
+	if len(keyPEM) == 0 {
+		return nil, fmt.Errorf("GITHUB_APP_PRIVATE_KEY or GITHUB_APP_PRIVATE_KEY_FILE must be set for GitHub App auth")
+	}
+
+	key, err := parseRSAPrivateKey(keyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	return &appTokenProvider{appID: appID, installationID: installationID, key: key}, nil
+}
+
+// parseRSAPrivateKey parses a PKCS#1 or PKCS#8 PEM-encoded RSA key
+func parseRSAPrivateKey(data []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("GitHub App private key is not PEM encoded")
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub App private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("GitHub App private key is not an RSA key")
+	}
+	return key, nil
+}
+
+// Token returns a valid installation token, refreshing it when close to expiry
+func (p *appTokenProvider) Token() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token != "" && time.Until(p.expiresAt) > appTokenRefreshMargin {
+		return p.token, nil
+	}
+	return p.refreshLocked()
+}
+
+// ForceRefresh discards the current token (e.g. after a 401) and mints a new one
+func (p *appTokenProvider) ForceRefresh() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.refreshLocked()
+}
+
+func (p *appTokenProvider) refreshLocked() (string, error) {
+	jwt, err := p.signJWT(time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	url := fmt.Sprintf("%s/app/installations/%s/access_tokens", githubAPIURL, p.installationID)
+	req, err := http.NewRequest("POST", url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	// Use the base transport so this request isn't rewritten by appAuthTransport
+	base := p.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := (&http.Client{Transport: base, Timeout: httpClient.Timeout}).Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != 201 {
+		return "", fmt.Errorf("failed to create installation token: GitHub API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var response struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return "", err
+	}
+
+	p.token = response.Token
+	p.expiresAt = response.ExpiresAt
+	redactor.Add(p.token)
+	return p.token, nil
+}
+
+// signJWT creates the short-lived RS256 JWT that authenticates as the App itself
+func (p *appTokenProvider) signJWT(now time.Time) (string, error) {
+	return signRS256JWT(p.key, map[string]interface{}{
+		"iat": now.Add(-60 * time.Second).Unix(), // allow for clock drift
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": p.appID,
+	})
+}
+
+// signRS256JWT signs claims as a JWT with an RSA key
+func signRS256JWT(key *rsa.PrivateKey, claims map[string]interface{}) (string, error) {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","typ":"JWT"}`))
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := header + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// tokenProvider hands out short-lived tokens: GitHub App installation tokens, or tokens
+// exchanged for the workflow's OIDC token
+type tokenProvider interface {
+	Token() (string, error)
+	ForceRefresh() (string, error)
+}
+
+// appAuthTransport injects a fresh short-lived token into every GitHub API request
+// and retries once with a new token if GitHub answers 401 (expired mid-run)
+type appAuthTransport struct {
+	base     http.RoundTripper
+	provider tokenProvider
+}
+
+func (t *appAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isGitHubAPIRequest(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	token, err := t.provider.Token()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(withBearer(req, token))
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	// The body can only be replayed if the request supports it
+	if req.Body != nil && req.GetBody == nil {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	token, err = t.provider.ForceRefresh()
+	if err != nil {
+		return nil, err
+	}
+	retry := withBearer(req, token)
+	if req.GetBody != nil {
+		retry.Body, err = req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return t.base.RoundTrip(retry)
+}
+
+// withBearer clones a request with a different bearer token
+func withBearer(req *http.Request, token string) *http.Request {
+	clone := req.Clone(req.Context())
+	clone.Header.Set("Authorization", "Bearer "+strings.TrimSpace(token))
+	return clone
+}
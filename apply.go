@@ -0,0 +1,57 @@
+package main
+
+import "time"
+
+// applyDiff creates the new variables and updates the changed ones in a diff (remote-only
+// variables are left alone), recording each write in report as it finishes so a run that
+// exits partway still reports it. Failures are logged with logf, and wrote, when set, is
+// called after each write. It stops early, leaving the rest not synced, when the run's
+// deadline passes or the failure breaker trips.
+func applyDiff(client GitHubClient, report *SyncReport, diff DiffResult, logf func(format string, args ...interface{}),
+	wrote func(name, action string, err error, duration time.Duration)) *SyncReport {
+	owner, repo, environment := report.Owner, report.Repo, report.Environment
+	writes := append([]Variable{}, diff.New...)
+	for _, c := range diff.Updated {
+		writes = append(writes, Variable{Name: c.Name, Value: c.NewValue})
+	}
+
+	breaker := newFailureBreaker()
+	for i, v := range writes {
+		if deadlineExceeded() {
+			for _, rest := range writes[i:] {
+				report.NotSynced = append(report.NotSynced, rest.Name)
+			}
+			logf("⏰ Deadline of %v exceeded; stopping with %d variable(s) not synced", *runDeadline, len(report.NotSynced))
+			break
+		}
+
+		started := time.Now()
+		err := syncVariable(client, owner, repo, environment, v)
+		elapsed := time.Since(started)
+		action := "update"
+		if i < len(diff.New) {
+			action = "create"
+		}
+		emitVariableSynced(owner, repo, environment, v.Name, action, err, elapsed)
+		switch {
+		case err != nil:
+			logf("❌ Error syncing variable '%s': %v", v.Name, err)
+			report.Failed = append(report.Failed, SyncFailure{Name: v.Name, Error: safeValue(err.Error())})
+		case action == "create":
+			report.Created = append(report.Created, v.Name)
+		default:
+			report.Updated = append(report.Updated, v.Name)
+		}
+		if wrote != nil {
+			wrote(v.Name, action, err, elapsed)
+		}
+		if reason := breaker.Record(err); reason != "" {
+			for _, rest := range writes[i+1:] {
+				report.NotSynced = append(report.NotSynced, rest.Name)
+			}
+			logf("🛑 Stopping with %d variable(s) not synced: %s", len(report.NotSynced), reason)
+			break
+		}
+	}
+	return report
+}
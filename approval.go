@@ -0,0 +1,535 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// approvalCodePrefix versions the approval code format
+const approvalCodePrefix = "sga1"
+
+// approvalRequestPrefix versions the format of the request a refused run prints for an approver
+const approvalRequestPrefix = "sgr1"
+
+// defaultApprovalTTL is how long an approval code is valid when the config doesn't say
+const defaultApprovalTTL = time.Hour
+
+// ApprovalConfig requires a second person to approve applies to matching targets
+type ApprovalConfig struct {
+	Targets       []string          `json:"targets"`        // Glob patterns: an environment name, owner/repo, or owner/repo:environment
+	Approvers     map[string]string `json:"approvers"`      // GitHub login -> Ed25519 public key (PEM file) their codes are signed with
+	TTL           string            `json:"ttl"`            // How long a code stays valid (default 1h)
+	GitHubReviews bool              `json:"github_reviews"` // Also accept an approved deployment review of the current workflow run
+}
+
+// validate checks the patterns and TTL when the config is loaded
+func (a ApprovalConfig) validate() error {
+	for _, pattern := range a.Targets {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid target pattern %q: %w", pattern, err)
+		}
+	}
+	if a.TTL != "" {
+		if _, err := time.ParseDuration(a.TTL); err != nil {
+			return fmt.Errorf("invalid ttl %q: %w", a.TTL, err)
+		}
+	}
+	if len(a.Targets) > 0 && len(a.Approvers) == 0 && !a.GitHubReviews {
+		return fmt.Errorf("targets need approvers or github_reviews, or nothing could approve them")
+	}
+	return nil
+}
+
+func (a ApprovalConfig) ttl() time.Duration {
+	if ttl, err := time.ParseDuration(a.TTL); err == nil && a.TTL != "" {
+		return ttl
+	}
+	return defaultApprovalTTL
+}
+
+// approvalRequired reports whether applying to a target needs a second person's approval
+func approvalRequired(owner, repo, environment string) bool {
+	candidates := []string{owner + "/" + repo}
+	if environment != "" {
+		candidates = []string{environment, owner + "/" + repo + ":" + environment}
+	}
+	for _, pattern := range config.Approval.Targets {
+		for _, candidate := range candidates {
+			if matched, _ := filepath.Match(pattern, candidate); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// approvalPayload is what an approver signs: the exact changes approved for one target
+type approvalPayload struct {
+	Target   string    `json:"target"`
+	Plan     string    `json:"plan"` // planDigest of the approved changes
+	Approver string    `json:"approver"`
+	Expires  time.Time `json:"expires"`
+	Nonce    string    `json:"nonce"`
+}
+
+// plannedChange is one write of a plan to approve. The digest covers the value hashes; the
+// shown values are what the approver sees, as they appear on screen.
+type plannedChange struct {
+	Op       string `json:"op"` // "+" create, "~" update, "-" delete
+	Name     string `json:"name"`
+	Old      string `json:"old,omitempty"` // SHA-256 of the current value (updates)
+	New      string `json:"new,omitempty"` // SHA-256 of the value written (creates and updates)
+	ShownOld string `json:"shown_old,omitempty"`
+	Shown    string `json:"shown,omitempty"`
+}
+
+// planChanges lists what a plan writes. New and Updated are written and, unlike in a sync's
+// diff, Deleted are deleted: build sync plans with syncPlan.
+func planChanges(plan DiffResult) []plannedChange {
+	changes := []plannedChange{}
+	for _, v := range plan.New {
+		changes = append(changes, plannedChange{Op: "+", Name: v.Name, New: sha256Hex([]byte(v.Value)), Shown: shownValue(v.Name, v.Value)})
+	}
+	for _, c := range plan.Updated {
+		changes = append(changes, plannedChange{Op: "~", Name: c.Name, Old: sha256Hex([]byte(c.OldValue)), New: sha256Hex([]byte(c.NewValue)),
+			ShownOld: shownValue(c.Name, c.OldValue), Shown: shownValue(c.Name, c.NewValue)})
+	}
+	for _, v := range plan.Deleted {
+		changes = append(changes, plannedChange{Op: "-", Name: v.Name, ShownOld: shownValue(v.Name, v.Value)})
+	}
+	return changes
+}
+
+// line is the change as it goes into the plan digest
+func (c plannedChange) line() string {
+	switch c.Op {
+	case "~":
+		return "~" + nameKey(c.Name) + "\x00" + c.Old + "\x00" + c.New
+	case "-":
+		return "-" + nameKey(c.Name)
+	}
+	return "+" + nameKey(c.Name) + "\x00" + c.New
+}
+
+// syncPlan is what a sync writes: the new and updated variables of its diff, and its renames.
+// Variables only in GitHub are never deleted by a sync, so they aren't part of it.
+func syncPlan(diff DiffResult, renames []Rename) DiffResult {
+	plan := DiffResult{New: append([]Variable{}, diff.New...), Updated: diff.Updated}
+	for _, r := range renames {
+		plan.New = append(plan.New, Variable{Name: r.New, Value: r.Value})
+		plan.Deleted = append(plan.Deleted, Variable{Name: r.Old, Value: r.Value})
+	}
+	return plan
+}
+
+// planDigest fingerprints the changes a plan would apply, so an approval covers exactly them
+// and is used up once they're applied
+func planDigest(owner, repo, environment string, plan DiffResult) string {
+	return changesDigest(targetName(owner, repo, environment), planChanges(plan))
+}
+
+func changesDigest(target string, changes []plannedChange) string {
+	lines := []string{}
+	for _, c := range changes {
+		lines = append(lines, c.line())
+	}
+	sort.Strings(lines)
+	return sha256Hex([]byte(target + "\n" + strings.Join(lines, "\n")))
+}
+
+// approvalRequest is printed by a run refused for lack of approval, so an approver can review
+// and sign its exact changes with approve --request, whatever command made them
+type approvalRequest struct {
+	Owner       string          `json:"owner"`
+	Repo        string          `json:"repo,omitempty"`
+	Environment string          `json:"environment,omitempty"`
+	Changes     []plannedChange `json:"changes"`
+}
+
+func encodeApprovalRequest(owner, repo, environment string, plan DiffResult) string {
+	data, _ := json.Marshal(approvalRequest{Owner: owner, Repo: repo, Environment: environment, Changes: planChanges(plan)})
+	return approvalRequestPrefix + "." + base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeApprovalRequest(code string) (*approvalRequest, error) {
+	encoded, ok := strings.CutPrefix(strings.TrimSpace(code), approvalRequestPrefix+".")
+	if !ok {
+		return nil, fmt.Errorf("not an approval request")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("not an approval request")
+	}
+	var request approvalRequest
+	if err := json.Unmarshal(data, &request); err != nil || request.Owner == "" || len(request.Changes) == 0 {
+		return nil, fmt.Errorf("not an approval request")
+	}
+	for _, c := range request.Changes {
+		hashed := len(c.New) == 64 && (c.Op == "+" || len(c.Old) == 64)
+		if c.Name == "" || (c.Op != "-" && !hashed) || (c.Op != "+" && c.Op != "~" && c.Op != "-") {
+			return nil, fmt.Errorf("not an approval request")
+		}
+	}
+	return &request, nil
+}
+
+// encodeApprovalCode signs a payload into a code that can be pasted to the operator applying it
+func encodeApprovalCode(payload approvalPayload, key ed25519.PrivateKey) (string, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+	signature := ed25519.Sign(key, data)
+	return approvalCodePrefix + "." + base64.RawURLEncoding.EncodeToString(data) + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// decodeApprovalCode checks a code's signature against the approver it names and returns
+// its payload
+func decodeApprovalCode(code string) (*approvalPayload, error) {
+	parts := strings.Split(strings.TrimSpace(code), ".")
+	if len(parts) != 3 || parts[0] != approvalCodePrefix {
+		return nil, fmt.Errorf("not an approval code")
+	}
+	data, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("not an approval code")
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("not an approval code")
+	}
+	var payload approvalPayload
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return nil, fmt.Errorf("not an approval code")
+	}
+
+	keyFile, ok := config.Approval.Approvers[payload.Approver]
+	if !ok {
+		return nil, fmt.Errorf("%s is not an approver", payload.Approver)
+	}
+	key, err := loadManifestPublicKey(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("approver %s: %w", payload.Approver, err)
+	}
+	if !ed25519.Verify(key, data, signature) {
+		return nil, fmt.Errorf("signature doesn't match %s's key", payload.Approver)
+	}
+	return &payload, nil
+}
+
+// approvalCodes returns the codes from --approval, or SYNC_APPROVAL_CODE
+func approvalCodes() []string {
+	value := *approvalCode
+	if value == "" {
+		value = os.Getenv("SYNC_APPROVAL_CODE")
+	}
+	return splitApprovalCodes(value)
+}
+
+// splitApprovalCodes splits comma-separated approval codes
+func splitApprovalCodes(value string) []string {
+	codes := []string{}
+	for _, code := range strings.Split(value, ",") {
+		if code = strings.TrimSpace(code); code != "" {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+// approvedWrites are the writes this run's approvals cover, per target: "set NAME <value hash>"
+// and "delete NAME"
+var (
+	approvedWrites   = map[string]map[string]bool{}
+	approvedWritesMu sync.Mutex
+)
+
+func approvedWriteKey(name string, value *string) string {
+	if value == nil {
+		return "delete " + nameKey(name)
+	}
+	return "set " + nameKey(name) + " " + sha256Hex([]byte(*value))
+}
+
+// grantApproval lets an approved plan's writes through checkWriteApproved
+func grantApproval(owner, repo, environment string, plan DiffResult) {
+	approvedWritesMu.Lock()
+	defer approvedWritesMu.Unlock()
+	target := targetName(owner, repo, environment)
+	if approvedWrites[target] == nil {
+		approvedWrites[target] = map[string]bool{}
+	}
+	for _, v := range plan.New {
+		approvedWrites[target][approvedWriteKey(v.Name, &v.Value)] = true
+	}
+	for _, c := range plan.Updated {
+		approvedWrites[target][approvedWriteKey(c.Name, &c.NewValue)] = true
+	}
+	for _, v := range plan.Deleted {
+		approvedWrites[target][approvedWriteKey(v.Name, nil)] = true
+	}
+}
+
+// checkWriteApproved refuses a write (a delete when value is nil) to a protected target that no
+// approval of this run covers. restClient checks every write, so no command can change a
+// protected target without going through checkApproval first.
+func checkWriteApproved(owner, repo, environment, name string, value *string) error {
+	if !approvalRequired(owner, repo, environment) {
+		return nil
+	}
+	approvedWritesMu.Lock()
+	defer approvedWritesMu.Unlock()
+	target := targetName(owner, repo, environment)
+	if !approvedWrites[target][approvedWriteKey(name, value)] {
+		return fmt.Errorf("%s needs a second person's approval, and this change to %s wasn't approved", target, name)
+	}
+	return nil
+}
+
+// approvalError is why a plan for a protected target isn't approved
+type approvalError struct {
+	Target   string
+	Request  string // For approve --request
+	Problems []string
+}
+
+func (e *approvalError) Error() string {
+	message := "applying to " + e.Target + " needs a second person's approval"
+	if len(e.Problems) > 0 {
+		message += ": " + strings.Join(e.Problems, "; ")
+	}
+	return message
+}
+
+// checkApproval returns an *approvalError unless the target isn't protected, or someone other
+// than the person applying approved exactly the plan's changes, with one of the signed codes
+// or a deployment review. An approved plan's writes are then let through.
+func checkApproval(token, owner, repo, environment string, plan DiffResult, codes []string) error {
+	if !approvalRequired(owner, repo, environment) || len(plan.New)+len(plan.Updated)+len(plan.Deleted) == 0 {
+		return nil
+	}
+	target := targetName(owner, repo, environment)
+	applier := auditIdentity(token)
+	digest := planDigest(owner, repo, environment, plan)
+
+	problems := []string{}
+	for _, code := range codes {
+		payload, err := decodeApprovalCode(code)
+		switch {
+		case err != nil:
+			problems = append(problems, err.Error())
+		case payload.Target != target:
+			continue // For another target of the same run
+		case payload.Plan != digest:
+			problems = append(problems, fmt.Sprintf("%s approved different changes (re-run approve against the current state)", payload.Approver))
+		case time.Now().After(payload.Expires):
+			problems = append(problems, fmt.Sprintf("%s's approval expired at %s", payload.Approver, payload.Expires.Local().Format("2006-01-02 15:04")))
+		case sameActor(payload.Approver, applier):
+			problems = append(problems, fmt.Sprintf("%s can't approve their own changes", payload.Approver))
+		default:
+			fmt.Printf("✅ Approved by %s for %s\n", payload.Approver, target)
+			grantApproval(owner, repo, environment, plan)
+			return nil
+		}
+	}
+
+	if config.Approval.GitHubReviews && environment != "" {
+		reviewer, err := deploymentReviewApprover(token, owner, repo, environment, applier)
+		if err != nil {
+			problems = append(problems, err.Error())
+		} else if reviewer != "" {
+			fmt.Printf("✅ Approved by %s in a deployment review of this workflow run\n", reviewer)
+			grantApproval(owner, repo, environment, plan)
+			return nil
+		}
+	}
+	return &approvalError{Target: target, Request: encodeApprovalRequest(owner, repo, environment, plan), Problems: problems}
+}
+
+// requireApproval stops the run before a plan is applied to a protected target without approval
+func requireApproval(token, owner, repo, environment string, plan DiffResult) {
+	missing, ok := checkApproval(token, owner, repo, environment, plan, approvalCodes()).(*approvalError)
+	if !ok {
+		return
+	}
+	runError = "approval required for " + missing.Target
+	fmt.Printf("❌ Applying to %s needs a second person's approval\n", missing.Target)
+	for _, problem := range missing.Problems {
+		fmt.Printf("   - %s\n", problem)
+	}
+	fmt.Println("   Ask an approver to run `approve --request` with this request (or `approve` against the same input),")
+	fmt.Println("   and pass the code they get with --approval:")
+	fmt.Printf("\n%s\n\n", missing.Request)
+	exit(exitValidation)
+}
+
+// sameActor compares GitHub logins, which are case-insensitive
+func sameActor(a, b string) bool {
+	return strings.EqualFold(a, b)
+}
+
+// deploymentReviewApprover returns who approved the current workflow run's deployment to the
+// environment, when that's someone other than the run's actor and the applier
+func deploymentReviewApprover(token, owner, repo, environment, applier string) (string, error) {
+	runID := os.Getenv("GITHUB_RUN_ID")
+	if runID == "" {
+		return "", nil
+	}
+	var reviews []struct {
+		State        string `json:"state"`
+		Environments []struct {
+			Name string `json:"name"`
+		} `json:"environments"`
+		User struct {
+			Login string `json:"login"`
+		} `json:"user"`
+	}
+	url := fmt.Sprintf("%s/repos/%s/%s/actions/runs/%s/approvals", githubAPIURL, owner, repo, runID)
+	if err := githubGetJSON(token, url, &reviews); err != nil {
+		return "", fmt.Errorf("failed to read deployment reviews of run %s: %w", runID, err)
+	}
+	for _, review := range reviews {
+		if review.State != "approved" || sameActor(review.User.Login, applier) || sameActor(review.User.Login, os.Getenv("GITHUB_ACTOR")) {
+			continue
+		}
+		for _, env := range review.Environments {
+			if env.Name == environment {
+				return review.User.Login, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// handleApprove is run by the second operator: it shows the changes an apply would make to
+// the target and, once confirmed, prints an approval code signed with their key. The changes
+// are the sync of the same input, or those of a request printed by a refused run.
+func handleApprove(args []string, token, owner, repo, environment string) {
+	fs := flag.NewFlagSet("approve", flag.ExitOnError)
+	envName := fs.String("env", environment, "Environment (defaults to GITHUB_ENVIRONMENT; empty for repository variables)")
+	keyFile := fs.String("key", "", "Your Ed25519 private key (PEM); its public key must be listed under approval.approvers")
+	as := fs.String("as", "", "Approver name in approval.approvers (defaults to your GitHub login)")
+	requestCode := fs.String("request", "", "Approval request printed by a run that was refused (instead of the sync of the input)")
+	fs.Parse(args)
+
+	if *keyFile == "" {
+		fatal(exitValidation, "Usage: approve --key approver-key.pem [--env NAME | --request sgr1.…] [--as LOGIN]")
+	}
+	data, err := os.ReadFile(*keyFile)
+	if err != nil {
+		fatal(exitFailure, "Error reading key: %v", err)
+	}
+	key, err := parseEd25519PrivateKey(data)
+	if err != nil {
+		fatal(exitFailure, "Error: %v", err)
+	}
+	approver := *as
+	if approver == "" {
+		approver = auditIdentity(token)
+	}
+	if _, ok := config.Approval.Approvers[approver]; !ok {
+		fatal(exitFailure, "%s is not listed under approval.approvers in the config file", approver)
+	}
+
+	var changes []plannedChange
+	if *requestCode != "" {
+		request, err := decodeApprovalRequest(*requestCode)
+		if err != nil {
+			fatal(exitValidation, "Error: --request: %v", err)
+		}
+		owner, repo, *envName, changes = request.Owner, request.Repo, request.Environment, request.Changes
+		displayRequestedChanges(targetName(owner, repo, *envName), changes)
+	} else {
+		changes = planChanges(approvedSyncPlan(token, owner, repo, *envName))
+	}
+	if !approvalRequired(owner, repo, *envName) {
+		fmt.Printf("ℹ️  %s doesn't require approval; the code is only needed for protected targets\n", targetName(owner, repo, *envName))
+	}
+	if len(changes) == 0 {
+		fmt.Println("✅ No changes to approve")
+		return
+	}
+
+	target := targetName(owner, repo, *envName)
+	if !askYesNo(fmt.Sprintf("⚠️  Approve these %d change(s) to %s as %s?", len(changes), target, approver)) {
+		fmt.Println("\n❌ Approval cancelled")
+		exit(exitCancelled)
+	}
+
+	nonce := make([]byte, 8)
+	rand.Read(nonce)
+	payload := approvalPayload{Target: target, Plan: changesDigest(target, changes), Approver: approver,
+		Expires: time.Now().UTC().Add(config.Approval.ttl()).Truncate(time.Second), Nonce: hex.EncodeToString(nonce)}
+	code, err := encodeApprovalCode(payload, key)
+	if err != nil {
+		fatal(exitFailure, "Error: %v", err)
+	}
+	fmt.Printf("\n🔑 Approval code (valid until %s, only for exactly these changes):\n\n%s\n", payload.Expires.Local().Format("2006-01-02 15:04"), code)
+}
+
+// approvedSyncPlan works out and shows what a sync of the input would write, shaped by the
+// same global flags as the apply so the digests match
+func approvedSyncPlan(token, owner, repo, environment string) DiffResult {
+	variables, source, err := LoadDesiredVariables(token, owner, repo, environment)
+	if err != nil {
+		fatal(exitValidation, "Error: %v", err)
+	}
+	remote, err := FetchGitHubVariables(token, owner, repo, environment)
+	if err != nil {
+		fatal(exitFailure, "Error fetching GitHub variables: %v", err)
+	}
+	var renames []Rename
+	compareAgainst := remote
+	if *renamesFile != "" {
+		if renames, compareAgainst, err = PlanRenames(*renamesFile, remote); err != nil {
+			fatal(exitValidation, "Error: --renames: %v", err)
+		}
+	}
+	diff := scopeToTeam(CompareSets(variables, compareAgainst))
+	if *strategy != StrategyLocalWins {
+		csvModTime, err := csvModificationTime(source)
+		if err != nil {
+			fatal(exitFailure, "Error: --strategy %s: %v", *strategy, err)
+		}
+		diff, _ = ApplyStrategy(*strategy, diff, csvModTime)
+	}
+	DisplayDiffSummary(diff)
+	DisplayDetailedDiff(diff)
+	DisplayRenames(renames)
+	return syncPlan(diff, renames)
+}
+
+// displayRequestedChanges shows a request's changes. Values are shown as the requester saw
+// them only when they match the hashes being signed; masked or redacted ones stay hidden.
+func displayRequestedChanges(target string, changes []plannedChange) {
+	verified := func(shown, hash string) string {
+		if sha256Hex([]byte(shown)) == hash {
+			return shown
+		}
+		return "•••• sha256:" + hash[:16]
+	}
+	fmt.Printf("\n%s📋 Requested changes to %s%s\n", ColorBold, target, ColorReset)
+	for _, c := range changes {
+		switch c.Op {
+		case "+":
+			fmt.Printf("%s+ %s = %s%s\n", ColorGreen, c.Name, truncateValue(verified(c.Shown, c.New), valueLimit(80, true)), ColorReset)
+		case "~":
+			fmt.Printf("%s~ %s: %s → %s%s\n", ColorYellow, c.Name, truncateValue(verified(c.ShownOld, c.Old), valueLimit(40, true)),
+				truncateValue(verified(c.Shown, c.New), valueLimit(40, true)), ColorReset)
+		case "-":
+			fmt.Printf("%s- %s (was %s)%s\n", ColorRed, c.Name, truncateValue(c.ShownOld, valueLimit(60, true)), ColorReset)
+		}
+	}
+	fmt.Println()
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// protectTargets puts targets under approval for the rest of the test
+func protectTargets(t *testing.T, targets ...string) {
+	saved, writes := config.Approval, approvedWrites
+	config.Approval = ApprovalConfig{Targets: targets, GitHubReviews: true}
+	approvedWrites = map[string]map[string]bool{}
+	t.Cleanup(func() { config.Approval, approvedWrites = saved, writes })
+}
+
+func TestWritesToProtectedTargetNeedApproval(t *testing.T) {
+	fake := startFakeGitHub(t, map[string]string{"OLD": "1", "KEEP": "k"})
+	protectTargets(t, "o/r")
+	client := newRESTClient("test-token")
+
+	if err := client.CreateVariable("o", "r", "", Variable{Name: "NEW", Value: "n"}); err == nil {
+		t.Fatal("unapproved create succeeded")
+	}
+	if err := client.DeleteVariable("o", "r", "", "OLD"); err == nil {
+		t.Fatal("unapproved delete succeeded")
+	}
+	if len(fake.requests) != 0 {
+		t.Fatalf("unapproved writes reached GitHub: %q", fake.requests)
+	}
+
+	grantApproval("o", "r", "", DiffResult{New: []Variable{{Name: "NEW", Value: "n"}}, Deleted: []Variable{{Name: "OLD"}}})
+	if err := client.CreateVariable("o", "r", "", Variable{Name: "NEW", Value: "n"}); err != nil {
+		t.Errorf("approved create: %v", err)
+	}
+	if err := client.DeleteVariable("o", "r", "", "OLD"); err != nil {
+		t.Errorf("approved delete: %v", err)
+	}
+	if err := client.UpdateVariable("o", "r", "", Variable{Name: "NEW", Value: "other"}); err == nil {
+		t.Error("update to a value that wasn't approved succeeded")
+	}
+	if !reflect.DeepEqual(fake.variables, map[string]string{"NEW": "n", "KEEP": "k"}) {
+		t.Errorf("variables = %v", fake.variables)
+	}
+}
+
+func TestApprovalRequestRoundTrip(t *testing.T) {
+	plan := DiffResult{
+		New:     []Variable{{Name: "NEW", Value: "n"}},
+		Updated: []VariableChange{{Name: "CHANGED", OldValue: "1", NewValue: "2"}},
+		Deleted: []Variable{{Name: "GONE", Value: "x"}},
+	}
+	request, err := decodeApprovalRequest(encodeApprovalRequest("o", "r", "prod", plan))
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := targetName(request.Owner, request.Repo, request.Environment)
+	if got, want := changesDigest(target, request.Changes), planDigest("o", "r", "prod", plan); got != want {
+		t.Errorf("digest of the request = %s, want the plan's %s", got, want)
+	}
+
+	if _, err := decodeApprovalRequest("sgr1.e30"); err == nil {
+		t.Error("empty request decoded")
+	}
+	if _, err := decodeApprovalRequest("sga1.e30.e30"); err == nil {
+		t.Error("approval code decoded as a request")
+	}
+}
+
+func TestSyncPlanLeavesRemoteOnlyVariables(t *testing.T) {
+	diff := DiffResult{New: []Variable{{Name: "A", Value: "1"}}, Deleted: []Variable{{Name: "REMOTE_ONLY", Value: "x"}}}
+	plan := syncPlan(diff, []Rename{{Old: "OLD_NAME", New: "NEW_NAME", Value: "v"}})
+	want := DiffResult{
+		New:     []Variable{{Name: "A", Value: "1"}, {Name: "NEW_NAME", Value: "v"}},
+		Deleted: []Variable{{Name: "OLD_NAME", Value: "v"}},
+	}
+	if !reflect.DeepEqual(plan, want) {
+		t.Errorf("syncPlan = %+v, want %+v", plan, want)
+	}
+}
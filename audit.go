@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Audit actions
+const (
+	AuditCreate = "create"
+	AuditUpdate = "update"
+	AuditDelete = "delete"
+)
+
+// AuditConfig configures the audit trail in the config file
+type AuditConfig struct {
+	Path   string `json:"path"`   // Append-only JSONL file (overridden by --audit-log)
+	Syslog bool   `json:"syslog"` // Also send each entry to the local syslog
+}
+
+// AuditEntry is one line of the audit log. Values are recorded only as hashes.
+type AuditEntry struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Actor       string    `json:"actor"`
+	Owner       string    `json:"owner"`
+	Repo        string    `json:"repo"`
+	Environment string    `json:"environment,omitempty"`
+	Action      string    `json:"action"`
+	Name        string    `json:"name"`
+	OldHash     string    `json:"old_hash,omitempty"`
+	NewHash     string    `json:"new_hash,omitempty"`
+	Result      string    `json:"result"` // "success" or "failure"
+	Error       string    `json:"error,omitempty"`
+}
+
+var (
+	auditMu       sync.Mutex
+	auditActor    string
+	auditActorSet bool
+)
+
+// auditLogPath returns the audit log file from --audit-log or the config file, or "" when disabled
+func auditLogPath() string {
+	if *auditLog != "" {
+		return *auditLog
+	}
+	return config.Audit.Path
+}
+
+// auditEnabled reports whether changes should be recorded
+func auditEnabled() bool {
+	return auditLogPath() != "" || config.Audit.Syslog
+}
+
+// auditIdentity resolves the token's identity via /user once per run
+func auditIdentity(token string) string {
+	if auditActorSet {
+		return auditActor
+	}
+	auditActorSet = true
+
+	var user struct {
+		Login string `json:"login"`
+	}
+	err := githubGetJSON(token, githubAPIURL+"/user", &user)
+	switch {
+	case err == nil && user.Login != "":
+		auditActor = user.Login
+	case os.Getenv("GITHUB_APP_ID") != "":
+		// Installation tokens can't call /user
+		auditActor = "app:" + os.Getenv("GITHUB_APP_ID")
+	default:
+		auditActor = "unknown"
+	}
+	return auditActor
+}
+
+// recordAudit appends an entry for a write. oldValue/newValue are nil when not applicable.
+// Failing to write the audit log is reported but doesn't undo the change it describes.
+func recordAudit(token, owner, repo, environment, action, name string, oldValue, newValue *string, writeErr error) {
+	if !auditEnabled() {
+		return
+	}
+
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	entry := AuditEntry{
+		Timestamp:   time.Now().UTC(),
+		Actor:       auditIdentity(token),
+		Owner:       owner,
+		Repo:        repo,
+		Environment: environment,
+		Action:      action,
+		Name:        name,
+		Result:      "success",
+	}
+	if oldValue != nil {
+		entry.OldHash = hashValue(*oldValue)
+	}
+	if newValue != nil {
+		entry.NewHash = hashValue(*newValue)
+	}
+	if writeErr != nil {
+		entry.Result = "failure"
+		entry.Error = safeValue(writeErr.Error())
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		fmt.Printf("⚠️  Failed to encode audit entry: %v\n", err)
+		return
+	}
+
+	if path := auditLogPath(); path != "" {
+		err = appendLine(path, line)
+		if err != nil {
+			fmt.Printf("⚠️  Failed to write audit log: %v\n", err)
+		}
+	}
+	if config.Audit.Syslog {
+		err = writeSyslog(string(line))
+		if err != nil {
+			fmt.Printf("⚠️  Failed to write to syslog: %v\n", err)
+		}
+	}
+}
+
+// appendLine appends one line to a file, creating it if needed
+func appendLine(path string, line []byte) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// ReadAuditLog reads every entry from a JSONL audit log
+func ReadAuditLog(path string) ([]AuditEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries := []AuditEntry{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry AuditEntry
+		err := json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", path, lineNum, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
+
+// getVariableValue returns the current value of a variable, or nil if it can't be read
+func getVariableValue(token, owner, repo, environment, name string) *string {
+	var variable Variable
+	if err := githubGetJSON(token, variableURL(owner, repo, environment, name), &variable); err != nil {
+		return nil
+	}
+	return &variable.Value
+}
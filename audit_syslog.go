@@ -0,0 +1,15 @@
+//go:build !windows && !plan9
+
+package main
+
+import "log/syslog"
+
+// writeSyslog sends an audit entry to the local syslog daemon
+func writeSyslog(message string) error {
+	w, err := syslog.New(syslog.LOG_NOTICE|syslog.LOG_AUTH, "sync-github-variable")
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	return w.Notice(message)
+}
@@ -0,0 +1,10 @@
+//go:build windows || plan9
+
+package main
+
+import "fmt"
+
+// writeSyslog is unavailable on platforms without syslog
+func writeSyslog(message string) error {
+	return fmt.Errorf("syslog is not supported on this platform")
+}
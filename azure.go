@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const azureKeyVaultAPIVersion = "7.4"
+
+// azureAccessToken returns a bearer token for an Azure resource, from AZURE_ACCESS_TOKEN
+// or by asking the Azure CLI (az login must have been run)
+func azureAccessToken(resource string) (string, error) {
+	if token := os.Getenv("AZURE_ACCESS_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	out, err := exec.Command("az", "account", "get-access-token",
+		"--resource", resource, "--query", "accessToken", "-o", "tsv").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get Azure access token (set AZURE_ACCESS_TOKEN or run 'az login'): %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// azureGetJSON performs an authenticated GET against an Azure REST endpoint
+func azureGetJSON(url, accessToken string, out interface{}) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("Azure returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return json.Unmarshal(body, out)
+}
+
+// FetchAzureKeyVaultSecrets reads every enabled secret in an Azure Key Vault
+func FetchAzureKeyVaultSecrets(vaultName string) ([]Variable, error) {
+	accessToken, err := azureAccessToken("https://vault.azure.net")
+	if err != nil {
+		return nil, err
+	}
+
+	// List secret identifiers (values are not included in the listing)
+	ids := []string{}
+	url := fmt.Sprintf("https://%s.vault.azure.net/secrets?api-version=%s", vaultName, azureKeyVaultAPIVersion)
+	for url != "" {
+		var page struct {
+			Value []struct {
+				ID         string `json:"id"`
+				Attributes struct {
+					Enabled bool `json:"enabled"`
+				} `json:"attributes"`
+			} `json:"value"`
+			NextLink string `json:"nextLink"`
+		}
+		err = azureGetJSON(url, accessToken, &page)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range page.Value {
+			if s.Attributes.Enabled {
+				ids = append(ids, s.ID)
+			}
+		}
+		url = page.NextLink
+	}
+
+	variables := []Variable{}
+	for _, id := range ids {
+		var secret struct {
+			Value string `json:"value"`
+		}
+		err = azureGetJSON(id+"?api-version="+azureKeyVaultAPIVersion, accessToken, &secret)
+		if err != nil {
+			return nil, err
+		}
+
+		// Key Vault names only allow letters, digits, and dashes
+		name := id[strings.LastIndex(id, "/")+1:]
+		variables = append(variables, Variable{
+			Name:  sourceKeyToVariableName(name),
+			Value: secret.Value,
+		})
+	}
+
+	sortVariables(variables)
+	return variables, nil
+}
@@ -1,39 +1,47 @@
 package main
 
 import (
-	"encoding/csv"
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"time"
 )
 
-// ExportVariablesToCSV exports GitHub variables to a CSV file
-func ExportVariablesToCSV(variables []Variable, filename string) error {
+// ExportVariablesToCSV exports GitHub variables to a CSV file, with the Note column filled
+// from notes (keyed by name key). With recipients, the file is encrypted to them.
+func ExportVariablesToCSV(variables []Variable, notes map[string]string, filename string, recipients []string) error {
+	records := [][]string{{"Key", "Value", "Note"}}
+	for _, v := range variables {
+		records = append(records, []string{v.Name, v.Value, notes[nameKey(v.Name)]})
+	}
+
+	var content bytes.Buffer
+	err := writeCSV(&content, records)
+	if err != nil {
+		return fmt.Errorf("failed to write variables: %w", err)
+	}
+	data := content.Bytes()
+	if len(recipients) > 0 {
+		data, err = encryptBackup(data, recipients)
+		if err != nil {
+			return fmt.Errorf("failed to encrypt backup: %w", err)
+		}
+	}
+
 	file, err := os.Create(filename)
 	if err != nil {
 		return fmt.Errorf("failed to create file: %w", err)
 	}
 	defer file.Close()
 
-	writer := csv.NewWriter(file)
-	defer writer.Flush()
-
-	// Write header
-	err = writer.Write([]string{"Key", "Value", "Note"})
+	_, err = file.Write(data)
 	if err != nil {
-		return fmt.Errorf("failed to write header: %w", err)
-	}
-
-	// Write variables
-	for _, v := range variables {
-		err = writer.Write([]string{v.Name, v.Value, ""})
-		if err != nil {
-			return fmt.Errorf("failed to write variable %s: %w", v.Name, err)
-		}
+		return fmt.Errorf("failed to write variables: %w", err)
 	}
 
-	return nil
+	return file.Close()
 }
 
 // BackupGitHubVariables creates a backup of GitHub variables to a timestamped file
@@ -59,13 +67,128 @@ func BackupGitHubVariables(token, owner, repo, environment string) (string, erro
 	} else {
 		filename = filepath.Join(backupDir, fmt.Sprintf("backup_%s_%s_%s.csv", owner, repo, timestamp))
 	}
+	recipients := backupRecipientList()
+	ext, err := backupEncryptionExt(recipients)
+	if err != nil {
+		return "", err
+	}
+	filename += ext
 
-	// Export to CSV
-	err = ExportVariablesToCSV(variables, filename)
+	// Export to CSV, keeping the notes GitHub can't store
+	notes := map[string]string{}
+	if sidecar, err := loadNotesFile(*notesFile); err != nil {
+		fmt.Printf("⚠️  Warning: notes not included in backup: %v\n", err)
+	} else {
+		notes = sidecar.notesFor(owner, repo, environment)
+	}
+	err = ExportVariablesToCSV(variables, notes, filename, recipients)
 	if err != nil {
 		return "", fmt.Errorf("failed to export backup: %w", err)
 	}
+	rememberBackup(owner, repo, environment, filename)
+
+	// Keep an off-machine copy when a destination is configured
+	dest, err := backupDestination(token)
+	if err != nil {
+		return "", err
+	}
+	if dest != nil {
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			return "", err
+		}
+		location, err := dest.Store(filepath.Base(filename), data)
+		if err != nil {
+			return "", fmt.Errorf("local backup %s saved, but %w", filename, err)
+		}
+		fmt.Printf("☁️  Backup copied to %s\n", location)
+	}
 
+	emitEvent(EventBackupCreated, eventTarget(owner, repo, environment), map[string]interface{}{"file": filename, "variables": len(variables)})
 	return filename, nil
 }
 
+
+// ListBackups returns the backup files for a target in the backups directory, oldest first
+func ListBackups(owner, repo, environment string) ([]string, error) {
+	prefix := fmt.Sprintf("backup_%s_%s_", owner, repo)
+	if environment != "" {
+		prefix += environment + "_"
+	}
+	// Anchor on the timestamp so repo-level lookups don't match environment backups
+	pattern := regexp.MustCompile("^" + regexp.QuoteMeta(prefix) + `\d{4}-\d{2}-\d{2}_\d{2}-\d{2}-\d{2}\.csv(\.age|\.gpg)?$`)
+
+	entries, err := os.ReadDir("backups")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []string{}, nil
+		}
+		return nil, err
+	}
+
+	// ReadDir sorts by name, and timestamps sort lexicographically, so this is oldest first
+	backups := []string{}
+	for _, entry := range entries {
+		if pattern.MatchString(entry.Name()) {
+			backups = append(backups, filepath.Join("backups", entry.Name()))
+		}
+	}
+	return backups, nil
+}
+
+// FindLatestBackup returns the most recent backup file for a target in the backups directory
+func FindLatestBackup(owner, repo, environment string) (string, error) {
+	backups, err := ListBackups(owner, repo, environment)
+	if err != nil {
+		return "", fmt.Errorf("no backups found: %w", err)
+	}
+	if len(backups) == 0 {
+		return "", fmt.Errorf("no backups found for this target in backups/")
+	}
+
+	return backups[len(backups)-1], nil
+}
+
+// restoreVariables writes a backup's variables to a target through client. With prune,
+// variables that aren't in the backup are deleted so the target matches it exactly.
+func restoreVariables(client GitHubClient, owner, repo, environment string, backup []Variable, prune bool) (*SyncReport, error) {
+	remote, err := client.ListVariables(owner, repo, environment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch variables: %w", err)
+	}
+
+	diff := restorePlan(backup, remote, prune)
+	report := newSyncReport(owner, repo, environment, "sync")
+	for _, v := range diff.New {
+		if err := createOrUpdateVariable(client, owner, repo, environment, v); err != nil {
+			report.Failed = append(report.Failed, SyncFailure{Name: v.Name, Error: safeValue(err.Error())})
+			continue
+		}
+		report.Created = append(report.Created, v.Name)
+	}
+	for _, c := range diff.Updated {
+		if err := client.UpdateVariable(owner, repo, environment, Variable{Name: c.Name, Value: c.NewValue}); err != nil {
+			report.Failed = append(report.Failed, SyncFailure{Name: c.Name, Error: safeValue(err.Error())})
+			continue
+		}
+		report.Updated = append(report.Updated, c.Name)
+	}
+	for _, v := range diff.Deleted {
+		if err := client.DeleteVariable(owner, repo, environment, v.Name); err != nil {
+			report.Failed = append(report.Failed, SyncFailure{Name: v.Name, Error: safeValue(err.Error())})
+			continue
+		}
+		report.Deleted = append(report.Deleted, v.Name)
+	}
+	return report, nil
+}
+
+// restorePlan is what restoring a backup writes: its variables that are missing or differ,
+// and with prune, deleting the ones it doesn't have
+func restorePlan(backup, remote []Variable, prune bool) DiffResult {
+	diff := CompareSets(backup, remote)
+	if !prune {
+		diff.Deleted = nil
+	}
+	return diff
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// File extensions of encrypted backups
+const (
+	backupExtAge = ".age"
+	backupExtGPG = ".gpg"
+)
+
+// backupRecipientList returns the recipients backups are encrypted to, from
+// --backup-recipients or the config file; empty means backups are written in plaintext
+func backupRecipientList() []string {
+	recipients := config.Backup.Recipients
+	if *backupRecipients != "" {
+		recipients = strings.Split(*backupRecipients, ",")
+	}
+	list := []string{}
+	for _, r := range recipients {
+		if r = strings.TrimSpace(r); r != "" {
+			list = append(list, r)
+		}
+	}
+	return list
+}
+
+// isAgeRecipient reports whether a recipient is an age public key (age1...) or an SSH key
+// age can encrypt to; anything else is taken as a GPG key ID, fingerprint, or email
+func isAgeRecipient(recipient string) bool {
+	return strings.HasPrefix(recipient, "age1") || strings.HasPrefix(recipient, "ssh-")
+}
+
+// backupEncryptionExt returns the extension encrypted backups get, or "" for plaintext.
+// age and GPG can't share a file, so the recipients must all be of one kind.
+func backupEncryptionExt(recipients []string) (string, error) {
+	if len(recipients) == 0 {
+		return "", nil
+	}
+	age := 0
+	for _, r := range recipients {
+		if isAgeRecipient(r) {
+			age++
+		}
+	}
+	switch age {
+	case len(recipients):
+		return backupExtAge, nil
+	case 0:
+		return backupExtGPG, nil
+	default:
+		return "", fmt.Errorf("backup recipients mix age and GPG keys; use one kind")
+	}
+}
+
+// encryptBackup encrypts a backup to the recipients with the age or gpg CLI. The plaintext
+// only ever goes through a pipe, never to disk.
+func encryptBackup(data []byte, recipients []string) ([]byte, error) {
+	ext, err := backupEncryptionExt(recipients)
+	if err != nil {
+		return nil, err
+	}
+	var cmd *exec.Cmd
+	if ext == backupExtAge {
+		args := []string{"--encrypt"}
+		for _, r := range recipients {
+			args = append(args, "--recipient", r)
+		}
+		cmd = exec.Command("age", args...)
+	} else {
+		args := []string{"--batch", "--yes", "--trust-model", "always", "--encrypt"}
+		for _, r := range recipients {
+			args = append(args, "--recipient", r)
+		}
+		cmd = exec.Command("gpg", args...)
+	}
+	return runCrypto(cmd, data)
+}
+
+// backupIdentity returns the age identity file encrypted backups are decrypted with, from
+// SYNC_BACKUP_IDENTITY or the config file
+func backupIdentity() string {
+	if identity := os.Getenv("SYNC_BACKUP_IDENTITY"); identity != "" {
+		return identity
+	}
+	return config.Backup.Identity
+}
+
+// decryptBackup decrypts an encrypted backup: .age files with the age identity, .gpg files
+// with whatever secret key gpg's keyring or agent has
+func decryptBackup(file string, data []byte) ([]byte, error) {
+	if strings.HasSuffix(file, backupExtAge) {
+		identity := backupIdentity()
+		if identity == "" {
+			return nil, fmt.Errorf("%s is encrypted with age; set SYNC_BACKUP_IDENTITY or backup.identity to an identity file", file)
+		}
+		return runCrypto(exec.Command("age", "--decrypt", "--identity", identity), data)
+	}
+	return runCrypto(exec.Command("gpg", "--batch", "--quiet", "--decrypt"), data)
+}
+
+// runCrypto pipes data through an age or gpg command and returns its output
+func runCrypto(cmd *exec.Cmd, data []byte) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s: %w: %s", cmd.Args[0], err, msg)
+		}
+		return nil, fmt.Errorf("%s: %w", cmd.Args[0], err)
+	}
+	return stdout.Bytes(), nil
+}
+
+// isEncryptedBackup reports whether a backup file was encrypted
+func isEncryptedBackup(file string) bool {
+	return strings.HasSuffix(file, backupExtAge) || strings.HasSuffix(file, backupExtGPG)
+}
+
+// readBackup reads a backup's variables, decrypting it first when it's encrypted
+func readBackup(file string) ([]Variable, error) {
+	if !isEncryptedBackup(file) {
+		return readCSV(file)
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := decryptBackup(file, data)
+	if err != nil {
+		return nil, err
+	}
+	return parseCSV(bytes.NewReader(plain))
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// BackupDestination stores copies of backup files off the machine
+type BackupDestination interface {
+	Describe() string
+	Store(name string, data []byte) (string, error) // Returns where the file was stored
+}
+
+// backupDestination returns the destination from --backup-dest or the config file, or nil.
+// token is the sync token, used for github:// destinations unless GITHUB_BACKUP_TOKEN is set.
+func backupDestination(token string) (BackupDestination, error) {
+	spec := *backupDest
+	if spec == "" {
+		spec = config.Backup.Destination
+	}
+	if spec == "" {
+		return nil, nil
+	}
+
+	switch {
+	case strings.HasPrefix(spec, githubInputScheme):
+		return newGitHubBackupDestination(spec, token)
+	case isObjectStoreURL(spec):
+		// A bucket (or account/container) alone is a valid prefix
+		if _, _, _, err := splitObjectURL(strings.TrimRight(spec, "/") + "/x"); err != nil {
+			return nil, err
+		}
+		return objectBackupDestination{prefix: spec}, nil
+	default:
+		return nil, fmt.Errorf("unsupported backup destination %q (use github://, s3://, gs://, or az://)", spec)
+	}
+}
+
+// githubBackupDestination commits backups to a directory on a branch via the contents API
+type githubBackupDestination struct {
+	owner, repo, dir, branch string // An empty branch means the default branch
+	token                    string
+}
+
+func newGitHubBackupDestination(spec, token string) (*githubBackupDestination, error) {
+	rest := strings.TrimPrefix(spec, githubInputScheme)
+	branch := ""
+	if at := strings.LastIndex(rest, "@"); at >= 0 {
+		rest, branch = rest[:at], rest[at+1:]
+	}
+	parts := strings.SplitN(strings.Trim(rest, "/"), "/", 3)
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return nil, fmt.Errorf("invalid backup destination %q (expected github://owner/repo/dir@branch)", spec)
+	}
+
+	d := &githubBackupDestination{owner: parts[0], repo: parts[1], branch: branch}
+	if len(parts) == 3 {
+		d.dir = parts[2]
+	}
+	// The backup repository often belongs to a different team than the target
+	d.token = os.Getenv("GITHUB_BACKUP_TOKEN")
+	if d.token == "" {
+		d.token = token
+	}
+	return d, nil
+}
+
+func (d *githubBackupDestination) Describe() string {
+	return fmt.Sprintf("github://%s/%s/%s", d.owner, d.repo, d.dir)
+}
+
+// Store commits the file as a new commit on the branch, creating the branch from the default branch if needed
+func (d *githubBackupDestination) Store(name string, data []byte) (string, error) {
+	token := d.token
+	repoURL := fmt.Sprintf("%s/repos/%s/%s", githubAPIURL, d.owner, d.repo)
+
+	if d.branch != "" {
+		err := d.ensureBranch(token, repoURL)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	filePath := path.Join(d.dir, name)
+	payload := map[string]string{
+		"message": "Backup " + strings.TrimSuffix(name, ".csv"),
+		"content": base64.StdEncoding.EncodeToString(data),
+	}
+	if d.branch != "" {
+		payload["branch"] = d.branch
+	}
+	escaped := strings.ReplaceAll(url.PathEscape(filePath), "%2F", "/")
+	err := githubSendJSON(token, "PUT", repoURL+"/contents/"+escaped, payload, 201, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to commit backup to %s/%s: %w", d.owner, d.repo, err)
+	}
+
+	location := fmt.Sprintf("github://%s/%s/%s", d.owner, d.repo, filePath)
+	if d.branch != "" {
+		location += "@" + d.branch
+	}
+	return location, nil
+}
+
+// ensureBranch creates the backup branch from the default branch when it doesn't exist yet
+func (d *githubBackupDestination) ensureBranch(token, repoURL string) error {
+	var ref struct {
+		Object struct {
+			SHA string `json:"sha"`
+		} `json:"object"`
+	}
+	if githubGetJSON(token, repoURL+"/git/ref/heads/"+d.branch, &ref) == nil {
+		return nil
+	}
+
+	var info struct {
+		DefaultBranch string `json:"default_branch"`
+	}
+	err := githubGetJSON(token, repoURL, &info)
+	if err != nil {
+		return fmt.Errorf("failed to read backup repository: %w", err)
+	}
+	err = githubGetJSON(token, repoURL+"/git/ref/heads/"+info.DefaultBranch, &ref)
+	if err != nil {
+		return fmt.Errorf("failed to read branch %s: %w", info.DefaultBranch, err)
+	}
+	err = githubSendJSON(token, "POST", repoURL+"/git/refs",
+		map[string]string{"ref": "refs/heads/" + d.branch, "sha": ref.Object.SHA}, 201, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create backup branch %s: %w", d.branch, err)
+	}
+	return nil
+}
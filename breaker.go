@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// breakerWindow is how many writes in a row must fail at the start of a sync for the
+// circuit breaker to trip: that pattern means a bad token or target, not bad variables
+const breakerWindow = 5
+
+// failureBreaker stops a sync early when writes keep failing, instead of grinding through
+// every remaining variable with the same error
+type failureBreaker struct {
+	maxFailures int // --max-failures; 0 is no limit
+	enabled     bool
+	succeeded   int
+	failures    []error
+}
+
+// newFailureBreaker configures the breaker from --max-failures and --no-circuit-breaker
+func newFailureBreaker() *failureBreaker {
+	return &failureBreaker{maxFailures: *maxFailures, enabled: !*noCircuitBreaker}
+}
+
+// Record counts the outcome of one write. It returns the reason to stop when the breaker
+// trips, or "" to carry on.
+func (b *failureBreaker) Record(err error) string {
+	if err == nil {
+		b.succeeded++
+		return ""
+	}
+	b.failures = append(b.failures, err)
+
+	switch {
+	case b.maxFailures > 0 && len(b.failures) >= b.maxFailures:
+		return fmt.Sprintf("%d writes failed (--max-failures %d); %s", len(b.failures), b.maxFailures, b.diagnosis())
+	case b.enabled && b.succeeded == 0 && len(b.failures) >= breakerWindow:
+		return fmt.Sprintf("the first %d writes all failed; %s", len(b.failures), b.diagnosis())
+	}
+	return ""
+}
+
+// diagnosis explains the failures when GitHub gave the same answer to all of them
+func (b *failureBreaker) diagnosis() string {
+	var first *APIError
+	for _, err := range b.failures {
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || (first != nil && apiErr.Status != first.Status) {
+			first = nil
+			break
+		}
+		if first == nil {
+			first = apiErr
+		}
+	}
+	if first == nil {
+		return fmt.Sprintf("the last error was: %s", safeValue(b.failures[len(b.failures)-1].Error()))
+	}
+	return fmt.Sprintf("all with the same error: %s", safeValue(first.Error()))
+}
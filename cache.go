@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// cachedResponse is a stored list response and the ETag it was served with
+type cachedResponse struct {
+	ETag string `json:"etag"`
+	Link string `json:"link,omitempty"` // Pagination links, which a 304 may not repeat
+	Body []byte `json:"body"`
+}
+
+// etagCacheTransport sends If-None-Match for variable listings and serves the cached
+// body on 304 Not Modified, which GitHub doesn't count against the rate limit
+type etagCacheTransport struct {
+	base http.RoundTripper
+	dir  string
+}
+
+// defaultCacheDir returns the per-user cache directory for list responses
+func defaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "sync-github-variable", "etag"), nil
+}
+
+// cacheable reports whether a request is a GitHub variable listing
+func cacheable(req *http.Request) bool {
+	return req.Method == "GET" && isGitHubAPIRequest(req) && strings.HasSuffix(req.URL.Path, "/variables")
+}
+
+// cacheKey identifies a listing by URL and credential, so identities never share entries
+func (t *etagCacheTransport) cacheKey(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.Header.Get("Authorization") + "\n" + req.URL.String()))
+	return filepath.Join(t.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (t *etagCacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !cacheable(req) {
+		return t.base.RoundTrip(req)
+	}
+
+	path := t.cacheKey(req)
+	var cached cachedResponse
+	if data, err := os.ReadFile(path); err == nil && json.Unmarshal(data, &cached) == nil && cached.ETag != "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		resp.Body.Close()
+		resp.StatusCode = http.StatusOK
+		resp.Status = "200 OK"
+		resp.Body = io.NopCloser(bytes.NewReader(cached.Body))
+		resp.ContentLength = int64(len(cached.Body))
+		resp.Header.Set("X-Sync-Cache", "hit")
+		if resp.Header.Get("Link") == "" && cached.Link != "" {
+			resp.Header.Set("Link", cached.Link)
+		}
+	case http.StatusOK:
+		etag := resp.Header.Get("ETag")
+		if etag == "" {
+			return resp, nil
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Cached bodies contain variable values, so keep them private to the user
+		if data, err := json.Marshal(cachedResponse{ETag: etag, Link: resp.Header.Get("Link"), Body: body}); err == nil {
+			if os.MkdirAll(t.dir, 0700) == nil {
+				os.WriteFile(path, data, 0600)
+			}
+		}
+	}
+	return resp, nil
+}
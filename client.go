@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// GitHubClient is the variables API the sync logic writes through. restClient talks to
+// GitHub's REST API; MemoryClient keeps variables in memory for tests.
+type GitHubClient interface {
+	ListVariables(owner, repo, environment string) ([]Variable, error)
+	VariableExists(owner, repo, environment, name string) (bool, error)
+	CreateVariable(owner, repo, environment string, variable Variable) error
+	UpdateVariable(owner, repo, environment string, variable Variable) error
+	DeleteVariable(owner, repo, environment, name string) error
+}
+
+// restClient is the GitHubClient backed by the REST API (and so by httpClient's transports).
+// It refuses writes to targets under approval that no approval of this run covers.
+type restClient struct {
+	token string
+}
+
+func newRESTClient(token string) GitHubClient {
+	return &restClient{token: token}
+}
+
+func (c *restClient) ListVariables(owner, repo, environment string) ([]Variable, error) {
+	return FetchGitHubVariables(c.token, owner, repo, environment)
+}
+
+func (c *restClient) VariableExists(owner, repo, environment, name string) (bool, error) {
+	return checkVariableExists(c.token, owner, repo, environment, name)
+}
+
+func (c *restClient) CreateVariable(owner, repo, environment string, variable Variable) error {
+	if err := checkWriteApproved(owner, repo, environment, variable.Name, &variable.Value); err != nil {
+		return err
+	}
+	return createVariable(c.token, owner, repo, environment, variable)
+}
+
+func (c *restClient) UpdateVariable(owner, repo, environment string, variable Variable) error {
+	if err := checkWriteApproved(owner, repo, environment, variable.Name, &variable.Value); err != nil {
+		return err
+	}
+	return updateVariable(c.token, owner, repo, environment, variable)
+}
+
+func (c *restClient) DeleteVariable(owner, repo, environment, name string) error {
+	if err := checkWriteApproved(owner, repo, environment, name, nil); err != nil {
+		return err
+	}
+	return deleteVariable(c.token, owner, repo, environment, name)
+}
+
+// MemoryClient is an in-memory GitHubClient. Errors can be injected per variable name
+// and operation to exercise failure paths without a server.
+type MemoryClient struct {
+	mu      sync.Mutex
+	targets map[string]map[string]Variable
+
+	// Errors maps "create:NAME", "update:NAME", "delete:NAME", or "list" to an error to return
+	Errors map[string]error
+
+	// Calls records every operation as "op owner/repo/environment NAME", in order
+	Calls []string
+}
+
+func NewMemoryClient() *MemoryClient {
+	return &MemoryClient{targets: make(map[string]map[string]Variable), Errors: make(map[string]error)}
+}
+
+// Seed sets the variables of a target, replacing any it had
+func (c *MemoryClient) Seed(owner, repo, environment string, variables []Variable) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	target := make(map[string]Variable)
+	for _, v := range variables {
+		target[v.Name] = v
+	}
+	c.targets[memoryTargetKey(owner, repo, environment)] = target
+}
+
+func memoryTargetKey(owner, repo, environment string) string {
+	return owner + "/" + repo + "/" + environment
+}
+
+// record logs a call and returns the injected error for it, if any
+func (c *MemoryClient) record(op, owner, repo, environment, name string) (map[string]Variable, error) {
+	key := memoryTargetKey(owner, repo, environment)
+	c.Calls = append(c.Calls, fmt.Sprintf("%s %s %s", op, key, name))
+	errKey := op
+	if name != "" {
+		errKey += ":" + name
+	}
+	if err := c.Errors[errKey]; err != nil {
+		return nil, err
+	}
+	if c.targets[key] == nil {
+		c.targets[key] = make(map[string]Variable)
+	}
+	return c.targets[key], nil
+}
+
+func (c *MemoryClient) ListVariables(owner, repo, environment string) ([]Variable, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	target, err := c.record("list", owner, repo, environment, "")
+	if err != nil {
+		return nil, err
+	}
+	variables := make([]Variable, 0, len(target))
+	for _, v := range target {
+		variables = append(variables, v)
+	}
+	sort.Slice(variables, func(i, j int) bool { return variables[i].Name < variables[j].Name })
+	return variables, nil
+}
+
+func (c *MemoryClient) VariableExists(owner, repo, environment, name string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	target, err := c.record("exists", owner, repo, environment, name)
+	if err != nil {
+		return false, err
+	}
+	_, ok := target[name]
+	return ok, nil
+}
+
+func (c *MemoryClient) CreateVariable(owner, repo, environment string, variable Variable) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	target, err := c.record("create", owner, repo, environment, variable.Name)
+	if err != nil {
+		return err
+	}
+	if _, ok := target[variable.Name]; ok {
+		return fmt.Errorf("%w: %s", errVariableExists, variable.Name)
+	}
+	target[variable.Name] = variable
+	return nil
+}
+
+func (c *MemoryClient) UpdateVariable(owner, repo, environment string, variable Variable) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	target, err := c.record("update", owner, repo, environment, variable.Name)
+	if err != nil {
+		return err
+	}
+	if _, ok := target[variable.Name]; !ok {
+		return fmt.Errorf("variable %s not found", variable.Name)
+	}
+	target[variable.Name] = variable
+	return nil
+}
+
+func (c *MemoryClient) DeleteVariable(owner, repo, environment, name string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	target, err := c.record("delete", owner, repo, environment, name)
+	if err != nil {
+		return err
+	}
+	if _, ok := target[name]; !ok {
+		return fmt.Errorf("variable %s not found", name)
+	}
+	delete(target, name)
+	return nil
+}
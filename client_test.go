@@ -0,0 +1,210 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeGitHub serves the repository variables API of o/r from memory
+type fakeGitHub struct {
+	mu        sync.Mutex
+	variables map[string]string
+	requests  []string       // "METHOD path", in order
+	fail      map[string]int // "METHOD path" -> error status to answer with instead
+}
+
+// startFakeGitHub points the API at a fakeGitHub for the rest of the test. The journal is
+// turned off so tests don't write sync-history.jsonl.
+func startFakeGitHub(t *testing.T, variables map[string]string) *fakeGitHub {
+	fake := &fakeGitHub{variables: variables}
+	server := httptest.NewServer(fake)
+	apiURL, journal := githubAPIURL, *journalFile
+	githubAPIURL, *journalFile = server.URL, ""
+	t.Cleanup(func() {
+		server.Close()
+		githubAPIURL, *journalFile = apiURL, journal
+	})
+	return fake
+}
+
+func (f *fakeGitHub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests = append(f.requests, r.Method+" "+r.URL.Path)
+	if status := f.fail[r.Method+" "+r.URL.Path]; status != 0 {
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"message": http.StatusText(status)})
+		return
+	}
+
+	const prefix = "/repos/o/r/actions/variables"
+	name := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, prefix), "/")
+	var body Variable
+	if r.Body != nil {
+		json.NewDecoder(r.Body).Decode(&body)
+	}
+	if r.Method == "POST" {
+		name = body.Name
+	}
+	_, exists := f.variables[name]
+	switch {
+	case !strings.HasPrefix(r.URL.Path, prefix):
+		w.WriteHeader(http.StatusNotFound)
+	case r.Method == "GET" && name == "":
+		response := GitHubVariablesResponse{TotalCount: len(f.variables)}
+		for n, v := range f.variables {
+			response.Variables = append(response.Variables, Variable{Name: n, Value: v})
+		}
+		sort.Slice(response.Variables, func(i, j int) bool { return response.Variables[i].Name < response.Variables[j].Name })
+		json.NewEncoder(w).Encode(response)
+	case r.Method == "POST" && exists:
+		w.WriteHeader(http.StatusConflict)
+	case r.Method == "POST":
+		f.variables[body.Name] = body.Value
+		w.WriteHeader(http.StatusCreated)
+	case !exists:
+		w.WriteHeader(http.StatusNotFound)
+	case r.Method == "GET":
+		json.NewEncoder(w).Encode(Variable{Name: name, Value: f.variables[name]})
+	case r.Method == "PATCH":
+		f.variables[name] = body.Value
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == "DELETE":
+		delete(f.variables, name)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestRESTClientWritesThroughAPI(t *testing.T) {
+	fake := startFakeGitHub(t, map[string]string{"OLD": "1", "KEEP": "k"})
+	client := newRESTClient("test-token")
+
+	if err := client.CreateVariable("o", "r", "", Variable{Name: "NEW", Value: "n"}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if err := client.UpdateVariable("o", "r", "", Variable{Name: "KEEP", Value: "k2"}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if err := client.DeleteVariable("o", "r", "", "OLD"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if exists, err := client.VariableExists("o", "r", "", "OLD"); err != nil || exists {
+		t.Errorf("VariableExists(OLD) = %v, %v after delete", exists, err)
+	}
+	if err := client.CreateVariable("o", "r", "", Variable{Name: "NEW", Value: "again"}); !errors.Is(err, errVariableExists) {
+		t.Errorf("create of an existing variable: got %v, want errVariableExists", err)
+	}
+	if err := client.DeleteVariable("o", "r", "", "MISSING"); err == nil {
+		t.Error("delete of a missing variable succeeded")
+	}
+
+	want := []string{
+		"POST /repos/o/r/actions/variables",
+		"PATCH /repos/o/r/actions/variables/KEEP",
+		"DELETE /repos/o/r/actions/variables/OLD",
+		"GET /repos/o/r/actions/variables/OLD",
+		"POST /repos/o/r/actions/variables",
+		"DELETE /repos/o/r/actions/variables/MISSING",
+	}
+	if !reflect.DeepEqual(fake.requests, want) {
+		t.Errorf("requests:\n got %q\nwant %q", fake.requests, want)
+	}
+	if !reflect.DeepEqual(fake.variables, map[string]string{"NEW": "n", "KEEP": "k2"}) {
+		t.Errorf("variables = %v", fake.variables)
+	}
+}
+
+func TestRESTClientReportsAPIErrors(t *testing.T) {
+	for _, status := range []int{http.StatusUnauthorized, http.StatusInternalServerError} {
+		t.Run(fmt.Sprintf("list %d", status), func(t *testing.T) {
+			fake := startFakeGitHub(t, map[string]string{"A": "1"})
+			fake.fail = map[string]int{"GET /repos/o/r/actions/variables": status}
+
+			variables, err := newRESTClient("test-token").ListVariables("o", "r", "")
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.Status != status {
+				t.Fatalf("got %v, %v; want a %d APIError", variables, err, status)
+			}
+		})
+	}
+
+	t.Run("update 422", func(t *testing.T) {
+		fake := startFakeGitHub(t, map[string]string{"A": "1", "B": "2"})
+		fake.fail = map[string]int{"PATCH /repos/o/r/actions/variables/A": http.StatusUnprocessableEntity}
+
+		diff := DiffResult{
+			New:     []Variable{{Name: "C", Value: "3"}},
+			Updated: []VariableChange{{Name: "A", OldValue: "1", NewValue: "x"}, {Name: "B", OldValue: "2", NewValue: "y"}},
+		}
+		report := applyDiff(newRESTClient("test-token"), newSyncReport("o", "r", "", "sync"), diff, t.Logf, nil)
+		if len(report.Failed) != 1 || report.Failed[0].Name != "A" || !strings.Contains(report.Failed[0].Error, "422") {
+			t.Errorf("Failed = %v, want A with status 422", report.Failed)
+		}
+		if !reflect.DeepEqual(report.Created, []string{"C"}) || !reflect.DeepEqual(report.Updated, []string{"B"}) {
+			t.Errorf("created %v, updated %v; want C and B", report.Created, report.Updated)
+		}
+		if !reflect.DeepEqual(fake.variables, map[string]string{"A": "1", "B": "y", "C": "3"}) {
+			t.Errorf("variables = %v", fake.variables)
+		}
+	})
+}
+
+func TestRestoreVariablesOverREST(t *testing.T) {
+	fake := startFakeGitHub(t, map[string]string{"A": "changed", "EXTRA": "x"})
+	backup := []Variable{{Name: "A", Value: "1"}, {Name: "B", Value: "2"}}
+
+	report, err := restoreVariables(newRESTClient("test-token"), "o", "r", "", backup, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fake.variables, map[string]string{"A": "1", "B": "2"}) {
+		t.Errorf("variables after restore = %v", fake.variables)
+	}
+	if len(report.Created) != 1 || len(report.Updated) != 1 || len(report.Deleted) != 1 || len(report.Failed) != 0 {
+		t.Errorf("report: created %v, updated %v, deleted %v, failed %v", report.Created, report.Updated, report.Deleted, report.Failed)
+	}
+}
+
+func TestRestoreVariablesReportsFailures(t *testing.T) {
+	client := NewMemoryClient()
+	client.Seed("o", "r", "prod", []Variable{{Name: "A", Value: "changed"}, {Name: "EXTRA", Value: "x"}})
+	client.Errors["update:A"] = fmt.Errorf("boom")
+
+	report, err := restoreVariables(client, "o", "r", "prod", []Variable{{Name: "A", Value: "1"}, {Name: "B", Value: "2"}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Failed) != 1 || report.Failed[0].Name != "A" {
+		t.Errorf("Failed = %v, want A", report.Failed)
+	}
+	if len(report.Deleted) != 0 {
+		t.Errorf("Deleted = %v without prune", report.Deleted)
+	}
+	remaining, _ := client.ListVariables("o", "r", "prod")
+	if len(remaining) != 3 {
+		t.Errorf("variables after restore = %v, want A, B, and EXTRA", remaining)
+	}
+}
+
+func TestCreateOrUpdateVariableRecoversFromRace(t *testing.T) {
+	client := NewMemoryClient()
+	client.Seed("o", "", "", []Variable{{Name: "A", Value: "theirs"}})
+
+	if err := createOrUpdateVariable(client, "o", "", "", Variable{Name: "A", Value: "ours"}); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"create o// A", "exists o// A", "update o// A"}
+	if !reflect.DeepEqual(client.Calls, want) {
+		t.Errorf("calls = %q, want %q", client.Calls, want)
+	}
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// Collision handling of the clone command, for variables that exist in both repositories with different values
+const (
+	CloneSkip      = "skip"      // Keep the destination's value
+	CloneOverwrite = "overwrite" // Replace it with the source's value
+	CloneFail      = "fail"      // Stop before anything is written
+)
+
+// cloneTarget is the repository level ("") or one environment of a clone
+type cloneTarget struct {
+	Environment string
+	Missing     bool // The environment doesn't exist in the destination yet
+	Diff        DiffResult
+}
+
+// handleClone copies every variable of one repository, and optionally all its environments,
+// to another repository
+func handleClone(args []string, token, owner, repo string) {
+	fs := flag.NewFlagSet("clone", flag.ExitOnError)
+	from := fs.String("from", owner+"/"+repo, "Source repository as owner/repo (defaults to GITHUB_OWNER/GITHUB_REPO)")
+	to := fs.String("to", "", "Destination repository as owner/repo")
+	environments := fs.Bool("environments", false, "Also copy every environment's variables, creating environments missing in the destination")
+	onConflict := fs.String("on-conflict", CloneSkip, "When a variable exists in both with different values: skip, overwrite, or fail")
+	fs.Parse(args)
+
+	fromOwner, fromRepo, ok := strings.Cut(*from, "/")
+	if !ok || fromOwner == "" || fromRepo == "" {
+		fatal(exitFailure, "Invalid --from %q (use owner/repo)", *from)
+	}
+	toOwner, toRepo, ok := strings.Cut(*to, "/")
+	if !ok || toOwner == "" || toRepo == "" {
+		fatal(exitFailure, "Invalid or missing --to %q (use owner/repo)", *to)
+	}
+	if *from == *to {
+		fatal(exitFailure, "--from and --to must be different repositories")
+	}
+	if *onConflict != CloneSkip && *onConflict != CloneOverwrite && *onConflict != CloneFail {
+		fatal(exitFailure, "Invalid --on-conflict %q (use skip, overwrite, or fail)", *onConflict)
+	}
+	fmt.Printf("🎯 Target: Clone %s → %s\n", *from, *to)
+
+	names := []string{""}
+	if *environments {
+		envs, err := ListEnvironments(token, fromOwner, fromRepo)
+		if err != nil {
+			fatal(exitFailure, "Error listing environments of %s: %v", *from, err)
+		}
+		names = append(names, envs...)
+	}
+
+	targets := []cloneTarget{}
+	collisions, pending := 0, 0
+	for _, environment := range names {
+		t := cloneTarget{Environment: environment}
+		source, err := FetchGitHubVariables(token, fromOwner, fromRepo, environment)
+		if err != nil {
+			fatal(exitFailure, "Error fetching variables of %s: %v", targetName(fromOwner, fromRepo, environment), err)
+		}
+
+		existing := []Variable{}
+		if environment != "" {
+			exists, err := environmentExists(token, toOwner, toRepo, environment)
+			if err != nil {
+				fatal(exitFailure, "Error checking environment '%s' in %s: %v", environment, *to, err)
+			}
+			t.Missing = !exists
+		}
+		if !t.Missing {
+			existing, err = FetchGitHubVariables(token, toOwner, toRepo, environment)
+			if err != nil {
+				fatal(exitFailure, "Error fetching variables of %s: %v", targetName(toOwner, toRepo, environment), err)
+			}
+		}
+
+		t.Diff = CompareSets(source, existing)
+		t.Diff.Deleted = nil // Variables only in the destination are always kept
+		collisions += len(t.Diff.Updated)
+		displayCloneTarget(t, *onConflict)
+		if *onConflict == CloneSkip {
+			t.Diff.Updated = nil
+		}
+		pending += len(t.Diff.New) + len(t.Diff.Updated)
+		targets = append(targets, t)
+	}
+
+	if collisions > 0 && *onConflict == CloneFail {
+		runError = fmt.Sprintf("%d variable(s) differ between %s and %s", collisions, *from, *to)
+		fmt.Printf("\n❌ %d variable(s) exist in both repositories with different values; nothing was copied\n", collisions)
+		fmt.Println("   Re-run with --on-conflict skip or --on-conflict overwrite")
+		exit(exitFailure)
+	}
+	if pending == 0 {
+		fmt.Printf("\n✅ Nothing to copy. %s already has every variable of %s\n", *to, *from)
+		return
+	}
+	if *diffMode {
+		fmt.Println("ℹ️  Diff mode: No changes were made")
+		return
+	}
+	for _, t := range targets {
+		requireApproval(token, toOwner, toRepo, t.Environment, syncPlan(t.Diff, nil))
+	}
+	if !askYesNo(fmt.Sprintf("\n⚠️  Copy %d variable(s) from %s to %s?", pending, *from, *to)) {
+		fmt.Println("\n❌ Clone cancelled by user")
+		exit(exitCancelled)
+	}
+
+	fmt.Print("\n🚀 Starting clone...\n")
+	client := newRESTClient(token)
+	failed := 0
+	for _, t := range targets {
+		if len(t.Diff.New)+len(t.Diff.Updated) == 0 {
+			continue
+		}
+		target := targetName(toOwner, toRepo, t.Environment)
+		fmt.Printf("\n📦 %s\n", target)
+		lockOrExit(token, toOwner, toRepo, t.Environment)
+
+		if t.Missing {
+			err := githubSendJSON(token, "PUT", environmentURL(toOwner, toRepo, t.Environment), environmentPayload{}, 200, nil)
+			if err != nil {
+				fatal(exitFailure, "Error creating environment '%s' in %s: %v", t.Environment, *to, err)
+			}
+			fmt.Printf("🆕 Created environment '%s'\n", t.Environment)
+		} else if len(t.Diff.Updated) > 0 && !*noBackup {
+			backupFile, err := BackupGitHubVariables(token, toOwner, toRepo, t.Environment)
+			if err != nil {
+				fatal(exitFailure, "Backup of %s failed, stopping before it is changed: %v", target, err)
+			}
+			fmt.Printf("💾 Backup saved: %s\n", backupFile)
+		}
+
+		report := applyDiff(client, newSyncReport(toOwner, toRepo, t.Environment, "sync"), t.Diff, func(format string, args ...interface{}) {
+			fmt.Printf(format+"\n", args...)
+		}, nil)
+		fmt.Printf("✅ Created %d, Updated %d, Failed %d\n", len(report.Created), len(report.Updated), len(report.Failed))
+		failed += len(report.Failed)
+	}
+
+	fmt.Printf("\n🎉 Completed! Copied %d variable(s) from %s to %s, %d failed\n", pending-failed, *from, *to, failed)
+	if failed > 0 {
+		runError = fmt.Sprintf("%d variable(s) failed to copy", failed)
+		exit(exitPartial)
+	}
+}
+
+// displayCloneTarget previews what the clone does to one repository level or environment
+func displayCloneTarget(t cloneTarget, onConflict string) {
+	label := "Repository variables"
+	if t.Environment != "" {
+		label = fmt.Sprintf("Environment '%s'", t.Environment)
+		if t.Missing {
+			label += " (will be created)"
+		}
+	}
+	fmt.Printf("\n%s%s%s: %d to copy, %d differ, %d identical\n", ColorBold, label, ColorReset,
+		len(t.Diff.New), len(t.Diff.Updated), len(t.Diff.Unchanged))
+
+	for _, v := range t.Diff.New {
+		fmt.Printf("%s+ %s = %s%s\n", ColorGreen, v.Name, truncateValue(shownValue(v.Name, v.Value), valueLimit(60, true)), ColorReset)
+	}
+	for _, c := range t.Diff.Updated {
+		switch onConflict {
+		case CloneOverwrite:
+			fmt.Printf("%s~ %s: %s → %s%s\n", ColorYellow, c.Name,
+				truncateValue(shownValue(c.Name, c.OldValue), valueLimit(30, true)), truncateValue(shownValue(c.Name, c.NewValue), valueLimit(30, true)), ColorReset)
+		case CloneSkip:
+			fmt.Printf("%s= %s (differs; destination value kept)%s\n", ColorGray, c.Name, ColorReset)
+		default:
+			fmt.Printf("%s! %s differs%s\n", ColorRed, c.Name, ColorReset)
+		}
+	}
+}
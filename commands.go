@@ -0,0 +1,60 @@
+package main
+
+// localCommands are the subcommands that only read local files, so they need no token
+var localCommands = map[string]bool{"diff-backups": true, "history": true}
+
+// runLocalCommand dispatches a subcommand of localCommands. The target only picks which
+// local files are meant, so it may be missing when they're named on the command line.
+func runLocalCommand(args []string, owner, repo, environment string) {
+	switch args[0] {
+	case "diff-backups":
+		handleDiffBackups(args[1:], owner, repo, environment)
+	case "history":
+		handleHistory(args[1:], owner, repo, environment)
+	}
+}
+
+// runCommand dispatches a subcommand given after the global flags
+func runCommand(args []string, token, owner, repo, environment string) {
+	if isOrgTarget(owner, repo) && !orgTargetCommands[args[0]] {
+		fatal(exitFailure, "%s needs a repository; it doesn't work on organization targets", args[0])
+	}
+	switch args[0] {
+	case "env":
+		runEnvCommand(args[1:], token, owner, repo, environment)
+	case "verify":
+		handleVerify(args[1:], token, owner, repo, environment)
+	case "import-run":
+		handleImportRun(args[1:], token, owner, repo, environment)
+	case "watch":
+		handleWatch(args[1:], token, owner, repo, environment)
+	case "serve":
+		handleServe(args[1:], token, owner, repo, environment)
+	case "environments":
+		handleEnvironments(args[1:], token, owner, repo)
+	case "org-access":
+		handleOrgAccess(args[1:], token, owner)
+	case "pr-check":
+		handlePRCheck(args[1:], token, owner, repo, environment)
+	case "set":
+		handleSet(args[1:], token, owner, repo, environment)
+	case "get":
+		handleGet(args[1:], token, owner, repo, environment)
+	case "unset":
+		handleUnset(args[1:], token, owner, repo, environment)
+	case "list":
+		handleList(args[1:], token, owner, repo, environment)
+	case "delete":
+		handleDelete(args[1:], token, owner, repo, environment)
+	case "clone":
+		handleClone(args[1:], token, owner, repo)
+	case "export":
+		handleExport(args[1:], token, owner, repo, environment)
+	case "rollback":
+		handleRollback(args[1:], token)
+	case "approve":
+		handleApprove(args[1:], token, owner, repo, environment)
+	default:
+		fatal(exitValidation, "Unknown command: %s", args[0])
+	}
+}
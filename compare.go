@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Comparison modes that can be assigned to variable name patterns in the config file
+const (
+	CompareExact           = "exact"
+	CompareCaseInsensitive = "case-insensitive"
+	CompareNumeric         = "numeric-equal"
+	CompareSemver          = "semver-equal"
+	CompareJSON            = "json-equal"
+)
+
+// validateComparisonModes checks that every configured comparison mode is known
+func validateComparisonModes(rules map[string]string) error {
+	for pattern, mode := range rules {
+		switch mode {
+		case CompareExact, CompareCaseInsensitive, CompareNumeric, CompareSemver, CompareJSON:
+		default:
+			return fmt.Errorf("unknown comparison mode %q for %q", mode, pattern)
+		}
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// comparisonMode returns the mode for a variable name: an exact name entry wins,
+// otherwise the longest matching glob pattern, otherwise exact comparison
+func comparisonMode(name string) string {
+	if mode, ok := patternValue(config.Compare, name); ok {
+		return mode
+	}
+	return CompareExact
+}
+
+// patternValue looks a variable name up in a config map of names and glob patterns: an
+// exact name entry wins, otherwise the longest matching pattern. Patterns of the same length
+// are taken in lexical order, so the choice doesn't depend on map iteration.
+func patternValue(patterns map[string]string, name string) (string, bool) {
+	if value, ok := patterns[name]; ok {
+		return value, true
+	}
+	best := ""
+	found := false
+	for pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, name); !matched {
+			continue
+		}
+		if !found || len(pattern) > len(best) || (len(pattern) == len(best) && pattern < best) {
+			best, found = pattern, true
+		}
+	}
+	return patterns[best], found
+}
+
+// valuesEqual compares a local and remote value using the variable's comparison mode.
+// Values that can't be parsed for the mode fall back to exact comparison.
+func valuesEqual(name, local, remote string) bool {
+	if local == remote {
+		return true
+	}
+	if normalizedEqual(name, local, remote) {
+		return true
+	}
+
+	switch comparisonMode(name) {
+	case CompareCaseInsensitive:
+		return strings.EqualFold(local, remote)
+	case CompareNumeric:
+		a, okA := new(big.Float).SetString(strings.TrimSpace(local))
+		b, okB := new(big.Float).SetString(strings.TrimSpace(remote))
+		return okA && okB && a.Cmp(b) == 0
+	case CompareSemver:
+		a, okA := parseSemver(local)
+		b, okB := parseSemver(remote)
+		return okA && okB && a == b
+	case CompareJSON:
+		var a, b interface{}
+		if json.Unmarshal([]byte(local), &a) != nil || json.Unmarshal([]byte(remote), &b) != nil {
+			return false
+		}
+		return reflect.DeepEqual(a, b)
+	}
+	return false
+}
+
+// semver is a parsed semantic version; build metadata is ignored as the spec requires
+type semver struct {
+	major, minor, patch int
+	pre                 string
+}
+
+// parseSemver parses versions like v1.2, 1.2.3, or 1.2.3-rc.1+build (missing parts are zero)
+func parseSemver(value string) (semver, bool) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "v")
+	value, _, _ = strings.Cut(value, "+")
+	core, pre, _ := strings.Cut(value, "-")
+
+	parts := strings.Split(core, ".")
+	if len(parts) == 0 || len(parts) > 3 {
+		return semver{}, false
+	}
+	numbers := [3]int{}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return semver{}, false
+		}
+		numbers[i] = n
+	}
+	return semver{numbers[0], numbers[1], numbers[2], pre}, true
+}
@@ -0,0 +1,35 @@
+package main
+
+import "testing"
+
+func TestComparisonModeBreaksTiesLexically(t *testing.T) {
+	saved := config.Compare
+	t.Cleanup(func() { config.Compare = saved })
+	config.Compare = map[string]string{
+		"APP_*":     CompareCaseInsensitive,
+		"APP_V*":    CompareNumeric,
+		"*_VERSION": CompareSemver,
+		"APP_URL":   CompareJSON,
+	}
+
+	tests := []struct{ name, want string }{
+		{"APP_VERSION", CompareSemver},       // Longest of three matches
+		{"APP_VALUE", CompareNumeric},        // Longer than APP_*
+		{"APP_HOST", CompareCaseInsensitive}, // Only match
+		{"APP_URL", CompareJSON},             // Exact name entry
+		{"OTHER", CompareExact},              // No match
+	}
+	for _, tt := range tests {
+		if got := comparisonMode(tt.name); got != tt.want {
+			t.Errorf("comparisonMode(%s) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	// "*_TAG" and "APP_*" both match with five characters; "*_TAG" sorts first, every time
+	config.Compare = map[string]string{"APP_*": CompareCaseInsensitive, "*_TAG": CompareSemver}
+	for i := 0; i < 50; i++ {
+		if got := comparisonMode("APP_TAG"); got != CompareSemver {
+			t.Fatalf("comparisonMode(APP_TAG) = %s on try %d, want %s", got, i, CompareSemver)
+		}
+	}
+}
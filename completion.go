@@ -0,0 +1,226 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// completeCommand is the hidden command the completion scripts call for candidates
+const completeCommand = "__complete"
+
+// commandNames are the subcommands offered for completion
+var commandNames = []string{
+	"env", "verify", "import-run", "watch", "serve", "environments", "org-access", "pr-check",
+	"set", "get", "unset", "list", "delete", "clone", "export", "diff-backups", "history",
+	"rollback", "approve", "validate", "login", "completion", "version",
+}
+
+// completionShells are the shells `completion` writes scripts for
+var completionShells = []string{"bash", "zsh", "fish", "powershell"}
+
+// handleCompletion prints the completion script for a shell. The scripts hand the words
+// typed so far to `__complete`, so environments and backups are completed from the live
+// repository and working directory.
+func handleCompletion(args []string) {
+	if len(args) != 1 {
+		fatal(exitValidation, "Usage: completion <%s>", strings.Join(completionShells, "|"))
+	}
+	prog := filepath.Base(os.Args[0])
+	fn := "_" + strings.NewReplacer("-", "_", ".", "_").Replace(prog)
+
+	switch args[0] {
+	case "bash":
+		fmt.Printf(`%[2]s() {
+	local IFS=$'\n'
+	COMPREPLY=($(%[1]s %[3]s "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F %[2]s %[1]s
+`, prog, fn, completeCommand)
+	case "zsh":
+		fmt.Printf(`#compdef %[1]s
+%[2]s() {
+	local -a candidates
+	candidates=("${(@f)$(%[1]s %[3]s "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+	if (( ${#candidates[@]} )) && [[ -n "${candidates[1]}" ]]; then
+		compadd -a candidates
+	else
+		_files
+	fi
+}
+compdef %[2]s %[1]s
+`, prog, fn, completeCommand)
+	case "fish":
+		fmt.Printf("complete -c %[1]s -f -a '(%[1]s %[2]s (commandline -opc)[2..-1] (commandline -ct) 2>/dev/null)'\n",
+			prog, completeCommand)
+	case "powershell":
+		fmt.Printf(`Register-ArgumentCompleter -Native -CommandName '%[1]s' -ScriptBlock {
+	param($wordToComplete, $commandAst, $cursorPosition)
+	$words = @($commandAst.CommandElements | Select-Object -Skip 1 | ForEach-Object { $_.ToString() })
+	if ($wordToComplete -eq '') { $words += '' }
+	& '%[1]s' %[2]s @words 2>$null | ForEach-Object {
+		[System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+	}
+}
+`, prog, completeCommand)
+	default:
+		fatal(exitValidation, "Unknown shell %q (use %s)", args[0], strings.Join(completionShells, ", "))
+	}
+}
+
+// handleComplete prints the candidates for the last word, one per line. The words before it
+// are the command line typed so far, without the program name.
+func handleComplete(words []string, token string) {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current, typed := words[len(words)-1], words[:len(words)-1]
+	for _, candidate := range completionCandidates(typed, current, token) {
+		if strings.HasPrefix(candidate, current) {
+			fmt.Println(candidate)
+		}
+	}
+}
+
+// completionCandidates works out what may come next after the typed words
+func completionCandidates(typed []string, current, token string) []string {
+	command, commandArgs := "", []string{}
+	previous := ""
+	if len(typed) > 0 {
+		previous = typed[len(typed)-1]
+	}
+
+	for i := 0; i < len(typed); i++ {
+		word := typed[i]
+		if command != "" {
+			commandArgs = append(commandArgs, word)
+			continue
+		}
+		if !strings.HasPrefix(word, "-") {
+			command = word
+			continue
+		}
+		// Global flags set the target the dynamic completions query
+		name, value, hasValue := strings.Cut(strings.TrimLeft(word, "-"), "=")
+		f := flag.Lookup(name)
+		if f == nil {
+			continue
+		}
+		if !hasValue && !isBoolFlag(f) && i+1 < len(typed) {
+			i++
+			value, hasValue = typed[i], true
+		}
+		if hasValue {
+			flag.Set(name, value)
+		}
+	}
+
+	// A flag waiting for its value
+	if strings.HasPrefix(previous, "-") && !strings.Contains(previous, "=") {
+		name := strings.TrimLeft(previous, "-")
+		switch {
+		case name == "env" || name == "environment" || (command == "env" && (name == "from" || name == "to")):
+			return completeEnvironments(token)
+		case command == "" && flag.Lookup(name) != nil && !isBoolFlag(flag.Lookup(name)):
+			return nil // The shell falls back to file names
+		}
+	}
+
+	if strings.HasPrefix(current, "-") {
+		if command == "" {
+			return globalFlagNames()
+		}
+		return nil
+	}
+
+	positional := []string{}
+	for _, arg := range commandArgs {
+		if !strings.HasPrefix(arg, "-") {
+			positional = append(positional, arg)
+		}
+	}
+
+	switch command {
+	case "":
+		return commandNames
+	case "completion":
+		if len(positional) == 0 {
+			return completionShells
+		}
+	case "env":
+		if len(positional) == 0 {
+			return []string{"clear", "move"}
+		}
+	case "diff-backups":
+		if len(positional) < 2 {
+			return completeBackups()
+		}
+	case "history", "rollback":
+		if len(positional) == 0 {
+			return completeRunIDs()
+		}
+	}
+	return nil
+}
+
+// isBoolFlag reports whether a flag takes no value
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+// globalFlagNames lists the global flags as --name
+func globalFlagNames() []string {
+	names := []string{}
+	flag.VisitAll(func(f *flag.Flag) {
+		names = append(names, "--"+f.Name)
+	})
+	return names
+}
+
+// completeEnvironments lists the target repository's environments; without a token or a
+// repository there is nothing to offer
+func completeEnvironments(token string) []string {
+	target, err := resolveTarget()
+	if err != nil || token == "" || target.Owner == "" || target.Repo == "" {
+		return nil
+	}
+	environments, err := ListEnvironments(token, target.Owner, target.Repo)
+	if err != nil {
+		return nil
+	}
+	return environments
+}
+
+// completeBackups lists the backup files in backups/ by name, as backupPath resolves them
+func completeBackups() []string {
+	entries, err := os.ReadDir("backups")
+	if err != nil {
+		return nil
+	}
+	names := []string{}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".csv") {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	return names
+}
+
+// completeRunIDs lists the runs in the journal, newest first
+func completeRunIDs() []string {
+	entries, err := ReadJournal(*journalFile)
+	if err != nil {
+		return nil
+	}
+	runs := groupJournalRuns(entries)
+	ids := []string{}
+	for i := len(runs) - 1; i >= 0; i-- {
+		ids = append(ids, runs[i].ID)
+	}
+	return ids
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// VerifyRemoteUnchanged re-fetches the target and returns an error naming every variable
+// that was added, changed, or removed since the snapshot the user approved
+func VerifyRemoteUnchanged(token, owner, repo, environment string, snapshot []Variable) error {
+	current, err := FetchGitHubVariables(token, owner, repo, environment)
+	if err != nil {
+		return fmt.Errorf("failed to re-fetch variables: %w", err)
+	}
+
+	changed := remoteChanges(snapshot, current)
+	if len(changed) > 0 {
+		return fmt.Errorf("%d variable(s) changed in GitHub since the diff was shown: %s",
+			len(changed), strings.Join(changed, ", "))
+	}
+	return nil
+}
+
+// remoteChanges lists names whose presence or value differs between two fetches
+func remoteChanges(before, after []Variable) []string {
+	beforeMap := make(map[string]string)
+	for _, v := range before {
+		beforeMap[v.Name] = v.Value
+	}
+	afterMap := make(map[string]string)
+	for _, v := range after {
+		afterMap[v.Name] = v.Value
+	}
+
+	changed := []string{}
+	for name, value := range beforeMap {
+		if afterValue, ok := afterMap[name]; !ok || afterValue != value {
+			changed = append(changed, name)
+		}
+	}
+	for name := range afterMap {
+		if _, ok := beforeMap[name]; !ok {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
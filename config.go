@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultConfigFile is loaded automatically when it exists
+const defaultConfigFile = "sync-config.yaml"
+
+// Config holds tool settings read from the YAML (or JSON) config file
+type Config struct {
+	Mapping      MappingRules      `json:"mapping"`
+	Sensitive    []string          `json:"sensitive"`     // Glob patterns of variable names whose values must never be printed
+	Mask         []string          `json:"mask"`          // Glob patterns of variable names whose values are shown only as hashes
+	AllowSecrets []string          `json:"allow_secrets"` // Glob patterns of variable names exempt from the credential scan
+	Compare      map[string]string `json:"compare"`       // Name or glob pattern -> comparison mode (see compare.go)
+	Normalize    map[string]string `json:"normalize"`     // Name or glob pattern -> normalization rules (see normalize.go)
+	Audit        AuditConfig       `json:"audit"`
+	Notify       []NotifierConfig  `json:"notify"` // Webhooks notified after a sync or when diff mode finds drift
+	Backup       BackupConfig      `json:"backup"`
+	Hooks        HooksConfig       `json:"hooks"`     // Commands run before and after a sync
+	Ownership    OwnershipConfig   `json:"ownership"` // Teams owning variables, for grouping and --team
+	Manifest     ManifestConfig    `json:"manifest"`  // Signed sync manifests written after each apply
+	Approval     ApprovalConfig    `json:"approval"`  // Targets whose applies need a second person's approval
+}
+
+// BackupConfig configures where backups are copied
+type BackupConfig struct {
+	Destination string   `json:"destination"` // Off-machine copy of every backup (overridden by --backup-dest)
+	Recipients  []string `json:"recipients"`  // Encrypt backups to these age recipients or GPG keys (overridden by --backup-recipients)
+	Identity    string   `json:"identity"`    // age identity file for reading encrypted backups (overridden by SYNC_BACKUP_IDENTITY)
+}
+
+// MappingRules filter and rename variables coming from a source before they are diffed
+type MappingRules struct {
+	IncludePrefix string            `json:"include_prefix"` // Only keep names starting with this prefix
+	StripPrefix   bool              `json:"strip_prefix"`   // Remove IncludePrefix from kept names
+	Exclude       []string          `json:"exclude"`        // Glob patterns of names to drop
+	Rename        map[string]string `json:"rename"`         // Source name -> GitHub name (after prefix stripping)
+}
+
+// config is the loaded configuration; empty when no config file is present
+var config = &Config{}
+
+// LoadConfig reads the config file. A missing file is only an error when required is set.
+func LoadConfig(path string, required bool) (*Config, error) {
+	cfg := &Config{}
+	err := decodeConfigFile(path, cfg)
+	if err != nil {
+		if os.IsNotExist(err) && !required {
+			return &Config{}, nil
+		}
+		return nil, err
+	}
+
+	err = validateComparisonModes(cfg.Compare)
+	if err != nil {
+		return nil, fmt.Errorf("%s: compare: %w", path, err)
+	}
+	err = validateNormalizeRules(cfg.Normalize)
+	if err != nil {
+		return nil, fmt.Errorf("%s: normalize: %w", path, err)
+	}
+	err = cfg.Approval.validate()
+	if err != nil {
+		return nil, fmt.Errorf("%s: approval: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// decodeConfigFile reads a YAML or JSON file like the config, policy, schema, or matrix file
+// into v. YAML is decoded through JSON so the struct tags apply to both formats, with numbers
+// as written, and unknown fields are errors in both. Errors other than reading the file name it.
+func decodeConfigFile(path string, v interface{}) error {
+	data, _, err := readTextFile(path)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(filepath.Ext(path), ".json") {
+		parsed, err := parseYAMLNumbers(data)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		data, err = json.Marshal(parsed)
+		if err != nil {
+			return err
+		}
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return nil
+}
+
+// Apply filters and renames variables according to the mapping rules
+func (m MappingRules) Apply(variables []Variable) []Variable {
+	result := []Variable{}
+	for _, v := range variables {
+		name := v.Name
+		if m.IncludePrefix != "" {
+			if !strings.HasPrefix(name, m.IncludePrefix) {
+				continue
+			}
+			if m.StripPrefix {
+				name = strings.TrimPrefix(name, m.IncludePrefix)
+			}
+		}
+
+		if matchesAnyPattern(name, m.Exclude) {
+			continue
+		}
+
+		if renamed, ok := m.Rename[name]; ok {
+			name = renamed
+		}
+
+		if name != "" {
+			result = append(result, Variable{Name: name, Value: v.Value})
+		}
+	}
+	return result
+}
+
+// matchesAnyPattern reports whether name matches any glob pattern (as in filepath.Match)
+func matchesAnyPattern(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,369 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// readCSVRecords reads every record of a CSV file, allowing rows with differing column counts.
+// It also returns the file's encoding, so it can be written back the same way.
+func readCSVRecords(filename string) ([][]string, string, error) {
+	content, encoding, err := readTextFile(filename)
+	if err != nil {
+		return nil, "", err
+	}
+
+	records, err := parseCSVRecords(content)
+	return records, encoding, err
+}
+
+// parseCSVRecords reads every record of a CSV file's content, allowing rows with differing
+// column counts
+func parseCSVRecords(data []byte) ([][]string, error) {
+	return newCSVReader(data).ReadAll()
+}
+
+// csvReader is a csv.Reader that also keeps "\r\n" inside quoted fields, which encoding/csv
+// always turns into "\n". In a file whose lines all end in "\r\n" it's the file's line ending,
+// so those files are left as encoding/csv reads them.
+type csvReader struct {
+	*csv.Reader
+	data       []byte
+	lineStarts []int // Byte offset of each line, to find fields by FieldPos
+	keepCRLF   bool
+}
+
+// newCSVReader returns a reader that tolerates rows with differing column counts and stray
+// quotes inside unquoted values (e.g. KEY,say "hi"), as hand-edited and exported files have
+func newCSVReader(data []byte) *csvReader {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	crlf := bytes.Count(data, []byte("\r\n"))
+	r := &csvReader{Reader: reader, data: data, lineStarts: []int{0}, keepCRLF: crlf > 0 && crlf < bytes.Count(data, []byte("\n"))}
+	for i, b := range data {
+		if b == '\n' {
+			r.lineStarts = append(r.lineStarts, i+1)
+		}
+	}
+	return r
+}
+
+// Read reads one record, with quoted fields' line breaks as they are in the file
+func (r *csvReader) Read() ([]string, error) {
+	record, err := r.Reader.Read()
+	if err != nil || !r.keepCRLF {
+		return record, err
+	}
+	for i, field := range record {
+		if !strings.Contains(field, "\n") {
+			continue
+		}
+		// Only trust the raw text when it's what encoding/csv read, give or take the "\r"s
+		if raw, ok := r.rawQuotedField(i); ok && strings.ReplaceAll(raw, "\r\n", "\n") == field {
+			record[i] = raw
+		}
+	}
+	return record, nil
+}
+
+// ReadAll reads the remaining records
+func (r *csvReader) ReadAll() ([][]string, error) {
+	var records [][]string
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+}
+
+// fieldOffset returns the byte offset in the file of a field of the record just read
+func (r *csvReader) fieldOffset(field int) (int, bool) {
+	line, column := r.FieldPos(field)
+	if line < 1 || line > len(r.lineStarts) || column < 1 {
+		return 0, false
+	}
+	offset := r.lineStarts[line-1] + column - 1
+	return offset, offset < len(r.data)
+}
+
+// quoted reports whether a field of the record just read was quoted in the file
+func (r *csvReader) quoted(field int) bool {
+	offset, ok := r.fieldOffset(field)
+	return ok && r.data[offset] == '"'
+}
+
+// rawQuotedField returns a quoted field of the record just read exactly as the file has it,
+// with doubled quotes undone
+func (r *csvReader) rawQuotedField(field int) (string, bool) {
+	offset, ok := r.fieldOffset(field)
+	if !ok || r.data[offset] != '"' {
+		return "", false
+	}
+	var b strings.Builder
+	for i := offset + 1; i < len(r.data); i++ {
+		switch {
+		case r.data[i] != '"':
+			b.WriteByte(r.data[i])
+		case i+1 < len(r.data) && r.data[i+1] == '"':
+			b.WriteByte('"')
+			i++
+		default:
+			return b.String(), true
+		}
+	}
+	return "", false
+}
+
+// How the first row of a CSV file is read, selected with --csv-header
+const (
+	CSVHeaderSkip   = "skip"   // The first row is a header and always skipped
+	CSVHeaderDetect = "detect" // The first row is skipped only when it looks like a header
+	CSVHeaderNone   = "none"   // There is no header; the first row is a variable
+)
+
+func validCSVHeader(mode string) bool {
+	return mode == CSVHeaderSkip || mode == CSVHeaderDetect || mode == CSVHeaderNone
+}
+
+// csvHeaderNames are first-column titles that mark the first row as a header
+var csvHeaderNames = map[string]bool{"key": true, "name": true, "variable": true, "variable_name": true}
+
+// isCSVHeader reports whether the first row of a CSV file is a header rather than a
+// variable, following --csv-header
+func isCSVHeader(record []string) bool {
+	switch *csvHeader {
+	case CSVHeaderNone:
+		return false
+	case CSVHeaderDetect:
+		return looksLikeCSVHeader(record)
+	}
+	return true
+}
+
+// looksLikeCSVHeader reports whether a row has a header's column titles
+func looksLikeCSVHeader(record []string) bool {
+	if len(record) == 0 {
+		return false
+	}
+	if csvHeaderNames[strings.ToLower(strings.TrimSpace(record[0]))] {
+		return true
+	}
+	return len(record) >= 2 && strings.EqualFold(strings.TrimSpace(record[1]), "value")
+}
+
+// writeCSV writes records as CSV. Unlike encoding/csv, fields with trailing whitespace are
+// quoted too, so values read back exactly (unquoted values are trimmed when read).
+func writeCSV(w io.Writer, records [][]string) error {
+	var b strings.Builder
+	for _, record := range records {
+		for i, field := range record {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			if csvFieldNeedsQuotes(field) {
+				b.WriteString(`"` + strings.ReplaceAll(field, `"`, `""`) + `"`)
+			} else {
+				b.WriteString(field)
+			}
+		}
+		b.WriteByte('\n')
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func csvFieldNeedsQuotes(field string) bool {
+	if field == "" {
+		return false
+	}
+	if strings.ContainsAny(field, ",\"\r\n") {
+		return true
+	}
+	first, _ := utf8.DecodeRuneInString(field)
+	last, _ := utf8.DecodeLastRuneInString(field)
+	return unicode.IsSpace(first) || unicode.IsSpace(last)
+}
+
+// writeCSVRecords atomically replaces a CSV file with the given records, in the given encoding
+func writeCSVRecords(filename string, records [][]string, encoding string) error {
+	var content bytes.Buffer
+	err := writeCSV(&content, records)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(filename), ".variables-*.csv")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(encodeText(content.Bytes(), encoding))
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filename)
+}
+
+// UpdateCSVValues replaces the Value column of the named rows in place, keeping row
+// order, the Note column, and any other columns untouched. The GitHub (old) value of
+// each change is written.
+func UpdateCSVValues(filename string, changes []VariableChange) error {
+	records, encoding, err := readCSVRecords(filename)
+	if err != nil {
+		return err
+	}
+
+	err = updateCSVRecordValues(records, changes)
+	if err != nil {
+		return err
+	}
+
+	return writeCSVRecords(filename, records, encoding)
+}
+
+// updateCSVRecordValues sets the Value column of the changed rows to their GitHub (old) value
+func updateCSVRecordValues(records [][]string, changes []VariableChange) error {
+	values := make(map[string]string)
+	for _, c := range changes {
+		values[nameKey(c.Name)] = c.OldValue
+	}
+
+	found := make(map[string]bool)
+	for i, record := range records {
+		if (i == 0 && isCSVHeader(record)) || len(record) < 2 {
+			continue
+		}
+		name := nameKey(strings.TrimSpace(record[0]))
+		if value, ok := values[name]; ok {
+			record[1] = value
+			found[name] = true
+		}
+	}
+	if len(found) != len(values) {
+		return fmt.Errorf("expected to update %d variable(s) but found %d in the file", len(values), len(found))
+	}
+	return nil
+}
+
+// AppendCSVRows adds variables as new rows at the end of a CSV file. The note is
+// written only if the file's header has a Note column.
+func AppendCSVRows(filename string, variables []Variable, note string) error {
+	records, encoding, err := readCSVRecords(filename)
+	if err != nil {
+		return err
+	}
+
+	return writeCSVRecords(filename, appendCSVRecords(records, variables, note), encoding)
+}
+
+// appendCSVRecords adds variables as rows, with the note when the header has a Note column
+func appendCSVRecords(records [][]string, variables []Variable, note string) [][]string {
+	withNote := len(records) > 0 && len(records[0]) >= 3
+	for _, v := range variables {
+		if withNote {
+			records = append(records, []string{v.Name, v.Value, note})
+		} else {
+			records = append(records, []string{v.Name, v.Value})
+		}
+	}
+	return records
+}
+
+// readCSVLineNumbers maps each variable name to the line numbers it appears on
+func readCSVLineNumbers(filename string) (map[string][]int, error) {
+	content, _, err := readTextFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := newCSVReader(content)
+	lines := map[string][]int{}
+	for row := 0; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if (row == 0 && isCSVHeader(record)) || len(record) == 0 {
+			continue
+		}
+		name := strings.TrimSpace(record[0])
+		line, _ := reader.FieldPos(0)
+		lines[name] = append(lines[name], line)
+	}
+	return lines, nil
+}
+
+// readCSVColumn maps each variable's name key to its value in the first column titled one
+// of titles (case-insensitive). Files without a header or such a column give an empty map.
+func readCSVColumn(filename string, titles ...string) (map[string]string, error) {
+	content, _, err := readTextFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	reader := newCSVReader(content)
+	header, err := reader.Read()
+	if err == io.EOF || (err == nil && !isCSVHeader(header)) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	column := -1
+	for i := len(header) - 1; i >= 0; i-- {
+		for _, title := range titles {
+			if strings.EqualFold(strings.TrimSpace(header[i]), title) {
+				column = i
+			}
+		}
+	}
+
+	values := map[string]string{}
+	if column < 0 {
+		return values, nil
+	}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) > column && strings.TrimSpace(record[0]) != "" {
+			value := record[column]
+			if !reader.quoted(column) {
+				// Like values, unquoted fields are trimmed and quoted ones kept exactly
+				value = strings.TrimSpace(value)
+			}
+			if value != "" {
+				values[nameKey(normalizeName(strings.TrimSpace(record[0])))] = value
+			}
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// gnarlyVariables are values and notes that CSV quoting has to carry through unchanged
+var gnarlyVariables = []struct{ name, value, note string }{
+	{"PLAIN", "value", ""},
+	{"COMMAS", "a,b,,c", "split, later"},
+	{"QUOTES", `say "hi" and ""bye""`, `the "legacy" one`},
+	{"LF", "line one\nline two\n", "first line\nsecond line"},
+	{"CRLF", "line one\r\nline two", "windows\r\nnote"},
+	{"BARE_CR", "a\rb", ""},
+	{"LEADING", "   padded", " note with space"},
+	{"TRAILING", "padded  ", "note with space "},
+	{"TABS", "\tindented\t", ""},
+	{"EMPTY", "", "empty on purpose"},
+	{"EVERYTHING", " \"a, b\"\r\n\"c\" ", "all, \"of\"\nit "},
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	variables := []Variable{}
+	notes := map[string]string{}
+	for _, g := range gnarlyVariables {
+		variables = append(variables, Variable{Name: g.name, Value: g.value})
+		if g.note != "" {
+			notes[nameKey(g.name)] = g.note
+		}
+	}
+
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.csv")
+	if err := ExportVariablesToCSV(variables, notes, first, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := readCSV(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read, variables) {
+		for i := range variables {
+			if i >= len(read) || read[i] != variables[i] {
+				t.Errorf("variable %d: got %q, want %q", i, read[i:i+1], variables[i])
+				break
+			}
+		}
+		t.Fatalf("read %d variables, want %d", len(read), len(variables))
+	}
+
+	readNotes, err := readCSVColumn(first, "note")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, g := range gnarlyVariables {
+		if readNotes[nameKey(g.name)] != g.note {
+			t.Errorf("%s note = %q, want %q", g.name, readNotes[nameKey(g.name)], g.note)
+		}
+	}
+
+	// Exporting what was read gives the same file again
+	second := filepath.Join(dir, "second.csv")
+	if err := ExportVariablesToCSV(read, readNotes, second, nil); err != nil {
+		t.Fatal(err)
+	}
+	a, _ := os.ReadFile(first)
+	b, _ := os.ReadFile(second)
+	if string(a) != string(b) {
+		t.Errorf("second export differs:\n%q\n%q", a, b)
+	}
+}
+
+func TestReadCSVHandWritten(t *testing.T) {
+	content := "Key,Value,Note\r\n" +
+		"HOST,  example.com  ,trimmed when unquoted\r\n" +
+		"MOTD,\"  kept  \",\"quoted, with \"\"quotes\"\"\"\r\n" +
+		"CERT,\"-----BEGIN-----\r\nabc\r\n-----END-----\",multi-line\r\n" +
+		"SAY,say \"hi\",stray quotes\r\n"
+	file := filepath.Join(t.TempDir(), "vars.csv")
+	if err := os.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	read, err := readCSV(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Variable{
+		{Name: "HOST", Value: "example.com"},
+		{Name: "MOTD", Value: "  kept  "},
+		{Name: "CERT", Value: "-----BEGIN-----\nabc\n-----END-----"},
+		{Name: "SAY", Value: `say "hi"`},
+	}
+	if !reflect.DeepEqual(read, want) {
+		t.Errorf("got %q\nwant %q", read, want)
+	}
+	notes, err := readCSVColumn(file, "note")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notes["MOTD"] != `quoted, with "quotes"` {
+		t.Errorf("MOTD note = %q", notes["MOTD"])
+	}
+}
+
+func TestReadCSVHeaderModes(t *testing.T) {
+	saved := *csvHeader
+	t.Cleanup(func() { *csvHeader = saved })
+	files := map[string]string{
+		"upper":      "NAME,VALUE\nA,1\nB,2\n",
+		"with-note":  "name,value,note\nA,1,first\nB,2,\n",
+		"headerless": "A,1\nB,2\n",
+	}
+	both := []Variable{{Name: "A", Value: "1"}, {Name: "B", Value: "2"}}
+	tests := []struct {
+		mode, file string
+		want       []Variable
+	}{
+		{CSVHeaderSkip, "upper", both},
+		{CSVHeaderSkip, "with-note", both},
+		{CSVHeaderSkip, "headerless", both[1:]}, // The first row is skipped, as it always was
+		{CSVHeaderDetect, "upper", both},
+		{CSVHeaderDetect, "with-note", both},
+		{CSVHeaderDetect, "headerless", both},
+		{CSVHeaderNone, "headerless", both},
+	}
+	dir := t.TempDir()
+	for _, tt := range tests {
+		path := filepath.Join(dir, tt.file+".csv")
+		if err := os.WriteFile(path, []byte(files[tt.file]), 0644); err != nil {
+			t.Fatal(err)
+		}
+		*csvHeader = tt.mode
+		read, err := readCSV(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(read, tt.want) {
+			t.Errorf("--csv-header %s, %s: got %q, want %q", tt.mode, tt.file, read, tt.want)
+		}
+		if tt.file == "with-note" {
+			notes, err := readCSVColumn(path, "note")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if notes["A"] != "first" {
+				t.Errorf("--csv-header %s: A note = %q, want first", tt.mode, notes["A"])
+			}
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// errDeadlineExceeded is returned for requests attempted after the --deadline has passed
+var errDeadlineExceeded = errors.New("run deadline exceeded")
+
+// runCtx carries the overall run deadline; it never expires when --deadline isn't set
+var (
+	runCtx                       = context.Background()
+	runCancel context.CancelFunc = func() {}
+)
+
+// setRunDeadline starts the overall run deadline, measured from now
+func setRunDeadline(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+	runCtx, runCancel = context.WithTimeout(context.Background(), d)
+}
+
+// deadlineExceeded reports whether the overall run deadline has passed
+func deadlineExceeded() bool {
+	return runCtx.Err() != nil
+}
+
+// deadlineTransport bounds every request by the overall run deadline, so a slow
+// proxy or server can't keep the run going past it
+type deadlineTransport struct {
+	base http.RoundTripper
+}
+
+func (t *deadlineTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if deadlineExceeded() {
+		return nil, errDeadlineExceeded
+	}
+	if _, ok := runCtx.Deadline(); !ok {
+		return t.base.RoundTrip(req)
+	}
+
+	resp, err := t.base.RoundTrip(req.WithContext(runCtx))
+	if err != nil && deadlineExceeded() {
+		return nil, errDeadlineExceeded
+	}
+	return resp, err
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// debugBodyLimit caps how much of each body --debug-http-bodies prints
+const debugBodyLimit = 4096
+
+// debugSensitiveKeys are JSON fields whose values are never logged: variable values,
+// file contents, and minted tokens
+var debugSensitiveKeys = map[string]bool{
+	"value":           true,
+	"content":         true,
+	"token":           true,
+	"encrypted_value": true,
+}
+
+// debugSafeQueryParams are query parameters logged as-is; any other value (e.g. a SAS signature) is redacted
+var debugSafeQueryParams = map[string]bool{
+	"page":     true,
+	"per_page": true,
+	"ref":      true,
+	"state":    true,
+	"head":     true,
+	"base":     true,
+}
+
+// debugTransport logs every request and response to stderr for --debug-http, with
+// credentials and variable values redacted
+type debugTransport struct {
+	base   http.RoundTripper
+	bodies bool
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	line := fmt.Sprintf("→ %s %s", req.Method, debugURL(req.URL))
+	if auth := req.Header.Get("Authorization"); auth != "" {
+		scheme, _, _ := strings.Cut(auth, " ")
+		line += fmt.Sprintf(" (Authorization: %s %s)", scheme, redactedPlaceholder)
+	}
+	debugLog(line)
+
+	if t.bodies && req.Body != nil && req.Body != http.NoBody {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(data))
+		debugBody(data)
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+	if err != nil {
+		debugLog(fmt.Sprintf("← error after %v: %v", elapsed, err))
+		return resp, err
+	}
+
+	line = fmt.Sprintf("← %s in %v", resp.Status, elapsed)
+	if remaining := resp.Header.Get("X-RateLimit-Remaining"); remaining != "" {
+		line += fmt.Sprintf(", rate limit %s/%s", remaining, resp.Header.Get("X-RateLimit-Limit"))
+		if resource := resp.Header.Get("X-RateLimit-Resource"); resource != "" {
+			line += " " + resource
+		}
+		if reset := resp.Header.Get("X-RateLimit-Reset"); reset != "" {
+			line += ", resets " + formatRateLimitReset(reset)
+		}
+	}
+	if retryAfter := resp.Header.Get("Retry-After"); retryAfter != "" {
+		line += ", Retry-After " + retryAfter
+	}
+	if requestID := resp.Header.Get("X-GitHub-Request-Id"); requestID != "" {
+		line += ", request " + requestID
+	}
+	debugLog(line)
+
+	if t.bodies && resp.Body != nil {
+		data, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(data))
+		if readErr != nil {
+			return resp, readErr
+		}
+		debugBody(data)
+	}
+	return resp, nil
+}
+
+// debugURL returns a URL for logging, with user info and non-allowlisted query values redacted
+func debugURL(u *url.URL) string {
+	safe := *u
+	safe.User = nil
+	query := safe.Query()
+	for key, values := range query {
+		if !debugSafeQueryParams[key] {
+			for i := range values {
+				values[i] = redactedPlaceholder
+			}
+		}
+	}
+	safe.RawQuery = strings.ReplaceAll(query.Encode(), url.QueryEscape(redactedPlaceholder), redactedPlaceholder)
+	return safeValue(safe.String())
+}
+
+// debugBody logs a request or response body, with sensitive JSON fields and known values redacted
+func debugBody(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	text := safeValue(string(redactJSONFields(data)))
+	if len(text) > debugBodyLimit {
+		text = fmt.Sprintf("%s... (%d bytes total)", text[:debugBodyLimit], len(text))
+	}
+	for _, line := range strings.Split(strings.TrimRight(text, "\n"), "\n") {
+		debugLog("    " + line)
+	}
+}
+
+// redactJSONFields replaces the values of debugSensitiveKeys anywhere in a JSON document;
+// anything that isn't JSON is returned unchanged
+func redactJSONFields(data []byte) []byte {
+	var parsed interface{}
+	if json.Unmarshal(data, &parsed) != nil {
+		return data
+	}
+	redacted, err := json.Marshal(redactJSONValue(parsed))
+	if err != nil {
+		return data
+	}
+	return redacted
+}
+
+func redactJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if debugSensitiveKeys[strings.ToLower(key)] {
+				if s, ok := field.(string); ok && s != "" {
+					v[key] = redactedPlaceholder + " " + hashValue(s)
+					continue
+				}
+			}
+			v[key] = redactJSONValue(field)
+		}
+	case []interface{}:
+		for i := range v {
+			v[i] = redactJSONValue(v[i])
+		}
+	}
+	return value
+}
+
+// formatRateLimitReset renders an X-RateLimit-Reset epoch as a local time
+func formatRateLimitReset(reset string) string {
+	var epoch int64
+	if _, err := fmt.Sscan(reset, &epoch); err != nil {
+		return reset
+	}
+	return time.Unix(epoch, 0).Local().Format("15:04:05")
+}
+
+func debugLog(line string) {
+	fmt.Fprintf(os.Stderr, "[http] %s\n", line)
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// handleDelete deletes the variables named on the command line or in a names file from the
+// target, after a preview and confirmation, leaving everything else alone
+func handleDelete(args []string, token, owner, repo, environment string) {
+	fs := flag.NewFlagSet("delete", flag.ExitOnError)
+	envName := fs.String("env", environment, "Environment (defaults to GITHUB_ENVIRONMENT; empty for repository variables)")
+	namesFile := fs.String("file", "", "File of variable names to delete, one per line (# starts a comment)")
+	fs.Parse(args)
+
+	names := fs.Args()
+	if *namesFile != "" {
+		fromFile, err := readNamesFile(*namesFile)
+		if err != nil {
+			fatal(exitValidation, "Error reading %s: %v", *namesFile, err)
+		}
+		names = append(names, fromFile...)
+	}
+	if len(names) == 0 {
+		fmt.Println("❌ Usage: delete [--env NAME] [--file names.txt] [VARIABLE...]")
+		exit(exitFailure)
+	}
+
+	lockOrExit(token, owner, repo, *envName)
+	target := targetName(owner, repo, *envName)
+	fmt.Printf("🎯 Target: %s\n", target)
+	fmt.Println("🔍 Fetching current variables from GitHub...")
+	remote, err := FetchGitHubVariables(token, owner, repo, *envName)
+	if err != nil {
+		fatal(exitFailure, "Error fetching GitHub variables: %v", err)
+	}
+	remoteByKey := map[string]Variable{}
+	for _, v := range remote {
+		remoteByKey[nameKey(v.Name)] = v
+	}
+
+	toDelete := []Variable{}
+	missing, ignored := []string{}, []string{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		key := nameKey(normalizeName(name))
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if isIgnored(name) {
+			ignored = append(ignored, name)
+		} else if v, ok := remoteByKey[key]; ok {
+			toDelete = append(toDelete, v)
+		} else {
+			missing = append(missing, name)
+		}
+	}
+
+	if len(ignored) > 0 {
+		fmt.Printf("\n🙈 Managed elsewhere (%s), not deleted: %s\n", *ignoreFile, strings.Join(ignored, ", "))
+	}
+	if len(missing) > 0 {
+		fmt.Printf("\nℹ️  Not in %s (already deleted?): %s\n", target, strings.Join(missing, ", "))
+	}
+	if len(toDelete) == 0 {
+		fmt.Println("✅ None of the variables exist. Nothing to delete")
+		return
+	}
+
+	fmt.Printf("\n%s[TO BE DELETED]%s\n", ColorRed+ColorBold, ColorReset)
+	for _, v := range toDelete {
+		fmt.Printf("%s- %s = %s%s%s\n", ColorRed, v.Name, truncateValue(shownValue(v.Name, v.Value), valueLimit(80, true)), ColorReset, lastChanged(v.UpdatedAt))
+	}
+	fmt.Printf("%s%d other variable(s) are kept%s\n", ColorGray, len(remote)-len(toDelete), ColorReset)
+
+	fmt.Println()
+	requireApproval(token, owner, repo, *envName, DiffResult{Deleted: toDelete})
+	if !confirmDeletion(len(toDelete), target, confirmationName(owner, repo)) {
+		fmt.Println("\n❌ Delete cancelled by user")
+		exit(exitCancelled)
+	}
+
+	deleted, failed, err := ClearEnvironmentVariables(token, owner, repo, *envName, toDelete)
+	if err != nil {
+		fatal(exitFailure, "%v", err)
+	}
+
+	fmt.Println()
+	fmt.Printf("🎉 Completed! Deleted %d, Failed %d variables\n", deleted, failed)
+	if failed > 0 {
+		runError = fmt.Sprintf("%d variable(s) failed to delete", failed)
+		exit(exitPartial)
+	}
+}
+
+// readNamesFile reads variable names, one per line. Blank lines and # comments are skipped,
+// and only the first CSV field is used, so a variables CSV works as a names file too.
+func readNamesFile(path string) ([]string, error) {
+	data, _, err := readTextFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	scanner := bufio.NewScanner(strings.NewReader(string(data)))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		name, _, _ := strings.Cut(text, ",")
+		name = strings.TrimSpace(name)
+		if line == 1 && csvHeaderNames[strings.ToLower(name)] {
+			continue // Header of a variables CSV
+		}
+		if err := validateVariableName(normalizeName(name)); err != nil {
+			return nil, fmt.Errorf("line %d: %v", line, err)
+		}
+		names = append(names, name)
+	}
+	return names, scanner.Err()
+}
@@ -4,7 +4,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
 )
 
 // ANSI color codes for terminal output
@@ -13,6 +18,7 @@ const (
 	ColorRed    = "\033[31m"
 	ColorGreen  = "\033[32m"
 	ColorYellow = "\033[33m"
+	ColorBlue   = "\033[34m"
 	ColorGray   = "\033[90m"
 	ColorBold   = "\033[1m"
 )
@@ -23,13 +29,15 @@ type DiffResult struct {
 	Updated   []VariableChange // Variables that exist but values differ (will be updated)
 	Unchanged []Variable       // Variables with same values (no action)
 	Deleted   []Variable       // Variables in GitHub but not in CSV (informational only)
+	Ignored   int              // Variables left out because they match the ignore file
 }
 
 // VariableChange represents a variable that will be updated
 type VariableChange struct {
-	Name     string
-	OldValue string // Current value in GitHub
-	NewValue string // New value from CSV
+	Name            string
+	OldValue        string    // Current value in GitHub
+	NewValue        string    // New value from CSV
+	RemoteUpdatedAt time.Time // When the GitHub value was last changed
 }
 
 // GitHubVariablesResponse represents the GitHub API response for listing variables
@@ -41,22 +49,18 @@ type GitHubVariablesResponse struct {
 // FetchGitHubVariables fetches all current variables from GitHub with pagination support
 // GitHub API returns max 30 items by default, 100 max per page
 func FetchGitHubVariables(token, owner, repo, environment string) ([]Variable, error) {
-	var baseURL string
-	if environment != "" {
-		// Environment-specific variable
-		baseURL = fmt.Sprintf("%s/repos/%s/%s/environments/%s/variables", githubAPIURL, owner, repo, environment)
-	} else {
-		// Repository-level variable
-		baseURL = fmt.Sprintf("%s/repos/%s/%s/actions/variables", githubAPIURL, owner, repo)
+	if pendingEnvironments[targetName(owner, repo, environment)] {
+		return []Variable{}, nil // Would be created by the sync
 	}
+	emitEvent(EventFetchStart, eventTarget(owner, repo, environment), nil)
+
+	baseURL := variableURL(owner, repo, environment, "")
 
 	allVariables := []Variable{}
-	page := 1
 	perPage := 100 // Maximum allowed by GitHub API
+	url := fmt.Sprintf("%s?per_page=%d&page=1", baseURL, perPage)
 
-	for {
-		url := fmt.Sprintf("%s?per_page=%d&page=%d", baseURL, perPage, page)
-		
+	for page := 1; ; page++ {
 		req, err := http.NewRequest("GET", url, nil)
 		if err != nil {
 			return nil, err
@@ -74,7 +78,7 @@ func FetchGitHubVariables(token, owner, repo, environment string) ([]Variable, e
 		if resp.StatusCode != 200 {
 			body, _ := io.ReadAll(resp.Body)
 			resp.Body.Close()
-			return nil, fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(body))
+			return nil, newAPIError(resp, body)
 		}
 
 		body, err := io.ReadAll(resp.Body)
@@ -91,20 +95,35 @@ func FetchGitHubVariables(token, owner, repo, environment string) ([]Variable, e
 
 		// Add variables from this page
 		allVariables = append(allVariables, response.Variables...)
+		registerSensitiveValues(response.Variables)
 
-		// Check if we've fetched all variables
-		// Break if: no more variables OR we've fetched all (total_count)
+		// The Link header's rel="next" is authoritative: total_count can be stale on GHES.
+		// Without a Link header (single page, or stripped by a proxy) fall back to total_count.
+		next, hasLinks, err := nextPageURL(baseURL, resp.Header)
+		if err != nil {
+			return nil, err
+		}
+		if hasLinks {
+			if next == "" || next == url {
+				break
+			}
+			url = next
+			continue
+		}
 		if len(response.Variables) == 0 || len(allVariables) >= response.TotalCount {
 			break
 		}
-
-		page++
+		url = fmt.Sprintf("%s?per_page=%d&page=%d", baseURL, perPage, page+1)
 	}
 
+	// The remote lock sentinel is the tool's own bookkeeping, not a managed variable
+	allVariables = withoutLockVariable(allVariables)
+	rememberValues(owner, repo, environment, allVariables)
 	return allVariables, nil
 }
 
-// CompareSets compares local CSV variables with remote GitHub variables
+// CompareSets compares local CSV variables with remote GitHub variables. Names are matched
+// regardless of case unless --name-case is exact.
 func CompareSets(local, remote []Variable) DiffResult {
 	result := DiffResult{
 		New:       []Variable{},
@@ -113,10 +132,19 @@ func CompareSets(local, remote []Variable) DiffResult {
 		Deleted:   []Variable{},
 	}
 
-	// Create a map of remote variables for quick lookup
-	remoteMap := make(map[string]string)
+	// Variables managed elsewhere are not compared at all, so they are never changed or pruned
+	local, ignoredLocal := withoutIgnored(local)
+	remote, ignoredRemote := withoutIgnored(remote)
+	ignored := map[string]bool{}
+	for _, v := range append(ignoredLocal, ignoredRemote...) {
+		ignored[nameKey(v.Name)] = true
+	}
+	result.Ignored = len(ignored)
+
+	// Create a map of remote variables for quick lookup, keyed per --name-case
+	remoteMap := make(map[string]Variable)
 	for _, v := range remote {
-		remoteMap[v.Name] = v.Value
+		remoteMap[nameKey(v.Name)] = v
 	}
 
 	// Check each local variable
@@ -125,19 +153,21 @@ func CompareSets(local, remote []Variable) DiffResult {
 			continue
 		}
 
-		remoteValue, exists := remoteMap[localVar.Name]
+		remoteVar, exists := remoteMap[nameKey(localVar.Name)]
+		remoteValue := remoteVar.Value
 		if !exists {
 			// Variable doesn't exist in GitHub - will be created
 			result.New = append(result.New, localVar)
-		} else if remoteValue != localVar.Value {
+		} else if !valuesEqual(localVar.Name, localVar.Value, remoteValue) {
 			// Variable exists but value is different - will be updated
 			result.Updated = append(result.Updated, VariableChange{
-				Name:     localVar.Name,
-				OldValue: remoteValue,
-				NewValue: localVar.Value,
+				Name:            localVar.Name,
+				OldValue:        remoteValue,
+				NewValue:        localVar.Value,
+				RemoteUpdatedAt: remoteVar.UpdatedAt,
 			})
 		} else {
-			// Variable exists with same value - no action needed
+			// Variable exists with same (or equivalent) value - no action needed
 			result.Unchanged = append(result.Unchanged, localVar)
 		}
 	}
@@ -146,13 +176,13 @@ func CompareSets(local, remote []Variable) DiffResult {
 	localMap := make(map[string]bool)
 	for _, v := range local {
 		if v.Name != "" {
-			localMap[v.Name] = true
+			localMap[nameKey(v.Name)] = true
 		}
 	}
 
 	// Find variables in GitHub but not in CSV
 	for _, remoteVar := range remote {
-		if !localMap[remoteVar.Name] {
+		if !localMap[nameKey(remoteVar.Name)] {
 			result.Deleted = append(result.Deleted, remoteVar)
 		}
 	}
@@ -160,6 +190,10 @@ func CompareSets(local, remote []Variable) DiffResult {
 	return result
 }
 
+// diffSides names the two sides of a diff in its output: the one compared against and the
+// input. DeletedNote says what happens to variables only in the first.
+var diffSides = struct{ Old, New, DeletedNote string }{"GitHub", "CSV", "These will NOT be deleted from GitHub"}
+
 // DisplayDiffSummary displays a summary table of the diff
 func DisplayDiffSummary(diff DiffResult) {
 	fmt.Println("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
@@ -171,7 +205,10 @@ func DisplayDiffSummary(diff DiffResult) {
 	fmt.Printf("%s✅ Unchanged:%s %d variable(s)\n", ColorGray, ColorReset, len(diff.Unchanged))
 	
 	if len(diff.Deleted) > 0 {
-		fmt.Printf("%s⚠️  Deleted:%s   %d variable(s) (in GitHub, not in CSV)\n", ColorRed, ColorReset, len(diff.Deleted))
+		fmt.Printf("%s⚠️  Deleted:%s   %d variable(s) (in %s, not in %s)\n", ColorRed, ColorReset, len(diff.Deleted), diffSides.Old, diffSides.New)
+	}
+	if diff.Ignored > 0 {
+		fmt.Printf("%s🙈 Ignored:%s   %d variable(s) (managed elsewhere, see %s)\n", ColorGray, ColorReset, diff.Ignored, *ignoreFile)
 	}
 	
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
@@ -182,12 +219,27 @@ func DisplayDetailedDiff(diff DiffResult) {
 	fmt.Println("\n📝 DETAILED CHANGES:")
 	fmt.Println()
 
+	// With owners, each team's changes are listed together
+	if ownershipConfigured() && *team == "" {
+		owners, groups := groupDiffByOwner(diff)
+		for _, owner := range owners {
+			g := groups[owner]
+			fmt.Printf("%s👥 %s%s %s(%d new, %d updated)%s\n\n", ColorBlue+ColorBold, owner, ColorReset, ColorGray, len(g.New), len(g.Updated), ColorReset)
+			displayDiffDetails(*g)
+		}
+		return
+	}
+	displayDiffDetails(diff)
+}
+
+// displayDiffDetails lists the new, updated, unchanged, and deleted variables of a diff
+func displayDiffDetails(diff DiffResult) {
 	// Display new variables
 	if len(diff.New) > 0 {
 		fmt.Printf("%s[NEW VARIABLES]%s\n", ColorGreen+ColorBold, ColorReset)
 		for _, v := range diff.New {
-			value := truncateValue(v.Value, 80)
-			fmt.Printf("%s+ %s = %s%s\n", ColorGreen, v.Name, value, ColorReset)
+			value := truncateValue(shownValue(v.Name, v.Value), valueLimit(80, true))
+			fmt.Printf("%s+ %s = %s%s%s\n", ColorGreen, v.Name, value, ColorReset, noteSuffix(v.Name))
 		}
 		fmt.Println()
 	}
@@ -195,13 +247,7 @@ func DisplayDetailedDiff(diff DiffResult) {
 	// Display updated variables
 	if len(diff.Updated) > 0 {
 		fmt.Printf("%s[UPDATED VARIABLES]%s\n", ColorYellow+ColorBold, ColorReset)
-		for _, change := range diff.Updated {
-			oldValue := truncateValue(change.OldValue, 60)
-			newValue := truncateValue(change.NewValue, 60)
-			fmt.Printf("%s~ %s:%s\n", ColorYellow, change.Name, ColorReset)
-			fmt.Printf("  %s- %s%s\n", ColorRed, oldValue, ColorReset)
-			fmt.Printf("  %s+ %s%s\n", ColorGreen, newValue, ColorReset)
-		}
+		displayUpdatedVariables(diff.Updated, *diffFormat)
 		fmt.Println()
 	}
 
@@ -214,21 +260,107 @@ func DisplayDetailedDiff(diff DiffResult) {
 
 	// Display deleted variables (informational)
 	if len(diff.Deleted) > 0 {
-		fmt.Printf("%s[DELETED - in GitHub but not in CSV]%s\n", ColorRed+ColorBold, ColorReset)
-		fmt.Printf("%sNote: These will NOT be deleted from GitHub%s\n", ColorGray, ColorReset)
+		fmt.Printf("%s[DELETED - in %s but not in %s]%s\n", ColorRed+ColorBold, diffSides.Old, diffSides.New, ColorReset)
+		if diffSides.DeletedNote != "" {
+			fmt.Printf("%sNote: %s%s\n", ColorGray, diffSides.DeletedNote, ColorReset)
+		}
 		for _, v := range diff.Deleted {
-			value := truncateValue(v.Value, 80)
-			fmt.Printf("%s- %s = %s%s\n", ColorRed, v.Name, value, ColorReset)
+			value := truncateValue(shownValue(v.Name, v.Value), valueLimit(80, true))
+			fmt.Printf("%s- %s = %s%s%s%s\n", ColorRed, v.Name, value, ColorReset, lastChanged(v.UpdatedAt), noteSuffix(v.Name))
 		}
 		fmt.Println()
 	}
 }
 
+// lastChanged formats a GitHub updated_at timestamp as a gray "(last changed ... ago)" suffix
+func lastChanged(updatedAt time.Time) string {
+	if updatedAt.IsZero() {
+		return ""
+	}
+	return fmt.Sprintf(" %s(last changed %s in GitHub)%s", ColorGray, humanizeAge(time.Since(updatedAt)), ColorReset)
+}
+
+// humanizeAge formats a duration as a rough age such as "5 minutes ago" or "3 days ago"
+func humanizeAge(age time.Duration) string {
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s ago", unit)
+		}
+		return fmt.Sprintf("%d %ss ago", n, unit)
+	}
+
+	switch {
+	case age < time.Minute:
+		return "just now"
+	case age < time.Hour:
+		return plural(int(age.Minutes()), "minute")
+	case age < 24*time.Hour:
+		return plural(int(age.Hours()), "hour")
+	case age < 30*24*time.Hour:
+		return plural(int(age.Hours()/24), "day")
+	case age < 365*24*time.Hour:
+		return plural(int(age.Hours()/(24*30)), "month")
+	default:
+		return plural(int(age.Hours()/(24*365)), "year")
+	}
+}
+
+// colorizeDiffLine colors a unified diff line by its prefix
+func colorizeDiffLine(line string) string {
+	switch {
+	case strings.HasPrefix(line, "@@"):
+		return ColorGray + line + ColorReset
+	case strings.HasPrefix(line, "-"):
+		return ColorRed + line + ColorReset
+	case strings.HasPrefix(line, "+"):
+		return ColorGreen + line + ColorReset
+	default:
+		return line
+	}
+}
+
 // truncateValue truncates a string to maxLen characters with ellipsis
 func truncateValue(value string, maxLen int) string {
-	if len(value) <= maxLen {
+	runes := []rune(value)
+	if len(runes) <= maxLen {
 		return value
 	}
-	return value[:maxLen-3] + "..."
+	if maxLen <= 3 {
+		return string(runes[:maxLen])
+	}
+	return string(runes[:maxLen-3]) + "..."
+}
+
+// valueLimit returns how many characters of a value to display where the layout's default
+// is def: unlimited with --show-full-values, --max-value-width when set, and otherwise def
+// widened to use a wide terminal (fitTerminal) so values aren't cut needlessly
+func valueLimit(def int, fitTerminal bool) int {
+	switch {
+	case *showFullValues:
+		return math.MaxInt32
+	case *maxValueWidth > 0:
+		return *maxValueWidth
+	case fitTerminal:
+		if width := outputWidth(); width-20 > def {
+			return width - 20
+		}
+	}
+	return def
+}
+
+var (
+	outputWidthOnce sync.Once
+	outputColumns   int
+)
+
+// outputWidth returns the terminal's width when stdout is a terminal, else 80
+func outputWidth() int {
+	outputWidthOnce.Do(func() {
+		outputColumns = 80
+		if isTerminal(os.Stdout) {
+			outputColumns, _ = terminalSize()
+		}
+	})
+	return outputColumns
 }
 
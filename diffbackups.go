@@ -0,0 +1,62 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// handleDiffBackups shows what changed between two backups, as the standard diff with the
+// older backup in place of GitHub. Without files, the target's two latest backups are compared.
+func handleDiffBackups(args []string, owner, repo, environment string) {
+	fs := flag.NewFlagSet("diff-backups", flag.ExitOnError)
+	envName := fs.String("env", environment, "Environment whose latest backups are compared when no files are given")
+	fs.Parse(args)
+
+	var before, after string
+	switch fs.NArg() {
+	case 0:
+		if owner == "" {
+			fatal(exitValidation, "diff-backups needs a target (GITHUB_OWNER and GITHUB_REPO, or --target) or two backup files")
+		}
+		backups, err := ListBackups(owner, repo, *envName)
+		if err != nil {
+			fatal(exitFailure, "Error listing backups: %v", err)
+		}
+		if len(backups) < 2 {
+			fatal(exitFailure, "Need two backups of %s in backups/ to compare, found %d", targetName(owner, repo, *envName), len(backups))
+		}
+		before, after = backups[len(backups)-2], backups[len(backups)-1]
+	case 2:
+		before, after = backupPath(fs.Arg(0)), backupPath(fs.Arg(1))
+	default:
+		fmt.Println("❌ Usage: diff-backups [--env NAME] [OLDER.csv NEWER.csv]")
+		exit(exitFailure)
+	}
+
+	old, err := readBackup(before)
+	if err != nil {
+		fatal(exitValidation, "Error reading %s: %v", before, err)
+	}
+	current, err := readBackup(after)
+	if err != nil {
+		fatal(exitValidation, "Error reading %s: %v", after, err)
+	}
+
+	fmt.Printf("🔍 Comparing backups\n   before: %s (%d variables)\n   after:  %s (%d variables)\n", before, len(old), after, len(current))
+	diffSides.Old, diffSides.New, diffSides.DeletedNote = filepath.Base(before), filepath.Base(after), ""
+	diff := CompareSets(current, old)
+	DisplayDiffSummary(diff)
+	DisplayDetailedDiff(diff)
+}
+
+// backupPath resolves a backup given by file name alone against backups/
+func backupPath(name string) string {
+	if _, err := os.Stat(name); err != nil && filepath.Base(name) == name {
+		if _, err := os.Stat(filepath.Join("backups", name)); err == nil {
+			return filepath.Join("backups", name)
+		}
+	}
+	return name
+}
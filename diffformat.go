@@ -0,0 +1,285 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Renderings of updated values for --diff-format
+const (
+	DiffFormatDefault    = "default"      // Before/after lines (line diff for multi-line and JSON values)
+	DiffFormatSideBySide = "side-by-side" // Old and new values in two columns
+	DiffFormatUnified    = "unified"      // Patch-style hunks for every value
+	DiffFormatTable      = "table"        // One compact row per change
+)
+
+// Reverse video marks the changed part of a value without disturbing the line's color
+const (
+	highlightOn  = "\033[7m"
+	highlightOff = "\033[27m"
+)
+
+func validDiffFormat(format string) bool {
+	switch format {
+	case DiffFormatDefault, DiffFormatSideBySide, DiffFormatUnified, DiffFormatTable:
+		return true
+	}
+	return false
+}
+
+// displayUpdatedVariables prints the updated variables of a diff in the given format
+func displayUpdatedVariables(changes []VariableChange, format string) {
+	width := outputWidth()
+	switch format {
+	case DiffFormatSideBySide:
+		displaySideBySide(changes, width)
+	case DiffFormatUnified:
+		displayUnified(changes)
+	case DiffFormatTable:
+		displayChangeTable(changes, width)
+	default:
+		displayBeforeAfter(changes)
+	}
+}
+
+// displayBeforeAfter is the default rendering: old and new value on separate lines with the
+// changed portion highlighted, or a line diff for multi-line and JSON values
+func displayBeforeAfter(changes []VariableChange) {
+	for _, change := range changes {
+		oldValue, newValue := shownValue(change.Name, change.OldValue), shownValue(change.Name, change.NewValue)
+		fmt.Printf("%s~ %s:%s%s%s\n", ColorYellow, change.Name, ColorReset, lastChanged(change.RemoteUpdatedAt), noteSuffix(change.Name))
+		if isStructuredValue(oldValue) || isStructuredValue(newValue) {
+			for _, line := range UnifiedValueDiff(oldValue, newValue, *diffContext) {
+				fmt.Printf("  %s\n", colorizeDiffLine(line))
+			}
+			continue
+		}
+
+		oldShown, newShown := highlightChange(oldValue, newValue, valueLimit(60, true))
+		fmt.Printf("  %s- %s%s\n", ColorRed, oldShown, ColorReset)
+		fmt.Printf("  %s+ %s%s\n", ColorGreen, newShown, ColorReset)
+	}
+}
+
+// displayUnified renders each change as a patch, so the output can be read like `git diff`
+func displayUnified(changes []VariableChange) {
+	for _, change := range changes {
+		fmt.Printf("%s--- a/%s%s\n", ColorBold, change.Name, ColorReset)
+		fmt.Printf("%s+++ b/%s%s\n", ColorBold, change.Name, ColorReset)
+		for _, line := range UnifiedValueDiff(shownValue(change.Name, change.OldValue), shownValue(change.Name, change.NewValue), *diffContext) {
+			fmt.Println(colorizeDiffLine(line))
+		}
+	}
+}
+
+// displaySideBySide renders old and new values in two columns; multi-line values are aligned line by line
+func displaySideBySide(changes []VariableChange, width int) {
+	column := (width - 7) / 2
+	if column < 20 {
+		column = 20
+	}
+
+	for _, change := range changes {
+		fmt.Printf("%s~ %s%s%s%s\n", ColorYellow, change.Name, ColorReset, lastChanged(change.RemoteUpdatedAt), noteSuffix(change.Name))
+		oldValue, newValue := shownValue(change.Name, change.OldValue), shownValue(change.Name, change.NewValue)
+		if isStructuredValue(oldValue) || isStructuredValue(newValue) {
+			if oldPretty, ok := prettyJSON(oldValue); ok {
+				if newPretty, ok := prettyJSON(newValue); ok {
+					oldValue, newValue = oldPretty, newPretty
+				}
+			}
+			for _, row := range pairDiffLines(diffLines(strings.Split(oldValue, "\n"), strings.Split(newValue, "\n"))) {
+				left, right := row[0], row[1]
+				if left != nil && right != nil && left.Kind != ' ' {
+					l, r := highlightChange(left.Text, right.Text, column)
+					printSideBySideRow(l, r, true, true, column)
+					continue
+				}
+				l, r := "", ""
+				if left != nil {
+					l = truncateDisplay(left.Text, column)
+				}
+				if right != nil {
+					r = truncateDisplay(right.Text, column)
+				}
+				printSideBySideRow(l, r, left != nil && left.Kind != ' ', right != nil && right.Kind != ' ', column)
+			}
+			continue
+		}
+
+		l, r := highlightChange(oldValue, newValue, column)
+		printSideBySideRow(l, r, true, true, column)
+	}
+}
+
+// pairDiffLines lines up removed and added lines of a diff so replacements share a row
+func pairDiffLines(lines []diffLine) [][2]*diffLine {
+	rows := [][2]*diffLine{}
+	for i := 0; i < len(lines); {
+		if lines[i].Kind == ' ' {
+			rows = append(rows, [2]*diffLine{&lines[i], &lines[i]})
+			i++
+			continue
+		}
+		removed, added := []*diffLine{}, []*diffLine{}
+		for ; i < len(lines) && lines[i].Kind != ' '; i++ {
+			if lines[i].Kind == '-' {
+				removed = append(removed, &lines[i])
+			} else {
+				added = append(added, &lines[i])
+			}
+		}
+		for k := 0; k < len(removed) || k < len(added); k++ {
+			var row [2]*diffLine
+			if k < len(removed) {
+				row[0] = removed[k]
+			}
+			if k < len(added) {
+				row[1] = added[k]
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func printSideBySideRow(left, right string, leftChanged, rightChanged bool, column int) {
+	leftColor, rightColor := ColorGray, ColorGray
+	if leftChanged {
+		leftColor = ColorRed
+	}
+	if rightChanged {
+		rightColor = ColorGreen
+	}
+	fmt.Printf("  %s%s%s │ %s%s%s\n", leftColor, padDisplay(left, column), ColorReset, rightColor, right, ColorReset)
+}
+
+// displayChangeTable renders one row per change with truncated, highlighted values
+func displayChangeTable(changes []VariableChange, width int) {
+	nameWidth := len("NAME")
+	for _, change := range changes {
+		if n := utf8.RuneCountInString(change.Name); n > nameWidth {
+			nameWidth = n
+		}
+	}
+	column := (width - nameWidth - 8) / 2
+	if column < 12 {
+		column = 12
+	}
+
+	fmt.Printf("  %s%s │ %s │ %s%s\n", ColorBold, padDisplay("NAME", nameWidth), padDisplay("OLD", column), "NEW", ColorReset)
+	fmt.Printf("  %s─┼─%s─┼─%s\n", strings.Repeat("─", nameWidth), strings.Repeat("─", column), strings.Repeat("─", column))
+	for _, change := range changes {
+		oldValue := strings.ReplaceAll(shownValue(change.Name, change.OldValue), "\n", "⏎")
+		newValue := strings.ReplaceAll(shownValue(change.Name, change.NewValue), "\n", "⏎")
+		l, r := highlightChange(oldValue, newValue, column)
+		fmt.Printf("  %s │ %s%s%s │ %s%s%s\n", padDisplay(change.Name, nameWidth),
+			ColorRed, padDisplay(l, column), ColorReset, ColorGreen, r, ColorReset)
+	}
+}
+
+// highlightChange returns both values with the part that differs highlighted. Values longer
+// than width are cut to a window around the change, so the difference in a long connection
+// string stays visible instead of being truncated away.
+func highlightChange(oldValue, newValue string, width int) (string, string) {
+	oldRunes, newRunes := []rune(oldValue), []rune(newValue)
+
+	prefix := 0
+	for prefix < len(oldRunes) && prefix < len(newRunes) && oldRunes[prefix] == newRunes[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(oldRunes)-prefix && suffix < len(newRunes)-prefix &&
+		oldRunes[len(oldRunes)-1-suffix] == newRunes[len(newRunes)-1-suffix] {
+		suffix++
+	}
+
+	// Start the window a little before the change, so it has context
+	start := 0
+	longest := len(oldRunes)
+	if len(newRunes) > longest {
+		longest = len(newRunes)
+	}
+	if longest > width && prefix > width/4 {
+		start = prefix - width/4
+	}
+	return highlightWindow(oldRunes, start, prefix, len(oldRunes)-suffix, width),
+		highlightWindow(newRunes, start, prefix, len(newRunes)-suffix, width)
+}
+
+// highlightWindow renders runes[start:start+width] with [from, to) highlighted and
+// ellipses where the value was cut
+func highlightWindow(runes []rune, start, from, to, width int) string {
+	end := start + width
+	lead, trail := "", ""
+	if start > 0 {
+		lead = "…"
+		start++
+	}
+	if end < len(runes) {
+		trail = "…"
+		end--
+	} else {
+		end = len(runes)
+	}
+	if start > end {
+		start = end
+	}
+
+	clamp := func(i int) int {
+		if i < start {
+			return start
+		}
+		if i > end {
+			return end
+		}
+		return i
+	}
+	from, to = clamp(from), clamp(to)
+
+	var b strings.Builder
+	b.WriteString(lead)
+	b.WriteString(string(runes[start:from]))
+	if from < to {
+		b.WriteString(highlightOn + string(runes[from:to]) + highlightOff)
+	}
+	b.WriteString(string(runes[to:end]))
+	b.WriteString(trail)
+	return b.String()
+}
+
+// displayWidth is the number of runes in s, ignoring ANSI escape sequences
+func displayWidth(s string) int {
+	width := 0
+	for i := 0; i < len(s); {
+		if s[i] == '\033' {
+			for i < len(s) && s[i] != 'm' {
+				i++
+			}
+			i++
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		width++
+	}
+	return width
+}
+
+// padDisplay pads s with spaces to width display columns
+func padDisplay(s string, width int) string {
+	if pad := width - displayWidth(s); pad > 0 {
+		return s + strings.Repeat(" ", pad)
+	}
+	return s
+}
+
+// truncateDisplay shortens s to width runes, ending in an ellipsis when cut
+func truncateDisplay(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	return string(runes[:width-1]) + "…"
+}
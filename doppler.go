@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const dopplerAPIURL = "https://api.doppler.com/v3"
+
+// FetchDopplerSecrets downloads the secrets of a Doppler project config using DOPPLER_TOKEN
+func FetchDopplerSecrets(project, configName string) ([]Variable, error) {
+	dopplerToken := os.Getenv("DOPPLER_TOKEN")
+	if dopplerToken == "" {
+		return nil, fmt.Errorf("DOPPLER_TOKEN must be set to read from Doppler")
+	}
+
+	query := url.Values{"format": {"json"}}
+	if project != "" {
+		query.Set("project", project)
+	}
+	if configName != "" {
+		query.Set("config", configName)
+	}
+
+	req, err := http.NewRequest("GET", dopplerAPIURL+"/configs/config/secrets/download?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+dopplerToken)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("Doppler API returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	variables, err := variablesFromJSONObject(string(body), "Doppler config")
+	if err != nil {
+		return nil, err
+	}
+
+	// Doppler adds its own metadata keys to every download
+	result := []Variable{}
+	for _, v := range variables {
+		if !strings.HasPrefix(v.Name, "DOPPLER_") {
+			result = append(result, v)
+		}
+	}
+	return result, nil
+}
+
+// onePasswordGet performs an authenticated GET against the 1Password Connect server
+func onePasswordGet(path string, out interface{}) error {
+	host := strings.TrimRight(os.Getenv("OP_CONNECT_HOST"), "/")
+	opToken := os.Getenv("OP_CONNECT_TOKEN")
+	if host == "" || opToken == "" {
+		return fmt.Errorf("OP_CONNECT_HOST and OP_CONNECT_TOKEN must be set to read from 1Password Connect")
+	}
+
+	req, err := http.NewRequest("GET", host+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+opToken)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("1Password Connect returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return json.Unmarshal(body, out)
+}
+
+// onePasswordLookupID resolves a vault or item title to its ID using a title/name filter
+func onePasswordLookupID(path, field, name string) (string, error) {
+	var matches []struct {
+		ID string `json:"id"`
+	}
+	filter := url.QueryEscape(fmt.Sprintf("%s eq \"%s\"", field, name))
+	err := onePasswordGet(path+"?filter="+filter, &matches)
+	if err != nil {
+		return "", err
+	}
+	if len(matches) == 0 {
+		return "", fmt.Errorf("%q not found in 1Password", name)
+	}
+	if len(matches) > 1 {
+		return "", fmt.Errorf("%q matches %d entries in 1Password, use its ID instead", name, len(matches))
+	}
+	return matches[0].ID, nil
+}
+
+// FetchOnePasswordItem reads every labeled field of a 1Password item as a variable
+func FetchOnePasswordItem(vault, item string) ([]Variable, error) {
+	vaultID, err := onePasswordLookupID("/v1/vaults", "name", vault)
+	if err != nil {
+		return nil, err
+	}
+	itemID, err := onePasswordLookupID("/v1/vaults/"+vaultID+"/items", "title", item)
+	if err != nil {
+		return nil, err
+	}
+
+	var fullItem struct {
+		Fields []struct {
+			Label   string `json:"label"`
+			Value   string `json:"value"`
+			Purpose string `json:"purpose"`
+		} `json:"fields"`
+	}
+	err = onePasswordGet("/v1/vaults/"+vaultID+"/items/"+itemID, &fullItem)
+	if err != nil {
+		return nil, err
+	}
+
+	variables := []Variable{}
+	for _, f := range fullItem.Fields {
+		// The built-in notes field isn't a key/value pair
+		if f.Label == "" || f.Purpose == "NOTES" {
+			continue
+		}
+		variables = append(variables, Variable{
+			Name:  sourceKeyToVariableName(strings.ReplaceAll(f.Label, " ", "_")),
+			Value: f.Value,
+		})
+	}
+
+	sortVariables(variables)
+	return variables, nil
+}
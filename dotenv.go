@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultEnvFile is loaded for tool configuration when present
+const defaultEnvFile = ".env"
+
+// loadEnvFile sets tool configuration (GITHUB_OWNER, GITHUB_TOKEN_FILE, ...) from a .env file.
+// Variables already set in the environment win. A missing file is only an error when required.
+func loadEnvFile(path string, required bool) error {
+	content, _, err := readTextFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && !required {
+			return nil
+		}
+		return err
+	}
+
+	entries, err := parseDotEnv(content)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	for _, entry := range entries {
+		if _, set := os.LookupEnv(entry.Name); !set {
+			os.Setenv(entry.Name, entry.Value)
+		}
+	}
+	return nil
+}
+
+// sameFile reports whether two paths name the same file, so the input isn't also read as configuration
+func sameFile(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// tokenFromFile reads a token mounted as a file (e.g. a Kubernetes secret), ignoring surrounding whitespace
+func tokenFromFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read GITHUB_TOKEN_FILE: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
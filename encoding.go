@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Text encodings recognized in input files. Excel on Windows saves "CSV UTF-8" with a
+// byte order mark and "Unicode Text" as UTF-16LE.
+const (
+	encodingUTF8    = "UTF-8"
+	encodingUTF8BOM = "UTF-8 with BOM"
+	encodingUTF16LE = "UTF-16LE"
+	encodingUTF16BE = "UTF-16BE"
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// detectEncoding identifies the encoding of data from its byte order mark, or for UTF-16
+// without one, from the zero high bytes of ASCII characters
+func detectEncoding(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		return encodingUTF8BOM
+	case bytes.HasPrefix(data, bomUTF16LE):
+		return encodingUTF16LE
+	case bytes.HasPrefix(data, bomUTF16BE):
+		return encodingUTF16BE
+	}
+
+	if len(data) < 4 || len(data)%2 != 0 {
+		return encodingUTF8
+	}
+	evenZeros, oddZeros := 0, 0
+	for i, b := range data {
+		if b != 0 {
+			continue
+		}
+		if i%2 == 0 {
+			evenZeros++
+		} else {
+			oddZeros++
+		}
+	}
+	// Mostly-ASCII text has a zero in every other byte; UTF-8 text has none
+	pairs := len(data) / 2
+	switch {
+	case oddZeros*2 > pairs && evenZeros == 0:
+		return encodingUTF16LE
+	case evenZeros*2 > pairs && oddZeros == 0:
+		return encodingUTF16BE
+	}
+	return encodingUTF8
+}
+
+// decodeText converts file content to UTF-8 without a byte order mark, returning the
+// encoding it was in. Content that is not valid UTF-8 (e.g. saved as Windows-1252) is an error,
+// since its values would otherwise reach GitHub corrupted.
+func decodeText(data []byte) ([]byte, string, error) {
+	encoding := detectEncoding(data)
+	switch encoding {
+	case encodingUTF8BOM:
+		data = data[len(bomUTF8):]
+	case encodingUTF16LE, encodingUTF16BE:
+		data = decodeUTF16(data, encoding)
+	}
+
+	if !utf8.Valid(data) {
+		offset := invalidUTF8Offset(data)
+		lineStart := bytes.LastIndexByte(data[:offset], '\n') + 1
+		return nil, encoding, &encodingError{
+			Line:   1 + bytes.Count(data[:offset], []byte("\n")),
+			Column: 1 + utf8.RuneCount(data[lineStart:offset]),
+		}
+	}
+	return data, encoding, nil
+}
+
+// encodingError locates the first byte of content that is not valid UTF-8
+type encodingError struct {
+	Line, Column int
+}
+
+func (e *encodingError) Error() string {
+	return fmt.Sprintf("line %d is not valid UTF-8; save the file as UTF-8 or UTF-16 (in Excel: \"CSV UTF-8\")", e.Line)
+}
+
+// decodeUTF16 converts UTF-16 content (with or without a byte order mark) to UTF-8
+func decodeUTF16(data []byte, encoding string) []byte {
+	var order binary.ByteOrder = binary.LittleEndian
+	if encoding == encodingUTF16BE {
+		order = binary.BigEndian
+	}
+	if bytes.HasPrefix(data, bomUTF16LE) || bytes.HasPrefix(data, bomUTF16BE) {
+		data = data[2:]
+	}
+
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+	var b bytes.Buffer
+	for _, r := range utf16.Decode(units) {
+		b.WriteRune(r)
+	}
+	return b.Bytes()
+}
+
+// encodeText converts UTF-8 content back to the given encoding, so files the tool rewrites
+// (e.g. with --pull) keep the encoding they were saved in
+func encodeText(data []byte, encoding string) []byte {
+	switch encoding {
+	case encodingUTF8BOM:
+		return append(append([]byte{}, bomUTF8...), data...)
+	case encodingUTF16LE, encodingUTF16BE:
+		var order binary.AppendByteOrder = binary.LittleEndian
+		bom := bomUTF16LE
+		if encoding == encodingUTF16BE {
+			order, bom = binary.BigEndian, bomUTF16BE
+		}
+		units := utf16.Encode([]rune(string(data)))
+		out := append(make([]byte, 0, 2+2*len(units)), bom...)
+		for _, u := range units {
+			out = order.AppendUint16(out, u)
+		}
+		return out
+	default:
+		return data
+	}
+}
+
+func invalidUTF8Offset(data []byte) int {
+	for i := 0; i < len(data); {
+		r, size := utf8.DecodeRune(data[i:])
+		if r == utf8.RuneError && size == 1 {
+			return i
+		}
+		i += size
+	}
+	return len(data)
+}
+
+// readTextFile reads a local file as UTF-8, converting it from the encoding it was saved in
+func readTextFile(path string) ([]byte, string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, "", err
+	}
+	content, encoding, err := decodeText(data)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", path, err)
+	}
+	return content, encoding, nil
+}
+
+// decodeInput converts the input's content to UTF-8, noting when it was converted from UTF-16
+func decodeInput(location string, content []byte) ([]byte, error) {
+	decoded, encoding, err := decodeText(content)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", redactURL(location), err)
+	}
+	if encoding == encodingUTF16LE || encoding == encodingUTF16BE {
+		fmt.Printf("ℹ️  Converted %s from %s\n", redactURL(location), encoding)
+	}
+	return decoded, nil
+}
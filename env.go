@@ -0,0 +1,201 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+)
+
+// runEnvCommand handles "env <subcommand>" operations on a whole environment
+func runEnvCommand(args []string, token, owner, repo, environment string) {
+	if len(args) == 0 {
+		fatal(exitValidation, "Missing env subcommand (available: clear, move)")
+	}
+
+	switch args[0] {
+	case "clear":
+		fs := flag.NewFlagSet("env clear", flag.ExitOnError)
+		envName := fs.String("env", environment, "Environment to clear (defaults to GITHUB_ENVIRONMENT)")
+		fs.Parse(args[1:])
+
+		if *envName == "" {
+			fatal(exitValidation, "No environment given. Use --env or set GITHUB_ENVIRONMENT")
+		}
+		lockOrExit(token, owner, repo, *envName)
+		handleEnvClear(token, owner, repo, *envName)
+	case "move":
+		fs := flag.NewFlagSet("env move", flag.ExitOnError)
+		from := fs.String("from", "", "Environment to move variables from")
+		to := fs.String("to", "", "Environment to move variables to")
+		fs.Parse(args[1:])
+
+		if *from == "" || *to == "" {
+			fatal(exitValidation, "Both --from and --to are required")
+		}
+		if *from == *to {
+			fatal(exitValidation, "--from and --to must be different environments")
+		}
+		lockOrExit(token, owner, repo, *from)
+		lockOrExit(token, owner, repo, *to)
+		handleEnvMove(token, owner, repo, *from, *to)
+	default:
+		fatal(exitValidation, "Unknown env subcommand: %s", args[0])
+	}
+}
+
+// handleEnvClear backs up and deletes every variable in an environment after confirmation
+func handleEnvClear(token, owner, repo, environment string) {
+	fmt.Printf("🎯 Target: Environment '%s' in %s/%s\n", environment, owner, repo)
+
+	fmt.Println("🔍 Fetching current variables from GitHub...")
+	variables, err := FetchGitHubVariables(token, owner, repo, environment)
+	if err != nil {
+		fmt.Printf("❌ Error fetching GitHub variables: %v\n", err)
+		exit(1)
+	}
+	variables, ignored := withoutIgnored(variables)
+	if len(ignored) > 0 {
+		fmt.Printf("🙈 %d variable(s) matching %s are kept\n", len(ignored), *ignoreFile)
+	}
+
+	if len(variables) == 0 {
+		fmt.Println("✅ Environment has no variables. Nothing to clear")
+		return
+	}
+
+	fmt.Printf("\n%s[TO BE DELETED]%s\n", ColorRed+ColorBold, ColorReset)
+	for _, v := range variables {
+		fmt.Printf("%s- %s = %s%s\n", ColorRed, v.Name, truncateValue(shownValue(v.Name, v.Value), valueLimit(80, true)), ColorReset)
+	}
+
+	fmt.Println()
+	requireApproval(token, owner, repo, environment, DiffResult{Deleted: variables})
+	if !confirmDeletion(len(variables), targetName(owner, repo, environment), owner+"/"+repo) {
+		fmt.Println("\n❌ Clear cancelled by user")
+		exit(exitCancelled)
+	}
+
+	deleted, failed, err := ClearEnvironmentVariables(token, owner, repo, environment, variables)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(1)
+	}
+
+	fmt.Println()
+	fmt.Printf("🎉 Completed! Deleted %d, Failed %d variables\n", deleted, failed)
+	if failed > 0 {
+		exit(exitPartial)
+	}
+}
+
+// ClearEnvironmentVariables backs up an environment and then deletes the given variables from it.
+// The backup is mandatory: nothing is deleted if it cannot be written.
+func ClearEnvironmentVariables(token, owner, repo, environment string, variables []Variable) (int, int, error) {
+	fmt.Println("\n💾 Creating backup before deleting...")
+	backupFile, err := BackupGitHubVariables(token, owner, repo, environment)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to create backup, nothing was deleted: %w", err)
+	}
+	fmt.Printf("✅ Backup saved: %s\n\n", backupFile)
+
+	deleted := 0
+	failed := 0
+	client := newRESTClient(token)
+	for _, v := range variables {
+		err := client.DeleteVariable(owner, repo, environment, v.Name)
+		if err != nil {
+			fmt.Printf("❌ Error deleting variable '%s': %v\n", v.Name, err)
+			failed++
+			continue
+		}
+		fmt.Printf("🗑️  Deleted variable: %s\n", v.Name)
+		deleted++
+	}
+
+	return deleted, failed, nil
+}
+
+// handleEnvMove copies every variable from one environment to another, verifies
+// the copy, and only then clears the source environment
+func handleEnvMove(token, owner, repo, from, to string) {
+	fmt.Printf("🎯 Target: Move environment '%s' → '%s' in %s/%s\n", from, to, owner, repo)
+
+	fmt.Println("🔍 Fetching current variables from GitHub...")
+	variables, err := FetchGitHubVariables(token, owner, repo, from)
+	if err != nil {
+		fmt.Printf("❌ Error fetching variables from '%s': %v\n", from, err)
+		exit(1)
+	}
+	variables, ignored := withoutIgnored(variables)
+	if len(ignored) > 0 {
+		fmt.Printf("🙈 %d variable(s) matching %s stay in '%s'\n", len(ignored), *ignoreFile, from)
+	}
+	if len(variables) == 0 {
+		fmt.Printf("✅ Environment '%s' has no variables. Nothing to move\n", from)
+		return
+	}
+
+	existing, err := FetchGitHubVariables(token, owner, repo, to)
+	if err != nil {
+		fmt.Printf("❌ Error fetching variables from '%s': %v\n", to, err)
+		exit(1)
+	}
+
+	// Show what the destination will look like using the standard diff
+	diff := CompareSets(variables, existing)
+	DisplayDiffSummary(diff)
+	DisplayDetailedDiff(diff)
+
+	fmt.Printf("📦 Will copy %d variable(s) to '%s', verify them, then delete them from '%s'\n\n", len(variables), to, from)
+	requireApproval(token, owner, repo, to, syncPlan(diff, nil))
+	requireApproval(token, owner, repo, from, DiffResult{Deleted: variables})
+	if !confirmDeletion(len(variables), targetName(owner, repo, from)+" once they're copied", owner+"/"+repo) {
+		fmt.Println("\n❌ Move cancelled by user")
+		exit(exitCancelled)
+	}
+
+	// Step 1: copy
+	fmt.Print("\n🚀 Copying variables...\n\n")
+	failed := 0
+	client := newRESTClient(token)
+	for _, v := range changedVariables(diff) { // The rest already has the same value there
+		err := syncVariable(client, owner, repo, to, v)
+		if err != nil {
+			fmt.Printf("❌ Error copying variable '%s': %v\n", v.Name, err)
+			failed++
+			continue
+		}
+		fmt.Printf("✅ Copied variable: %s\n", v.Name)
+	}
+	if failed > 0 {
+		fmt.Printf("\n❌ %d variable(s) failed to copy. '%s' was left untouched\n", failed, from)
+		exit(exitPartial)
+	}
+
+	// Step 2: verify
+	fmt.Printf("\n🔍 Verifying variables in '%s'...\n", to)
+	copied, err := FetchGitHubVariables(token, owner, repo, to)
+	if err != nil {
+		fmt.Printf("❌ Error verifying '%s': %v. '%s' was left untouched\n", to, err, from)
+		exit(1)
+	}
+	verify := CompareSets(variables, copied)
+	if len(verify.New) > 0 || len(verify.Updated) > 0 {
+		fmt.Printf("❌ Verification failed: %d missing, %d mismatched in '%s'. '%s' was left untouched\n",
+			len(verify.New), len(verify.Updated), to, from)
+		exit(1)
+	}
+	fmt.Printf("✅ All %d variable(s) verified\n", len(variables))
+
+	// Step 3: clear the old environment (with backup)
+	deleted, failedDeletes, err := ClearEnvironmentVariables(token, owner, repo, from, variables)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		exit(1)
+	}
+
+	fmt.Println()
+	fmt.Printf("🎉 Completed! Moved %d variables from '%s' to '%s' (%d failed to delete)\n", deleted, from, to, failedDeletes)
+	if failedDeletes > 0 {
+		exit(exitPartial)
+	}
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	neturl "net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxEnvironmentReviewers is GitHub's limit on required reviewers per environment
+const maxEnvironmentReviewers = 6
+
+// pendingEnvironments are target environments (by targetName) that don't exist yet, in a read-only run
+// (--diff, --backup, --pull) that reports what creating them would do; their variable lists are empty
+var pendingEnvironments = map[string]bool{}
+
+// environmentURL returns the API URL of an environment
+func environmentURL(owner, repo, environment string) string {
+	return fmt.Sprintf("%s/repos/%s/%s/environments/%s", githubAPIURL, owner, repo, neturl.PathEscape(environment))
+}
+
+// environmentExists reports whether the environment exists. A 404 only means it's missing
+// when the repository itself is visible; otherwise the preflight check explains the problem.
+func environmentExists(token, owner, repo, environment string) (bool, error) {
+	resp, _, err := preflightGet(token, environmentURL(owner, repo, environment))
+	if err != nil {
+		return false, err
+	}
+	if resp.StatusCode != 404 {
+		return true, nil
+	}
+
+	resp, _, err = preflightGet(token, fmt.Sprintf("%s/repos/%s/%s", githubAPIURL, owner, repo))
+	if err != nil {
+		return false, err
+	}
+	return resp.StatusCode != 200, nil
+}
+
+// ensureEnvironment checks that the target environment exists. A missing one is created with
+// --create-environment when the run writes, and otherwise is an error with the remedy.
+func ensureEnvironment(token, owner, repo, environment string, needWrite bool) {
+	exists, err := environmentExists(token, owner, repo, environment)
+	if err != nil {
+		fatal(exitFailure, "Error checking environment '%s': %v", environment, err)
+	}
+	if exists {
+		return
+	}
+
+	if !*createEnvironment {
+		runError = fmt.Sprintf("environment '%s' does not exist", environment)
+		fmt.Printf("❌ Environment '%s' does not exist in %s/%s\n", environment, owner, repo)
+		fmt.Println("   Fix: create it under Settings → Environments, or re-run with --create-environment")
+		exit(exitFailure)
+	}
+
+	settings, err := environmentSettings(token)
+	if err != nil {
+		fatal(exitValidation, "Error: %v", err)
+	}
+	if !needWrite {
+		fmt.Printf("ℹ️  Environment '%s' does not exist yet; it would be created%s\n", environment, describeEnvironmentSettings(settings))
+		pendingEnvironments[targetName(owner, repo, environment)] = true
+		return
+	}
+
+	err = githubSendJSON(token, "PUT", environmentURL(owner, repo, environment), settings, 200, nil)
+	if err != nil {
+		fatal(exitFailure, "Error creating environment '%s': %v\n   Creating environments needs admin access to the repository (Administration: Read and write)", environment, err)
+	}
+	fmt.Printf("🆕 Created environment '%s' in %s/%s%s\n", environment, owner, repo, describeEnvironmentSettings(settings))
+}
+
+// environmentReviewer is a required reviewer in the environment API payload
+type environmentReviewer struct {
+	Type string `json:"type"` // User or Team
+	ID   int64  `json:"id"`
+}
+
+// environmentPayload is the body of the create-or-update environment request
+type environmentPayload struct {
+	WaitTimer *int                  `json:"wait_timer,omitempty"` // Minutes
+	Reviewers []environmentReviewer `json:"reviewers,omitempty"`
+}
+
+// environmentSettings builds the protection rules for a new environment from
+// --environment-wait-timer and --environment-reviewers, resolving reviewer IDs
+func environmentSettings(token string) (environmentPayload, error) {
+	settings := environmentPayload{}
+	if *environmentWaitTimer != 0 {
+		if *environmentWaitTimer < 0 || *environmentWaitTimer > 30*24*time.Hour || *environmentWaitTimer%time.Minute != 0 {
+			return settings, fmt.Errorf("--environment-wait-timer must be whole minutes up to 30 days, not %s", *environmentWaitTimer)
+		}
+		minutes := int(*environmentWaitTimer / time.Minute)
+		settings.WaitTimer = &minutes
+	}
+
+	for _, reviewer := range strings.Split(*environmentReviewers, ",") {
+		reviewer = strings.TrimSpace(reviewer)
+		if reviewer == "" {
+			continue
+		}
+		var account struct {
+			ID int64 `json:"id"`
+		}
+		reviewerType, url := "User", fmt.Sprintf("%s/users/%s", githubAPIURL, neturl.PathEscape(reviewer))
+		if org, team, ok := strings.Cut(reviewer, "/"); ok {
+			reviewerType, url = "Team", fmt.Sprintf("%s/orgs/%s/teams/%s", githubAPIURL, neturl.PathEscape(org), neturl.PathEscape(team))
+		}
+		if err := githubGetJSON(token, url, &account); err != nil {
+			return settings, fmt.Errorf("reviewer %s: %w", reviewer, err)
+		}
+		settings.Reviewers = append(settings.Reviewers, environmentReviewer{Type: reviewerType, ID: account.ID})
+	}
+	if len(settings.Reviewers) > maxEnvironmentReviewers {
+		return settings, fmt.Errorf("an environment can have at most %d required reviewers, not %d", maxEnvironmentReviewers, len(settings.Reviewers))
+	}
+	return settings, nil
+}
+
+// describeEnvironmentSettings summarizes the protection rules, e.g. " (wait timer 5m, 2 reviewer(s))"
+func describeEnvironmentSettings(settings environmentPayload) string {
+	parts := []string{}
+	if settings.WaitTimer != nil {
+		parts = append(parts, fmt.Sprintf("wait timer %s", time.Duration(*settings.WaitTimer)*time.Minute))
+	}
+	if len(settings.Reviewers) > 0 {
+		parts = append(parts, fmt.Sprintf("%d required reviewer(s)", len(settings.Reviewers)))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, ", ") + ")"
+}
+
+// environmentSummary is an environment with the number of variables it holds
+type environmentSummary struct {
+	Name      string `json:"name"`
+	Variables int    `json:"variables"`
+}
+
+// ListEnvironments returns the names of the repository's environments, following pagination
+func ListEnvironments(token, owner, repo string) ([]string, error) {
+	baseURL := fmt.Sprintf("%s/repos/%s/%s/environments", githubAPIURL, owner, repo)
+	url := baseURL + "?per_page=100"
+	names := []string{}
+	for {
+		resp, body, err := preflightGet(token, url)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != 200 {
+			return nil, newAPIError(resp, body)
+		}
+		var page struct {
+			Environments []struct {
+				Name string `json:"name"`
+			} `json:"environments"`
+		}
+		err = json.Unmarshal(body, &page)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse environments: %w", err)
+		}
+		for _, env := range page.Environments {
+			names = append(names, env.Name)
+		}
+
+		next, _, err := nextPageURL(baseURL, resp.Header)
+		if err != nil {
+			return nil, err
+		}
+		if next == "" {
+			return names, nil
+		}
+		url = next
+	}
+}
+
+// countVariables returns how many variables the repository (environment "") or an environment holds
+func countVariables(token, owner, repo, environment string) (int, error) {
+	url := variableURL(owner, repo, environment, "") + "?per_page=1"
+	if environment != "" {
+		url = environmentURL(owner, repo, environment) + "/variables?per_page=1"
+	}
+	var response GitHubVariablesResponse
+	err := githubGetJSON(token, url, &response)
+	if err != nil {
+		return 0, err
+	}
+	return response.TotalCount, nil
+}
+
+// summarizeEnvironments lists the environments with their variable counts
+func summarizeEnvironments(token, owner, repo string) ([]environmentSummary, error) {
+	names, err := ListEnvironments(token, owner, repo)
+	if err != nil {
+		return nil, err
+	}
+	summaries := make([]environmentSummary, len(names))
+	for i, name := range names {
+		count, err := countVariables(token, owner, repo, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count variables in %s: %w", name, err)
+		}
+		summaries[i] = environmentSummary{Name: name, Variables: count}
+	}
+	return summaries, nil
+}
+
+// handleEnvironments lists the repository's environments with their variable counts
+func handleEnvironments(args []string, token, owner, repo string) {
+	fs := flag.NewFlagSet("environments", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print the list as JSON")
+	fs.Parse(args)
+
+	summaries, err := summarizeEnvironments(token, owner, repo)
+	if err != nil {
+		fatal(exitFailure, "Error listing environments: %v", err)
+	}
+	if *asJSON {
+		data, err := json.MarshalIndent(summaries, "", "  ")
+		if err != nil {
+			fatal(exitFailure, "Error: %v", err)
+		}
+		fmt.Println(string(data))
+		return
+	}
+
+	repoCount, err := countVariables(token, owner, repo, "")
+	if err != nil {
+		fatal(exitFailure, "Error counting repository variables: %v", err)
+	}
+	fmt.Printf("🌐 Environments in %s/%s\n\n", owner, repo)
+	fmt.Printf("  %-30s %d variable(s)\n", "(repository)", repoCount)
+	for _, env := range summaries {
+		fmt.Printf("  %-30s %d variable(s)\n", env.Name, env.Variables)
+	}
+	if len(summaries) == 0 {
+		fmt.Println("\n  No environments; variables can only be synced at the repository level")
+	}
+}
+
+// selectEnvironment asks which environment to sync when GITHUB_ENVIRONMENT is unset and the
+// run is interactive, so repository-level variables aren't targeted by accident. It returns ""
+// for repository-level variables, including when the repository has no environments.
+func selectEnvironment(token, owner, repo string) string {
+	summaries, err := summarizeEnvironments(token, owner, repo)
+	if err != nil || len(summaries) == 0 {
+		return ""
+	}
+	repoCount, err := countVariables(token, owner, repo, "")
+	if err != nil {
+		return ""
+	}
+
+	fmt.Printf("🌐 GITHUB_ENVIRONMENT is not set. Which variables of %s/%s should be synced?\n", owner, repo)
+	fmt.Printf("  0) Repository-level variables (%d)\n", repoCount)
+	for i, env := range summaries {
+		fmt.Printf("  %d) %s (%d)\n", i+1, env.Name, env.Variables)
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Print("Select [0]: ")
+		input, err := reader.ReadString('\n')
+		input = strings.TrimSpace(input)
+		if input == "" && err != nil {
+			fmt.Println()
+			exit(exitCancelled)
+		}
+		if input == "" {
+			return ""
+		}
+		if n, convErr := strconv.Atoi(input); convErr == nil && n >= 0 && n <= len(summaries) {
+			if n == 0 {
+				return ""
+			}
+			return summaries[n-1].Name
+		}
+		for _, env := range summaries {
+			if env.Name == input {
+				return env.Name
+			}
+		}
+		fmt.Printf("   Enter a number from 0 to %d, or an environment name\n", len(summaries))
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// Event kinds of the --events stream
+const (
+	EventRunStarted     = "run-started"
+	EventFetchStart     = "fetch-start"
+	EventDiffComputed   = "diff-computed"
+	EventBackupCreated  = "backup-created"
+	EventVariableSynced = "variable-synced"
+	EventRunFinished    = "run-finished"
+)
+
+// Event is one line of the --events stream. Values of variables never appear in events.
+type Event struct {
+	Time   time.Time              `json:"time"`
+	Event  string                 `json:"event"`
+	RunID  string                 `json:"run_id"`
+	Target string                 `json:"target,omitempty"` // In the --target syntax
+	Data   map[string]interface{} `json:"data,omitempty"`
+}
+
+// eventStream writes events as NDJSON to the --events file, one line per event as it happens
+type eventStream struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+// events is the --events stream; nil when not requested
+var events *eventStream
+
+// openEventStream starts the --events stream. The path may be a regular file, a named pipe,
+// or /dev/fd/3 for a descriptor the calling process holds open. The stream ends with a
+// run-finished event when the program exits.
+func openEventStream(path string) error {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open --events: %w", err)
+	}
+	events = &eventStream{file: file}
+	command := flag.Arg(0)
+	if command == "" {
+		command = "sync"
+	}
+	emitEvent(EventRunStarted, "", map[string]interface{}{"version": currentBuild().Version, "command": command})
+	atExit(func() {
+		data := map[string]interface{}{"exit_code": exitStatus}
+		if runReport != nil {
+			data["created"], data["updated"] = len(runReport.Created), len(runReport.Updated)
+			data["failed"], data["not_synced"] = len(runReport.Failed), len(runReport.NotSynced)
+		}
+		emitEvent(EventRunFinished, "", data)
+		events.file.Close()
+		events = nil
+	})
+	return nil
+}
+
+// emitEvent writes one event; it does nothing without --events. A failing stream is
+// reported once and then dropped, so it can't fail the sync.
+func emitEvent(kind, target string, data map[string]interface{}) {
+	if events == nil {
+		return
+	}
+	line, err := json.Marshal(Event{Time: time.Now().UTC(), Event: kind, RunID: currentRunID(), Target: target, Data: data})
+	if err != nil {
+		return
+	}
+
+	events.mu.Lock()
+	defer events.mu.Unlock()
+	if events.file == nil {
+		return
+	}
+	if _, err := events.file.Write(append(line, '\n')); err != nil {
+		fmt.Printf("⚠️  Warning: --events stream failed, no more events will be written: %v\n", err)
+		events.file.Close()
+		events.file = nil
+	}
+}
+
+// eventTarget formats a target for events in the --target syntax
+func eventTarget(owner, repo, environment string) string {
+	switch {
+	case isOrgTarget(owner, repo):
+		return Target{Kind: TargetOrg, Owner: owner}.String()
+	case environment != "":
+		return Target{Kind: TargetEnv, Owner: owner, Repo: repo, Environment: environment}.String()
+	}
+	return Target{Kind: TargetRepo, Owner: owner, Repo: repo}.String()
+}
+
+// emitDiffComputed reports the counts of a diff about to be reviewed or applied
+func emitDiffComputed(owner, repo, environment string, diff DiffResult) {
+	emitEvent(EventDiffComputed, eventTarget(owner, repo, environment), map[string]interface{}{
+		"new": len(diff.New), "updated": len(diff.Updated), "unchanged": len(diff.Unchanged),
+		"remote_only": len(diff.Deleted), "ignored": diff.Ignored,
+	})
+}
+
+// emitVariableSynced reports the outcome of one write
+func emitVariableSynced(owner, repo, environment, name, action string, err error, duration time.Duration) {
+	data := map[string]interface{}{"name": name, "action": action, "result": "ok", "duration_ms": duration.Milliseconds()}
+	if err != nil {
+		data["result"], data["error"] = "failed", safeValue(err.Error())
+	}
+	emitEvent(EventVariableSynced, eventTarget(owner, repo, environment), data)
+}
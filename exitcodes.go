@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Exit codes, so CI can tell why a run failed without parsing output. 2 is left to the
+// flag package for usage errors, and 130 is used when the run is interrupted.
+const (
+	exitOK          = 0
+	exitFailure     = 1 // Anything not covered below (network, unexpected API errors)
+	exitAuth        = 3 // Missing or rejected credentials, or missing permissions
+	exitValidation  = 4 // The input or settings couldn't be read, parsed, or resolved, a command or flag was misused, or GitHub changed after review
+	exitPartial     = 5 // Some writes failed or weren't attempted
+	exitCancelled   = 6 // Declined at a confirmation prompt
+	exitLocked      = 7 // Another run holds the target's lock
+	exitInterrupted = 130
+)
+
+// exitReason names an exit code for the failure report
+func exitReason(code int) string {
+	switch code {
+	case exitOK:
+		return "success"
+	case exitAuth:
+		return "auth"
+	case exitValidation:
+		return "validation"
+	case exitPartial:
+		return "partial"
+	case exitCancelled:
+		return "cancelled"
+	case exitLocked:
+		return "locked"
+	case exitInterrupted:
+		return "interrupted"
+	default:
+		return "error"
+	}
+}
+
+// runReport is the sync's outcome once writes have started, for the failure report
+var runReport *SyncReport
+
+// runError is the message of the error that ended the run, if any
+var runError string
+
+// fatal prints an error, records it for the failure report, and exits with code
+func fatal(code int, format string, args ...interface{}) {
+	runError = safeValue(fmt.Sprintf(format, args...))
+	fmt.Printf("❌ %s\n", runError)
+	exit(code)
+}
+
+// FailureReport is written by --failures-file for CI to upload as an artifact
+type FailureReport struct {
+	ExitCode  int           `json:"exit_code"`
+	Reason    string        `json:"reason"`
+	Error     string        `json:"error,omitempty"`
+	Timestamp time.Time     `json:"timestamp"`
+	Failed    []SyncFailure `json:"failed"`
+	NotSynced []string      `json:"not_synced,omitempty"`
+	Report    *SyncReport   `json:"report,omitempty"`
+}
+
+// writeFailureReport writes the run's outcome to path; it runs as an exit hook
+func writeFailureReport(path string, code int) {
+	report := FailureReport{
+		ExitCode:  code,
+		Reason:    exitReason(code),
+		Error:     runError,
+		Timestamp: time.Now().UTC(),
+		Failed:    []SyncFailure{},
+		Report:    runReport,
+	}
+	if runReport != nil {
+		report.Failed = runReport.Failed
+		report.NotSynced = runReport.NotSynced
+	}
+
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err == nil {
+		err = os.WriteFile(path, append(data, '\n'), 0644)
+	}
+	if err != nil {
+		fmt.Printf("⚠️  Warning: Failed to write failure report %s: %v\n", path, err)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// environmentExport is the variables of the repository level ("") or one environment
+type environmentExport struct {
+	Environment string
+	Variables   []Variable
+	Notes       map[string]string // Name key -> note from the notes sidecar
+}
+
+// note returns the sidecar's note of an exported variable
+func (e environmentExport) note(name string) string {
+	return e.Notes[nameKey(name)]
+}
+
+// exportFormat renders exported variables as file content
+type exportFormat struct {
+	render func(owner, repo string, exports []environmentExport) string
+	multi  bool // Can hold several targets, for --environments
+}
+
+// exportFormats are the --format values of the export command
+var exportFormats = map[string]exportFormat{
+	"terraform":        {TerraformHCL, true},
+	"terraform-import": {TerraformImportScript, true},
+	"tfvars":           {TFVars, false},
+	"configmap":        {ConfigMapYAML, true},
+	"env":              {ComposeEnvFile, false},
+}
+
+// handleExport writes the target's variables in another tool's format. Values are exported as
+// they are in GitHub, unmasked, since the output is meant to replace them.
+func handleExport(args []string, token, owner, repo, environment string) {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", "", "Output format: "+strings.Join(exportFormatNames(), ", "))
+	envName := fs.String("env", environment, "Environment (defaults to GITHUB_ENVIRONMENT; empty for repository variables)")
+	environments := fs.Bool("environments", false, "Export the repository variables and every environment's variables")
+	out := fs.String("out", "", "Write to this file instead of stdout")
+	fs.Parse(args)
+
+	selected, ok := exportFormats[*format]
+	if !ok {
+		fatal(exitFailure, "Invalid or missing --format %q (use %s)", *format, strings.Join(exportFormatNames(), ", "))
+	}
+	if *environments && !selected.multi {
+		fatal(exitFailure, "--format %s holds one target; export each environment with --env instead of --environments", *format)
+	}
+
+	names := []string{*envName}
+	if *environments {
+		envs, err := ListEnvironments(token, owner, repo)
+		if err != nil {
+			fatal(exitFailure, "Error listing environments: %v", err)
+		}
+		names = append([]string{""}, envs...)
+	}
+
+	exports := []environmentExport{}
+	total := 0
+	for _, name := range names {
+		variables, err := FetchGitHubVariables(token, owner, repo, name)
+		if err != nil {
+			fatal(exitFailure, "Error fetching variables of %s: %v", targetName(owner, repo, name), err)
+		}
+		sort.Slice(variables, func(i, j int) bool { return variables[i].Name < variables[j].Name })
+		exports = append(exports, environmentExport{Environment: name, Variables: variables})
+		total += len(variables)
+	}
+	attachExportNotes(owner, repo, exports)
+
+	content := selected.render(owner, repo, exports)
+	if *out == "" {
+		fmt.Print(content)
+		return
+	}
+	err := os.WriteFile(*out, []byte(content), 0644)
+	if err != nil {
+		fatal(exitFailure, "Error writing %s: %v", *out, err)
+	}
+	fmt.Printf("✅ Exported %d variable(s) from %d target(s) to %s\n", total, len(exports), *out)
+}
+
+// exportFormatNames lists the --format values of the export command
+func exportFormatNames() []string {
+	names := make([]string, 0, len(exportFormats))
+	for name := range exportFormats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// TFVars renders one target's variables as Terraform variable assignments
+func TFVars(owner, repo string, exports []environmentExport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Actions variables of %s\n", targetName(owner, repo, exports[0].Environment))
+	for _, v := range exports[0].Variables {
+		b.WriteString(noteComment("", exports[0].note(v.Name)))
+		fmt.Fprintf(&b, "%s = %s\n", v.Name, tfString(v.Value))
+	}
+	return b.String()
+}
+
+// ConfigMapYAML renders each target's variables as a Kubernetes ConfigMap named after the
+// repository and environment, e.g. my-repo-production
+func ConfigMapYAML(owner, repo string, exports []environmentExport) string {
+	var b strings.Builder
+	for i, export := range exports {
+		if i > 0 {
+			b.WriteString("---\n")
+		}
+		name := repo
+		if export.Environment != "" {
+			name += "-" + export.Environment
+		}
+		fmt.Fprintf(&b, "# Actions variables of %s\n", targetName(owner, repo, export.Environment))
+		b.WriteString("apiVersion: v1\nkind: ConfigMap\nmetadata:\n")
+		fmt.Fprintf(&b, "  name: %s\n", kubernetesName(name))
+		if len(export.Variables) == 0 {
+			b.WriteString("data: {}\n")
+			continue
+		}
+		b.WriteString("data:\n")
+		for _, v := range export.Variables {
+			b.WriteString(noteComment("  ", export.note(v.Name)))
+			fmt.Fprintf(&b, "  %s: %s\n", v.Name, yamlString(v.Value))
+		}
+	}
+	return b.String()
+}
+
+// kubernetesName makes s a valid object name: lowercase alphanumerics and dashes
+func kubernetesName(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '.' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('-')
+		}
+	}
+	return strings.Trim(b.String(), "-.")
+}
+
+// yamlString quotes s as a YAML double-quoted scalar; JSON string syntax is valid YAML
+func yamlString(s string) string {
+	var b strings.Builder
+	encoder := json.NewEncoder(&b)
+	encoder.SetEscapeHTML(false)
+	encoder.Encode(s)
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// ComposeEnvFile renders one target's variables as a docker compose env_file
+func ComposeEnvFile(owner, repo string, exports []environmentExport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Actions variables of %s\n", targetName(owner, repo, exports[0].Environment))
+	for _, v := range exports[0].Variables {
+		b.WriteString(noteComment("", exports[0].note(v.Name)))
+		fmt.Fprintf(&b, "%s=%s\n", v.Name, composeEnvValue(v.Value))
+	}
+	return b.String()
+}
+
+// composeEnvValue quotes a value so compose reads it back literally: plain when it's safe,
+// single-quoted (no interpolation) when possible, and otherwise double-quoted with escapes
+func composeEnvValue(value string) string {
+	if !strings.ContainsAny(value, " \t\n\r'\"#$\\") {
+		return value
+	}
+	if !strings.ContainsAny(value, "'\n\r") {
+		return "'" + value + "'"
+	}
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "$", "$$")
+	return `"` + replacer.Replace(value) + `"`
+}
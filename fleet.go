@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Kinds of multi-target syncs that keep a FleetState
+const (
+	FleetMatrix          = "matrix"
+	FleetAllEnvironments = "all-environments"
+)
+
+// FleetState records which targets of a multi-target sync finished, so a run stopped
+// partway (killed, timed out in CI, out of credentials) can go on with --resume from the
+// first unfinished target instead of starting over. It holds no values.
+type FleetState struct {
+	Kind      string    `json:"kind"`
+	Source    string    `json:"source"` // Absolute path of the matrix file or environments directory
+	Owner     string    `json:"owner,omitempty"`
+	Repo      string    `json:"repo,omitempty"`
+	StartedAt time.Time `json:"started_at"`
+	Completed []string  `json:"completed"` // Targets (targetName) synced without failures
+
+	path     string
+	finished bool
+}
+
+// fleetStatePath is the state file of a multi-target sync, in the working directory like
+// the other resume files: resume_matrix_sync-matrix.json, resume_all-environments_my-org_my-repo.json
+func fleetStatePath(kind, owner, repo, source string) string {
+	if kind == FleetMatrix {
+		base := filepath.Base(source)
+		return fmt.Sprintf("resume_%s_%s.json", kind, strings.TrimSuffix(base, filepath.Ext(base)))
+	}
+	return fmt.Sprintf("resume_%s_%s_%s.json", kind, owner, repo)
+}
+
+// newFleetState starts the record of a multi-target sync. Nothing is written until the
+// first target completes.
+func newFleetState(kind, owner, repo, source string) *FleetState {
+	if abs, err := filepath.Abs(source); err == nil {
+		source = abs
+	}
+	return &FleetState{Kind: kind, Source: source, Owner: owner, Repo: repo, StartedAt: time.Now().UTC(),
+		Completed: []string{}, path: fleetStatePath(kind, owner, repo, source)}
+}
+
+// loadFleetState reads the record of an earlier, unfinished run of the same multi-target sync
+func loadFleetState(kind, owner, repo, source string) (*FleetState, error) {
+	expected := newFleetState(kind, owner, repo, source)
+	data, err := os.ReadFile(expected.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no resume file %s; only a --%s sync that stopped before finishing every target leaves one",
+			expected.path, kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	state := &FleetState{}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("%s: %w", expected.path, err)
+	}
+	if state.Kind != kind || state.Source != expected.Source || state.Owner != owner || state.Repo != repo {
+		return nil, fmt.Errorf("%s is for --%s %s, not this run", expected.path, state.Kind, state.Source)
+	}
+	state.path = expected.path
+	return state, nil
+}
+
+// Done reports whether an earlier run already synced a target
+func (s *FleetState) Done(target string) bool {
+	for _, t := range s.Completed {
+		if t == target {
+			return true
+		}
+	}
+	return false
+}
+
+// MarkCompleted records a finished target, saving right away so the progress survives a
+// run that never reaches its end
+func (s *FleetState) MarkCompleted(target string) {
+	if s.Done(target) {
+		return
+	}
+	s.Completed = append(s.Completed, target)
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err == nil {
+		err = os.WriteFile(s.path, append(data, '\n'), 0644)
+	}
+	if err != nil {
+		fmt.Printf("⚠️  Warning: Failed to write resume file %s: %v\n", s.path, err)
+	}
+}
+
+// Finish removes the state once every target has completed, or says how to go on
+func (s *FleetState) Finish(complete bool) {
+	if s.finished {
+		return
+	}
+	s.finished = true
+	if complete {
+		if err := os.Remove(s.path); err == nil {
+			fmt.Printf("🧹 Removed %s; every target is synced\n", s.path)
+		}
+		return
+	}
+	if len(s.Completed) > 0 {
+		fmt.Printf("💾 Progress saved: %s (%d target(s) done)\n", s.path, len(s.Completed))
+		fmt.Println("   Re-run with --resume to continue with the unfinished targets")
+	}
+}
+
+// fleetResumeState returns the state a multi-target sync records its progress in: the
+// earlier run's with --resume, else a new one replacing it. A run that exits early still
+// says how to go on.
+func fleetResumeState(kind, owner, repo, source string) *FleetState {
+	var state *FleetState
+	if *resumeMode {
+		var err error
+		state, err = loadFleetState(kind, owner, repo, source)
+		if err != nil {
+			fatal(exitValidation, "Error: --resume: %v", err)
+		}
+		fmt.Printf("🔁 Resuming the --%s run from %s: %d target(s) already done\n", kind,
+			state.StartedAt.Local().Format("2006-01-02 15:04"), len(state.Completed))
+	} else {
+		state = newFleetState(kind, owner, repo, source)
+		os.Remove(state.path)
+	}
+	atExit(func() { state.Finish(false) })
+	return state
+}
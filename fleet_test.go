@@ -0,0 +1,34 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestFleetStateRecordsCompletedTargets(t *testing.T) {
+	wd, _ := os.Getwd()
+	os.Chdir(t.TempDir())
+	defer os.Chdir(wd)
+
+	state := newFleetState(FleetAllEnvironments, "o", "r", "vars")
+	state.MarkCompleted("o/r (staging)")
+
+	loaded, err := loadFleetState(FleetAllEnvironments, "o", "r", "vars")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Done("o/r (staging)") || loaded.Done("o/r (production)") {
+		t.Errorf("Completed = %v, want only staging", loaded.Completed)
+	}
+	if _, err := loadFleetState(FleetAllEnvironments, "o", "other", "vars"); err == nil {
+		t.Error("loaded another repository's state")
+	}
+	if _, err := loadFleetState(FleetAllEnvironments, "o", "r", "elsewhere"); err == nil {
+		t.Error("loaded the state of another directory")
+	}
+
+	loaded.Finish(true)
+	if _, err := os.Stat(fleetStatePath(FleetAllEnvironments, "o", "r", "vars")); !os.IsNotExist(err) {
+		t.Errorf("state file left after every target completed: %v", err)
+	}
+}
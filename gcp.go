@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+const gcpSecretManagerURL = "https://secretmanager.googleapis.com/v1"
+
+// gcpAccessToken returns an OAuth access token from GOOGLE_OAUTH_ACCESS_TOKEN
+// or by asking the gcloud CLI
+func gcpAccessToken() (string, error) {
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	out, err := exec.Command("gcloud", "auth", "print-access-token").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get GCP access token (set GOOGLE_OAUTH_ACCESS_TOKEN or run 'gcloud auth login'): %w", err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// gcpGetJSON performs an authenticated GET against a Google API endpoint
+func gcpGetJSON(url, accessToken string, out interface{}) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("Google API returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return json.Unmarshal(body, out)
+}
+
+// FetchGCPSecrets reads the latest version of every secret in a GCP project,
+// optionally limited to secret names starting with prefix
+func FetchGCPSecrets(project, prefix string) ([]Variable, error) {
+	accessToken, err := gcpAccessToken()
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{}
+	pageToken := ""
+	for {
+		listURL := fmt.Sprintf("%s/projects/%s/secrets?pageSize=250", gcpSecretManagerURL, project)
+		if pageToken != "" {
+			listURL += "&pageToken=" + url.QueryEscape(pageToken)
+		}
+
+		var page struct {
+			Secrets []struct {
+				Name string `json:"name"` // projects/<p>/secrets/<id>
+			} `json:"secrets"`
+			NextPageToken string `json:"nextPageToken"`
+		}
+		err = gcpGetJSON(listURL, accessToken, &page)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range page.Secrets {
+			id := s.Name[strings.LastIndex(s.Name, "/")+1:]
+			if strings.HasPrefix(id, prefix) {
+				names = append(names, s.Name)
+			}
+		}
+
+		if page.NextPageToken == "" {
+			break
+		}
+		pageToken = page.NextPageToken
+	}
+
+	variables := []Variable{}
+	for _, name := range names {
+		var version struct {
+			Payload struct {
+				Data string `json:"data"`
+			} `json:"payload"`
+		}
+		err = gcpGetJSON(fmt.Sprintf("%s/%s/versions/latest:access", gcpSecretManagerURL, name), accessToken, &version)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := base64.StdEncoding.DecodeString(version.Payload.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode secret %s: %w", name, err)
+		}
+
+		id := name[strings.LastIndex(name, "/")+1:]
+		variables = append(variables, Variable{
+			Name:  sourceKeyToVariableName(strings.TrimPrefix(id, prefix)),
+			Value: string(value),
+		})
+	}
+
+	sortVariables(variables)
+	return variables, nil
+}
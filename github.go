@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strings"
+)
+
+// newGitHubRequest creates an API request with the standard GitHub headers
+func newGitHubRequest(method, url, token string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+// githubGetJSON performs a GET request against the GitHub API and decodes the JSON response
+func githubGetJSON(token, url string, out interface{}) error {
+	req, err := newGitHubRequest("GET", url, token, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode != 200 {
+		return newAPIError(resp, body)
+	}
+
+	return json.Unmarshal(body, out)
+}
+
+// fetchRepoFile downloads a file from a repository via the contents API. An empty ref means the default branch.
+func fetchRepoFile(token, owner, repo, path, ref string) ([]byte, error) {
+	content, _, err := fetchRepoFileWithSHA(token, owner, repo, path, ref)
+	return content, err
+}
+
+// fetchRepoFileWithSHA downloads a file and returns its blob SHA, which updates of the file must reference
+func fetchRepoFileWithSHA(token, owner, repo, path, ref string) ([]byte, string, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/contents/%s", githubAPIURL, owner, repo, strings.TrimLeft(path, "/"))
+	if ref != "" {
+		url += "?ref=" + neturl.QueryEscape(ref)
+	}
+
+	var file struct {
+		Type     string `json:"type"`
+		Encoding string `json:"encoding"`
+		Content  string `json:"content"`
+		SHA      string `json:"sha"`
+	}
+	err := githubGetJSON(token, url, &file)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch %s/%s/%s: %w", owner, repo, path, err)
+	}
+	if file.Type != "file" || file.Encoding != "base64" {
+		return nil, "", fmt.Errorf("%s/%s/%s is not a regular file", owner, repo, path)
+	}
+
+	content, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(file.Content, "\n", ""))
+	return content, file.SHA, err
+}
+
+// githubSendJSON sends a JSON body to the GitHub API, decoding the response into out (if non-nil)
+// when the status matches want
+func githubSendJSON(token, method, url string, payload interface{}, want int, out interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := newGitHubRequest(method, url, token, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != want {
+		return newAPIError(resp, body)
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(body, out)
+}
+
+// resolveCommitSHA resolves a branch, tag, or (short) SHA to a full commit SHA. An empty ref means the default branch.
+func resolveCommitSHA(token, owner, repo, ref string) (string, error) {
+	if ref == "" {
+		ref = "HEAD"
+	}
+	url := fmt.Sprintf("%s/repos/%s/%s/commits/%s", githubAPIURL, owner, repo, neturl.PathEscape(ref))
+
+	var commit struct {
+		SHA string `json:"sha"`
+	}
+	err := githubGetJSON(token, url, &commit)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s in %s/%s: %w", ref, owner, repo, err)
+	}
+	return commit.SHA, nil
+}
+
+// variableURL returns the API URL of the target's variables collection, or of one variable when name is set
+func variableURL(owner, repo, environment, name string) string {
+	url := fmt.Sprintf("%s/repos/%s/%s/actions/variables", githubAPIURL, owner, repo)
+	switch {
+	case environment != "":
+		url = fmt.Sprintf("%s/repos/%s/%s/environments/%s/variables", githubAPIURL, owner, repo, environment)
+	case isOrgTarget(owner, repo):
+		url = fmt.Sprintf("%s/orgs/%s/actions/variables", githubAPIURL, owner)
+	}
+	if name != "" {
+		url += "/" + name
+	}
+	return url
+}
+
+// variablePayload is the body creating a variable. Organization variables also need a
+// visibility, from --org-visibility.
+func variablePayload(owner, repo string, variable Variable) map[string]string {
+	payload := map[string]string{"name": variable.Name, "value": variable.Value}
+	if isOrgTarget(owner, repo) {
+		payload["visibility"] = *orgVisibility
+	}
+	return payload
+}
+
+// nextPageURL returns the rel="next" page from an RFC 5988 Link header, and whether the
+// response had pagination links at all. Only the next link's query is used, applied to
+// baseURL, so proxies and GHES instances that report an internal hostname still work. A
+// next link that can't be parsed is an error rather than the last page, so a listing is
+// never cut short without notice.
+func nextPageURL(baseURL string, header http.Header) (string, bool, error) {
+	links := header.Values("Link")
+	if len(links) == 0 {
+		return "", false, nil
+	}
+	for _, link := range strings.Split(strings.Join(links, ","), ",") {
+		target, params, ok := strings.Cut(link, ";")
+		if !ok {
+			continue
+		}
+		isNext := false
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if strings.EqualFold(key, "rel") && strings.Contains(" "+strings.Trim(value, `"`)+" ", " next ") {
+				isNext = true
+			}
+		}
+		if !isNext {
+			continue
+		}
+		next, err := neturl.Parse(strings.Trim(strings.TrimSpace(target), "<>"))
+		if err != nil {
+			return "", true, fmt.Errorf("invalid next page in Link header: %w", err)
+		}
+		return baseURL + "?" + next.RawQuery, true, nil
+	}
+	return "", true, nil
+}
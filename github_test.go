@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestNextPageURL(t *testing.T) {
+	const base = "https://api.github.com/repos/o/r/actions/variables"
+	tests := []struct {
+		name     string
+		links    []string
+		want     string
+		hasLinks bool
+	}{
+		{"no Link header", nil, "", false},
+		{
+			"next among other relations",
+			[]string{`<https://api.github.com/repositories/1/actions/variables?per_page=100&page=1>; rel="prev", <https://api.github.com/repositories/1/actions/variables?per_page=100&page=3>; rel="next", <https://api.github.com/repositories/1/actions/variables?per_page=100&page=5>; rel="last", <https://api.github.com/repositories/1/actions/variables?per_page=100&page=1>; rel="first"`},
+			base + "?per_page=100&page=3",
+			true,
+		},
+		{
+			"relations split across header lines",
+			[]string{`<https://ghe.example.com/api/v3/x?page=1>; rel="first"`, `<https://ghe.example.com/api/v3/x?page=4>; rel="next"`},
+			base + "?page=4",
+			true,
+		},
+		{
+			"several relation types in one rel",
+			[]string{`<https://api.github.com/x?page=2>; rel="next last"`},
+			base + "?page=2",
+			true,
+		},
+		{
+			"last page without next",
+			[]string{`<https://api.github.com/x?page=1>; rel="first", <https://api.github.com/x?page=2>; rel="prev"`},
+			"",
+			true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for _, link := range tt.links {
+				header.Add("Link", link)
+			}
+			got, hasLinks, err := nextPageURL(base, header)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want || hasLinks != tt.hasLinks {
+				t.Errorf("nextPageURL = %q, %v; want %q, %v", got, hasLinks, tt.want, tt.hasLinks)
+			}
+		})
+	}
+}
+
+// pagedVariables serves pages of two variables each, linked like GitHub's API. total_count
+// is left stale at 1 so only the Link header can lead past the first page.
+func pagedVariables(t *testing.T, pages int) (requests *int) {
+	requests = new(int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		link := func(p int, rel string) string {
+			return fmt.Sprintf(`<http://%s/repositories/1/actions/variables?per_page=100&page=%d>; rel="%s"`, r.Host, p, rel)
+		}
+		links := link(1, "first")
+		if page > 1 {
+			links += ", " + link(page-1, "prev")
+		}
+		if page < pages {
+			links += ", " + link(page+1, "next") + ", " + link(pages, "last")
+		}
+		w.Header().Set("Link", links)
+		json.NewEncoder(w).Encode(GitHubVariablesResponse{TotalCount: 1, Variables: []Variable{
+			{Name: fmt.Sprintf("P%d_A", page), Value: "a"},
+			{Name: fmt.Sprintf("P%d_B", page), Value: "b"},
+		}})
+	}))
+	apiURL := githubAPIURL
+	githubAPIURL = server.URL
+	t.Cleanup(func() {
+		server.Close()
+		githubAPIURL = apiURL
+	})
+	return requests
+}
+
+func TestFetchGitHubVariablesRejectsBrokenNextLink(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Link", `<http://%zz/repositories/1/actions/variables?page=2>; rel="next"`)
+		json.NewEncoder(w).Encode(GitHubVariablesResponse{TotalCount: 4, Variables: []Variable{{Name: "A", Value: "a"}}})
+	}))
+	defer server.Close()
+	apiURL := githubAPIURL
+	githubAPIURL = server.URL
+	defer func() { githubAPIURL = apiURL }()
+
+	variables, err := FetchGitHubVariables("test-token", "o", "r", "")
+	if err == nil || !strings.Contains(err.Error(), "Link header") {
+		t.Errorf("got %v, %v; want an error about the Link header", variables, err)
+	}
+	if requests != 1 {
+		t.Errorf("made %d requests, want 1", requests)
+	}
+}
+
+func TestFetchGitHubVariablesFollowsNextLinks(t *testing.T) {
+	for _, pages := range []int{1, 3, 5} {
+		t.Run(fmt.Sprintf("%d pages", pages), func(t *testing.T) {
+			requests := pagedVariables(t, pages)
+			variables, err := FetchGitHubVariables("test-token", "o", "r", "")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *requests != pages {
+				t.Errorf("made %d requests, want %d", *requests, pages)
+			}
+			if len(variables) != 2*pages {
+				t.Fatalf("got %d variables, want %d", len(variables), 2*pages)
+			}
+			if last := variables[len(variables)-1].Name; last != fmt.Sprintf("P%d_B", pages) {
+				t.Errorf("last variable = %s, want the last page's", last)
+			}
+		})
+	}
+}
@@ -0,0 +1,490 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// journalRun is the entries of one run, in the order they were applied
+type journalRun struct {
+	ID         string
+	Started    time.Time
+	Entries    []JournalEntry
+	RollbackOf string
+	RolledBack []string // Runs that rolled this one back
+}
+
+// groupJournalRuns groups entries by run, oldest first
+func groupJournalRuns(entries []JournalEntry) []*journalRun {
+	runs := []*journalRun{}
+	byID := map[string]*journalRun{}
+	for _, e := range entries {
+		run := byID[e.RunID]
+		if run == nil {
+			run = &journalRun{ID: e.RunID, Started: e.Timestamp, RollbackOf: e.RollbackOf}
+			byID[e.RunID] = run
+			runs = append(runs, run)
+		}
+		run.Entries = append(run.Entries, e)
+	}
+	for _, run := range runs {
+		if original := byID[run.RollbackOf]; original != nil {
+			original.RolledBack = append(original.RolledBack, run.ID)
+		}
+	}
+	return runs
+}
+
+// counts summarizes a run's changes as "+created ~updated -deleted"
+func (r *journalRun) counts() string {
+	created, updated, deleted := 0, 0, 0
+	for _, e := range r.Entries {
+		switch e.Action {
+		case AuditCreate:
+			created++
+		case AuditUpdate:
+			updated++
+		case AuditDelete:
+			deleted++
+		}
+	}
+	return fmt.Sprintf("+%d ~%d -%d", created, updated, deleted)
+}
+
+// targets lists the targets a run changed, in the order it changed them
+func (r *journalRun) targets() []string {
+	targets := []string{}
+	seen := map[string]bool{}
+	for _, e := range r.Entries {
+		target := targetName(e.Owner, e.Repo, e.Environment)
+		if !seen[target] {
+			seen[target] = true
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// handleHistory lists the runs in the local journal that changed the target, newest first,
+// or with a run ID, every change that run applied
+func handleHistory(args []string, owner, repo, environment string) {
+	fs := flag.NewFlagSet("history", flag.ExitOnError)
+	envName := fs.String("env", environment, "Environment (defaults to GITHUB_ENVIRONMENT; empty for repository variables)")
+	all := fs.Bool("all", false, "List runs against every target, not just this one")
+	limit := fs.Int("limit", 20, "Show at most this many runs (0 for all)")
+	name := fs.String("name", "", "Only list runs that changed this variable")
+	fs.Parse(args)
+
+	if !journalEnabled() {
+		fatal(exitFailure, "The journal is disabled (--journal is empty)")
+	}
+	entries, err := ReadJournal(*journalFile)
+	if err != nil {
+		fatal(exitFailure, "Error reading journal: %v", err)
+	}
+
+	if fs.NArg() > 0 {
+		displayJournalRun(entries, fs.Arg(0))
+		return
+	}
+
+	if owner == "" && !*all {
+		fatal(exitValidation, "history needs a target (GITHUB_OWNER and GITHUB_REPO, or --target), --all, or a run ID")
+	}
+	target := targetName(owner, repo, *envName)
+	selected := []JournalEntry{}
+	for _, e := range entries {
+		if !*all && targetName(e.Owner, e.Repo, e.Environment) != target {
+			continue
+		}
+		if *name != "" && nameKey(e.Name) != nameKey(normalizeName(*name)) {
+			continue
+		}
+		selected = append(selected, e)
+	}
+	runs := groupJournalRuns(selected)
+	if len(runs) == 0 {
+		fmt.Printf("No changes to %s in %s\n", target, *journalFile)
+		return
+	}
+
+	shown := runs
+	if *limit > 0 && len(shown) > *limit {
+		shown = shown[len(shown)-*limit:]
+	}
+	fmt.Printf("📜 Runs in %s, newest first\n\n", *journalFile)
+	fmt.Printf("  %s%-22s │ %-16s │ %-12s │ %s%s\n", ColorBold, "RUN", "TIME", "CHANGES", "TARGET", ColorReset)
+	for i := len(shown) - 1; i >= 0; i-- {
+		run := shown[i]
+		note := ""
+		if run.RollbackOf != "" {
+			note += fmt.Sprintf(" %s(rollback of %s)%s", ColorGray, run.RollbackOf, ColorReset)
+		}
+		if len(run.RolledBack) > 0 {
+			note += fmt.Sprintf(" %s(rolled back by %s)%s", ColorGray, strings.Join(run.RolledBack, ", "), ColorReset)
+		}
+		fmt.Printf("  %-22s │ %-16s │ %-12s │ %s%s\n", run.ID, run.Started.Local().Format("2006-01-02 15:04"),
+			run.counts(), strings.Join(run.targets(), ", "), note)
+	}
+	if len(shown) < len(runs) {
+		fmt.Printf("\n%d of %d run(s) shown (use --limit 0 for all)\n", len(shown), len(runs))
+	}
+	fmt.Println("\nShow a run's changes with `history RUN`; revert one with `rollback RUN`")
+}
+
+// displayJournalRun prints every change a run applied
+func displayJournalRun(entries []JournalEntry, id string) {
+	run := findJournalRun(entries, id)
+	fmt.Printf("📜 Run %s, %s: %s\n", run.ID, run.Started.Local().Format("2006-01-02 15:04:05"), run.counts())
+	if run.RollbackOf != "" {
+		fmt.Printf("   Rollback of %s\n", run.RollbackOf)
+	}
+	if len(run.RolledBack) > 0 {
+		fmt.Printf("   Rolled back by %s\n", strings.Join(run.RolledBack, ", "))
+	}
+
+	target := ""
+	for _, e := range run.Entries {
+		if t := targetName(e.Owner, e.Repo, e.Environment); t != target {
+			target = t
+			fmt.Printf("\n%s%s%s\n", ColorBold, target, ColorReset)
+		}
+		switch e.Action {
+		case AuditCreate:
+			fmt.Printf("%s+ %s = %s%s\n", ColorGreen, e.Name, journalValue(e.NewHash), ColorReset)
+		case AuditUpdate:
+			fmt.Printf("%s~ %s:%s\n", ColorYellow, e.Name, ColorReset)
+			fmt.Printf("  %s- %s%s\n", ColorRed, journalValue(e.OldHash), ColorReset)
+			fmt.Printf("  %s+ %s%s\n", ColorGreen, journalValue(e.NewHash), ColorReset)
+		case AuditDelete:
+			fmt.Printf("%s- %s = %s%s\n", ColorRed, e.Name, journalValue(e.OldHash), ColorReset)
+		}
+	}
+}
+
+// findJournalRun returns a run by ID, exiting when the journal doesn't have it
+func findJournalRun(entries []JournalEntry, id string) *journalRun {
+	for _, run := range groupJournalRuns(entries) {
+		if run.ID == id {
+			return run
+		}
+	}
+	fatal(exitFailure, "No run %s in %s (list runs with `history`)", id, *journalFile)
+	return nil
+}
+
+// journalValue shows a value the journal recorded, which it only has the hash of
+func journalValue(hash string) string {
+	if hash == "" {
+		return "(unknown)"
+	}
+	return "•••• sha256:" + hash[:16]
+}
+
+// rollbackValue formats a value of a rollback plan for display, masked like any other value
+func rollbackValue(name string, value *string, limit int) string {
+	if value == nil {
+		return "(unknown)"
+	}
+	return truncateValue(shownValue(name, *value), limit)
+}
+
+// rollbackStep restores one variable to its value from before a run
+type rollbackStep struct {
+	Name     string
+	Restore  *string // Value before the run; nil if the run created the variable
+	Current  *string // Value now; nil if it doesn't exist
+	Conflict bool    // Changed again since the run
+}
+
+// rollbackTarget is the steps of a rollback for one target
+type rollbackTarget struct {
+	Owner, Repo, Environment string
+	Steps                    []rollbackStep
+	Reverted                 int // Variables already back at their old value
+}
+
+// handleRollback reverts a run by reapplying the values its changes replaced. Variables
+// changed again since the run are left alone unless --force is given.
+func handleRollback(args []string, token string) {
+	fs := flag.NewFlagSet("rollback", flag.ExitOnError)
+	force := fs.Bool("force", false, "Also revert variables that were changed again after the run")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fmt.Println("❌ Usage: rollback [--force] RUN")
+		exit(exitFailure)
+	}
+	if !journalEnabled() {
+		fatal(exitFailure, "The journal is disabled (--journal is empty)")
+	}
+	entries, err := ReadJournal(*journalFile)
+	if err != nil {
+		fatal(exitFailure, "Error reading journal: %v", err)
+	}
+	run := findJournalRun(entries, fs.Arg(0))
+	planned, err := planRollback(run)
+	if err != nil {
+		fatal(exitFailure, "Run %s can't be rolled back: %v", run.ID, err)
+	}
+
+	plans := []*rollbackTarget{}
+	pending, conflicts := 0, 0
+	for _, plan := range planned {
+		lockOrExit(token, plan.Owner, plan.Repo, plan.Environment)
+		remote, err := FetchGitHubVariables(token, plan.Owner, plan.Repo, plan.Environment)
+		if err != nil {
+			fatal(exitFailure, "Error fetching variables of %s: %v", targetName(plan.Owner, plan.Repo, plan.Environment), err)
+		}
+		if err := plan.resolve(run, remote); err != nil {
+			fatal(exitFailure, "%v", err)
+		}
+		for _, step := range plan.Steps {
+			if step.Conflict {
+				conflicts++
+			}
+		}
+		pending += len(plan.Steps)
+		plans = append(plans, plan)
+	}
+
+	fmt.Printf("⏪ Rollback of run %s (%s)\n", run.ID, run.Started.Local().Format("2006-01-02 15:04:05"))
+	for _, plan := range plans {
+		displayRollbackPlan(plan, *force)
+	}
+	if !*force {
+		pending -= conflicts
+	}
+	if conflicts > 0 && !*force {
+		fmt.Printf("\n⚠️  %d variable(s) changed again since the run and will be left alone (use --force to revert them too)\n", conflicts)
+	}
+	if pending == 0 {
+		fmt.Println("\n✅ Nothing to roll back")
+		return
+	}
+
+	// Undoing creations deletes variables, so it needs the typed confirmation: the repository,
+	// or the run ID when the run spanned several
+	deletions, repos := 0, map[string]bool{}
+	for _, plan := range plans {
+		for _, step := range plan.Steps {
+			if step.Restore == nil && (!step.Conflict || *force) {
+				deletions++
+				repos[confirmationName(plan.Owner, plan.Repo)] = true
+			}
+		}
+	}
+
+	for _, plan := range plans {
+		requireApproval(token, plan.Owner, plan.Repo, plan.Environment, plan.changes(*force))
+	}
+
+	fmt.Println()
+	confirmed := false
+	if deletions > 0 {
+		fmt.Printf("⚠️  Roll back %d variable(s) to their values from before run %s\n", pending, run.ID)
+		expected := run.ID
+		if len(repos) == 1 {
+			for name := range repos {
+				expected = name
+			}
+		}
+		confirmed = confirmDeletion(deletions, "the run's targets", expected)
+	} else {
+		confirmed = askYesNo(fmt.Sprintf("⚠️  Roll back %d variable(s) to their values from before run %s?", pending, run.ID))
+	}
+	if !confirmed {
+		fmt.Println("\n❌ Rollback cancelled by user")
+		exit(exitCancelled)
+	}
+
+	journalRollbackOf = run.ID
+	restored, failed := 0, 0
+	client := newRESTClient(token)
+	for _, plan := range plans {
+		if !*noBackup {
+			backupFile, err := BackupGitHubVariables(token, plan.Owner, plan.Repo, plan.Environment)
+			if err != nil {
+				fatal(exitFailure, "Failed to create backup, nothing was rolled back in %s: %v", targetName(plan.Owner, plan.Repo, plan.Environment), err)
+			}
+			fmt.Printf("💾 Backup saved: %s\n", backupFile)
+		}
+		for _, step := range plan.Steps {
+			if step.Conflict && !*force {
+				continue
+			}
+			var err error
+			switch {
+			case step.Restore == nil:
+				err = client.DeleteVariable(plan.Owner, plan.Repo, plan.Environment, step.Name)
+			case step.Current == nil:
+				err = client.CreateVariable(plan.Owner, plan.Repo, plan.Environment, Variable{Name: step.Name, Value: *step.Restore})
+			default:
+				err = client.UpdateVariable(plan.Owner, plan.Repo, plan.Environment, Variable{Name: step.Name, Value: *step.Restore})
+			}
+			if err != nil {
+				fmt.Printf("❌ Error restoring '%s': %v\n", step.Name, err)
+				failed++
+				continue
+			}
+			restored++
+		}
+	}
+
+	fmt.Printf("\n🎉 Completed! Restored %d, Failed %d variables (journaled as run %s)\n", restored, failed, currentRunID())
+	if failed > 0 {
+		runError = fmt.Sprintf("%d variable(s) failed to roll back", failed)
+		exit(exitPartial)
+	}
+}
+
+// planRollback finds, for every variable a run changed, the value it had before the run,
+// grouped by target. The run's first change to the variable has its hash, and the backup
+// taken before that change the value itself.
+func planRollback(run *journalRun) ([]*rollbackTarget, error) {
+	plans := []*rollbackTarget{}
+	byTarget := map[string]*rollbackTarget{}
+	seen := map[string]bool{}
+	backups := map[string]map[string]string{} // Backup file -> name key -> value
+	for _, e := range run.Entries {
+		target := targetName(e.Owner, e.Repo, e.Environment)
+		plan := byTarget[target]
+		if plan == nil {
+			plan = &rollbackTarget{Owner: e.Owner, Repo: e.Repo, Environment: e.Environment}
+			byTarget[target] = plan
+			plans = append(plans, plan)
+		}
+		if key := target + "\x00" + nameKey(e.Name); !seen[key] {
+			seen[key] = true
+			step := rollbackStep{Name: e.Name}
+			if e.Action != AuditCreate {
+				value, err := backedUpValue(backups, e)
+				if err != nil {
+					return nil, err
+				}
+				step.Restore = &value
+			}
+			plan.Steps = append(plan.Steps, step)
+		}
+	}
+	return plans, nil
+}
+
+// backedUpValue reads the value an update or delete replaced from the backup taken before it,
+// checked against the hash the journal recorded. backups caches the backups already read.
+func backedUpValue(backups map[string]map[string]string, e JournalEntry) (string, error) {
+	if e.OldHash == "" {
+		return "", fmt.Errorf("the old value of %s wasn't recorded", e.Name)
+	}
+	if e.Backup == "" {
+		return "", fmt.Errorf("no backup was taken before %s was changed (--no-backup, or a command that doesn't back up)", e.Name)
+	}
+	values, ok := backups[e.Backup]
+	if !ok {
+		variables, err := readBackup(e.Backup)
+		if err != nil {
+			return "", fmt.Errorf("reading the backup taken before the run: %w", err)
+		}
+		values = map[string]string{}
+		for _, v := range variables {
+			values[nameKey(v.Name)] = v.Value
+		}
+		backups[e.Backup] = values
+	}
+	value, ok := values[nameKey(e.Name)]
+	if !ok || sha256Hex([]byte(value)) != e.OldHash {
+		return "", fmt.Errorf("%s doesn't have the value %s had before the run", e.Backup, e.Name)
+	}
+	return value, nil
+}
+
+// resolve compares each step with the target's current variables: steps already reverted are
+// dropped, and ones no longer at the value the run left are marked as conflicts
+func (p *rollbackTarget) resolve(run *journalRun, remote []Variable) error {
+	current := map[string]string{}
+	for _, v := range remote {
+		current[nameKey(v.Name)] = v.Value
+	}
+	// The value the run left each variable at is its last change's new value ("" if deleted)
+	left := map[string]string{}
+	for _, e := range run.Entries {
+		if e.Owner == p.Owner && e.Repo == p.Repo && e.Environment == p.Environment {
+			left[nameKey(e.Name)] = e.NewHash
+		}
+	}
+
+	steps := []rollbackStep{}
+	for _, step := range p.Steps {
+		key := nameKey(step.Name)
+		if value, ok := current[key]; ok {
+			step.Current = &value
+		}
+		if sameJournalValue(step.Current, step.Restore) {
+			p.Reverted++
+			continue
+		}
+		if isIgnored(step.Name) {
+			return fmt.Errorf("%s is managed elsewhere (%s); remove it from there before rolling back", step.Name, *ignoreFile)
+		}
+		step.Conflict = journalHash(step.Current) != left[key]
+		steps = append(steps, step)
+	}
+	p.Steps = steps
+	return nil
+}
+
+// changes is what the rollback writes to the target, for approval. Conflicting steps are only
+// part of it with force.
+func (p *rollbackTarget) changes(force bool) DiffResult {
+	plan := DiffResult{}
+	for _, step := range p.Steps {
+		switch {
+		case step.Conflict && !force:
+		case step.Restore == nil:
+			plan.Deleted = append(plan.Deleted, Variable{Name: step.Name, Value: *step.Current})
+		case step.Current == nil:
+			plan.New = append(plan.New, Variable{Name: step.Name, Value: *step.Restore})
+		default:
+			plan.Updated = append(plan.Updated, VariableChange{Name: step.Name, OldValue: *step.Current, NewValue: *step.Restore})
+		}
+	}
+	return plan
+}
+
+// sameJournalValue compares two optional values; nil means the variable doesn't exist
+func sameJournalValue(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// displayRollbackPlan prints what a rollback will do to one target
+func displayRollbackPlan(plan *rollbackTarget, force bool) {
+	fmt.Printf("\n%s%s%s\n", ColorBold, targetName(plan.Owner, plan.Repo, plan.Environment), ColorReset)
+	limit := valueLimit(80, true)
+	for _, step := range plan.Steps {
+		conflict := ""
+		if step.Conflict {
+			conflict = fmt.Sprintf(" %s(changed since the run", ColorGray)
+			if !force {
+				conflict += "; skipped"
+			}
+			conflict += ")" + ColorReset
+		}
+		switch {
+		case step.Restore == nil:
+			fmt.Printf("%s- %s = %s%s%s\n", ColorRed, step.Name, rollbackValue(step.Name, step.Current, limit), ColorReset, conflict)
+		case step.Current == nil:
+			fmt.Printf("%s+ %s = %s%s%s\n", ColorGreen, step.Name, rollbackValue(step.Name, step.Restore, limit), ColorReset, conflict)
+		default:
+			fmt.Printf("%s~ %s:%s%s\n", ColorYellow, step.Name, ColorReset, conflict)
+			fmt.Printf("  %s- %s%s\n", ColorRed, rollbackValue(step.Name, step.Current, limit), ColorReset)
+			fmt.Printf("  %s+ %s%s\n", ColorGreen, rollbackValue(step.Name, step.Restore, limit), ColorReset)
+		}
+	}
+	if plan.Reverted > 0 {
+		fmt.Printf("%s%d variable(s) already have their old value%s\n", ColorGray, plan.Reverted, ColorReset)
+	}
+}
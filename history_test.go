@@ -0,0 +1,64 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestJournalKeepsOnlyHashes(t *testing.T) {
+	dir := t.TempDir()
+	saved, savedBackups := *journalFile, runBackups
+	*journalFile, runBackups = filepath.Join(dir, "state", "sync-history.jsonl"), map[string]string{}
+	t.Cleanup(func() { *journalFile, runBackups = saved, savedBackups })
+
+	old, updated := "old-secret", "new-secret"
+	rememberBackup("o", "r", "", filepath.Join(dir, "backup.csv"))
+	recordJournal("o", "r", "", AuditUpdate, "TOKEN", &old, &updated, nil)
+
+	data, err := os.ReadFile(*journalFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret") {
+		t.Errorf("journal has a plaintext value: %s", data)
+	}
+	entries, err := ReadJournal(*journalFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := entries[0]; e.OldHash != sha256Hex([]byte(old)) || e.NewHash != sha256Hex([]byte(updated)) || e.Backup == "" {
+		t.Errorf("entry = %+v", e)
+	}
+}
+
+func TestPlanRollbackReadsOldValuesFromBackup(t *testing.T) {
+	backup := filepath.Join(t.TempDir(), "backup.csv")
+	if err := ExportVariablesToCSV([]Variable{{Name: "A", Value: "1"}, {Name: "B", Value: "2"}}, nil, backup, nil); err != nil {
+		t.Fatal(err)
+	}
+	run := &journalRun{ID: "run", Entries: []JournalEntry{
+		{Owner: "o", Repo: "r", Action: AuditCreate, Name: "NEW", NewHash: sha256Hex([]byte("n"))},
+		{Owner: "o", Repo: "r", Action: AuditUpdate, Name: "A", OldHash: sha256Hex([]byte("1")), NewHash: sha256Hex([]byte("x")), Backup: backup},
+		{Owner: "o", Repo: "r", Action: AuditDelete, Name: "B", OldHash: sha256Hex([]byte("2")), Backup: backup},
+	}}
+
+	plans, err := planRollback(run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := plans[0].Steps
+	if steps[0].Restore != nil || *steps[1].Restore != "1" || *steps[2].Restore != "2" {
+		t.Errorf("steps = %+v", steps)
+	}
+
+	run.Entries[1].OldHash = sha256Hex([]byte("something else"))
+	if _, err := planRollback(run); err == nil {
+		t.Error("planned a rollback to a value the backup doesn't have")
+	}
+	run.Entries[1].Backup = ""
+	if _, err := planRollback(run); err == nil {
+		t.Error("planned a rollback of an update without a backup")
+	}
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"time"
+)
+
+// hookTimeout stops a hook command that hangs, so it can't block the run forever
+const hookTimeout = 5 * time.Minute
+
+// Hook events
+const (
+	HookPreSync   = "pre_sync"
+	HookPostSync  = "post_sync"
+	HookOnFailure = "on_failure"
+)
+
+// HooksConfig lists shell commands run around a sync. Each command gets the event as JSON on
+// stdin and a summary in SYNC_* environment variables.
+type HooksConfig struct {
+	PreSync   []string `json:"pre_sync"`   // Before the confirmation; a non-zero exit stops the sync
+	PostSync  []string `json:"post_sync"`  // After every sync that changed something, even partially
+	OnFailure []string `json:"on_failure"` // After a sync with failed or unsynced variables
+}
+
+// hookPayload is the JSON a hook reads on stdin
+type hookPayload struct {
+	Event       string      `json:"event"`
+	Owner       string      `json:"owner"`
+	Repo        string      `json:"repo"`
+	Environment string      `json:"environment,omitempty"`
+	Target      string      `json:"target"`
+	Diff        *hookDiff   `json:"diff,omitempty"`   // pre_sync: what is about to change
+	Report      *SyncReport `json:"report,omitempty"` // post_sync and on_failure: what happened
+}
+
+// hookDiff is the pending diff, with values masked as they are on screen
+type hookDiff struct {
+	New     []hookVariable `json:"new"`
+	Updated []hookChange   `json:"updated"`
+}
+
+// hookVariable is a new variable in a hook's diff
+type hookVariable struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// hookChange is an updated variable in a hook's diff
+type hookChange struct {
+	Name     string `json:"name"`
+	OldValue string `json:"old_value"`
+	NewValue string `json:"new_value"`
+}
+
+// runPreSyncHooks runs the pre_sync hooks for a pending diff and stops the run when one fails
+func runPreSyncHooks(owner, repo, environment string, diff DiffResult) {
+	if len(config.Hooks.PreSync) == 0 {
+		return
+	}
+	pending := &hookDiff{New: []hookVariable{}, Updated: []hookChange{}}
+	for _, v := range diff.New {
+		pending.New = append(pending.New, hookVariable{Name: v.Name, Value: shownValue(v.Name, v.Value)})
+	}
+	for _, c := range diff.Updated {
+		pending.Updated = append(pending.Updated, hookChange{Name: c.Name, OldValue: shownValue(c.Name, c.OldValue), NewValue: shownValue(c.Name, c.NewValue)})
+	}
+
+	payload := hookPayload{Event: HookPreSync, Owner: owner, Repo: repo, Environment: environment, Target: targetName(owner, repo, environment), Diff: pending}
+	env := []string{"SYNC_NEW=" + strconv.Itoa(len(diff.New)), "SYNC_UPDATED=" + strconv.Itoa(len(diff.Updated))}
+	if err := runHooks(config.Hooks.PreSync, payload, env); err != nil {
+		runError = err.Error()
+		fmt.Printf("❌ pre_sync hook rejected the sync of %s: %v\n", payload.Target, err)
+		fmt.Println("   Nothing was changed")
+		exit(exitValidation)
+	}
+}
+
+// runPostSyncHooks runs the post_sync hooks after a sync, and the on_failure hooks too when
+// anything failed. The sync already happened, so hook failures are warnings.
+func runPostSyncHooks(report *SyncReport) {
+	failed := len(report.Failed) > 0 || len(report.NotSynced) > 0
+	if len(config.Hooks.PostSync) == 0 && !(failed && len(config.Hooks.OnFailure) > 0) {
+		return
+	}
+	env := []string{
+		"SYNC_CREATED=" + strconv.Itoa(len(report.Created)),
+		"SYNC_UPDATED=" + strconv.Itoa(len(report.Updated)),
+		"SYNC_FAILED=" + strconv.Itoa(len(report.Failed)),
+		"SYNC_NOT_SYNCED=" + strconv.Itoa(len(report.NotSynced)),
+	}
+	payload := hookPayload{Event: HookPostSync, Owner: report.Owner, Repo: report.Repo, Environment: report.Environment, Target: report.Target(), Report: report}
+	if err := runHooks(config.Hooks.PostSync, payload, env); err != nil {
+		fmt.Printf("⚠️  post_sync hook failed: %v\n", err)
+	}
+	if failed {
+		payload.Event = HookOnFailure
+		if err := runHooks(config.Hooks.OnFailure, payload, env); err != nil {
+			fmt.Printf("⚠️  on_failure hook failed: %v\n", err)
+		}
+	}
+}
+
+// runHooks runs commands in order with the payload on stdin, stopping at the first that fails.
+// Their output goes straight to the terminal.
+func runHooks(commands []string, payload hookPayload, extraEnv []string) error {
+	input, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	env := append(os.Environ(),
+		"SYNC_HOOK_EVENT="+payload.Event,
+		"SYNC_OWNER="+payload.Owner,
+		"SYNC_REPO="+payload.Repo,
+		"SYNC_ENVIRONMENT="+payload.Environment,
+		"SYNC_TARGET="+payload.Target,
+	)
+	env = append(env, extraEnv...)
+
+	for _, command := range commands {
+		fmt.Printf("🪝 Running %s hook: %s\n", payload.Event, command)
+		ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+		cmd := hookCommand(ctx, command)
+		cmd.Stdin = bytes.NewReader(input)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		cmd.Env = env
+		err := cmd.Run()
+		timedOut := ctx.Err() == context.DeadlineExceeded
+		cancel()
+		if timedOut {
+			return fmt.Errorf("%s: timed out after %v", command, hookTimeout)
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", command, err)
+		}
+	}
+	return nil
+}
+
+// hookCommand runs a hook through the platform's shell, so pipes and arguments work as typed
+func hookCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// isHTTPSURL reports whether a location is an https:// URL
+func isHTTPSURL(location string) bool {
+	return strings.HasPrefix(location, "https://")
+}
+
+// fetchHTTPSInput downloads an input over HTTPS and verifies its SHA-256 checksum: the one
+// given with --input-sha256, or otherwise a "<url>.sha256" file published next to it
+func fetchHTTPSInput(location, token string) ([]byte, error) {
+	content, status, err := httpsGet(location, token)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("%s returned status %d", redactURL(location), status)
+	}
+
+	expected := *inputSHA256
+	if expected == "" {
+		sidecar, status, err := httpsGet(sidecarURL(location), token)
+		switch {
+		case err != nil:
+			return nil, fmt.Errorf("failed to fetch checksum: %w", err)
+		case status == http.StatusNotFound:
+			fmt.Printf("⚠️  No checksum for %s (use --input-sha256 or publish a .sha256 file); content is not verified\n", redactURL(location))
+			return content, nil
+		case status != http.StatusOK:
+			return nil, fmt.Errorf("checksum file returned status %d", status)
+		}
+		// sha256sum format: "<hex>  <file name>"
+		fields := strings.Fields(string(sidecar))
+		if len(fields) == 0 {
+			return nil, fmt.Errorf("checksum file is empty")
+		}
+		expected = fields[0]
+	}
+
+	err = verifySHA256(content, expected, redactURL(location))
+	if err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// verifySHA256 checks content against an expected hex SHA-256
+func verifySHA256(content []byte, expected, label string) error {
+	sum := sha256.Sum256(content)
+	actual := hex.EncodeToString(sum[:])
+	expected = strings.ToLower(strings.TrimSpace(expected))
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(actual)) != 1 {
+		return fmt.Errorf("checksum mismatch for %s: expected sha256 %s, got %s", label, expected, actual)
+	}
+	fmt.Printf("🔒 Verified sha256 of %s\n", label)
+	return nil
+}
+
+// httpsGet fetches a URL with the --input-header (or INPUT_AUTH_HEADER) header. GitHub hosts
+// get the GitHub token when no header is given, so raw contents URLs of private repositories work.
+func httpsGet(location, token string) ([]byte, int, error) {
+	req, err := http.NewRequest("GET", location, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	header := *inputHeader
+	if header == "" {
+		header = os.Getenv("INPUT_AUTH_HEADER")
+	}
+	if header != "" {
+		name, value, ok := strings.Cut(header, ":")
+		if !ok {
+			return nil, 0, fmt.Errorf("--input-header must look like 'Name: value'")
+		}
+		value = strings.TrimSpace(value)
+		redactor.Add(value)
+		req.Header.Set(strings.TrimSpace(name), value)
+	} else if isGitHubContentHost(req.URL.Host) && token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s", safeValue(err.Error()))
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, err
+	}
+	return body, resp.StatusCode, nil
+}
+
+// isGitHubContentHost reports whether a host serves repository content for the configured GitHub
+func isGitHubContentHost(host string) bool {
+	if host == "raw.githubusercontent.com" {
+		return true
+	}
+	api, err := url.Parse(githubAPIURL)
+	return err == nil && host == api.Host
+}
+
+// sidecarURL returns the URL of the checksum file published next to an input
+func sidecarURL(location string) string {
+	u, err := url.Parse(location)
+	if err != nil {
+		return location + ".sha256"
+	}
+	u.Path += ".sha256"
+	u.RawPath = ""
+	return u.String()
+}
+
+// redactURL drops the query string, which may carry a signed token, for display
+func redactURL(location string) string {
+	base, _, _ := strings.Cut(location, "?")
+	return base
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// authIdentity names who the token acts as when GET /user can't tell (GitHub App auth);
+// main sets it while configuring auth
+var authIdentity func() string
+
+// tokenKind names the kind of token from its prefix
+func tokenKind(token string) string {
+	switch {
+	case strings.HasPrefix(token, "ghp_"):
+		return "classic personal access token"
+	case strings.HasPrefix(token, "github_pat_"):
+		return "fine-grained personal access token"
+	case strings.HasPrefix(token, "gho_"):
+		return "OAuth token"
+	case strings.HasPrefix(token, "ghu_"):
+		return "GitHub App user token"
+	case strings.HasPrefix(token, "ghs_"):
+		return "GitHub App installation token"
+	}
+	return "token"
+}
+
+// describeIdentity says who a sync will act as, e.g. "octocat (classic personal access
+// token)", so a wrong token is noticed before confirming. It never fails: when the identity
+// can't be looked up, it says why.
+func describeIdentity(token string) string {
+	if token == replayToken {
+		return "none (offline replay)"
+	}
+	if authIdentity != nil {
+		return authIdentity()
+	}
+
+	var user struct {
+		Login string `json:"login"`
+		Type  string `json:"type"`
+	}
+	err := githubGetJSON(token, githubAPIURL+"/user", &user)
+	switch {
+	case err == nil && user.Login != "":
+		return fmt.Sprintf("%s (%s)", user.Login, tokenKind(token))
+	case strings.HasPrefix(token, "ghs_") && os.Getenv("GITHUB_ACTIONS") == "true" && token == os.Getenv("GITHUB_TOKEN"):
+		return "github-actions[bot] (the workflow's GITHUB_TOKEN)"
+	case strings.HasPrefix(token, "ghs_"):
+		return "a GitHub App installation (installation tokens can't look up their app)"
+	case err != nil:
+		reason, _, _ := strings.Cut(err.Error(), "\n")
+		return fmt.Sprintf("unknown %s (%s)", tokenKind(token), reason)
+	}
+	return "unknown " + tokenKind(token)
+}
+
+// Identity names the App the installation tokens belong to, looked up with the App's JWT
+func (p *appTokenProvider) Identity() string {
+	fallback := fmt.Sprintf("GitHub App %s (installation %s)", p.appID, p.installationID)
+	jwt, err := p.signJWT(time.Now())
+	if err != nil {
+		return fallback
+	}
+	req, err := newGitHubRequest("GET", githubAPIURL+"/app", jwt, nil)
+	if err != nil {
+		return fallback
+	}
+	// Use the base transport so the JWT isn't replaced by an installation token
+	base := p.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := (&http.Client{Transport: base, Timeout: httpClient.Timeout}).Do(req)
+	if err != nil {
+		return fallback
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || resp.StatusCode != 200 {
+		return fallback
+	}
+	var app struct {
+		Slug string `json:"slug"`
+	}
+	if json.Unmarshal(body, &app) != nil || app.Slug == "" {
+		return fallback
+	}
+	return fmt.Sprintf("%s[bot] (GitHub App installation %s)", app.Slug, p.installationID)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultIgnoreFile is loaded automatically when it exists
+const defaultIgnoreFile = ".syncignore"
+
+// ignoreRule is one line of an ignore file
+type ignoreRule struct {
+	pattern string // Uppercased glob pattern
+	negate  bool   // !PATTERN: not ignored after all
+}
+
+// ignoreRules are the loaded ignore file's rules; empty when there is none
+var ignoreRules []ignoreRule
+
+// LoadIgnoreFile reads glob patterns of variable names managed elsewhere, one per line.
+// Blank lines and # comments are skipped, and !PATTERN re-includes names an earlier line
+// ignored, as in .gitignore. A missing file is only an error when required is set.
+func LoadIgnoreFile(path string, required bool) ([]ignoreRule, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) && !required {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	rules := []ignoreRule{}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		rule := ignoreRule{}
+		if strings.HasPrefix(text, "!") {
+			rule.negate = true
+			text = strings.TrimSpace(text[1:])
+		}
+		rule.pattern = strings.ToUpper(text)
+		if _, err := filepath.Match(rule.pattern, ""); err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid pattern %q", path, line, text)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, scanner.Err()
+}
+
+// isIgnored reports whether a variable name is managed elsewhere. Names are matched
+// case-insensitively, as GitHub treats them, and the last matching line wins.
+func isIgnored(name string) bool {
+	ignored := false
+	upper := strings.ToUpper(name)
+	for _, rule := range ignoreRules {
+		if matched, _ := filepath.Match(rule.pattern, upper); matched {
+			ignored = !rule.negate
+		}
+	}
+	return ignored
+}
+
+// withoutIgnored splits variables into the ones this tool may touch and the ignored ones
+func withoutIgnored(variables []Variable) (kept, ignored []Variable) {
+	if len(ignoreRules) == 0 {
+		return variables, nil
+	}
+	kept = []Variable{}
+	for _, v := range variables {
+		if isIgnored(v.Name) {
+			ignored = append(ignored, v)
+		} else {
+			kept = append(kept, v)
+		}
+	}
+	return kept, ignored
+}
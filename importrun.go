@@ -0,0 +1,198 @@
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// logTimestampPattern matches the timestamp GitHub prefixes to every log line
+var logTimestampPattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?Z `)
+
+// runEnvValue is one environment value seen in a workflow run's step logs
+type runEnvValue struct {
+	Name  string
+	Value string
+	Job   string // Log file the value was seen in (job/step)
+}
+
+// handleImportRun reconstructs the variables a workflow run consumed from its logs
+func handleImportRun(args []string, token, owner, repo, environment string) {
+	fs := flag.NewFlagSet("import-run", flag.ExitOnError)
+	output := fs.String("output", "", "Write the reconstructed variables to this CSV file (default: stdout)")
+	all := fs.Bool("all", false, "Include every step env value, not only names that exist as variables")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fatal(exitValidation, "Usage: import-run [--output file.csv] [--all] <run-id>")
+	}
+	runID, err := strconv.ParseInt(fs.Arg(0), 10, 64)
+	if err != nil {
+		fatal(exitValidation, "Invalid run ID %q", fs.Arg(0))
+	}
+
+	// Progress goes to stderr so the CSV can be piped from stdout
+	var run struct {
+		Name      string `json:"name"`
+		HeadSHA   string `json:"head_sha"`
+		CreatedAt string `json:"created_at"`
+		HTMLURL   string `json:"html_url"`
+	}
+	err = githubGetJSON(token, fmt.Sprintf("%s/repos/%s/%s/actions/runs/%d", githubAPIURL, owner, repo, runID), &run)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error fetching workflow run: %v\n", err)
+		exit(exitFailure)
+	}
+	fmt.Fprintf(os.Stderr, "🏃 Run %d: %s @ %.7s (%s)\n", runID, run.Name, run.HeadSHA, run.CreatedAt)
+
+	values, err := FetchRunEnvValues(token, owner, repo, runID)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error reading run logs: %v\n", err)
+		exit(exitFailure)
+	}
+
+	if !*all {
+		// Keep only names that exist as repository (or environment) variables today
+		known := make(map[string]bool)
+		targets := []string{""}
+		if environment != "" {
+			targets = append(targets, environment)
+		}
+		for _, env := range targets {
+			variables, err := FetchGitHubVariables(token, owner, repo, env)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "❌ Error fetching variables: %v\n", err)
+				exit(exitFailure)
+			}
+			for _, v := range variables {
+				known[strings.ToUpper(v.Name)] = true
+			}
+		}
+
+		filtered := []runEnvValue{}
+		for _, v := range values {
+			if known[strings.ToUpper(v.Name)] {
+				filtered = append(filtered, v)
+			}
+		}
+		values = filtered
+	}
+
+	out := os.Stdout
+	if *output != "" {
+		out, err = os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Error creating output file: %v\n", err)
+			exit(exitValidation)
+		}
+		defer out.Close()
+	}
+
+	records := [][]string{{"Key", "Value", "Note"}}
+	for _, v := range values {
+		records = append(records, []string{v.Name, v.Value, fmt.Sprintf("run %d: %s", runID, v.Job)})
+	}
+	if err := writeCSV(out, records); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Error writing CSV: %v\n", err)
+		exit(exitFailure)
+	}
+
+	fmt.Fprintf(os.Stderr, "✅ Reconstructed %d value(s) used by the run\n", len(values))
+	if *output != "" {
+		fmt.Fprintf(os.Stderr, "✅ Saved: %s\n", *output)
+	}
+}
+
+// FetchRunEnvValues downloads a workflow run's log archive and extracts the env
+// values printed in each step's header. Secrets appear as *** and are skipped.
+func FetchRunEnvValues(token, owner, repo string, runID int64) ([]runEnvValue, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s/actions/runs/%d/logs", githubAPIURL, owner, repo, runID)
+	req, err := newGitHubRequest("GET", url, token, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// The API redirects to a signed archive URL; the client drops the token on the cross-host redirect
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("GitHub API returned status %d: %s (logs may have expired)", resp.StatusCode, string(data))
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log archive: %w", err)
+	}
+
+	// Later steps override earlier ones, matching how env is applied at run time
+	byName := make(map[string]runEnvValue)
+	for _, file := range archive.File {
+		// Top-level files are whole-job logs that duplicate the per-step files in subdirectories
+		if !strings.Contains(file.Name, "/") {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range parseStepEnv(rc, strings.TrimSuffix(file.Name, ".txt")) {
+			byName[v.Name] = v
+		}
+		rc.Close()
+	}
+
+	values := make([]runEnvValue, 0, len(byName))
+	for _, v := range byName {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].Name < values[j].Name })
+	return values, nil
+}
+
+// parseStepEnv extracts "env:" blocks from the step header groups of a log file
+func parseStepEnv(r io.Reader, job string) []runEnvValue {
+	values := []runEnvValue{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+
+	inGroup, inEnv := false, false
+	for scanner.Scan() {
+		line := logTimestampPattern.ReplaceAllString(scanner.Text(), "")
+
+		switch {
+		case strings.HasPrefix(line, "##[group]Run "):
+			inGroup, inEnv = true, false
+		case strings.HasPrefix(line, "##[endgroup]"):
+			inGroup, inEnv = false, false
+		case inGroup && strings.TrimSpace(line) == "env:":
+			inEnv = true
+		case inEnv:
+			name, value, ok := strings.Cut(strings.TrimSpace(line), ": ")
+			if !ok || !strings.HasPrefix(line, "    ") {
+				inEnv = false
+				continue
+			}
+			if value == "***" {
+				continue
+			}
+			values = append(values, runEnvValue{Name: name, Value: value, Job: job})
+		}
+	}
+	return values
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// githubInputScheme prefixes inputs read from a repository: github://owner/repo/path@ref
+const githubInputScheme = "github://"
+
+// readInput returns the raw content of a local file, a github:// or https:// URL, or an s3://, gs://, or az:// object
+func readInput(location, token string) ([]byte, error) {
+	if strings.HasPrefix(location, githubInputScheme) {
+		owner, repo, filePath, ref, err := parseGitHubInput(location)
+		if err != nil {
+			return nil, err
+		}
+		// Pin the ref to a commit first, so the file read is exactly the reviewed revision
+		// even if a branch moves meanwhile, and the run records which one it was
+		sha, err := resolveCommitSHA(token, owner, repo, ref)
+		if err != nil {
+			return nil, err
+		}
+		if ref == "" {
+			ref = "default branch"
+		}
+		fmt.Printf("📌 %s/%s@%s resolved to commit %s\n", owner, repo, ref, sha)
+		content, err := fetchRepoFile(token, owner, repo, filePath, sha)
+		if err != nil || *inputSHA256 == "" {
+			return content, err
+		}
+		return content, verifySHA256(content, *inputSHA256, location)
+	}
+	if isObjectStoreURL(location) {
+		return readObject(location)
+	}
+	if isHTTPSURL(location) {
+		return fetchHTTPSInput(location, token)
+	}
+	return os.ReadFile(location)
+}
+
+// parseGitHubInput splits github://owner/repo/path/to/file@ref into its parts (ref is optional)
+func parseGitHubInput(location string) (owner, repo, filePath, ref string, err error) {
+	rest := strings.TrimPrefix(location, githubInputScheme)
+	if at := strings.LastIndex(rest, "@"); at >= 0 {
+		rest, ref = rest[:at], rest[at+1:]
+	}
+
+	parts := strings.SplitN(rest, "/", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", "", "", "", fmt.Errorf("invalid input %q (expected github://owner/repo/path@ref)", location)
+	}
+	return parts[0], parts[1], parts[2], ref, nil
+}
+
+// inputFormat determines the input format from the file extension, ignoring an @ref
+// suffix, a URL query string, and a template extension (.tmpl / .tpl)
+func inputFormat(location string) string {
+	name := location
+	if strings.HasPrefix(name, githubInputScheme) {
+		if at := strings.LastIndex(name, "@"); at >= 0 {
+			name = name[:at]
+		}
+	}
+	if isHTTPSURL(name) {
+		name = redactURL(name)
+	}
+	name = strings.TrimSuffix(strings.TrimSuffix(name, ".tmpl"), ".tpl")
+
+	switch strings.ToLower(path.Ext(name)) {
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".json":
+		return "json"
+	case ".env":
+		return "env"
+	default:
+		return "csv"
+	}
+}
+
+// parseVariables parses input content according to the format of its file name
+func parseVariables(location string, content []byte) ([]Variable, error) {
+	switch inputFormat(location) {
+	case "yaml":
+		parsed, err := ParseYAMLText(content)
+		if err != nil {
+			return nil, err
+		}
+		return variablesFromMap(parsed)
+	case "json":
+		// Numbers keep their text, as in YAML: 1.10 mustn't become 1.1
+		var parsed interface{}
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.UseNumber()
+		if err := decoder.Decode(&parsed); err != nil {
+			return nil, err
+		}
+		if _, err := decoder.Token(); err != io.EOF {
+			return nil, fmt.Errorf("unexpected content after the JSON document")
+		}
+		return variablesFromMap(parsed)
+	case "env":
+		return parseDotEnv(content)
+	default:
+		return parseCSV(bytes.NewReader(content))
+	}
+}
+
+// variablesFromMap converts a top-level NAME: value mapping into variables. Numbers and
+// booleans arrive as their original text (ParseYAMLText, json.Number), never reformatted.
+func variablesFromMap(parsed interface{}) ([]Variable, error) {
+	fields, ok := parsed.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("input must be a mapping of variable names to values")
+	}
+
+	variables := []Variable{}
+	for name, value := range fields {
+		var s string
+		switch v := value.(type) {
+		case nil:
+			s = ""
+		case string:
+			s = v
+		case json.Number:
+			s = v.String()
+		case bool:
+			s = strconv.FormatBool(v) // JSON's true and false are already their text
+		default:
+			return nil, fmt.Errorf("variable %s: value must be a scalar, not a nested structure", name)
+		}
+		variables = append(variables, Variable{Name: strings.TrimSpace(name), Value: s})
+	}
+
+	sort.Slice(variables, func(i, j int) bool { return variables[i].Name < variables[j].Name })
+	return variables, nil
+}
+
+// parseDotEnv parses KEY=VALUE lines, skipping comments and an optional "export " prefix
+func parseDotEnv(content []byte) ([]Variable, error) {
+	variables := []Variable{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNum)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", lineNum, err)
+			}
+			value = unquoted
+		} else if len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'' {
+			value = value[1 : len(value)-1]
+		}
+
+		variables = append(variables, Variable{Name: strings.TrimSpace(name), Value: value})
+	}
+	return variables, scanner.Err()
+}
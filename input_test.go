@@ -0,0 +1,49 @@
+package main
+
+import "testing"
+
+func TestParseVariablesKeepsScalarText(t *testing.T) {
+	want := map[string]string{
+		"VERSION": "1.10",
+		"ZIP":     "007",
+		"BIG":     "12345678901234567890",
+		"RATIO":   "1e3",
+		"DEBUG":   "True",
+		"QUOTED":  "1.10",
+		"EMPTY":   "",
+	}
+	yaml := "VERSION: 1.10\nZIP: 007\nBIG: 12345678901234567890\nRATIO: 1e3\nDEBUG: True\nQUOTED: \"1.10\"\nEMPTY: ~\n"
+	json := `{"VERSION": 1.10, "ZIP": "007", "BIG": 12345678901234567890, "RATIO": 1e3, "DEBUG": "True", "QUOTED": "1.10", "EMPTY": null}`
+
+	for _, input := range []struct{ name, content string }{{"vars.yaml", yaml}, {"vars.json", json}} {
+		variables, err := parseVariables(input.name, []byte(input.content))
+		if err != nil {
+			t.Fatalf("%s: %v", input.name, err)
+		}
+		if len(variables) != len(want) {
+			t.Fatalf("%s: got %d variables, want %d", input.name, len(variables), len(want))
+		}
+		for _, v := range variables {
+			if v.Value != want[v.Name] {
+				t.Errorf("%s: %s = %q, want %q", input.name, v.Name, v.Value, want[v.Name])
+			}
+		}
+	}
+}
+
+func TestParseVariablesRejectsTrailingJSON(t *testing.T) {
+	if _, err := parseVariables("vars.json", []byte(`{"A": "1"} {"B": "2"}`)); err == nil {
+		t.Fatal("expected an error for content after the JSON document")
+	}
+}
+
+func TestParseYAMLStillResolvesScalars(t *testing.T) {
+	parsed, err := ParseYAML([]byte("ttl: 30\nenabled: true\nratio: 1.5\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields := parsed.(map[string]interface{})
+	if fields["ttl"] != 30 || fields["enabled"] != true || fields["ratio"] != 1.5 {
+		t.Errorf("config scalars changed: %#v", fields)
+	}
+}
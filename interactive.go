@@ -0,0 +1,81 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// interactiveHelp explains the answers to the per-change prompt, as `git add -p` does
+const interactiveHelp = `y - apply this change
+n - defer this change (leave GitHub as it is)
+a - apply this change and all remaining ones
+d - defer this change and all remaining ones
+q - quit; apply only the changes accepted so far
+? - print help`
+
+// ReviewChangesInteractively asks about each new and updated variable in turn, like
+// `git add -p`, and returns the diff reduced to the accepted changes plus the names of the
+// deferred ones. It fails when the answers run out (e.g. stdin isn't a terminal), so nothing
+// is applied by accident.
+func ReviewChangesInteractively(diff DiffResult) (DiffResult, []string, error) {
+	return reviewChanges(diff, bufio.NewReader(os.Stdin))
+}
+
+func reviewChanges(diff DiffResult, reader *bufio.Reader) (DiffResult, []string, error) {
+	selected := diff
+	selected.New, selected.Updated = []Variable{}, []VariableChange{}
+	deferred := []string{}
+	total := len(diff.New) + len(diff.Updated)
+
+	// rest is the answer applying to every remaining change after a, d, or q
+	rest := ""
+	for i := 0; i < total; i++ {
+		isNew := i < len(diff.New)
+		name := ""
+		if isNew {
+			name = diff.New[i].Name
+		} else {
+			name = diff.Updated[i-len(diff.New)].Name
+		}
+
+		answer := rest
+		for answer == "" {
+			fmt.Println()
+			if isNew {
+				v := diff.New[i]
+				fmt.Printf("%s+ %s = %s%s%s\n", ColorGreen, v.Name, truncateValue(shownValue(v.Name, v.Value), valueLimit(80, true)), ColorReset, noteSuffix(v.Name))
+			} else {
+				displayUpdatedVariables([]VariableChange{diff.Updated[i-len(diff.New)]}, *diffFormat)
+			}
+			fmt.Printf("%s(%d/%d) Apply this change [y,n,a,d,q,?]? %s", ColorBold, i+1, total, ColorReset)
+			input, err := reader.ReadString('\n')
+			if err != nil && (err != io.EOF || input == "") {
+				fmt.Println()
+				return diff, nil, fmt.Errorf("no answer for %s; --interactive-apply needs a terminal", name)
+			}
+			switch input = strings.TrimSpace(strings.ToLower(input)); input {
+			case "y", "n":
+				answer = input
+			case "a", "d":
+				answer, rest = input, input
+			case "q":
+				answer, rest = "d", "d"
+			default:
+				fmt.Println(interactiveHelp)
+			}
+		}
+
+		switch {
+		case answer == "n" || answer == "d":
+			deferred = append(deferred, name)
+		case isNew:
+			selected.New = append(selected.New, diff.New[i])
+		default:
+			selected.Updated = append(selected.Updated, diff.Updated[i-len(diff.New)])
+		}
+	}
+	return selected, deferred, nil
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// defaultJournalFile is the name of the local change journal
+const defaultJournalFile = "sync-history.jsonl"
+
+// defaultJournalPath is the journal in the user's config directory, shared by every working
+// directory and out of any checkout; the working directory only if there is no config directory
+func defaultJournalPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return defaultJournalFile
+	}
+	return filepath.Join(dir, "sync-github-variable", defaultJournalFile)
+}
+
+// JournalEntry is one applied change in the journal. Values are only recorded as SHA-256
+// hashes; the value a change replaced is kept in the backup taken before it (encrypted with
+// the backup recipients when they're set), which rollback reads it back from.
+type JournalEntry struct {
+	RunID       string    `json:"run_id"`
+	Timestamp   time.Time `json:"timestamp"`
+	Owner       string    `json:"owner"`
+	Repo        string    `json:"repo"`
+	Environment string    `json:"environment,omitempty"`
+	Action      string    `json:"action"` // AuditCreate, AuditUpdate, or AuditDelete
+	Name        string    `json:"name"`
+	OldHash     string    `json:"old_hash,omitempty"` // Empty for creates, and when the old value is unknown
+	NewHash     string    `json:"new_hash,omitempty"` // Empty for deletes
+	Backup      string    `json:"backup,omitempty"`   // Backup of the target taken before the change, if any
+	RollbackOf  string    `json:"rollback_of,omitempty"`
+}
+
+var (
+	journalMu sync.Mutex
+	runID     string
+
+	// journalRollbackOf is set while a rollback runs, so its entries point at the run it reverts
+	journalRollbackOf string
+
+	// knownValues are the values listings returned, keyed by target and name key, so updates
+	// and deletes can record the value they replace without reading it again
+	knownValues   = map[string]string{}
+	knownValuesMu sync.Mutex
+
+	// runBackups are the backups this run took, by target, for the journal to point at
+	runBackups = map[string]string{}
+)
+
+// journalEnabled reports whether applied changes are journaled (--journal "" turns it off)
+func journalEnabled() bool {
+	return *journalFile != ""
+}
+
+// currentRunID identifies this process's changes in the journal, e.g. 20241105-140312-3f9a1c
+func currentRunID() string {
+	if runID == "" {
+		suffix := make([]byte, 3)
+		rand.Read(suffix)
+		runID = time.Now().UTC().Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+	}
+	return runID
+}
+
+func knownValueKey(owner, repo, environment, name string) string {
+	return targetName(owner, repo, environment) + "\x00" + nameKey(name)
+}
+
+// rememberBackup records a backup taken of a target before writing to it, so the journal
+// entries of the writes that follow can point at it
+func rememberBackup(owner, repo, environment, file string) {
+	if abs, err := filepath.Abs(file); err == nil {
+		file = abs
+	}
+	journalMu.Lock()
+	defer journalMu.Unlock()
+	runBackups[targetName(owner, repo, environment)] = file
+}
+
+// journalHash is the hash a journal entry records for a value; "" for none
+func journalHash(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return sha256Hex([]byte(*value))
+}
+
+// rememberValues records the values of a listing for later journal and audit entries
+func rememberValues(owner, repo, environment string, variables []Variable) {
+	knownValuesMu.Lock()
+	defer knownValuesMu.Unlock()
+	for _, v := range variables {
+		knownValues[knownValueKey(owner, repo, environment, v.Name)] = v.Value
+	}
+}
+
+// forgetValue drops a remembered value once the variable is written or deleted
+func forgetValue(owner, repo, environment, name string) {
+	knownValuesMu.Lock()
+	defer knownValuesMu.Unlock()
+	delete(knownValues, knownValueKey(owner, repo, environment, name))
+}
+
+// priorValue returns the value an update or delete replaces, for the audit log and the
+// journal: the one last listed, else read from GitHub. It's nil when neither is enabled.
+func priorValue(token, owner, repo, environment, name string) *string {
+	if !auditEnabled() && !journalEnabled() {
+		return nil
+	}
+	knownValuesMu.Lock()
+	value, ok := knownValues[knownValueKey(owner, repo, environment, name)]
+	knownValuesMu.Unlock()
+	if ok {
+		return &value
+	}
+	return getVariableValue(token, owner, repo, environment, name)
+}
+
+// recordJournal appends a successful write to the journal. Failed writes changed nothing, so
+// there is nothing to roll back; they're left to the audit log.
+func recordJournal(owner, repo, environment, action, name string, oldValue, newValue *string, writeErr error) {
+	forgetValue(owner, repo, environment, name)
+	if writeErr != nil || !journalEnabled() {
+		return
+	}
+
+	journalMu.Lock()
+	defer journalMu.Unlock()
+
+	entry := JournalEntry{
+		RunID:       currentRunID(),
+		Timestamp:   time.Now().UTC(),
+		Owner:       owner,
+		Repo:        repo,
+		Environment: environment,
+		Action:      action,
+		Name:        name,
+		OldHash:     journalHash(oldValue),
+		NewHash:     journalHash(newValue),
+		RollbackOf:  journalRollbackOf,
+	}
+	if action != AuditCreate {
+		entry.Backup = runBackups[targetName(owner, repo, environment)]
+	}
+	line, err := json.Marshal(entry)
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(*journalFile), 0700)
+	}
+	if err == nil {
+		err = appendLine(*journalFile, line)
+	}
+	if err != nil {
+		fmt.Printf("⚠️  Failed to write journal %s: %v\n", *journalFile, err)
+	}
+}
+
+// ReadJournal reads every entry of the journal; a missing journal has none
+func ReadJournal(path string) ([]JournalEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []JournalEntry{}, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	entries := []JournalEntry{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var entry JournalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", path, line, err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,334 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// JSONSchema is a parsed JSON Schema document. The keywords most used for config blobs are
+// supported: type, enum, const, properties, required, additionalProperties, items, minItems,
+// maxItems, minLength, maxLength, pattern, minimum, maximum, exclusiveMinimum, exclusiveMaximum,
+// allOf, anyOf, oneOf, not, and local $ref (#/definitions/..., #/$defs/...).
+type JSONSchema struct {
+	root     interface{}
+	patterns map[string]*regexp.Regexp
+}
+
+// LoadJSONSchema reads a JSON Schema file
+func LoadJSONSchema(path string) (*JSONSchema, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var root interface{}
+	if err := json.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("%s is not JSON: %w", path, err)
+	}
+	if _, ok := root.(map[string]interface{}); !ok {
+		if _, ok := root.(bool); !ok {
+			return nil, fmt.Errorf("%s is not a JSON Schema object", path)
+		}
+	}
+	return &JSONSchema{root: root, patterns: map[string]*regexp.Regexp{}}, nil
+}
+
+// ValidateJSON parses value as JSON and validates it, returning one error per failing path,
+// such as "$.flags.beta: expected boolean, got string"
+func (s *JSONSchema) ValidateJSON(value string) []string {
+	var instance interface{}
+	if err := json.Unmarshal([]byte(value), &instance); err != nil {
+		return []string{"not valid JSON: " + jsonErrorPosition(value, err)}
+	}
+	errors := []string{}
+	s.validate(s.root, instance, "$", &errors, 0)
+	return errors
+}
+
+// jsonErrorPosition adds the line and column of a syntax error to its message
+func jsonErrorPosition(value string, err error) string {
+	syntaxErr, ok := err.(*json.SyntaxError)
+	if !ok {
+		return err.Error()
+	}
+	before := value[:syntaxErr.Offset]
+	line := strings.Count(before, "\n") + 1
+	column := len(before) - strings.LastIndex(before, "\n")
+	return fmt.Sprintf("%v (line %d, column %d)", err, line, column)
+}
+
+// maxSchemaDepth stops $ref cycles that never reach the instance's leaves
+const maxSchemaDepth = 64
+
+func (s *JSONSchema) validate(schemaValue, instance interface{}, path string, errors *[]string, depth int) {
+	if depth > maxSchemaDepth {
+		*errors = append(*errors, path+": schema nesting too deep (recursive $ref?)")
+		return
+	}
+	if b, ok := schemaValue.(bool); ok {
+		if !b {
+			*errors = append(*errors, path+": not allowed")
+		}
+		return
+	}
+	schema, ok := schemaValue.(map[string]interface{})
+	if !ok {
+		return
+	}
+	fail := func(format string, args ...interface{}) {
+		*errors = append(*errors, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if ref, ok := schema["$ref"].(string); ok {
+		target, err := s.resolve(ref)
+		if err != nil {
+			fail("%v", err)
+			return
+		}
+		s.validate(target, instance, path, errors, depth+1)
+	}
+
+	if types := schemaTypes(schema["type"]); len(types) > 0 && !matchesAnyType(instance, types) {
+		fail("expected %s, got %s", strings.Join(types, " or "), jsonTypeName(instance))
+		return // The other keywords would only repeat the type mismatch
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok && !containsJSONValue(enum, instance) {
+		fail("must be one of %s", compactJSON(enum))
+	}
+	if constant, ok := schema["const"]; ok && !reflect.DeepEqual(constant, instance) {
+		fail("must be %s", compactJSON(constant))
+	}
+
+	switch v := instance.(type) {
+	case map[string]interface{}:
+		s.validateObject(schema, v, path, errors, depth)
+	case []interface{}:
+		if n, ok := schemaNumber(schema, "minItems"); ok && float64(len(v)) < n {
+			fail("must have at least %v item(s), has %d", n, len(v))
+		}
+		if n, ok := schemaNumber(schema, "maxItems"); ok && float64(len(v)) > n {
+			fail("must have at most %v item(s), has %d", n, len(v))
+		}
+		if items, ok := schema["items"]; ok {
+			for i, item := range v {
+				s.validate(items, item, fmt.Sprintf("%s[%d]", path, i), errors, depth+1)
+			}
+		}
+	case string:
+		length := float64(len([]rune(v)))
+		if n, ok := schemaNumber(schema, "minLength"); ok && length < n {
+			fail("must be at least %v character(s)", n)
+		}
+		if n, ok := schemaNumber(schema, "maxLength"); ok && length > n {
+			fail("must be at most %v character(s)", n)
+		}
+		if pattern, ok := schema["pattern"].(string); ok {
+			re, err := s.compile(pattern)
+			if err != nil {
+				fail("invalid pattern in schema: %v", err)
+			} else if !re.MatchString(v) {
+				fail("must match %s", pattern)
+			}
+		}
+	case float64:
+		if n, ok := schemaNumber(schema, "minimum"); ok && v < n {
+			fail("must be >= %v", n)
+		}
+		if n, ok := schemaNumber(schema, "maximum"); ok && v > n {
+			fail("must be <= %v", n)
+		}
+		if n, ok := schemaNumber(schema, "exclusiveMinimum"); ok && v <= n {
+			fail("must be > %v", n)
+		}
+		if n, ok := schemaNumber(schema, "exclusiveMaximum"); ok && v >= n {
+			fail("must be < %v", n)
+		}
+	}
+
+	if allOf, ok := schema["allOf"].([]interface{}); ok {
+		for _, sub := range allOf {
+			s.validate(sub, instance, path, errors, depth+1)
+		}
+	}
+	if anyOf, ok := schema["anyOf"].([]interface{}); ok && s.countMatches(anyOf, instance, path, depth) == 0 {
+		fail("must match at least one schema of anyOf")
+	}
+	if oneOf, ok := schema["oneOf"].([]interface{}); ok {
+		if n := s.countMatches(oneOf, instance, path, depth); n != 1 {
+			fail("must match exactly one schema of oneOf, matches %d", n)
+		}
+	}
+	if notSchema, ok := schema["not"]; ok && s.countMatches([]interface{}{notSchema}, instance, path, depth) == 1 {
+		fail("must not match the schema of not")
+	}
+}
+
+func (s *JSONSchema) validateObject(schema, object map[string]interface{}, path string, errors *[]string, depth int) {
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, r := range required {
+			if name, ok := r.(string); ok {
+				if _, present := object[name]; !present {
+					*errors = append(*errors, fmt.Sprintf("%s: missing required property %q", path, name))
+				}
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(map[string]interface{})
+	keys := make([]string, 0, len(object))
+	for key := range object {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		child := jsonPath(path, key)
+		if propertySchema, ok := properties[key]; ok {
+			s.validate(propertySchema, object[key], child, errors, depth+1)
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				*errors = append(*errors, child+": property not allowed")
+			}
+		case map[string]interface{}:
+			s.validate(additional, object[key], child, errors, depth+1)
+		}
+	}
+}
+
+// countMatches counts the subschemas the instance is valid against
+func (s *JSONSchema) countMatches(schemas []interface{}, instance interface{}, path string, depth int) int {
+	matched := 0
+	for _, sub := range schemas {
+		errors := []string{}
+		s.validate(sub, instance, path, &errors, depth+1)
+		if len(errors) == 0 {
+			matched++
+		}
+	}
+	return matched
+}
+
+// resolve follows a local JSON Pointer reference such as #/$defs/flag
+func (s *JSONSchema) resolve(ref string) (interface{}, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("only local $ref is supported, not %q", ref)
+	}
+	current := s.root
+	for _, token := range strings.Split(strings.TrimPrefix(ref, "#"), "/")[1:] {
+		token = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+		switch node := current.(type) {
+		case map[string]interface{}:
+			next, ok := node[token]
+			if !ok {
+				return nil, fmt.Errorf("$ref %q not found in schema", ref)
+			}
+			current = next
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("$ref %q not found in schema", ref)
+			}
+			current = node[i]
+		default:
+			return nil, fmt.Errorf("$ref %q not found in schema", ref)
+		}
+	}
+	return current, nil
+}
+
+func (s *JSONSchema) compile(pattern string) (*regexp.Regexp, error) {
+	if re, ok := s.patterns[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	s.patterns[pattern] = re
+	return re, nil
+}
+
+// schemaTypes returns the type keyword as a list
+func schemaTypes(value interface{}) []string {
+	switch t := value.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		types := []string{}
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+func matchesAnyType(instance interface{}, types []string) bool {
+	actual := jsonTypeName(instance)
+	for _, t := range types {
+		if t == actual || (t == "number" && actual == "integer") {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonTypeName is the JSON Schema type of a decoded value; whole numbers are integers
+func jsonTypeName(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+func schemaNumber(schema map[string]interface{}, keyword string) (float64, bool) {
+	n, ok := schema[keyword].(float64)
+	return n, ok
+}
+
+func containsJSONValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func compactJSON(value interface{}) string {
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// jsonPath appends a property to a path: $.name, or $["odd name"] when it isn't an identifier
+func jsonPath(path, key string) string {
+	if identifierPattern.MatchString(key) {
+		return path + "." + key
+	}
+	return path + "[" + strconv.Quote(key) + "]"
+}
+
+var identifierPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// lintIssue is a problem found by the validate command, located in the input file
+type lintIssue struct {
+	Line    int // 0 when the issue is about the whole file
+	Column  int
+	Warning bool
+	Message string
+}
+
+// handleValidate checks input files offline, without a token or any GitHub request, and
+// exits non-zero on errors (or warnings, with --strict) so CI can gate on it
+func handleValidate(args []string) {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	strict := fs.Bool("strict", false, "Fail on warnings (missing header, empty values, skipped rows) too")
+	format := fs.String("format", "text", "Output format: text, or github for workflow annotations")
+	fs.Parse(args)
+
+	if *format != "text" && *format != "github" {
+		fatal(exitFailure, "Invalid --format %q (use text or github)", *format)
+	}
+	files := fs.Args()
+	if len(files) == 0 {
+		files = []string{*inputFile}
+	}
+
+	errorCount, warningCount := 0, 0
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			fatal(exitFailure, "Error reading %s: %v", file, err)
+		}
+		var issues []lintIssue
+		variables := 0
+		if inputFormat(file) == "csv" {
+			issues, variables = lintCSV(content)
+		} else {
+			issues, variables = lintStructured(file, content)
+		}
+
+		for _, issue := range issues {
+			if issue.Warning {
+				warningCount++
+			} else {
+				errorCount++
+			}
+			printLintIssue(file, issue, *format == "github")
+		}
+		if len(issues) == 0 {
+			fmt.Printf("✅ %s: %d variable(s), no problems found\n", file, variables)
+		}
+	}
+
+	if errorCount > 0 || (*strict && warningCount > 0) {
+		runError = fmt.Sprintf("%d error(s), %d warning(s)", errorCount, warningCount)
+		fmt.Printf("❌ %s\n", runError)
+		exit(exitValidation)
+	}
+	if warningCount > 0 {
+		fmt.Printf("⚠️  %d warning(s)\n", warningCount)
+	}
+}
+
+// printLintIssue prints an issue as file:line:column, or as a GitHub Actions annotation
+func printLintIssue(file string, issue lintIssue, github bool) {
+	level := "error"
+	if issue.Warning {
+		level = "warning"
+	}
+	if github {
+		fmt.Println(annotationAt(level, file, issue.Line, issue.Column, "Invalid input", issue.Message))
+		return
+	}
+
+	location := file
+	if issue.Line > 0 {
+		location += fmt.Sprintf(":%d:%d", issue.Line, issue.Column)
+	}
+	fmt.Printf("%s: %s: %s\n", location, level, issue.Message)
+}
+
+// lintCSV checks CSV content the way parseCSV reads it, returning the issues and the number of variables
+func lintCSV(content []byte) ([]lintIssue, int) {
+	issues := []lintIssue{}
+	content, _, err := decodeText(content)
+	if err != nil {
+		var encErr *encodingError
+		if errors.As(err, &encErr) {
+			return append(issues, lintIssue{Line: encErr.Line, Column: encErr.Column, Message: err.Error()}), 0
+		}
+		return append(issues, lintIssue{Message: err.Error()}), 0
+	}
+
+	reader := newCSVReader(content)
+	seen := map[string]int{}
+	variables, total := 0, 0
+	lastQuoted := false // Whether the last field read was quoted
+	lastLine, lastColumn := 0, 0
+	for row := 0; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				return append(issues, lintIssue{Line: parseErr.Line, Column: parseErr.Column, Message: parseErr.Err.Error()}), variables
+			}
+			return append(issues, lintIssue{Message: err.Error()}), variables
+		}
+		line, column := reader.FieldPos(0)
+		lastQuoted = reader.quoted(len(record) - 1)
+		lastLine, lastColumn = reader.FieldPos(len(record) - 1)
+
+		if row == 0 {
+			if isCSVHeader(record) {
+				if !looksLikeCSVHeader(record) {
+					issues = append(issues, lintIssue{Line: line, Column: column, Warning: true,
+						Message: fmt.Sprintf("first row %q is skipped as a header but looks like a variable (use --csv-header detect or none)", strings.TrimSpace(record[0]))})
+				}
+				continue
+			}
+			issues = append(issues, lintIssue{Line: line, Column: column, Warning: true,
+				Message: "no header row (Key,Value,Note); the first row is read as a variable"})
+		}
+
+		name := strings.TrimSpace(record[0])
+		if len(record) < 2 {
+			issues = append(issues, lintIssue{Line: line, Column: column, Warning: true,
+				Message: fmt.Sprintf("row has no value column and is skipped: %q", name)})
+			continue
+		}
+		if name == "" {
+			issues = append(issues, lintIssue{Line: line, Column: column, Warning: true,
+				Message: "row has no variable name and is skipped"})
+			continue
+		}
+		variables++
+
+		if err := validateVariableName(name); err != nil {
+			issues = append(issues, lintIssue{Line: line, Column: column, Message: err.Error()})
+		}
+		if first, ok := seen[nameKey(name)]; ok {
+			issues = append(issues, lintIssue{Line: line, Column: column,
+				Message: fmt.Sprintf("duplicate variable %s (first defined on line %d)", name, first)})
+		} else {
+			seen[nameKey(name)] = line
+		}
+
+		value := record[1]
+		if !reader.quoted(1) {
+			value = strings.TrimSpace(value)
+		}
+		valueLine, valueColumn := reader.FieldPos(1)
+		switch {
+		case value == "":
+			issues = append(issues, lintIssue{Line: valueLine, Column: valueColumn, Warning: true,
+				Message: fmt.Sprintf("value of %s is empty", name)})
+		case len(value) > maxVariableSize:
+			issues = append(issues, lintIssue{Line: valueLine, Column: valueColumn,
+				Message: fmt.Sprintf("value of %s is %d bytes, over GitHub's %d KB limit", name, len(value), maxVariableSize/1024)})
+		}
+		total += len(value)
+	}
+
+	// Stray quotes are read literally, but an opening quote that is never closed swallows the rest of the file
+	if lastQuoted && !strings.HasSuffix(strings.TrimRight(string(content), "\r\n"), `"`) {
+		issues = append(issues, lintIssue{Line: lastLine, Column: lastColumn,
+			Message: "quoted value is never closed, so it runs to the end of the file"})
+	}
+	if variables == 0 {
+		issues = append(issues, lintIssue{Warning: true, Message: "the file defines no variables"})
+	}
+	if total > maxTotalSize {
+		issues = append(issues, lintIssue{
+			Message: fmt.Sprintf("combined size of all values is %d bytes, over GitHub's %d KB limit", total, maxTotalSize/1024)})
+	}
+	return issues, variables
+}
+
+// lintStructured checks YAML, JSON, and .env content. These formats carry no line
+// numbers through parsing, so issues are reported for the whole file.
+func lintStructured(file string, content []byte) ([]lintIssue, int) {
+	issues := []lintIssue{}
+	content, _, err := decodeText(content)
+	if err == nil {
+		var variables []Variable
+		variables, err = parseVariables(file, content)
+		if err == nil {
+			rows := make([]inputRow, len(variables))
+			for i, v := range variables {
+				rows[i] = inputRow{Variable: v}
+				if v.Value == "" {
+					issues = append(issues, lintIssue{Warning: true, Message: fmt.Sprintf("value of %s is empty", v.Name)})
+				}
+			}
+			for _, issue := range validateRows(rows) {
+				issues = append(issues, lintIssue{Message: issue.Message})
+			}
+			return issues, len(variables)
+		}
+	}
+	var encErr *encodingError
+	if errors.As(err, &encErr) {
+		return append(issues, lintIssue{Line: encErr.Line, Column: encErr.Column, Message: err.Error()}), 0
+	}
+	return append(issues, lintIssue{Message: err.Error()}), 0
+}
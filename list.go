@@ -0,0 +1,140 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// listedVariable is a variable in the list command's JSON output
+type listedVariable struct {
+	Name      string    `json:"name"`
+	Value     string    `json:"value"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// handleList prints the target's variables as a table, JSON, or CSV. Masked variables are
+// masked in every format.
+func handleList(args []string, token, owner, repo, environment string) {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	envName := fs.String("env", environment, "Environment (defaults to GITHUB_ENVIRONMENT; empty for repository variables)")
+	sortBy := fs.String("sort", "name", "Sort by name, updated, or created")
+	reverse := fs.Bool("reverse", false, "Reverse the sort order")
+	filter := fs.String("filter", "", "Only list variables whose name matches this regular expression")
+	output := fs.String("output", "table", "Output format: table, json, or csv")
+	fs.Parse(args)
+
+	less, ok := variableOrders[*sortBy]
+	if !ok {
+		fatal(exitFailure, "Invalid --sort %q (use name, updated, or created)", *sortBy)
+	}
+	if *output != "table" && *output != "json" && *output != "csv" {
+		fatal(exitFailure, "Invalid --output %q (use table, json, or csv)", *output)
+	}
+	var pattern *regexp.Regexp
+	if *filter != "" {
+		var err error
+		pattern, err = regexp.Compile(*filter)
+		if err != nil {
+			fatal(exitFailure, "Invalid --filter: %v", err)
+		}
+	}
+
+	remote, err := FetchGitHubVariables(token, owner, repo, *envName)
+	if err != nil {
+		fatal(exitFailure, "Error fetching variables: %v", err)
+	}
+	variables := []Variable{}
+	for _, v := range remote {
+		if pattern == nil || pattern.MatchString(v.Name) {
+			variables = append(variables, v)
+		}
+	}
+	sort.SliceStable(variables, func(i, j int) bool {
+		if *reverse {
+			return less(variables[j], variables[i])
+		}
+		return less(variables[i], variables[j])
+	})
+
+	switch *output {
+	case "json":
+		listed := make([]listedVariable, len(variables))
+		for i, v := range variables {
+			listed[i] = listedVariable{Name: v.Name, Value: shownValue(v.Name, v.Value), CreatedAt: v.CreatedAt, UpdatedAt: v.UpdatedAt}
+		}
+		data, err := json.MarshalIndent(listed, "", "  ")
+		if err != nil {
+			fatal(exitFailure, "Error: %v", err)
+		}
+		fmt.Println(string(data))
+	case "csv":
+		records := [][]string{{"name", "value", "updated_at"}}
+		for _, v := range variables {
+			records = append(records, []string{v.Name, shownValue(v.Name, v.Value), v.UpdatedAt.Format(time.RFC3339)})
+		}
+		err := writeCSV(os.Stdout, records)
+		if err != nil {
+			fatal(exitFailure, "Error: %v", err)
+		}
+	default:
+		displayVariableTable(variables)
+		if pattern != nil {
+			fmt.Printf("\n%d of %d variable(s) in %s match %s\n", len(variables), len(remote), targetName(owner, repo, *envName), *filter)
+		} else {
+			fmt.Printf("\n%d variable(s) in %s\n", len(variables), targetName(owner, repo, *envName))
+		}
+	}
+}
+
+// variableOrders are the --sort orders of the list command. Dates sort newest first.
+var variableOrders = map[string]func(a, b Variable) bool{
+	"name":    func(a, b Variable) bool { return a.Name < b.Name },
+	"updated": func(a, b Variable) bool { return a.UpdatedAt.After(b.UpdatedAt) },
+	"created": func(a, b Variable) bool { return a.CreatedAt.After(b.CreatedAt) },
+}
+
+// displayVariableTable prints one row per variable with a one-line value preview sized to the terminal
+func displayVariableTable(variables []Variable) {
+	nameWidth := len("NAME")
+	for _, v := range variables {
+		if n := utf8.RuneCountInString(v.Name); n > nameWidth {
+			nameWidth = n
+		}
+	}
+	const updatedWidth = 30
+	column := outputWidth() - nameWidth - updatedWidth - 8
+	if column < 12 {
+		column = 12
+	}
+	// --max-value-width and --show-full-values override the fit; the column is never wider than its values
+	column = valueLimit(column, false)
+	values := make([]string, len(variables))
+	longest := len("VALUE")
+	for i, v := range variables {
+		values[i] = strings.ReplaceAll(shownValue(v.Name, v.Value), "\n", "⏎")
+		if n := utf8.RuneCountInString(values[i]); n > longest {
+			longest = n
+		}
+	}
+	if column > longest {
+		column = longest
+	}
+
+	fmt.Printf("  %s%s │ %s │ %s%s\n", ColorBold, padDisplay("NAME", nameWidth), padDisplay("VALUE", column), "UPDATED", ColorReset)
+	fmt.Printf("  %s─┼─%s─┼─%s\n", strings.Repeat("─", nameWidth), strings.Repeat("─", column), strings.Repeat("─", updatedWidth))
+	for i, v := range variables {
+		updated := ""
+		if !v.UpdatedAt.IsZero() {
+			updated = fmt.Sprintf("%s %s(%s)%s", v.UpdatedAt.Local().Format("2006-01-02 15:04"), ColorGray, humanizeAge(time.Since(v.UpdatedAt)), ColorReset)
+		}
+		fmt.Printf("  %s │ %s │ %s\n", padDisplay(v.Name, nameWidth), padDisplay(truncateDisplay(values[i], column), column), updated)
+	}
+}
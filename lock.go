@@ -0,0 +1,400 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// lockVariableName is the sentinel variable used as a remote lock; it's hidden from diffs
+const lockVariableName = "SYNC_GITHUB_VARIABLE_LOCK"
+
+// lockInfo identifies who holds a lock and until when it's valid
+type lockInfo struct {
+	Holder    string    `json:"holder"` // user@host
+	PID       int       `json:"pid"`
+	StartedAt time.Time `json:"started_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (l lockInfo) String() string {
+	return fmt.Sprintf("%s (pid %d, since %s)", l.Holder, l.PID, l.StartedAt.Local().Format("2006-01-02 15:04:05"))
+}
+
+// newLockInfo describes this process as a lock holder
+func newLockInfo() lockInfo {
+	host, _ := os.Hostname()
+	name := "unknown"
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	now := time.Now().UTC()
+	return lockInfo{Holder: name + "@" + host, PID: os.Getpid(), StartedAt: now, ExpiresAt: now.Add(*lockTTL)}
+}
+
+// stale reports whether a lock can be taken over: expired, or held by a dead process on this host
+func (l lockInfo) stale() bool {
+	if time.Now().After(l.ExpiresAt) {
+		return true
+	}
+	host, _ := os.Hostname()
+	if at := strings.LastIndex(l.Holder, "@"); at >= 0 && l.Holder[at+1:] == host {
+		return !processAlive(l.PID)
+	}
+	return false
+}
+
+// processAlive reports whether a process with the given PID exists on this machine
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		// FindProcess opens the process there, so success means it exists
+		return true
+	}
+	// Signal 0 checks for existence without affecting the process
+	return process.Signal(syscall.Signal(0)) == nil
+}
+
+// lockHeldError is a lock another run holds that isn't stale
+type lockHeldError struct {
+	message string
+}
+
+func (e *lockHeldError) Error() string {
+	return e.message
+}
+
+// lockExitCode is the exit code for a failure to take a lock: exitLocked when another run
+// holds it
+func lockExitCode(err error) int {
+	var held *lockHeldError
+	if errors.As(err, &held) {
+		return exitLocked
+	}
+	return exitFailure
+}
+
+// targetLock holds the local (and optionally remote) lock for one target
+type targetLock struct {
+	token, owner, repo, environment string
+	localPath                       string
+	remote                          bool
+	info                            lockInfo
+
+	mu   sync.Mutex
+	stop chan struct{} // Closed on release to end the refreshing
+}
+
+// takeoverTimeout is how long a takeover file may exist before it's taken as left by a
+// crashed run; taking over only rewrites one small file
+const takeoverTimeout = time.Minute
+
+// AcquireTargetLock takes the local lockfile for the target and, with --remote-lock, the
+// sentinel variable on GitHub. Locks are released when the program exits.
+func AcquireTargetLock(token, owner, repo, environment string) error {
+	if *forceUnlock {
+		fmt.Println("🔓 --force-unlock: removing existing locks for this target")
+		if path, err := localLockPath(owner, repo, environment); err == nil {
+			os.Remove(path)
+		}
+		if *remoteLock {
+			deleteVariableRaw(token, owner, repo, environment, lockVariableName)
+		}
+	}
+
+	lock, err := lockTarget(token, owner, repo, environment)
+	if err != nil {
+		return err
+	}
+	atExit(lock.release)
+
+	// Release on Ctrl+C / SIGTERM instead of leaving the lock for stale detection
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		fmt.Println("\n⚠️  Interrupted; releasing lock")
+		exit(exitInterrupted)
+	}()
+	return nil
+}
+
+// lockTarget takes the target's locks until release is called, for a process that writes to
+// it more than once, like the API server. The locks are refreshed while they're held, so a
+// run that takes longer than --lock-ttl keeps them.
+func lockTarget(token, owner, repo, environment string) (*targetLock, error) {
+	path, err := localLockPath(owner, repo, environment)
+	if err != nil {
+		return nil, err
+	}
+	lock := &targetLock{token: token, owner: owner, repo: repo, environment: environment,
+		localPath: path, info: newLockInfo(), stop: make(chan struct{})}
+	err = lock.acquireLocal()
+	if err != nil {
+		return nil, err
+	}
+	if *remoteLock {
+		err = lock.acquireRemote()
+		if err != nil {
+			lock.releaseLocal()
+			return nil, err
+		}
+	}
+	go lock.keepFresh()
+	return lock, nil
+}
+
+// lockOrExit acquires the target lock for a subcommand, exiting if it's held
+func lockOrExit(token, owner, repo, environment string) {
+	err := AcquireTargetLock(token, owner, repo, environment)
+	if err != nil {
+		fatal(lockExitCode(err), "%v", err)
+	}
+}
+
+// localLockPath is a per-target lockfile in the user's cache directory
+func localLockPath(owner, repo, environment string) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("no directory for lockfiles: %w", err)
+	}
+	sum := sha256.Sum256([]byte(owner + "/" + repo + "/" + environment))
+	return filepath.Join(dir, "sync-github-variable", "locks", hex.EncodeToString(sum[:8])+".lock"), nil
+}
+
+func (l *targetLock) acquireLocal() error {
+	err := os.MkdirAll(filepath.Dir(l.localPath), 0700)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(l.info)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; attempt < 3; attempt++ {
+		f, err := os.OpenFile(l.localPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			_, err = f.Write(data)
+			f.Close()
+			return err
+		}
+		if !os.IsExist(err) {
+			return err
+		}
+
+		// Someone holds it: take over only if stale
+		var holder lockInfo
+		existing, readErr := os.ReadFile(l.localPath)
+		if os.IsNotExist(readErr) {
+			continue // Released meanwhile
+		}
+		if readErr == nil && json.Unmarshal(existing, &holder) == nil && !holder.stale() {
+			return &lockHeldError{fmt.Sprintf("another run against this target is in progress: %s\n   Lockfile: %s (use --force-unlock if it's abandoned)", holder, l.localPath)}
+		}
+		took, err := l.takeOver(existing, data)
+		if err != nil || took {
+			return err
+		}
+	}
+	return fmt.Errorf("could not acquire lockfile %s", l.localPath)
+}
+
+// takeOver replaces a stale lockfile with ours, reporting false if it changed in the
+// meantime. Only the run that creates the takeover file may replace it, and it's replaced
+// by a rename, so there is never a moment without a lockfile for another run to create.
+func (l *targetLock) takeOver(stale, data []byte) (bool, error) {
+	guard := l.localPath + ".takeover"
+	f, err := os.OpenFile(guard, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if os.IsExist(err) {
+		if info, statErr := os.Stat(guard); statErr == nil && time.Since(info.ModTime()) > takeoverTimeout {
+			os.Remove(guard) // Left by a run that crashed while taking over
+			return false, nil
+		}
+		return false, &lockHeldError{fmt.Sprintf("another run is taking over the stale lock %s", l.localPath)}
+	}
+	if err != nil {
+		return false, err
+	}
+	f.Close()
+	defer os.Remove(guard)
+
+	current, err := os.ReadFile(l.localPath)
+	if err != nil && !os.IsNotExist(err) {
+		return false, err
+	}
+	if !bytes.Equal(current, stale) {
+		return false, nil
+	}
+	fmt.Printf("🔓 Taking over stale lock %s\n", l.localPath)
+	return true, l.writeLocal(data)
+}
+
+// writeLocal replaces the lockfile through a rename, so it's never seen half-written
+func (l *targetLock) writeLocal(data []byte) error {
+	tmp := fmt.Sprintf("%s.%d.tmp", l.localPath, os.Getpid())
+	err := os.WriteFile(tmp, data, 0600)
+	if err == nil {
+		err = os.Rename(tmp, l.localPath)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// keepFresh extends the locks every third of --lock-ttl until they're released
+func (l *targetLock) keepFresh() {
+	if *lockTTL <= 0 {
+		return
+	}
+	ticker := time.NewTicker(*lockTTL / 3)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			if err := l.refresh(); err != nil {
+				fmt.Printf("⚠️  Warning: Failed to refresh the lock: %v\n", err)
+			}
+		}
+	}
+}
+
+// refresh moves the locks' expiry to --lock-ttl from now, unless they were released or the
+// lockfile is no longer ours
+func (l *targetLock) refresh() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	select {
+	case <-l.stop:
+		return nil
+	default:
+	}
+
+	var holder lockInfo
+	existing, err := os.ReadFile(l.localPath)
+	if err != nil || json.Unmarshal(existing, &holder) != nil || holder.PID != os.Getpid() {
+		return fmt.Errorf("lockfile %s was taken over or removed", l.localPath)
+	}
+	l.info.ExpiresAt = time.Now().UTC().Add(*lockTTL)
+	data, err := json.Marshal(l.info)
+	if err != nil {
+		return err
+	}
+	err = l.writeLocal(data)
+	if err != nil || !l.remote {
+		return err
+	}
+	return updateVariableRaw(l.token, l.owner, l.repo, l.environment, lockVariableName, string(data))
+}
+
+func (l *targetLock) acquireRemote() error {
+	data, err := json.Marshal(l.info)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		created, err := createVariableRaw(l.token, l.owner, l.repo, l.environment, lockVariableName, string(data))
+		if err != nil {
+			return fmt.Errorf("failed to create remote lock: %w", err)
+		}
+		if created {
+			l.remote = true
+			return nil
+		}
+
+		// The sentinel exists: take over only if stale
+		current := getVariableValue(l.token, l.owner, l.repo, l.environment, lockVariableName)
+		var holder lockInfo
+		if current != nil && json.Unmarshal([]byte(*current), &holder) == nil && !holder.stale() {
+			return &lockHeldError{fmt.Sprintf("another run against this target holds the remote lock: %s, until %s\n   Use --force-unlock if it's abandoned",
+				holder, holder.ExpiresAt.Local().Format("15:04:05"))}
+		}
+		fmt.Println("🔓 Removing stale remote lock")
+		deleteVariableRaw(l.token, l.owner, l.repo, l.environment, lockVariableName)
+	}
+	return fmt.Errorf("could not acquire remote lock")
+}
+
+func (l *targetLock) releaseLocal() {
+	// Only remove our own lockfile, never one taken over after ours went stale
+	var holder lockInfo
+	data, err := os.ReadFile(l.localPath)
+	if err == nil && json.Unmarshal(data, &holder) == nil && holder.PID == os.Getpid() {
+		os.Remove(l.localPath)
+	}
+}
+
+func (l *targetLock) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	select {
+	case <-l.stop:
+	default:
+		close(l.stop)
+	}
+	if l.remote {
+		deleteVariableRaw(l.token, l.owner, l.repo, l.environment, lockVariableName)
+		l.remote = false
+	}
+	l.releaseLocal()
+}
+
+// withoutLockVariable hides the remote lock sentinel from a variable listing
+func withoutLockVariable(variables []Variable) []Variable {
+	result := variables[:0:0]
+	for _, v := range variables {
+		if v.Name != lockVariableName {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// createVariableRaw creates a variable without auditing, reporting false if it already exists
+func createVariableRaw(token, owner, repo, environment, name, value string) (bool, error) {
+	err := githubSendJSON(token, "POST", variableURL(owner, repo, environment, ""),
+		variablePayload(owner, repo, Variable{Name: name, Value: value}), 201, nil)
+	if err == nil {
+		return true, nil
+	}
+	if exists, _ := checkVariableExists(token, owner, repo, environment, name); exists {
+		return false, nil
+	}
+	return false, err
+}
+
+// updateVariableRaw updates a variable without auditing
+func updateVariableRaw(token, owner, repo, environment, name, value string) error {
+	return githubSendJSON(token, "PATCH", variableURL(owner, repo, environment, name),
+		variablePayload(owner, repo, Variable{Name: name, Value: value}), 204, nil)
+}
+
+// deleteVariableRaw deletes a variable without auditing, ignoring errors
+func deleteVariableRaw(token, owner, repo, environment, name string) {
+	req, err := newGitHubRequest("DELETE", variableURL(owner, repo, environment, name), token, nil)
+	if err != nil {
+		return
+	}
+	if resp, err := httpClient.Do(req); err == nil {
+		resp.Body.Close()
+	}
+}
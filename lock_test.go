@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func readLockInfo(t *testing.T, path string) lockInfo {
+	t.Helper()
+	var info lockInfo
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(data, &info); err != nil {
+		t.Fatal(err)
+	}
+	return info
+}
+
+func TestLockTakesOverStaleLockfile(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	path, err := localLockPath("o", "r", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	expired := lockInfo{Holder: "someone@elsewhere", PID: 1, ExpiresAt: time.Now().Add(-time.Minute)}
+	data, _ := json.Marshal(expired)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := lockTarget("", "o", "r", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info := readLockInfo(t, path); info.PID != os.Getpid() {
+		t.Errorf("lockfile holder = %s, want this process", info)
+	}
+	if _, err := os.Stat(path + ".takeover"); !os.IsNotExist(err) {
+		t.Errorf("takeover file left behind: %v", err)
+	}
+	if dir, _ := os.Stat(filepath.Dir(path)); dir.Mode().Perm() != 0700 {
+		t.Errorf("lock directory mode = %v, want 0700", dir.Mode().Perm())
+	}
+
+	if _, err := lockTarget("", "o", "r", ""); lockExitCode(err) != exitLocked {
+		t.Errorf("second lock: %v, want it held", err)
+	}
+
+	before := readLockInfo(t, path).ExpiresAt
+	time.Sleep(10 * time.Millisecond)
+	if err := lock.refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if after := readLockInfo(t, path).ExpiresAt; !after.After(before) {
+		t.Errorf("refresh kept expiry at %v", after)
+	}
+
+	lock.release()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("lockfile left after release: %v", err)
+	}
+}
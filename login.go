@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// credentialsFile records, per host, the token `login` obtained, or that it's in the keychain
+const credentialsFile = "credentials.json"
+
+// storedCredential is one host's entry in the credentials file
+type storedCredential struct {
+	User     string    `json:"user"`
+	Store    string    `json:"store"`           // "keychain", or "file" with the token below
+	Token    string    `json:"token,omitempty"` // Only when the keychain isn't available
+	LoggedIn time.Time `json:"logged_in"`
+}
+
+// deviceCode is GitHub's answer to a device authorization request
+type deviceCode struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+// handleLogin signs in with GitHub's device flow: the user enters a code in the browser, and
+// the token is stored for the host, so later runs need no GITHUB_TOKEN
+func handleLogin(args []string) {
+	fs := flag.NewFlagSet("login", flag.ExitOnError)
+	clientID := fs.String("client-id", os.Getenv("SYNC_OAUTH_CLIENT_ID"), "Client ID of the OAuth app with device flow enabled (or SYNC_OAUTH_CLIENT_ID)")
+	scopes := fs.String("scopes", "repo", "OAuth scopes to request; add admin:org for organization variables")
+	fs.Parse(args)
+
+	if *clientID == "" {
+		fatal(exitAuth, "login needs the client ID of an OAuth app with device flow enabled: --client-id or SYNC_OAUTH_CLIENT_ID")
+	}
+	host := githubHost()
+	code, err := requestDeviceCode(*clientID, *scopes)
+	if err != nil {
+		fatal(exitAuth, "Login failed: %v", err)
+	}
+	fmt.Printf("🔑 Open %s and enter the code: %s%s%s\n", code.VerificationURI, ColorBold, code.UserCode, ColorReset)
+	fmt.Printf("⏳ Waiting for authorization (the code expires in %d minutes)...\n", code.ExpiresIn/60)
+
+	token, err := pollDeviceToken(*clientID, code)
+	if err != nil {
+		fatal(exitAuth, "Login failed: %v", err)
+	}
+	redactor.Add(token)
+
+	var user struct {
+		Login string `json:"login"`
+	}
+	if err := githubGetJSON(token, githubAPIURL+"/user", &user); err != nil {
+		fatal(exitAuth, "Login failed: the new token doesn't work: %v", err)
+	}
+	store, err := saveLoginToken(host, user.Login, token)
+	if err != nil {
+		fatal(exitFailure, "Logged in as %s, but the token couldn't be stored: %v", user.Login, err)
+	}
+	fmt.Printf("✅ Logged in to %s as %s (token stored in the %s)\n", host, user.Login, store)
+}
+
+// githubWebURL returns the web root the device flow runs on (api.github.com → github.com;
+// a GHES API URL's host)
+func githubWebURL() string {
+	api, err := neturl.Parse(githubAPIURL)
+	if err != nil || api.Host == "" || api.Host == "api.github.com" {
+		return "https://github.com"
+	}
+	return api.Scheme + "://" + api.Host
+}
+
+// requestDeviceCode starts the device flow
+func requestDeviceCode(clientID, scopes string) (*deviceCode, error) {
+	var code deviceCode
+	form := neturl.Values{"client_id": {clientID}, "scope": {scopes}}
+	if err := postDeviceForm("/login/device/code", form, &code); err != nil {
+		return nil, err
+	}
+	if code.DeviceCode == "" {
+		return nil, fmt.Errorf("GitHub returned no device code (is device flow enabled for the OAuth app?)")
+	}
+	if code.Interval <= 0 {
+		code.Interval = 5
+	}
+	return &code, nil
+}
+
+// pollDeviceToken waits for the user to enter the code, at the interval GitHub asks for
+func pollDeviceToken(clientID string, code *deviceCode) (string, error) {
+	interval := time.Duration(code.Interval) * time.Second
+	deadline := time.Now().Add(time.Duration(code.ExpiresIn) * time.Second)
+	form := neturl.Values{"client_id": {clientID}, "device_code": {code.DeviceCode},
+		"grant_type": {"urn:ietf:params:oauth:grant-type:device_code"}}
+
+	for time.Now().Before(deadline) {
+		time.Sleep(interval)
+		var result struct {
+			AccessToken string `json:"access_token"`
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+			Interval    int    `json:"interval"`
+		}
+		if err := postDeviceForm("/login/oauth/access_token", form, &result); err != nil {
+			return "", err
+		}
+		switch result.Error {
+		case "":
+			if result.AccessToken == "" {
+				return "", fmt.Errorf("GitHub returned no token")
+			}
+			return result.AccessToken, nil
+		case "authorization_pending":
+		case "slow_down":
+			if result.Interval > 0 {
+				interval = time.Duration(result.Interval) * time.Second
+			} else {
+				interval += 5 * time.Second
+			}
+		case "expired_token":
+			return "", fmt.Errorf("the code expired; run login again")
+		case "access_denied":
+			return "", fmt.Errorf("authorization was denied")
+		default:
+			return "", fmt.Errorf("%s: %s", result.Error, result.Description)
+		}
+	}
+	return "", fmt.Errorf("the code expired; run login again")
+}
+
+// postDeviceForm posts a device flow form and decodes the JSON answer
+func postDeviceForm(path string, form neturl.Values, out interface{}) error {
+	req, err := http.NewRequest("POST", githubWebURL()+path, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("%s returned status %d", path, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// credentialsPath is the credentials file in the user's config directory
+func credentialsPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "sync-github-variable", credentialsFile), nil
+}
+
+// loadCredentials reads the credentials file; a missing file has no hosts, and neither has a
+// user without a config directory (no $HOME, as in some CI containers), so token lookups go
+// on to the other sources
+func loadCredentials() (map[string]storedCredential, error) {
+	hosts := map[string]storedCredential{}
+	path, err := credentialsPath()
+	if err != nil {
+		return hosts, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return hosts, nil
+	}
+	if err != nil {
+		return hosts, err
+	}
+	return hosts, json.Unmarshal(data, &hosts)
+}
+
+// saveLoginToken stores a host's token in the OS keychain, or, where there is none, in the
+// credentials file readable only by the user. It returns where the token went.
+func saveLoginToken(host, user, token string) (string, error) {
+	path, err := credentialsPath()
+	if err != nil {
+		return "", err
+	}
+	hosts, err := loadCredentials()
+	if err != nil {
+		return "", err
+	}
+	credential := storedCredential{User: user, Store: "keychain", LoggedIn: time.Now().UTC()}
+	if err := storeKeychainToken(host, token); err != nil {
+		credential.Store, credential.Token = "file", token
+	}
+	hosts[host] = credential
+
+	data, err := json.MarshalIndent(hosts, "", "  ")
+	if err == nil {
+		err = os.MkdirAll(filepath.Dir(path), 0700)
+	}
+	if err == nil {
+		err = os.WriteFile(path, append(data, '\n'), 0600)
+	}
+	if err != nil {
+		return "", err
+	}
+	if credential.Store == "file" {
+		return "credentials file " + path, nil
+	}
+	return "OS keychain", nil
+}
+
+// storeKeychainToken saves the token for host where --token-source keychain reads it. The
+// token is passed on stdin, never on a command line.
+func storeKeychainToken(host, token string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "-i")
+		cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n", keychainService, host, token))
+	case "linux":
+		cmd = exec.Command("secret-tool", "store", "--label", keychainService, "service", keychainService, "account", host)
+		cmd.Stdin = strings.NewReader(token)
+	default:
+		return fmt.Errorf("no keychain support on %s", runtime.GOOS)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// loginToken returns the token `login` stored for host, or "" when it never ran there
+func loginToken(host string) (string, error) {
+	hosts, err := loadCredentials()
+	if err != nil {
+		return "", fmt.Errorf("failed to read stored credentials: %w", err)
+	}
+	credential, ok := hosts[host]
+	switch {
+	case !ok:
+		return "", nil
+	case credential.Store == "keychain":
+		return keychainToken(host)
+	}
+	return credential.Token, nil
+}
@@ -0,0 +1,17 @@
+package main
+
+import "testing"
+
+func TestLoginTokenWithoutConfigDir(t *testing.T) {
+	t.Setenv("HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("AppData", "")
+
+	token, err := loginToken("github.com")
+	if token != "" || err != nil {
+		t.Errorf("loginToken = %q, %v; want no stored login", token, err)
+	}
+	if _, err := saveLoginToken("github.com", "me", "ghp_x"); err == nil {
+		t.Error("saved a login without a config directory")
+	}
+}
@@ -3,8 +3,8 @@ package main
 import (
 	"bufio"
 	"bytes"
-	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -14,55 +14,470 @@ import (
 	"time"
 )
 
-const (
-	githubAPIURL = "https://api.github.com"
-)
+// GitHub API base URL; GITHUB_API_URL points it at GitHub Enterprise Server
+var githubAPIURL = "https://api.github.com"
 
-// Shared HTTP client with timeout for all API requests
+// Shared HTTP client with timeout for all API requests (--request-timeout)
 var httpClient = &http.Client{
 	Timeout: 30 * time.Second,
 }
 
 type Variable struct {
-	Name  string `json:"name"`
-	Value string `json:"value"`
+	Name      string    `json:"name"`
+	Value     string    `json:"value"`
+	CreatedAt time.Time `json:"created_at"` // Only set for variables fetched from GitHub
+	UpdatedAt time.Time `json:"updated_at"` // Only set for variables fetched from GitHub
 }
 
 // Command-line flags
 var (
-	diffMode   = flag.Bool("diff", false, "Show diff and exit without syncing")
-	backupMode = flag.Bool("backup", false, "Create backup and exit without syncing")
-	noBackup   = flag.Bool("no-backup", false, "Skip automatic backup before syncing")
+	diffMode             = flag.Bool("diff", false, "Show diff and exit without syncing")
+	backupMode           = flag.Bool("backup", false, "Create backup and exit without syncing")
+	noBackup             = flag.Bool("no-backup", false, "Skip automatic backup before syncing")
+	diffContext          = flag.Int("diff-context", 3, "Context lines shown around changes in multi-line and JSON values")
+	throttle             = flag.Duration("throttle", 0, "Delay between write API calls (e.g. 200ms)")
+	inputFile            = flag.String("input", "variables.csv", "Input file: CSV, YAML, JSON, or .env; local path, github://owner/repo/path@ref, https:// URL, or s3://, gs://, az:// URL")
+	valuesFile           = flag.String("values", "", "Render the input file as a Go template with this YAML/JSON values file")
+	sourceSpec           = flag.String("source", "", "Variable source instead of the CSV file (ssm:, secretsmanager:, azurekv:, gcpsm:, doppler:, 1password:, terraform:, sheets:)")
+	sensitiveNames       = flag.String("sensitive", "", "Comma-separated glob patterns of variable names whose values are sensitive")
+	guardOutput          = flag.Bool("guard-output", false, "Redact sensitive values from all output and fail the run if any would have been printed")
+	configFile           = flag.String("config", defaultConfigFile, "Path to the YAML/JSON config file")
+	mergeMode            = flag.Bool("merge", false, "Three-way merge against the latest backup instead of overwriting remote changes")
+	strategy             = flag.String("strategy", StrategyLocalWins, "How to resolve differing values: local-wins, remote-wins, newest-wins")
+	pullMode             = flag.Bool("pull", false, "Update the CSV from GitHub (changed values and remote-only variables) instead of syncing")
+	noCache              = flag.Bool("no-cache", false, "Disable ETag caching of variable listings")
+	proxyURL             = flag.String("proxy", "", "Proxy URL for all HTTP requests (default: HTTPS_PROXY / HTTP_PROXY / NO_PROXY)")
+	caCert               = flag.String("ca-cert", "", "PEM bundle of additional CAs to trust (e.g. an internal GHES CA)")
+	clientCert           = flag.String("client-cert", "", "PEM client certificate for mutual TLS")
+	clientKey            = flag.String("client-key", "", "PEM private key for --client-cert")
+	requestTimeout       = flag.Duration("request-timeout", 30*time.Second, "Timeout for each HTTP request")
+	runDeadline          = flag.Duration("deadline", 0, "Overall time limit for the run (e.g. 10m); stops cleanly with a partial summary when exceeded")
+	skipPreflight        = flag.Bool("skip-preflight", false, "Skip the token permission check before syncing")
+	tokenSource          = flag.String("token-source", "env", "Where to read the GitHub token: env (GITHUB_TOKEN), gh (gh CLI), or keychain")
+	envFile              = flag.String("env-file", defaultEnvFile, "Tool configuration file (GITHUB_OWNER, GITHUB_TOKEN_FILE, ...), loaded if present")
+	auditLog             = flag.String("audit-log", "", "Append a JSONL record of every create/update/delete to this file")
+	notifyWebhook        = flag.String("notify-webhook", "", "Comma-separated webhook URLs (Slack, Teams, Discord, or generic JSON) notified after a sync or when diff mode finds drift")
+	openPR               = flag.Bool("open-pr", false, "With --pull, commit the updated CSV to a new branch and open a pull request instead of editing the file")
+	prRepo               = flag.String("pr-repo", "", "Repository holding the CSV for --open-pr (default: GITHUB_REPOSITORY, or the github:// input)")
+	backupDest           = flag.String("backup-dest", "", "Also store backups here: github://owner/repo/dir@branch, s3://bucket/prefix, gs://bucket/prefix, or az://account/container/prefix")
+	inputHeader          = flag.String("input-header", "", "HTTP header sent when --input is an https:// URL, e.g. \"Authorization: Bearer ...\" (or INPUT_AUTH_HEADER)")
+	inputSHA256          = flag.String("input-sha256", "", "Expected SHA-256 of an https:// or github:// input (https:// default: verify against <url>.sha256 when published)")
+	remoteLock           = flag.Bool("remote-lock", false, "Also lock the target on GitHub with a sentinel variable, so runs on other machines are excluded")
+	forceUnlock          = flag.Bool("force-unlock", false, "Remove existing locks for the target before acquiring (for abandoned runs)")
+	lockTTL              = flag.Duration("lock-ttl", 30*time.Minute, "How long a lock is valid before it counts as stale")
+	failuresFile         = flag.String("failures-file", "", "Write a JSON report of failed variables and the exit reason to this file (for CI artifacts)")
+	debugHTTP            = flag.Bool("debug-http", false, "Log every HTTP request and response (method, URL, status, rate limit) to stderr, with credentials redacted")
+	debugHTTPBodies      = flag.Bool("debug-http-bodies", false, "With --debug-http, also log request and response bodies (variable values redacted)")
+	recordFile           = flag.String("record", "", "Record sanitized API requests and responses to this fixture file")
+	replayFile           = flag.String("replay", "", "Answer API requests from a fixture file written by --record, without network access")
+	tuiMode              = flag.Bool("tui", false, "Review changes in an interactive, scrollable list where each one can be toggled before confirming")
+	diffFormat           = flag.String("diff-format", DiffFormatDefault, "How updated values are shown: default, side-by-side, unified, or table")
+	outputFormat         = flag.String("output", OutputText, "Diff output format: text, or markdown for pasting into pull requests and change tickets")
+	outputFile           = flag.String("output-file", "", "With --output markdown, also write the Markdown diff to this file")
+	maskValues           = flag.Bool("mask-values", false, "Show every value in diffs and reports as a hash, so output can be shared without exposing values")
+	maskNames            = flag.String("mask-names", "", "Comma-separated glob patterns of variable names whose values are shown only as hashes")
+	strictSecrets        = flag.Bool("strict-secrets", false, "Fail instead of warning when input values look like credentials (keys, tokens, private keys)")
+	showFullValues       = flag.Bool("show-full-values", false, "Show values in full instead of truncating them in diffs and listings")
+	maxValueWidth        = flag.Int("max-value-width", 0, "Truncate displayed values to this many characters (default: fit the terminal, 60/80 when not a terminal)")
+	csvHeader            = flag.String("csv-header", CSVHeaderSkip, "First row of a CSV input: skip (always a header), detect (skipped only when it looks like one, e.g. Key,Value), or none (a variable)")
+	nameCase             = flag.String("name-case", NameCaseUpper, "Variable name case policy: upper (uppercase input names), preserve (keep them, match GitHub names regardless of case), or exact")
+	createEnvironment    = flag.Bool("create-environment", false, "Create the target environment if it does not exist")
+	environmentWaitTimer = flag.Duration("environment-wait-timer", 0, "With --create-environment, wait this long (whole minutes) before jobs can use the environment")
+	environmentReviewers = flag.String("environment-reviewers", "", "With --create-environment, comma-separated required reviewers: user logins or org/team slugs")
+	repoLevel            = flag.Bool("repo-level", false, "Sync repository-level variables without asking for an environment when GITHUB_ENVIRONMENT is unset")
+	allEnvironments      = flag.String("all-environments", "", "Sync each file in this directory to the environment named by its base name (e.g. vars/production.csv)")
+	renamesFile          = flag.String("renames", "", "CSV file of old,new variable names: values move to the new name and the old variable is deleted")
+	noProgress           = flag.Bool("no-progress", false, "Print a line per variable even for large syncs, instead of a progress bar or periodic progress lines")
+	reportFile           = flag.String("report", "", "Write a summary report of the run (target, counts, per-variable outcomes, durations, rate limit use, backup) to this file: Markdown for .md, otherwise JSON")
+	resumeMode           = flag.Bool("resume", false, "Retry only the variables the last sync of this target failed or didn't attempt, from its resume file; with --matrix or --all-environments, continue with the targets the last run didn't finish")
+	matrixFile           = flag.String("matrix", "", "Sync every entry of this matrix file (e.g. sync-matrix.yaml), each with its own source, target, filters, and strategy")
+	sheetTab             = flag.String("sheet-tab", "", "Google Sheets tab for --source sheets:, or env=tab pairs per environment (e.g. production=Prod,staging=Staging)")
+	policyFile           = flag.String("policy", defaultPolicyFile, "Path to the YAML/JSON policy file whose rules changes are checked against")
+	policyEnforce        = flag.Bool("policy-enforce", false, "Reject the run when changes violate the policy instead of warning")
+	schemaFile           = flag.String("schema", defaultSchemaFile, "Path to the YAML/JSON schema file of required variables per target")
+	ignoreFile           = flag.String("ignore-file", defaultIgnoreFile, "File of variable name patterns managed elsewhere, left out of diff, sync, delete, and restore")
+	team                 = flag.String("team", "", "Only sync variables owned by this team (Owner column or ownership.owners in the config)")
+	notesFile            = flag.String("notes-file", defaultNotesFile, "Sidecar file keeping each variable's note, since GitHub has nowhere to store it")
+	journalFile          = flag.String("journal", defaultJournalPath(), "Local journal of every applied change, for history and rollback (empty to disable)")
+	backupRecipients     = flag.String("backup-recipients", "", "Encrypt backups to these comma-separated age recipients (age1..., ssh-...) or GPG key IDs")
+	manifestKey          = flag.String("manifest-key", "", "Sign a manifest of the applied variables with this Ed25519 private key (PEM) after each sync")
+	approvalCode         = flag.String("approval", "", "Approval code(s) from the approve command, comma-separated, for targets under approval in the config (or SYNC_APPROVAL_CODE)")
+	interactiveApply     = flag.Bool("interactive-apply", false, "Ask about each new and updated variable in turn (y/n/a/d/q, like git add -p) and apply only the accepted ones")
+	targetSpec           = flag.String("target", "", "Target as repo:owner/name, env:owner/name/environment, or org:name (instead of GITHUB_OWNER, GITHUB_REPO, GITHUB_ENVIRONMENT)")
+	orgVisibility        = flag.String("org-visibility", "private", "Visibility of organization variables created with an org target: all, private, or selected")
+	oidcBroker           = flag.String("oidc-broker", "", "In GitHub Actions, exchange the workflow's OIDC token for a GitHub token at this broker URL (or SYNC_OIDC_BROKER_URL)")
+	ownerFlag            = flag.String("owner", "", "Repository owner or organization (overrides GITHUB_OWNER)")
+	repoFlag             = flag.String("repo", "", "Repository name, or owner/name (overrides GITHUB_REPO)")
+	environmentFlag      = flag.String("environment", "", "Environment name (overrides GITHUB_ENVIRONMENT)")
+	showVersion          = flag.Bool("version", false, "Print the version, commit, build date, and Go version, and exit")
+	maxFailures          = flag.Int("max-failures", 0, "Stop the sync after this many failed writes (0 for no limit)")
+	noCircuitBreaker     = flag.Bool("no-circuit-breaker", false, "Keep going even when the first writes of a sync all fail")
+	eventsFile           = flag.String("events", "", "Write one JSON event per line for each step of the run (fetch, diff, backup, each write, finish) to this file")
+	mergePrune           = flag.Bool("merge-prune", false, "Let --merge delete variables missing from the input even if they were never synced from one")
 )
 
+// lastWrite records when the previous write call was sent, for --throttle
+var lastWrite time.Time
+
 func main() {
-	// Parse command-line flags
+	run()
+	exit(0)
+}
+
+func run() {
+	// Parse command-line flags (--file is an alias of --input)
+	flag.StringVar(inputFile, "file", *inputFile, "Alias for --input")
 	flag.Parse()
+	if *showVersion || flag.Arg(0) == "version" {
+		printVersion()
+		return
+	}
+	httpClient.Timeout = *requestTimeout
+	setRunDeadline(*runDeadline)
+	// Registered first so its run-finished event comes after every other exit hook
+	if *eventsFile != "" {
+		if err := openEventStream(*eventsFile); err != nil {
+			fatal(exitFailure, "Error: %v", err)
+		}
+	}
+	if *failuresFile != "" {
+		atExit(func() { writeFailureReport(*failuresFile, exitStatus) })
+	}
+	if *reportFile != "" {
+		atExit(func() { writeRunSummary(*reportFile, exitStatus) })
+	}
+
+	// Load the config, policy, schema, ignore, and .env files (only an error if the flag was given explicitly)
+	configRequired := false
+	policyRequired := false
+	schemaRequired := false
+	ignoreRequired := false
+	envFileRequired := false
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "config":
+			configRequired = true
+		case "policy":
+			policyRequired = true
+		case "schema":
+			schemaRequired = true
+		case "ignore-file":
+			ignoreRequired = true
+		case "env-file":
+			envFileRequired = true
+		}
+	})
+	if *envFile != "" && !sameFile(*envFile, *inputFile) {
+		err := loadEnvFile(*envFile, envFileRequired)
+		if err != nil {
+			fatal(exitValidation, "Error loading env file: %v", err)
+		}
+	}
+	loadedConfig, err := LoadConfig(*configFile, configRequired)
+	if err != nil {
+		fatal(exitValidation, "Error loading config: %v", err)
+	}
+	config = loadedConfig
+	policy, err = LoadPolicy(*policyFile, policyRequired)
+	if err != nil {
+		fatal(exitValidation, "Error loading policy: %v", err)
+	}
+	schema, err = LoadSchema(*schemaFile, schemaRequired)
+	if err != nil {
+		fatal(exitValidation, "Error loading schema: %v", err)
+	}
+	ignoreRules, err = LoadIgnoreFile(*ignoreFile, ignoreRequired)
+	if err != nil {
+		fatal(exitValidation, "Error loading ignore file: %v", err)
+	}
+
+	if !validOutputFormat(*outputFormat) {
+		fatal(exitValidation, "Invalid --output %q (use text or markdown)", *outputFormat)
+	}
+	if !validNameCase(*nameCase) {
+		fatal(exitValidation, "Invalid --name-case %q (use upper, preserve, or exact)", *nameCase)
+	}
+	if !validCSVHeader(*csvHeader) {
+		fatal(exitValidation, "Invalid --csv-header %q (use skip, detect, or none)", *csvHeader)
+	}
+	if !validDiffFormat(*diffFormat) {
+		fatal(exitValidation, "Invalid --diff-format %q (use default, side-by-side, unified, or table)", *diffFormat)
+	}
+
+	if *mergePrune && !*mergeMode {
+		fatal(exitValidation, "--merge-prune requires --merge")
+	}
+
+	if *renamesFile != "" && (*pullMode || *mergeMode) {
+		fatal(exitValidation, "--renames can't be combined with --pull or --merge")
+	}
+
+	if *resumeMode && (*diffMode || *pullMode || *mergeMode || *backupMode || *renamesFile != "") {
+		fatal(exitValidation, "--resume can't be combined with --diff, --pull, --merge, --backup, or --renames")
+	}
 
-	// Get information from environment variables
-	token := os.Getenv("GITHUB_TOKEN")
-	owner := os.Getenv("GITHUB_OWNER")
-	repo := os.Getenv("GITHUB_REPO")
-	environment := os.Getenv("GITHUB_ENVIRONMENT") // Optional: for environment-specific variables
+	if *interactiveApply && (*tuiMode || *diffMode || *pullMode || *mergeMode || *resumeMode || *allEnvironments != "" || *matrixFile != "") {
+		fatal(exitValidation, "--interactive-apply can't be combined with --tui, --diff, --pull, --merge, --resume, --all-environments, or --matrix")
+	}
 
-	if token == "" || owner == "" || repo == "" {
+	if !validStrategy(*strategy) {
+		fatal(exitValidation, "Invalid --strategy %q (use local-wins, remote-wins, or newest-wins)", *strategy)
+	}
+
+	if *maxFailures < 0 {
+		fatal(exitValidation, "--max-failures can't be negative")
+	}
+
+	if *orgVisibility != "all" && *orgVisibility != "private" && *orgVisibility != "selected" {
+		fatal(exitValidation, "Invalid --org-visibility %q (use all, private, or selected)", *orgVisibility)
+	}
+
+	// validate only reads the input, so it runs without a token or target
+	if flag.Arg(0) == "validate" {
+		handleValidate(flag.Args()[1:])
+		return
+	}
+	if flag.Arg(0) == "completion" {
+		handleCompletion(flag.Args()[1:])
+		return
+	}
+
+	if apiURL := apiURLFromEnv(); apiURL != "" {
+		githubAPIURL = apiURL
+	}
+
+	// The target comes from --target, or from GITHUB_OWNER, GITHUB_REPO, and GITHUB_ENVIRONMENT
+	// (with the repository inferred in Actions or inside a clone)
+	target, err := resolveTarget()
+	if err != nil {
+		fatal(exitValidation, "Error: %v", err)
+	}
+	owner, repo, environment := target.Owner, target.Repo, target.Environment
+	if target.Inferred != "" && flag.Arg(0) != completeCommand {
+		fmt.Printf("ℹ️  Using %s/%s from %s\n", owner, repo, target.Inferred)
+	}
+
+	// Subcommands that only read local files (backups, the journal) run without a token
+	if localCommands[flag.Arg(0)] {
+		runLocalCommand(flag.Args(), owner, repo, environment)
+		return
+	}
+
+	// The token comes from GITHUB_TOKEN by default, or the gh CLI / OS keychain / a stored
+	// login; login itself gets a new one
+	token := ""
+	switch flag.Arg(0) {
+	case "login":
+	case completeCommand:
+		token, _ = resolveToken(*tokenSource) // Completion stays quiet without a token
+	default:
+		token, err = resolveToken(*tokenSource)
+		if err != nil {
+			fatal(exitAuth, "Error reading token: %v", err)
+		}
+	}
+
+	// Proxy, custom CA, and client certificate apply to every request, including token minting
+	err = configureTransport(*proxyURL, *caCert, *clientCert, *clientKey)
+	if err != nil {
+		fatal(exitValidation, "Error configuring HTTP transport: %v", err)
+	}
+	// --record captures API traffic to a fixture file; --replay answers from one, offline
+	if *recordFile != "" && *replayFile != "" {
+		fatal(exitValidation, "--record and --replay can't be used together")
+	}
+	if *replayFile != "" {
+		replay, err := loadReplayTransport(*replayFile)
+		if err != nil {
+			fatal(exitFailure, "Error loading --replay fixture: %v", err)
+		}
+		httpClient.Transport = replay
+	}
+	if *recordFile != "" {
+		recorder := &recordTransport{}
+		wrapTransport(func(base http.RoundTripper) http.RoundTripper {
+			recorder.base = base
+			return recorder
+		})
+		atExit(func() {
+			if err := recorder.save(*recordFile); err != nil {
+				fmt.Printf("⚠️  Warning: Failed to write --record fixture: %v\n", err)
+			}
+		})
+	}
+	if *debugHTTP || *debugHTTPBodies {
+		wrapTransport(func(base http.RoundTripper) http.RoundTripper {
+			return &debugTransport{base: base, bodies: *debugHTTPBodies}
+		})
+	}
+	agent := userAgent()
+	wrapTransport(func(base http.RoundTripper) http.RoundTripper {
+		return &userAgentTransport{base: base, agent: agent}
+	})
+	wrapTransport(func(base http.RoundTripper) http.RoundTripper {
+		return &deadlineTransport{base: base}
+	})
+	if *reportFile != "" {
+		wrapTransport(func(base http.RoundTripper) http.RoundTripper {
+			return &rateLimitTransport{base: base}
+		})
+	}
+
+	// Conditional requests make repeated listings of unchanged targets free
+	if !*noCache && *recordFile == "" && *replayFile == "" {
+		if dir, err := defaultCacheDir(); err == nil {
+			wrapTransport(func(base http.RoundTripper) http.RoundTripper {
+				return &etagCacheTransport{base: base, dir: dir}
+			})
+		}
+	}
+
+	if flag.Arg(0) == "login" {
+		handleLogin(flag.Args()[1:])
+		return
+	}
+	if flag.Arg(0) == completeCommand {
+		handleComplete(flag.Args()[1:], token)
+		return
+	}
+
+	// GitHub App auth mints installation tokens and refreshes them transparently mid-run
+	if token == "" {
+		provider, err := newAppTokenProviderFromEnv()
+		if err != nil {
+			fatal(exitAuth, "GitHub App auth: %v", err)
+		}
+		if provider != nil {
+			wrapTransport(func(base http.RoundTripper) http.RoundTripper {
+				provider.base = base
+				return &appAuthTransport{base: base, provider: provider}
+			})
+			token, err = provider.Token()
+			if err != nil {
+				fatal(exitAuth, "GitHub App auth: %v", err)
+			}
+			authIdentity = provider.Identity
+		}
+	}
+
+	// In GitHub Actions, a token broker can exchange the workflow's OIDC token for a short-lived one
+	if token == "" {
+		provider, err := newOIDCTokenProviderFromEnv(owner, repo)
+		if err != nil {
+			fatal(exitAuth, "OIDC auth: %v", err)
+		}
+		if provider != nil {
+			wrapTransport(func(base http.RoundTripper) http.RoundTripper {
+				provider.base = base
+				return &appAuthTransport{base: base, provider: provider}
+			})
+			token, err = provider.Token()
+			if err != nil {
+				fatal(exitAuth, "OIDC auth: %v", err)
+			}
+		}
+	}
+
+	// Replayed runs are offline and need no real credentials
+	if token == "" && *replayFile != "" {
+		token = replayToken
+	}
+
+	// The token is always sensitive; --guard-output makes redaction a hard guarantee
+	redactor.Add(token)
+	if *guardOutput {
+		err = startOutputGuard()
+		if err != nil {
+			fatal(exitFailure, "Error starting output guard: %v", err)
+		}
+	}
+
+	// A matrix file names its own targets; GITHUB_OWNER/GITHUB_REPO are only defaults
+	if *matrixFile != "" && token != "" {
+		handleMatrix(token, owner, repo, *matrixFile)
+		return
+	}
+
+	if token == "" || owner == "" || (repo == "" && target.Kind != TargetOrg) {
 		fmt.Println("❌ Missing required information!")
 		fmt.Println("Please set the following environment variables:")
-		fmt.Println("  GITHUB_TOKEN        - GitHub Personal Access Token")
+		fmt.Println("  GITHUB_TOKEN        - GitHub Personal Access Token (or GH_TOKEN)")
+		fmt.Println("                        (or GITHUB_APP_ID, GITHUB_APP_INSTALLATION_ID, GITHUB_APP_PRIVATE_KEY_FILE)")
+		fmt.Println("                        (or --token-source gh / keychain, or run login)")
 		fmt.Println("  GITHUB_OWNER        - Owner/organization name")
 		fmt.Println("  GITHUB_REPO         - Repository name")
+		fmt.Println("                        (or GITHUB_REPOSITORY, or run inside a clone with a GitHub origin remote)")
 		fmt.Println("  GITHUB_ENVIRONMENT  - (Optional) Environment name (e.g., production, staging)")
-		os.Exit(1)
+		fmt.Println("                        (or --target repo:owner/name, env:owner/name/environment, org:name)")
+		runError = "missing required information"
+		if token == "" {
+			exit(exitAuth)
+		}
+		exit(exitFailure)
+	}
+
+	// Subcommands (e.g. "env clear") handle their own output and target selection
+	if flag.NArg() > 0 {
+		runCommand(flag.Args(), token, owner, repo, environment)
+		return
 	}
 
 	fmt.Println("^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^^")
 
+	// --all-environments syncs a directory of files, one per environment
+	if *allEnvironments != "" {
+		if isOrgTarget(owner, repo) {
+			fatal(exitFailure, "--all-environments needs a repository; organizations have no environments")
+		}
+		handleAllEnvironments(token, owner, repo, *allEnvironments)
+		return
+	}
+
+	// Ask for the environment instead of silently targeting repository-level variables
+	if environment == "" && !*repoLevel && !isOrgTarget(owner, repo) && isTerminal(os.Stdin) && *replayFile == "" {
+		environment = selectEnvironment(token, owner, repo)
+	}
+
 	// Display sync target
-	if environment != "" {
+	switch {
+	case environment != "":
 		fmt.Printf("🎯 Target: Environment '%s' in %s/%s\n", environment, owner, repo)
-	} else {
+	case isOrgTarget(owner, repo):
+		fmt.Printf("🎯 Target: Organization %s (new variables get visibility %q)\n", owner, *orgVisibility)
+	default:
 		fmt.Printf("🎯 Target: Repository %s/%s\n", owner, repo)
 	}
+	setSummaryTarget(owner, repo, environment)
+
+	// Check token permissions up front instead of failing on the first write with a raw 403
+	needWrite := !*diffMode && !*backupMode && !*pullMode
+	if environment != "" && (*createEnvironment || !*skipPreflight) {
+		ensureEnvironment(token, owner, repo, environment, needWrite)
+	}
+	if !*skipPreflight {
+		preflightEnvironment := environment
+		if pendingEnvironments[targetName(owner, repo, environment)] {
+			preflightEnvironment = "" // Nothing to list yet; still check repository access
+		}
+		err = PreflightCheck(token, owner, repo, preflightEnvironment, needWrite)
+		if err != nil {
+			fatal(exitAuth, "Preflight check failed: %v", err)
+		}
+	}
+
+	// Runs that write hold the target's lock from before the diff until exit
+	if needWrite {
+		err = AcquireTargetLock(token, owner, repo, environment)
+		if err != nil {
+			fatal(lockExitCode(err), "%v", err)
+		}
+	}
+
+	// --resume retries what the last sync of this target left unfinished, without re-reading the input
+	if *resumeMode {
+		handleResume(token, owner, repo, environment)
+		return
+	}
 
 	// Handle manual backup mode
 	if *backupMode {
@@ -70,35 +485,142 @@ func main() {
 		return
 	}
 
-	// Read CSV file
-	variables, err := readCSV("variables.csv")
+	// Read desired variables (CSV file by default, or an external --source)
+	variables, source, err := LoadDesiredVariables(token, owner, repo, environment)
 	if err != nil {
-		fmt.Printf("❌ Error reading CSV file: %v\n", err)
-		os.Exit(1)
+		fatal(exitValidation, "Error: %v", err)
 	}
-
-	fmt.Printf("📝 Read %d variables from CSV file\n", len(variables))
+	checkSecretLeaks(source, variables)
 
 	// Fetch current GitHub variables
 	fmt.Println("🔍 Fetching current variables from GitHub...")
 	remoteVariables, err := FetchGitHubVariables(token, owner, repo, environment)
 	if err != nil {
-		fmt.Printf("❌ Error fetching GitHub variables: %v\n", err)
-		os.Exit(1)
+		fatal(exitFailure, "Error fetching GitHub variables: %v", err)
 	}
 	fmt.Printf("✅ Fetched %d variables from GitHub\n", len(remoteVariables))
 
+	// Three-way merge mode uses the latest backup as the merge base
+	if *mergeMode {
+		handleMerge(token, owner, repo, environment, variables, remoteVariables)
+		exit(0)
+	}
+
+	// --renames moves values to their new names first, so the diff only shows what else changes
+	var renames []Rename
+	compareAgainst := remoteVariables
+	if *renamesFile != "" {
+		renames, compareAgainst, err = PlanRenames(*renamesFile, remoteVariables)
+		if err != nil {
+			fatal(exitValidation, "Error: --renames: %v", err)
+		}
+	}
+
 	// Compare local and remote variables
-	diffResult := CompareSets(variables, remoteVariables)
+	diffResult := CompareSets(variables, compareAgainst)
+	diffResult = scopeToTeam(diffResult)
+	emitDiffComputed(owner, repo, environment, diffResult)
 
-	// Display diff summary and details
-	DisplayDiffSummary(diffResult)
-	DisplayDetailedDiff(diffResult)
+	// Pull mode writes GitHub's state into the CSV instead of the other way around
+	if *pullMode {
+		if *openPR {
+			handlePullPR(token, source, diffResult)
+		} else {
+			handlePull(source, diffResult)
+		}
+		exit(0)
+	}
+
+	// Apply --strategy: some updates may go to the CSV instead of GitHub
+	var csvUpdates []VariableChange
+	if *strategy != StrategyLocalWins {
+		csvModTime, err := csvModificationTime(source)
+		if err != nil {
+			fatal(exitValidation, "Error: --strategy %s: %v", *strategy, err)
+		}
+		diffResult, csvUpdates = ApplyStrategy(*strategy, diffResult, csvModTime)
+	}
+
+	// --tui reviews the changes interactively instead of as a flat dump, when there's a terminal
+	useTUI := *tuiMode && len(diffResult.New)+len(diffResult.Updated) > 0
+	if useTUI && !tuiAvailable() {
+		fmt.Println("ℹ️  --tui needs an interactive terminal; using plain output")
+		useTUI = false
+	}
+
+	// Display diff summary and details (as Markdown with --output markdown)
+	if *outputFormat == OutputMarkdown {
+		writeMarkdownDiff(owner, repo, environment, diffResult)
+	} else {
+		DisplayDiffSummary(diffResult)
+		if !useTUI {
+			DisplayDetailedDiff(diffResult)
+		}
+	}
+	DisplayRenames(renames)
+	DisplayCSVUpdates(csvUpdates, *strategy)
+
+	// With --tui, the selection made there replaces the confirmation prompt
+	confirmed := false
+	if useTUI {
+		selected, ok, err := ReviewDiffTUI(targetName(owner, repo, environment), diffResult, *diffMode)
+		switch {
+		case err != nil:
+			fmt.Printf("⚠️  Interactive review unavailable (%v); using plain output\n", err)
+			DisplayDetailedDiff(diffResult)
+		case ok:
+			diffResult, confirmed = selected, true
+			fmt.Printf("✅ Selected %d new and %d updated variable(s) for sync\n", len(diffResult.New), len(diffResult.Updated))
+		case !*diffMode:
+			fmt.Println("\n❌ Sync cancelled by user")
+			exit(exitCancelled)
+		}
+	}
+
+	enforceSchema(owner, repo, environment, variables, compareAgainst)
+	enforcePolicy(owner, repo, environment, variables, diffResult)
 
 	// If --diff flag is set, exit after showing diff
 	if *diffMode {
 		fmt.Println("ℹ️  Diff mode: No changes were made")
-		os.Exit(0)
+		report := driftReport(owner, repo, environment, diffResult)
+		setSummaryReport(report, diffResult)
+		if report.HasDrift() {
+			sendNotifications(report)
+		}
+		exit(0)
+	}
+
+	// --interactive-apply asks about each change; the answers replace the confirmation prompt
+	if *interactiveApply && len(diffResult.New)+len(diffResult.Updated) > 0 {
+		fmt.Printf("\n🔎 Reviewing %d change(s) one at a time\n", len(diffResult.New)+len(diffResult.Updated))
+		selected, deferred, err := ReviewChangesInteractively(diffResult)
+		if err != nil {
+			fmt.Printf("\n❌ %v. No changes were made\n", err)
+			exit(exitCancelled)
+		}
+		diffResult, confirmed = selected, true
+		if len(deferred) > 0 {
+			fmt.Printf("\n⏭️  Deferred %d change(s), left as they are in GitHub: %s\n", len(deferred), strings.Join(deferred, ", "))
+		}
+		if len(diffResult.New)+len(diffResult.Updated) == 0 && len(renames) == 0 {
+			fmt.Println("\n❌ No changes accepted. Nothing was synced")
+			exit(exitCancelled)
+		}
+		fmt.Printf("✅ Accepted %d new and %d updated variable(s) for sync\n", len(diffResult.New), len(diffResult.Updated))
+	}
+
+	// Write remote-wins values back into the CSV
+	if len(csvUpdates) > 0 {
+		if !askYesNo(fmt.Sprintf("\n⚠️  Update %d value(s) in %s from GitHub?", len(csvUpdates), *inputFile)) {
+			fmt.Println("\n❌ CSV update cancelled by user")
+			exit(exitCancelled)
+		}
+		err = UpdateCSVValues(*inputFile, csvUpdates)
+		if err != nil {
+			fatal(exitValidation, "Error updating CSV file: %v", err)
+		}
+		fmt.Printf("✅ Updated %d value(s) in %s\n", len(csvUpdates), *inputFile)
 	}
 
 	// Calculate variables to sync (only new and updated)
@@ -112,15 +634,19 @@ func main() {
 	}
 
 	// If nothing to sync, exit
-	if len(variablesToSync) == 0 {
+	if len(variablesToSync) == 0 && len(renames) == 0 {
 		fmt.Println("\n✅ No changes to sync. All variables are up to date!")
-		os.Exit(0)
+		saveTargetNotes(owner, repo, environment)
+		exit(0)
 	}
 
+	runPreSyncHooks(owner, repo, environment, diffResult)
+	requireApproval(token, owner, repo, environment, syncPlan(diffResult, renames))
+
 	// Show confirmation before syncing
-	if !confirmSync(owner, repo, environment, token, diffResult) {
+	if !confirmed && !confirmSync(owner, repo, environment, token, diffResult) {
 		fmt.Println("\n❌ Sync cancelled by user")
-		os.Exit(0)
+		exit(exitCancelled)
 	}
 
 	// Auto-backup before syncing (unless disabled)
@@ -135,14 +661,30 @@ func main() {
 			input = strings.TrimSpace(strings.ToLower(input))
 			if input != "yes" && input != "y" {
 				fmt.Println("❌ Sync cancelled")
-				os.Exit(0)
+				exit(exitCancelled)
 			}
 		} else {
 			fmt.Printf("✅ Backup saved: %s\n", backupFile)
+			runSummary.Backup = backupFile
 		}
 	}
 
-	fmt.Println("\n🚀 Starting sync...\n")
+	// Make sure nobody changed GitHub while the diff was being reviewed
+	fmt.Println("\n🔍 Re-checking GitHub for concurrent changes...")
+	err = VerifyRemoteUnchanged(token, owner, repo, environment, remoteVariables)
+	if err != nil {
+		fatal(exitValidation, "Sync aborted: %v\n   Nothing was changed. Re-run to review the new diff", err)
+	}
+
+	fmt.Print("\n🚀 Starting sync...\n\n")
+
+	client := newRESTClient(token)
+	if len(renames) > 0 {
+		err = ApplyRenames(client, owner, repo, environment, renames)
+		if err != nil {
+			fatal(exitFailure, "Rename failed: %v", err)
+		}
+	}
 
 	// Create a map of new variables for O(1) lookup
 	newVarMap := make(map[string]bool)
@@ -151,58 +693,76 @@ func main() {
 	}
 
 	// Sync only the changed variables
-	newCount := 0
-	updateCount := 0
-	failedCount := 0
-	for _, variable := range variablesToSync {
-		if variable.Name == "" {
-			continue
+	report := newSyncReport(owner, repo, environment, "sync")
+	runReport = report
+	setSummaryReport(report, diffResult)
+	// Large syncs show a progress bar (or periodic lines in CI) instead of a line per variable
+	progress := newSyncProgress(len(variablesToSync))
+	applyDiff(client, report, diffResult, func(format string, args ...interface{}) {
+		if progress != nil {
+			progress.Clear()
 		}
-
-		err := syncVariable(token, owner, repo, environment, variable)
-		if err != nil {
-			fmt.Printf("❌ Error syncing variable '%s': %v\n", variable.Name, err)
-			failedCount++
-		} else {
-			// Check if this is a new or updated variable using map lookup (O(1))
-			if newVarMap[variable.Name] {
-				fmt.Printf("✅ Created variable: %s\n", variable.Name)
-				newCount++
-			} else {
-				fmt.Printf("✅ Updated variable: %s\n", variable.Name)
-				updateCount++
-			}
+		fmt.Printf(format+"\n", args...)
+	}, func(name, action string, err error, duration time.Duration) {
+		recordOutcome(name, action, err, duration)
+		if progress != nil {
+			progress.Step(err != nil)
+		} else if err == nil && action == "create" {
+			fmt.Printf("✅ Created variable: %s\n", name)
+		} else if err == nil {
+			fmt.Printf("✅ Updated variable: %s\n", name)
 		}
+	})
+	for _, name := range report.NotSynced {
+		recordNotSynced(name, syncAction(newVarMap, name))
+	}
+	if progress != nil {
+		progress.Finish()
 	}
 
 	// Display final results
+	newCount, updateCount, failedCount := len(report.Created), len(report.Updated), len(report.Failed)
 	fmt.Println()
-	if failedCount > 0 {
+	if len(report.NotSynced) > 0 {
+		fmt.Printf("⚠️  Partial sync! Created %d, Updated %d, Failed %d, Not synced %d of %d variables\n",
+			newCount, updateCount, failedCount, len(report.NotSynced), len(variablesToSync))
+	} else if failedCount > 0 {
 		fmt.Printf("🎉 Completed! Created %d, Updated %d, Failed %d, Total %d variables\n", 
 			newCount, updateCount, failedCount, newCount+updateCount+failedCount)
 	} else {
 		fmt.Printf("🎉 Completed! Created %d, Updated %d, Total %d variables\n", 
 			newCount, updateCount, newCount+updateCount)
 	}
+
+	saveResumeState(owner, repo, environment, source.Describe(), unfinishedVariables(report, variablesToSync, newVarMap))
+	saveTargetNotes(owner, repo, environment)
+	writeSyncManifest(token, report)
+	sendNotifications(report)
+	runPostSyncHooks(report)
+	if failedCount > 0 || len(report.NotSynced) > 0 {
+		exit(exitPartial)
+	}
 }
 
 func readCSV(filename string) ([]Variable, error) {
-	file, err := os.Open(filename)
+	content, _, err := readTextFile(filename)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
 
-	reader := csv.NewReader(file)
-	
-	// Read header (skip first line)
-	_, err = reader.Read()
+	return parseCSV(bytes.NewReader(content))
+}
+
+func parseCSV(r io.Reader) ([]Variable, error) {
+	// Keep the raw text to tell quoted fields apart: their values are kept exactly
+	data, err := io.ReadAll(r)
 	if err != nil {
 		return nil, err
 	}
+	reader := newCSVReader(data)
 
 	variables := []Variable{}
-	for {
+	for row := 0; ; row++ {
 		record, err := reader.Read()
 		if err == io.EOF {
 			break
@@ -210,10 +770,17 @@ func readCSV(filename string) ([]Variable, error) {
 		if err != nil {
 			return nil, err
 		}
+		if row == 0 && isCSVHeader(record) {
+			continue
+		}
 
 		if len(record) >= 2 {
 			key := strings.TrimSpace(record[0])
-			value := strings.TrimSpace(record[1])
+			value := record[1]
+			if !reader.quoted(1) {
+				// Unquoted values are trimmed, so "KEY, value" works
+				value = strings.TrimSpace(value)
+			}
 			
 			if key != "" {
 				variables = append(variables, Variable{
@@ -233,11 +800,16 @@ func confirmSync(owner, repo, environment, token string, diff DiffResult) bool {
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	
 	// Display target information
-	fmt.Printf("Repository:  %s/%s\n", owner, repo)
+	if isOrgTarget(owner, repo) {
+		fmt.Printf("Organization: %s\n", owner)
+		fmt.Printf("Target:      Organization variables\n")
+	} else {
+		fmt.Printf("Repository:  %s/%s\n", owner, repo)
+	}
 	if environment != "" {
 		fmt.Printf("Environment: %s\n", environment)
 		fmt.Printf("Target:      Environment-specific variables\n")
-	} else {
+	} else if !isOrgTarget(owner, repo) {
 		fmt.Printf("Environment: (none)\n")
 		fmt.Printf("Target:      Repository-level variables\n")
 	}
@@ -245,6 +817,7 @@ func confirmSync(owner, repo, environment, token string, diff DiffResult) bool {
 	// Mask token for display
 	maskedToken := maskToken(token)
 	fmt.Printf("Token:       %s\n", maskedToken)
+	fmt.Printf("Identity:    %s\n", describeIdentity(token))
 	
 	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 	
@@ -274,31 +847,24 @@ func maskToken(token string) string {
 	return token[:4] + strings.Repeat("*", len(token)-8) + token[len(token)-4:]
 }
 
-func syncVariable(token, owner, repo, environment string, variable Variable) error {
+func syncVariable(client GitHubClient, owner, repo, environment string, variable Variable) error {
 	// Check if variable already exists
-	exists, err := checkVariableExists(token, owner, repo, environment, variable.Name)
+	exists, err := client.VariableExists(owner, repo, environment, variable.Name)
 	if err != nil {
 		return err
 	}
 
 	if exists {
 		// Update existing variable
-		return updateVariable(token, owner, repo, environment, variable)
+		return client.UpdateVariable(owner, repo, environment, variable)
 	}
 	
 	// Create new variable
-	return createVariable(token, owner, repo, environment, variable)
+	return createOrUpdateVariable(client, owner, repo, environment, variable)
 }
 
 func checkVariableExists(token, owner, repo, environment, name string) (bool, error) {
-	var url string
-	if environment != "" {
-		// Environment-specific variable
-		url = fmt.Sprintf("%s/repos/%s/%s/environments/%s/variables/%s", githubAPIURL, owner, repo, environment, name)
-	} else {
-		// Repository-level variable
-		url = fmt.Sprintf("%s/repos/%s/%s/actions/variables/%s", githubAPIURL, owner, repo, name)
-	}
+	url := variableURL(owner, repo, environment, name)
 	
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -318,26 +884,22 @@ func checkVariableExists(token, owner, repo, environment, name string) (bool, er
 	return resp.StatusCode == 200, nil
 }
 
-func createVariable(token, owner, repo, environment string, variable Variable) error {
-	var url string
-	if environment != "" {
-		// Environment-specific variable
-		url = fmt.Sprintf("%s/repos/%s/%s/environments/%s/variables", githubAPIURL, owner, repo, environment)
-	} else {
-		// Repository-level variable
-		url = fmt.Sprintf("%s/repos/%s/%s/actions/variables", githubAPIURL, owner, repo)
-	}
-	
-	payload := map[string]string{
-		"name":  variable.Name,
-		"value": variable.Value,
-	}
+func createVariable(token, owner, repo, environment string, variable Variable) (err error) {
+	defer func() {
+		recordAudit(token, owner, repo, environment, AuditCreate, variable.Name, nil, &variable.Value, err)
+		recordJournal(owner, repo, environment, AuditCreate, variable.Name, nil, &variable.Value, err)
+	}()
+
+	url := variableURL(owner, repo, environment, "")
+	payload := variablePayload(owner, repo, variable)
 	
 	jsonData, err := json.Marshal(payload)
 	if err != nil {
 		return err
 	}
 
+	waitForThrottle()
+
 	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return err
@@ -354,23 +916,49 @@ func createVariable(token, owner, repo, environment string, variable Variable) e
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == 409 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%w: GitHub API returned status %d: %s", errVariableExists, resp.StatusCode, string(body))
+	}
 	if resp.StatusCode != 201 {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(body))
+		return newAPIError(resp, body)
 	}
 
 	return nil
 }
 
-func updateVariable(token, owner, repo, environment string, variable Variable) error {
-	var url string
-	if environment != "" {
-		// Environment-specific variable
-		url = fmt.Sprintf("%s/repos/%s/%s/environments/%s/variables/%s", githubAPIURL, owner, repo, environment, variable.Name)
-	} else {
-		// Repository-level variable
-		url = fmt.Sprintf("%s/repos/%s/%s/actions/variables/%s", githubAPIURL, owner, repo, variable.Name)
+// errVariableExists marks a create that lost a race with another writer (409 Conflict)
+var errVariableExists = errors.New("variable already exists")
+
+// createOrUpdateVariable creates a variable, and if another writer created it first,
+// re-checks and updates it instead so the race doesn't count as a failure
+func createOrUpdateVariable(client GitHubClient, owner, repo, environment string, variable Variable) error {
+	err := client.CreateVariable(owner, repo, environment, variable)
+	if !errors.Is(err, errVariableExists) {
+		return err
+	}
+
+	exists, checkErr := client.VariableExists(owner, repo, environment, variable.Name)
+	if checkErr != nil {
+		return fmt.Errorf("%w (re-check failed: %v)", err, checkErr)
 	}
+	if !exists {
+		// Deleted again in between; one more create settles it
+		return client.CreateVariable(owner, repo, environment, variable)
+	}
+	fmt.Printf("🔁 %s was created concurrently; updating it instead\n", variable.Name)
+	return client.UpdateVariable(owner, repo, environment, variable)
+}
+
+func updateVariable(token, owner, repo, environment string, variable Variable) (err error) {
+	oldValue := priorValue(token, owner, repo, environment, variable.Name)
+	defer func() {
+		recordAudit(token, owner, repo, environment, AuditUpdate, variable.Name, oldValue, &variable.Value, err)
+		recordJournal(owner, repo, environment, AuditUpdate, variable.Name, oldValue, &variable.Value, err)
+	}()
+
+	url := variableURL(owner, repo, environment, variable.Name)
 	
 	payload := map[string]string{
 		"name":  variable.Name,
@@ -382,6 +970,8 @@ func updateVariable(token, owner, repo, environment string, variable Variable) e
 		return err
 	}
 
+	waitForThrottle()
+
 	req, err := http.NewRequest("PATCH", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return err
@@ -400,22 +990,99 @@ func updateVariable(token, owner, repo, environment string, variable Variable) e
 
 	if resp.StatusCode != 204 {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("GitHub API returned status %d: %s", resp.StatusCode, string(body))
+		return newAPIError(resp, body)
 	}
 
 	return nil
 }
 
+func deleteVariable(token, owner, repo, environment, name string) (err error) {
+	oldValue := priorValue(token, owner, repo, environment, name)
+	defer func() {
+		recordAudit(token, owner, repo, environment, AuditDelete, name, oldValue, nil, err)
+		recordJournal(owner, repo, environment, AuditDelete, name, oldValue, nil, err)
+	}()
+
+	url := variableURL(owner, repo, environment, name)
+
+	waitForThrottle()
+
+	req, err := http.NewRequest("DELETE", url, nil)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 204 {
+		body, _ := io.ReadAll(resp.Body)
+		return newAPIError(resp, body)
+	}
+
+	return nil
+}
+
+// askYesNo prints a question and returns true if the user answers yes
+func askYesNo(question string) bool {
+	fmt.Print(question + " (yes/no): ")
+	reader := bufio.NewReader(os.Stdin)
+	input, err := reader.ReadString('\n')
+	if err != nil {
+		return false
+	}
+	input = strings.TrimSpace(strings.ToLower(input))
+	return input == "yes" || input == "y"
+}
+
+// confirmDeletion guards destructive operations: instead of yes/no, which is easy to answer
+// out of habit, the repository's owner/repo has to be typed, so a prompt for the wrong target
+// stands out
+func confirmDeletion(count int, target, expected string) bool {
+	fmt.Printf("⚠️  This deletes %d variable(s) from %s\n", count, target)
+	fmt.Printf("   Type %s to confirm: ", expected)
+	reader := bufio.NewReader(os.Stdin)
+	input, err := reader.ReadString('\n')
+	if err != nil {
+		return false
+	}
+	input = strings.TrimSpace(input)
+	if input != expected && input != "" {
+		fmt.Printf("❌ %q doesn't match %s\n", input, expected)
+	}
+	return input == expected
+}
+
+// waitForThrottle sleeps until at least --throttle has passed since the previous write call
+func waitForThrottle() {
+	if *throttle <= 0 {
+		return
+	}
+	if !lastWrite.IsZero() {
+		if wait := *throttle - time.Since(lastWrite); wait > 0 {
+			time.Sleep(wait)
+		}
+	}
+	lastWrite = time.Now()
+}
+
 // handleBackupMode creates a backup of GitHub variables
 func handleBackupMode(token, owner, repo, environment string) {
 	fmt.Println("💾 Backup Mode: Creating backup of GitHub variables...")
 	
 	backupFile, err := BackupGitHubVariables(token, owner, repo, environment)
 	if err != nil {
-		fmt.Printf("❌ Error creating backup: %v\n", err)
-		os.Exit(1)
+		fatal(exitFailure, "Error creating backup: %v", err)
 	}
 	
 	fmt.Printf("✅ Backup saved: %s\n", backupFile)
+	runSummary.Backup = backupFile
 }
 
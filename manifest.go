@@ -0,0 +1,253 @@
+package main
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+)
+
+// manifestDir is where sync manifests are written, next to backups/
+const manifestDir = "manifests"
+
+// ManifestConfig configures signed sync manifests in the config file
+type ManifestConfig struct {
+	Key       string `json:"key"`        // Ed25519 private key (PEM) manifests are signed with (overridden by --manifest-key)
+	PublicKey string `json:"public_key"` // Ed25519 public key (PEM) verify checks signatures with
+}
+
+// SyncManifest attests which variables a target had right after an apply. Values are recorded
+// only as SHA-256 hashes; the signature covers every other field.
+type SyncManifest struct {
+	Version     int                `json:"version"`
+	Owner       string             `json:"owner"`
+	Repo        string             `json:"repo"`
+	Environment string             `json:"environment,omitempty"`
+	Actor       string             `json:"actor"`
+	Timestamp   time.Time          `json:"timestamp"`
+	RunID       string             `json:"run_id"`
+	Digest      string             `json:"digest"` // SHA-256 of the sorted name/hash pairs
+	Variables   []ManifestVariable `json:"variables"`
+	KeyID       string             `json:"key_id,omitempty"`
+	Signature   string             `json:"signature,omitempty"` // Ed25519, base64
+}
+
+// ManifestVariable is one variable of a manifest
+type ManifestVariable struct {
+	Name string `json:"name"`
+	Hash string `json:"sha256"`
+}
+
+// manifestSigningKey loads the signing key from --manifest-key or the config file, or from
+// SYNC_MANIFEST_KEY holding the PEM itself (for CI secrets). It's nil when none is configured.
+func manifestSigningKey() (ed25519.PrivateKey, error) {
+	var data []byte
+	path := *manifestKey
+	if path == "" {
+		path = config.Manifest.Key
+	}
+	if path != "" {
+		var err error
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("failed to read manifest key: %w", err)
+		}
+	} else if pemKey := os.Getenv("SYNC_MANIFEST_KEY"); pemKey != "" {
+		data = []byte(pemKey)
+	} else {
+		return nil, nil
+	}
+
+	key, err := parseEd25519PrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("manifest key: %w", err)
+	}
+	return key, nil
+}
+
+// parseEd25519PrivateKey parses an Ed25519 private key (PEM, PKCS8), as written by
+// `openssl genpkey -algorithm ed25519`
+func parseEd25519PrivateKey(data []byte) (ed25519.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key: %w", err)
+	}
+	key, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("key must be an Ed25519 private key")
+	}
+	return key, nil
+}
+
+// loadManifestPublicKey reads an Ed25519 public key (PEM, PKIX) for verifying manifests
+func loadManifestPublicKey(path string) (ed25519.PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("%s is not PEM encoded", path)
+	}
+	parsed, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	key, ok := parsed.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an Ed25519 public key", path)
+	}
+	return key, nil
+}
+
+// manifestKeyID identifies a public key by the start of its SHA-256
+func manifestKeyID(key crypto.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(der)
+	return hex.EncodeToString(sum[:8])
+}
+
+// manifestVariables hashes a variable set in name order, for a manifest or a comparison with one
+func manifestVariables(variables []Variable) ([]ManifestVariable, string) {
+	entries := make([]ManifestVariable, 0, len(variables))
+	for _, v := range variables {
+		sum := sha256.Sum256([]byte(v.Value))
+		entries = append(entries, ManifestVariable{Name: v.Name, Hash: hex.EncodeToString(sum[:])})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries, manifestDigest(entries)
+}
+
+// manifestDigest hashes a manifest's name/hash pairs, so the set has one fingerprint
+func manifestDigest(entries []ManifestVariable) string {
+	digest := sha256.New()
+	for _, e := range entries {
+		fmt.Fprintf(digest, "%s\x00%s\n", e.Name, e.Hash)
+	}
+	return hex.EncodeToString(digest.Sum(nil))
+}
+
+// signedBytes is the content a manifest's signature covers: the manifest without it
+func (m SyncManifest) signedBytes() ([]byte, error) {
+	m.Signature = ""
+	return json.Marshal(m)
+}
+
+// writeSyncManifest records the target's variables right after an apply in a signed manifest
+// under manifests/. It does nothing unless a signing key is configured, or when the apply
+// didn't fully succeed; failures only warn, since the apply itself is done.
+func writeSyncManifest(token string, report *SyncReport) {
+	if len(report.Failed) > 0 || len(report.NotSynced) > 0 {
+		return
+	}
+	owner, repo, environment := report.Owner, report.Repo, report.Environment
+	key, err := manifestSigningKey()
+	if err != nil {
+		fmt.Printf("⚠️  Warning: no sync manifest written: %v\n", err)
+		return
+	}
+	if key == nil {
+		return
+	}
+
+	live, err := FetchGitHubVariables(token, owner, repo, environment)
+	if err != nil {
+		fmt.Printf("⚠️  Warning: no sync manifest written: failed to fetch variables: %v\n", err)
+		return
+	}
+	live, _ = withoutIgnored(live)
+
+	manifest := SyncManifest{Version: 1, Owner: owner, Repo: repo, Environment: environment,
+		Actor: auditIdentity(token), Timestamp: time.Now().UTC(), RunID: currentRunID(),
+		KeyID: manifestKeyID(key.Public())}
+	manifest.Variables, manifest.Digest = manifestVariables(live)
+	signed, err := manifest.signedBytes()
+	if err != nil {
+		fmt.Printf("⚠️  Warning: no sync manifest written: %v\n", err)
+		return
+	}
+	manifest.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, signed))
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err == nil {
+		err = os.MkdirAll(manifestDir, 0755)
+	}
+	timestamp := manifest.Timestamp.Local().Format("2006-01-02_15-04-05")
+	path := filepath.Join(manifestDir, fmt.Sprintf("manifest_%s_%s_%s.json", owner, repo, timestamp))
+	if environment != "" {
+		path = filepath.Join(manifestDir, fmt.Sprintf("manifest_%s_%s_%s_%s.json", owner, repo, environment, timestamp))
+	}
+	if err == nil {
+		err = os.WriteFile(path, append(data, '\n'), 0644)
+	}
+	if err != nil {
+		fmt.Printf("⚠️  Warning: no sync manifest written: %v\n", err)
+		return
+	}
+	fmt.Printf("🔏 Signed sync manifest saved: %s (%d variables)\n", path, len(manifest.Variables))
+}
+
+// findLatestManifest returns the newest manifest of a target under manifests/
+func findLatestManifest(owner, repo, environment string) (string, error) {
+	prefix := fmt.Sprintf("manifest_%s_%s_", owner, repo)
+	if environment != "" {
+		prefix += environment + "_"
+	}
+	pattern := regexp.MustCompile("^" + regexp.QuoteMeta(prefix) + `\d{4}-\d{2}-\d{2}_\d{2}-\d{2}-\d{2}\.json$`)
+
+	entries, err := os.ReadDir(manifestDir)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	latest := ""
+	for _, entry := range entries {
+		if pattern.MatchString(entry.Name()) {
+			latest = filepath.Join(manifestDir, entry.Name())
+		}
+	}
+	return latest, nil
+}
+
+// readSyncManifest reads a manifest and checks its signature against the public key
+func readSyncManifest(path string, key ed25519.PublicKey) (*SyncManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var manifest SyncManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	signature, err := base64.StdEncoding.DecodeString(manifest.Signature)
+	if err != nil || manifest.Signature == "" {
+		return nil, fmt.Errorf("%s has no valid signature", path)
+	}
+	signed, err := manifest.signedBytes()
+	if err != nil {
+		return nil, err
+	}
+	if !ed25519.Verify(key, signed, signature) {
+		return nil, fmt.Errorf("%s: signature doesn't match (tampered, or signed with key %s)", path, manifest.KeyID)
+	}
+	if manifestDigest(manifest.Variables) != manifest.Digest {
+		return nil, fmt.Errorf("%s: digest doesn't match its variables", path)
+	}
+	return &manifest, nil
+}
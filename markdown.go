@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Output formats for the diff, selected with --output
+const (
+	OutputText     = "text"
+	OutputMarkdown = "markdown"
+)
+
+func validOutputFormat(format string) bool {
+	return format == OutputText || format == OutputMarkdown
+}
+
+// MarkdownDiff renders a diff as GitHub-flavored Markdown sections, ready to paste into a
+// pull request description or a change ticket
+func MarkdownDiff(owner, repo, environment string, diff DiffResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## Variable changes for `%s`\n\n", targetName(owner, repo, environment))
+
+	if len(diff.New)+len(diff.Updated)+len(diff.Deleted) == 0 {
+		fmt.Fprintf(&b, "No changes: GitHub already matches the input (%d variables).\n", len(diff.Unchanged))
+		return b.String()
+	}
+
+	fmt.Fprintf(&b, "**%d** to create, **%d** to update, %d unchanged", len(diff.New), len(diff.Updated), len(diff.Unchanged))
+	if len(diff.Deleted) > 0 {
+		fmt.Fprintf(&b, ", %d only in GitHub", len(diff.Deleted))
+	}
+	b.WriteString("\n")
+
+	if len(diff.New) > 0 {
+		fmt.Fprintf(&b, "\n### ➕ New (%d)\n\n| Variable | Value |\n|---|---|\n", len(diff.New))
+		for _, v := range diff.New {
+			fmt.Fprintf(&b, "| `%s` | %s |\n", v.Name, markdownCell(v.Name, v.Value))
+		}
+	}
+	if len(diff.Updated) > 0 {
+		fmt.Fprintf(&b, "\n### ✏️ Updated (%d)\n\n| Variable | Current | Proposed |\n|---|---|---|\n", len(diff.Updated))
+		for _, c := range diff.Updated {
+			fmt.Fprintf(&b, "| `%s` | %s | %s |\n", c.Name, markdownCell(c.Name, c.OldValue), markdownCell(c.Name, c.NewValue))
+		}
+	}
+	if len(diff.Deleted) > 0 {
+		fmt.Fprintf(&b, "\n### ⚠️ Only in GitHub (%d, not deleted)\n\n| Variable | Current |\n|---|---|\n", len(diff.Deleted))
+		for _, v := range diff.Deleted {
+			fmt.Fprintf(&b, "| `%s` | %s |\n", v.Name, markdownCell(v.Name, v.Value))
+		}
+	}
+	return b.String()
+}
+
+// writeMarkdownDiff prints the Markdown diff, and also saves it to --output-file when set
+func writeMarkdownDiff(owner, repo, environment string, diff DiffResult) {
+	markdown := MarkdownDiff(owner, repo, environment, diff)
+	fmt.Println()
+	fmt.Println(markdown)
+
+	if *outputFile != "" {
+		err := os.WriteFile(*outputFile, []byte(markdown), 0644)
+		if err != nil {
+			fmt.Printf("⚠️  Warning: Failed to write %s: %v\n", *outputFile, err)
+			return
+		}
+		fmt.Printf("📝 Markdown diff saved: %s\n", *outputFile)
+	}
+}
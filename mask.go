@@ -0,0 +1,38 @@
+package main
+
+import "strings"
+
+// maskPatterns returns the glob patterns of variable names whose values are masked in diffs,
+// from --mask-names and the config file
+func maskPatterns() []string {
+	patterns := append([]string{}, config.Mask...)
+	for _, p := range strings.Split(*maskNames, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// isMasked reports whether a variable's value is shown only as a hash
+func isMasked(name string) bool {
+	return *maskValues || matchesAnyPattern(strings.ToUpper(name), upperAll(maskPatterns()))
+}
+
+// maskedValue hides a value but keeps a fingerprint, so a reader can still tell that it
+// changed, and match it against a value they hold
+func maskedValue(value string) string {
+	if value == "" {
+		return ""
+	}
+	return "•••• " + hashValue(value)
+}
+
+// shownValue is how a variable's value appears in diffs, prompts, and reports: masked with
+// --mask-values or --mask-names, otherwise with sensitive content redacted
+func shownValue(name, value string) string {
+	if isMasked(name) {
+		return maskedValue(value)
+	}
+	return safeValue(value)
+}
@@ -0,0 +1,380 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Matrix is a sync-matrix.yaml file: many sources synced to many targets in one run
+type Matrix struct {
+	Targets []MatrixEntry `json:"targets"`
+}
+
+// MatrixEntry is one source synced to one repository or environment
+type MatrixEntry struct {
+	Name        string       `json:"name"`        // Label in the output; defaults to the target
+	Input       string       `json:"input"`       // Input file or URL, relative to the matrix file
+	Source      string       `json:"source"`      // External source, as with --source
+	Target      string       `json:"target"`      // Target in the --target syntax, instead of repo and environment
+	Repo        string       `json:"repo"`        // owner/repo; defaults to GITHUB_OWNER/GITHUB_REPO
+	Environment string       `json:"environment"` // Empty for repository variables
+	Mapping     MappingRules `json:"mapping"`     // Filters and renames for this entry only
+	Strategy    string       `json:"strategy"`    // Defaults to local-wins
+
+	owner, repo string
+}
+
+// matrixRun is the state of one entry during a matrix run
+type matrixRun struct {
+	Entry  MatrixEntry
+	Remote []Variable
+	Diff   DiffResult
+	Kept   int    // Updates where GitHub's value wins under the entry's strategy
+	Err    string // Why the entry couldn't be synced
+	Report *SyncReport
+}
+
+// LoadMatrix reads and validates a matrix file. owner and repo are the defaults for entries without a repo.
+func LoadMatrix(path, owner, repo string) (*Matrix, error) {
+	matrix := &Matrix{}
+	if err := decodeConfigFile(path, matrix); err != nil {
+		return nil, err
+	}
+	if len(matrix.Targets) == 0 {
+		return nil, fmt.Errorf("%s: no targets", path)
+	}
+
+	seen := map[string]int{}
+	for i := range matrix.Targets {
+		e := &matrix.Targets[i]
+		position := fmt.Sprintf("%s: targets[%d]", path, i)
+		if e.Name != "" {
+			position += " (" + e.Name + ")"
+		}
+
+		e.owner, e.repo = owner, repo
+		if e.Target != "" {
+			if e.Repo != "" || e.Environment != "" {
+				return nil, fmt.Errorf("%s: target can't be combined with repo or environment", position)
+			}
+			target, err := ParseTarget(e.Target)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", position, err)
+			}
+			e.owner, e.repo, e.Environment = target.Owner, target.Repo, target.Environment
+		}
+		if e.Repo != "" {
+			var ok bool
+			e.owner, e.repo, ok = strings.Cut(e.Repo, "/")
+			if !ok || e.owner == "" || e.repo == "" {
+				return nil, fmt.Errorf("%s: repo %q must be owner/repo", position, e.Repo)
+			}
+		}
+		if e.owner == "" || (e.repo == "" && !strings.HasPrefix(e.Target, TargetOrg+":")) {
+			return nil, fmt.Errorf("%s: no repo, and GITHUB_OWNER/GITHUB_REPO are not set", position)
+		}
+		if e.Input == "" && (e.Source == "" || e.Source == "csv") {
+			return nil, fmt.Errorf("%s: needs an input or a source", position)
+		}
+		if e.Input != "" && !strings.Contains(e.Input, "://") && !filepath.IsAbs(e.Input) {
+			e.Input = filepath.Join(filepath.Dir(path), e.Input)
+		}
+		if e.Strategy == "" {
+			e.Strategy = StrategyLocalWins
+		}
+		if !validStrategy(e.Strategy) {
+			return nil, fmt.Errorf("%s: invalid strategy %q (use local-wins, remote-wins, or newest-wins)", position, e.Strategy)
+		}
+
+		target := targetName(e.owner, e.repo, e.Environment)
+		if first, ok := seen[target]; ok {
+			return nil, fmt.Errorf("%s: %s is already the target of targets[%d]", position, target, first)
+		}
+		seen[target] = i
+		if e.Name == "" {
+			e.Name = target
+		}
+	}
+	return matrix, nil
+}
+
+// handleMatrix syncs every entry of a matrix file: all diffs first, one confirmation, then each
+// entry in turn. An entry that fails doesn't stop the others; the exit code covers them all.
+func handleMatrix(token, owner, repo, path string) {
+	switch {
+	case *pullMode || *mergeMode || *backupMode || *tuiMode || *renamesFile != "":
+		fatal(exitFailure, "--matrix can't be combined with --pull, --merge, --backup, --tui, or --renames")
+	case *sourceSpec != "" || *allEnvironments != "":
+		fatal(exitFailure, "--matrix entries name their own sources; it can't be combined with --source or --all-environments")
+	}
+
+	matrix, err := LoadMatrix(path, owner, repo)
+	if err != nil {
+		fatal(exitValidation, "Error: --matrix %v", err)
+	}
+	var fleet *FleetState
+	if !*diffMode {
+		fleet = fleetResumeState(FleetMatrix, owner, repo, path)
+		matrix.Targets = unfinishedMatrixEntries(fleet, matrix.Targets)
+	}
+	fmt.Printf("🎯 Target: %d matrix entr%s from %s\n", len(matrix.Targets), pluralY(len(matrix.Targets)), path)
+
+	configMapping := config.Mapping
+	runs := make([]*matrixRun, len(matrix.Targets))
+	for i, entry := range matrix.Targets {
+		run := &matrixRun{Entry: entry}
+		runs[i] = run
+		fmt.Printf("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━\n🧩 %s → %s\n", entry.Name, targetName(entry.owner, entry.repo, entry.Environment))
+
+		config.Mapping = entry.Mapping
+		err := planMatrixEntry(token, run)
+		config.Mapping = configMapping
+		if err != nil {
+			run.Err = safeValue(err.Error())
+			fmt.Printf("❌ %s\n", run.Err)
+			continue
+		}
+		DisplayDiffSummary(run.Diff)
+		DisplayDetailedDiff(run.Diff)
+		if run.Kept > 0 {
+			fmt.Printf("ℹ️  %d changed variable(s) keep their GitHub value under strategy %s\n", run.Kept, entry.Strategy)
+		}
+	}
+
+	pending := 0
+	for _, run := range runs {
+		if run.Err == "" {
+			pending += len(run.Diff.New) + len(run.Diff.Updated)
+		}
+	}
+	switch {
+	case *diffMode:
+		for _, run := range runs {
+			if run.Err == "" {
+				run.Report = driftReport(run.Entry.owner, run.Entry.repo, run.Entry.Environment, run.Diff)
+				if run.Report.HasDrift() {
+					sendNotifications(run.Report)
+				}
+			}
+		}
+		displayMatrixReport(runs)
+		fmt.Println("ℹ️  Diff mode: No changes were made")
+		exit(matrixExitCode(runs))
+	case pending == 0:
+		markMatrixCompleted(fleet, runs)
+		fleet.Finish(matrixCompleted(fleet, runs))
+		displayMatrixReport(runs)
+		fmt.Println("\n✅ No changes to sync. Every matrix target is up to date!")
+		exit(matrixExitCode(runs))
+	}
+	for _, run := range runs {
+		if run.Err == "" && len(run.Diff.New)+len(run.Diff.Updated) > 0 {
+			runPreSyncHooks(run.Entry.owner, run.Entry.repo, run.Entry.Environment, run.Diff)
+			requireApproval(token, run.Entry.owner, run.Entry.repo, run.Entry.Environment, syncPlan(run.Diff, nil))
+		}
+	}
+	if !askYesNo(fmt.Sprintf("\n⚠️  Sync %d variable(s) across %d matrix entr%s?", pending, len(runs), pluralY(len(runs)))) {
+		fmt.Println("\n❌ Sync cancelled by user")
+		exit(exitCancelled)
+	}
+
+	fmt.Print("\n🚀 Starting sync...\n")
+	client := newRESTClient(token)
+	for _, run := range runs {
+		e := run.Entry
+		if run.Err != "" {
+			continue
+		}
+		run.Report = newSyncReport(e.owner, e.repo, e.Environment, "sync")
+		if len(run.Diff.New)+len(run.Diff.Updated) == 0 {
+			markMatrixCompleted(fleet, []*matrixRun{run})
+			continue
+		}
+		fmt.Printf("\n🧩 %s\n", e.Name)
+
+		if !*noBackup {
+			backupFile, err := BackupGitHubVariables(token, e.owner, e.repo, e.Environment)
+			if err != nil {
+				run.Err = fmt.Sprintf("backup failed, nothing was changed: %v", err)
+				fmt.Printf("❌ %s\n", run.Err)
+				continue
+			}
+			fmt.Printf("💾 Backup saved: %s\n", backupFile)
+		}
+		if err := VerifyRemoteUnchanged(token, e.owner, e.repo, e.Environment, run.Remote); err != nil {
+			run.Err = fmt.Sprintf("aborted: %v", err)
+			fmt.Printf("❌ %s\n", run.Err)
+			continue
+		}
+
+		run.Report = applyDiff(client, newSyncReport(e.owner, e.repo, e.Environment, "sync"), run.Diff, func(format string, args ...interface{}) {
+			fmt.Printf(format+"\n", args...)
+		}, nil)
+		fmt.Printf("✅ Created %d, Updated %d, Failed %d\n", len(run.Report.Created), len(run.Report.Updated), len(run.Report.Failed))
+		writeSyncManifest(token, run.Report)
+		sendNotifications(run.Report)
+		runPostSyncHooks(run.Report)
+		markMatrixCompleted(fleet, []*matrixRun{run})
+	}
+
+	displayMatrixReport(runs)
+	fleet.Finish(matrixCompleted(fleet, runs))
+	if code := matrixExitCode(runs); code != exitOK {
+		runError = "some matrix entries failed"
+		exit(code)
+	}
+}
+
+// unfinishedMatrixEntries leaves out the entries an earlier run already synced
+func unfinishedMatrixEntries(fleet *FleetState, entries []MatrixEntry) []MatrixEntry {
+	remaining := []MatrixEntry{}
+	for _, e := range entries {
+		if fleet.Done(targetName(e.owner, e.repo, e.Environment)) {
+			fmt.Printf("⏭️  %s was synced by the earlier run; skipping\n", e.Name)
+			continue
+		}
+		remaining = append(remaining, e)
+	}
+	if len(remaining) == 0 {
+		fleet.Finish(true)
+		fmt.Println("\n✅ Nothing left to resume")
+		exit(0)
+	}
+	return remaining
+}
+
+// markMatrixCompleted records the entries that synced without failures, or had nothing to sync
+func markMatrixCompleted(fleet *FleetState, runs []*matrixRun) {
+	for _, run := range runs {
+		if run.Err == "" && (run.Report == nil || len(run.Report.Failed)+len(run.Report.NotSynced) == 0) {
+			fleet.MarkCompleted(targetName(run.Entry.owner, run.Entry.repo, run.Entry.Environment))
+		}
+	}
+}
+
+// matrixCompleted reports whether every entry of the run is recorded as completed
+func matrixCompleted(fleet *FleetState, runs []*matrixRun) bool {
+	for _, run := range runs {
+		if !fleet.Done(targetName(run.Entry.owner, run.Entry.repo, run.Entry.Environment)) {
+			return false
+		}
+	}
+	return true
+}
+
+// planMatrixEntry loads an entry's source and diffs it against its target, holding the target's lock
+func planMatrixEntry(token string, run *matrixRun) error {
+	e := run.Entry
+	if e.Environment != "" {
+		exists, err := environmentExists(token, e.owner, e.repo, e.Environment)
+		if err != nil {
+			return fmt.Errorf("checking environment '%s': %w", e.Environment, err)
+		}
+		if !exists && !*createEnvironment {
+			return fmt.Errorf("environment '%s' does not exist (re-run with --create-environment to create it)", e.Environment)
+		}
+		if !exists {
+			ensureEnvironment(token, e.owner, e.repo, e.Environment, !*diffMode)
+		}
+	}
+	if !*diffMode {
+		if err := AcquireTargetLock(token, e.owner, e.repo, e.Environment); err != nil {
+			return err
+		}
+	}
+
+	savedInput := *inputFile
+	*inputFile, *sourceSpec = e.Input, e.Source
+	variables, source, err := LoadDesiredVariables(token, e.owner, e.repo, e.Environment)
+	*inputFile, *sourceSpec = savedInput, ""
+	if err != nil {
+		return err
+	}
+	checkSecretLeaks(source, variables)
+
+	run.Remote, err = FetchGitHubVariables(token, e.owner, e.repo, e.Environment)
+	if err != nil {
+		return fmt.Errorf("fetching variables: %w", err)
+	}
+	run.Diff = scopeToTeam(CompareSets(variables, run.Remote))
+	emitDiffComputed(e.owner, e.repo, e.Environment, run.Diff)
+
+	// A matrix run never writes back to its inputs: updates GitHub wins are left as they are
+	if e.Strategy != StrategyLocalWins {
+		modTime, err := csvModificationTime(source)
+		if err != nil {
+			return fmt.Errorf("strategy %s: %w", e.Strategy, err)
+		}
+		var kept []VariableChange
+		run.Diff, kept = ApplyStrategy(e.Strategy, run.Diff, modTime)
+		run.Kept = len(kept)
+	}
+
+	if errors := displaySchemaProblems(e.owner, e.repo, e.Environment, variables, run.Remote); errors > 0 {
+		return fmt.Errorf("%d required variable(s) missing or invalid", errors)
+	}
+	if violations := displayPolicyViolations(e.owner, e.repo, e.Environment, variables, run.Diff); violations > 0 && *policyEnforce {
+		return fmt.Errorf("rejected by policy: %d violation(s)", violations)
+	}
+	return nil
+}
+
+// matrixExitCode is 0 when every entry succeeded, exitFailure when none did, and exitPartial otherwise
+func matrixExitCode(runs []*matrixRun) int {
+	failed := 0
+	for _, run := range runs {
+		if run.Err != "" || (run.Report != nil && len(run.Report.Failed) > 0) {
+			failed++
+		}
+	}
+	switch {
+	case failed == 0:
+		return exitOK
+	case failed == len(runs):
+		return exitFailure
+	default:
+		return exitPartial
+	}
+}
+
+// displayMatrixReport prints one row per matrix entry with its counts and result
+func displayMatrixReport(runs []*matrixRun) {
+	verb := "Created"
+	if *diffMode {
+		verb = "New"
+	}
+	width := len("Entry")
+	for _, run := range runs {
+		if len(run.Entry.Name) > width {
+			width = len(run.Entry.Name)
+		}
+	}
+
+	fmt.Println("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Println("📊 MATRIX")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Printf("%-*s %8s %8s %8s  %s\n", width, "Entry", verb, "Updated", "Failed", "Result")
+	for _, run := range runs {
+		created, updated, failed := 0, 0, 0
+		if run.Report != nil {
+			created, updated, failed = len(run.Report.Created), len(run.Report.Updated), len(run.Report.Failed)
+		}
+		result := "✅ ok"
+		switch {
+		case run.Err != "":
+			result = "❌ " + run.Err
+		case failed > 0:
+			result = "⚠️  partial"
+		}
+		fmt.Printf("%-*s %8d %8d %8d  %s\n", width, run.Entry.Name, created, updated, failed, result)
+	}
+}
+
+// pluralY returns the ending of "entry"/"entries" for n
+func pluralY(n int) string {
+	if n == 1 {
+		return "y"
+	}
+	return "ies"
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// mergeEntry is the state of one variable name in the base, local, and remote sets
+type mergeEntry struct {
+	Name   string
+	Base   *string // nil when absent
+	Local  *string
+	Remote *string
+}
+
+// MergePlan is the result of a three-way merge of local and remote against a base snapshot
+type MergePlan struct {
+	Create        []Variable       // Added locally since the base
+	Update        []VariableChange // Changed locally since the base
+	Delete        []Variable       // Removed locally since the base (pruned from GitHub)
+	Unmanaged     []Variable       // In the base and GitHub but never synced from an input (kept)
+	RemoteChanges []mergeEntry     // Changed in GitHub since the base (kept as-is)
+	Conflicts     []mergeEntry     // Changed differently on both sides
+	Ignored       int              // Names left out because they match the ignore file
+}
+
+// ThreeWayMerge compares local and remote against the base snapshot so that changes
+// made on either side since the base are detected separately. The base is a backup of
+// GitHub, so a name missing locally only counts as removed when synced says it was once
+// written from an input (see syncedNames); other such names are kept unless --merge-prune.
+func ThreeWayMerge(base, local, remote []Variable, synced map[string]bool) MergePlan {
+	// Variables managed elsewhere are left out of all three sides, so they are never pruned
+	base, ignoredBase := withoutIgnored(base)
+	local, ignoredLocal := withoutIgnored(local)
+	remote, ignoredRemote := withoutIgnored(remote)
+	ignored := map[string]bool{}
+	for _, v := range append(append(ignoredBase, ignoredLocal...), ignoredRemote...) {
+		ignored[nameKey(v.Name)] = true
+	}
+
+	entries := make(map[string]*mergeEntry)
+	entry := func(name string) *mergeEntry {
+		key := nameKey(name)
+		if entries[key] == nil {
+			entries[key] = &mergeEntry{Name: name}
+		}
+		return entries[key]
+	}
+	for _, v := range base {
+		value := v.Value
+		entry(v.Name).Base = &value
+	}
+	for _, v := range local {
+		if v.Name == "" {
+			continue
+		}
+		value := v.Value
+		entry(v.Name).Local = &value
+	}
+	for _, v := range remote {
+		value := v.Value
+		entry(v.Name).Remote = &value
+	}
+
+	names := make([]string, 0, len(entries))
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	plan := MergePlan{Ignored: len(ignored)}
+	for _, name := range names {
+		e := entries[name]
+		switch {
+		case sameValue(e.Local, e.Remote):
+			// Both sides agree (including both removed) - nothing to do
+		case sameValue(e.Local, e.Base):
+			plan.RemoteChanges = append(plan.RemoteChanges, *e)
+		case e.Local == nil && !synced[name] && !*mergePrune:
+			plan.Unmanaged = append(plan.Unmanaged, Variable{Name: e.Name, Value: *e.Remote})
+		case sameValue(e.Remote, e.Base):
+			plan.addLocalChange(*e)
+		default:
+			plan.Conflicts = append(plan.Conflicts, *e)
+		}
+	}
+	return plan
+}
+
+// syncedNames returns the name keys the journal shows were created or updated in the target
+// from an input, i.e. names a local input once had. Without a journal it's empty.
+func syncedNames(owner, repo, environment string) (map[string]bool, error) {
+	names := map[string]bool{}
+	if !journalEnabled() {
+		return names, nil
+	}
+	entries, err := ReadJournal(*journalFile)
+	if err != nil {
+		return nil, err
+	}
+	target := targetName(owner, repo, environment)
+	for _, e := range entries {
+		if e.RollbackOf == "" && e.Action != AuditDelete && targetName(e.Owner, e.Repo, e.Environment) == target {
+			names[nameKey(e.Name)] = true
+		}
+	}
+	return names, nil
+}
+
+// addLocalChange schedules the local side of an entry to be applied to GitHub
+func (p *MergePlan) addLocalChange(e mergeEntry) {
+	switch {
+	case e.Local == nil:
+		p.Delete = append(p.Delete, Variable{Name: e.Name, Value: *e.Remote})
+	case e.Remote == nil:
+		p.Create = append(p.Create, Variable{Name: e.Name, Value: *e.Local})
+	default:
+		p.Update = append(p.Update, VariableChange{Name: e.Name, OldValue: *e.Remote, NewValue: *e.Local})
+	}
+}
+
+// sameValue compares two optional values, treating two absent values as equal
+func sameValue(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// describeValue formats an optional value of a variable for display
+func describeValue(name string, v *string) string {
+	if v == nil {
+		return "(absent)"
+	}
+	return truncateValue(shownValue(name, *v), valueLimit(60, true))
+}
+
+// DisplayMergePlan prints the planned changes and any conflicts
+func DisplayMergePlan(plan MergePlan) {
+	fmt.Println("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Println("🔀 THREE-WAY MERGE")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Printf("%s✨ Create:%s          %d variable(s)\n", ColorGreen, ColorReset, len(plan.Create))
+	fmt.Printf("%s🔄 Update:%s          %d variable(s)\n", ColorYellow, ColorReset, len(plan.Update))
+	fmt.Printf("%s🗑️  Delete:%s          %d variable(s)\n", ColorRed, ColorReset, len(plan.Delete))
+	fmt.Printf("%s🌐 Remote changes:%s  %d variable(s) (kept)\n", ColorGray, ColorReset, len(plan.RemoteChanges))
+	fmt.Printf("%s⚔️  Conflicts:%s       %d variable(s)\n", ColorRed+ColorBold, ColorReset, len(plan.Conflicts))
+	if len(plan.Unmanaged) > 0 {
+		fmt.Printf("%s📌 Not from input:%s  %d variable(s) (kept, --merge-prune deletes them)\n", ColorGray, ColorReset, len(plan.Unmanaged))
+	}
+	if plan.Ignored > 0 {
+		fmt.Printf("%s🙈 Ignored:%s         %d variable(s) (managed elsewhere, see %s)\n", ColorGray, ColorReset, plan.Ignored, *ignoreFile)
+	}
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	fmt.Println()
+	for _, v := range plan.Create {
+		fmt.Printf("%s+ %s = %s%s\n", ColorGreen, v.Name, truncateValue(shownValue(v.Name, v.Value), valueLimit(80, true)), ColorReset)
+	}
+	for _, c := range plan.Update {
+		fmt.Printf("%s~ %s:%s\n", ColorYellow, c.Name, ColorReset)
+		fmt.Printf("  %s- %s%s\n", ColorRed, truncateValue(shownValue(c.Name, c.OldValue), valueLimit(60, true)), ColorReset)
+		fmt.Printf("  %s+ %s%s\n", ColorGreen, truncateValue(shownValue(c.Name, c.NewValue), valueLimit(60, true)), ColorReset)
+	}
+	for _, v := range plan.Delete {
+		fmt.Printf("%s- %s (removed from CSV since base)%s\n", ColorRed, v.Name, ColorReset)
+	}
+	if len(plan.Unmanaged) > 0 {
+		fmt.Printf("\n%s[ONLY IN GITHUB, NEVER SYNCED FROM INPUT - kept]%s\n", ColorGray, ColorReset)
+		for _, v := range plan.Unmanaged {
+			fmt.Printf("%s  %s%s\n", ColorGray, v.Name, ColorReset)
+		}
+	}
+	if len(plan.RemoteChanges) > 0 {
+		fmt.Printf("\n%s[CHANGED IN GITHUB SINCE BASE - kept, not in CSV]%s\n", ColorGray, ColorReset)
+		for _, e := range plan.RemoteChanges {
+			fmt.Printf("%s  %s: %s → %s%s\n", ColorGray, e.Name, describeValue(e.Name, e.Base), describeValue(e.Name, e.Remote), ColorReset)
+		}
+	}
+	if len(plan.Conflicts) > 0 {
+		fmt.Printf("\n%s[CONFLICTS - changed on both sides]%s\n", ColorRed+ColorBold, ColorReset)
+		for _, e := range plan.Conflicts {
+			fmt.Printf("%s! %s%s\n", ColorRed, e.Name, ColorReset)
+			fmt.Printf("    base:   %s\n", describeValue(e.Name, e.Base))
+			fmt.Printf("    local:  %s\n", describeValue(e.Name, e.Local))
+			fmt.Printf("    remote: %s\n", describeValue(e.Name, e.Remote))
+		}
+	}
+	fmt.Println()
+}
+
+// ResolveConflicts asks how to resolve each conflict. It returns false if the user aborts.
+func ResolveConflicts(plan *MergePlan) bool {
+	reader := bufio.NewReader(os.Stdin)
+	for _, e := range plan.Conflicts {
+		fmt.Printf("⚔️  %s: keep [l]ocal, keep [r]emote, or [a]bort? ", e.Name)
+		input, err := reader.ReadString('\n')
+		if err != nil {
+			return false
+		}
+
+		switch strings.TrimSpace(strings.ToLower(input)) {
+		case "l", "local":
+			plan.addLocalChange(e)
+		case "r", "remote":
+			// GitHub already has the remote value
+		default:
+			return false
+		}
+	}
+	plan.Conflicts = nil
+	return true
+}
+
+// handleMerge runs a three-way merge sync using the latest backup as the merge base
+func handleMerge(token, owner, repo, environment string, local, remote []Variable) {
+	baseFile, err := FindLatestBackup(owner, repo, environment)
+	if err != nil {
+		fatal(exitValidation, "Merge needs a base snapshot: %v\n   Create one with --backup (or run a normal sync, which backs up automatically)", err)
+	}
+
+	base, err := readBackup(baseFile)
+	if err != nil {
+		fatal(exitValidation, "Error reading base snapshot %s: %v", baseFile, err)
+	}
+	fmt.Printf("📂 Merge base: %s (%d variables)\n", baseFile, len(base))
+
+	synced := map[string]bool{}
+	if !*mergePrune {
+		synced, err = syncedNames(owner, repo, environment)
+	}
+	if err != nil {
+		fatal(exitValidation, "Error reading journal %s: %v\n   It tells which variables came from an input; use --merge-prune to prune without it", *journalFile, err)
+	}
+
+	plan := ThreeWayMerge(base, local, remote, synced)
+	DisplayMergePlan(plan)
+
+	if *diffMode {
+		fmt.Println("ℹ️  Diff mode: No changes were made")
+		exit(0)
+	}
+
+	if len(plan.Conflicts) > 0 && !ResolveConflicts(&plan) {
+		fmt.Println("\n❌ Merge aborted. No changes were made")
+		exit(exitCancelled)
+	}
+
+	total := len(plan.Create) + len(plan.Update) + len(plan.Delete)
+	if total == 0 {
+		fmt.Println("\n✅ Nothing to apply. GitHub already reflects all local changes")
+		exit(0)
+	}
+
+	fmt.Printf("\n📦 Will apply %d change(s) (%d create, %d update, %d delete)\n\n",
+		total, len(plan.Create), len(plan.Update), len(plan.Delete))
+	requireApproval(token, owner, repo, environment, DiffResult{New: plan.Create, Updated: plan.Update, Deleted: plan.Delete})
+	confirmed := false
+	if len(plan.Delete) > 0 {
+		confirmed = confirmDeletion(len(plan.Delete), targetName(owner, repo, environment), confirmationName(owner, repo))
+	} else {
+		confirmed = askYesNo("⚠️  Do you want to proceed with the merge?")
+	}
+	if !confirmed {
+		fmt.Println("\n❌ Merge cancelled by user")
+		exit(exitCancelled)
+	}
+
+	if !*noBackup {
+		fmt.Println("\n💾 Creating backup before merge...")
+		backupFile, err := BackupGitHubVariables(token, owner, repo, environment)
+		if err != nil {
+			fmt.Printf("❌ Failed to create backup: %v\n", err)
+			exit(1)
+		}
+		fmt.Printf("✅ Backup saved: %s\n", backupFile)
+	}
+
+	fmt.Println("\n🔍 Re-checking GitHub for concurrent changes...")
+	err = VerifyRemoteUnchanged(token, owner, repo, environment, remote)
+	if err != nil {
+		fatal(exitValidation, "Merge aborted: %v\n   Nothing was changed. Re-run to review the new merge plan", err)
+	}
+
+	fmt.Print("\n🚀 Applying merge...\n\n")
+	failed := applyMergePlan(newRESTClient(token), owner, repo, environment, plan)
+
+	fmt.Println()
+	fmt.Printf("🎉 Completed! Applied %d, Failed %d changes\n", total-failed, failed)
+	if failed > 0 {
+		exit(exitPartial)
+	}
+}
+
+// applyMergePlan writes the creates, updates, and deletes of a merge plan, returning how
+// many of them failed
+func applyMergePlan(client GitHubClient, owner, repo, environment string, plan MergePlan) int {
+	failed := 0
+	for _, v := range plan.Create {
+		if err := createOrUpdateVariable(client, owner, repo, environment, v); err != nil {
+			fmt.Printf("❌ Error creating variable '%s': %v\n", v.Name, err)
+			failed++
+		} else {
+			fmt.Printf("✅ Created variable: %s\n", v.Name)
+		}
+	}
+	for _, c := range plan.Update {
+		if err := client.UpdateVariable(owner, repo, environment, Variable{Name: c.Name, Value: c.NewValue}); err != nil {
+			fmt.Printf("❌ Error updating variable '%s': %v\n", c.Name, err)
+			failed++
+		} else {
+			fmt.Printf("✅ Updated variable: %s\n", c.Name)
+		}
+	}
+	for _, v := range plan.Delete {
+		if err := client.DeleteVariable(owner, repo, environment, v.Name); err != nil {
+			fmt.Printf("❌ Error deleting variable '%s': %v\n", v.Name, err)
+			failed++
+		} else {
+			fmt.Printf("🗑️  Deleted variable: %s\n", v.Name)
+		}
+	}
+	return failed
+}
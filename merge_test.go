@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestThreeWayMergeSkipsIgnoredVariables(t *testing.T) {
+	ignoreRules = []ignoreRule{{pattern: "TERRAFORM_*"}}
+	defer func() { ignoreRules = nil }()
+
+	base := []Variable{{Name: "TERRAFORM_STATE", Value: "s3://a"}, {Name: "API_URL", Value: "v1"}}
+	local := []Variable{{Name: "API_URL", Value: "v2"}}
+	remote := []Variable{{Name: "TERRAFORM_STATE", Value: "s3://a"}, {Name: "API_URL", Value: "v1"}}
+
+	plan := ThreeWayMerge(base, local, remote, nil)
+	if len(plan.Delete) != 0 {
+		t.Errorf("ignored variable scheduled for deletion: %v", plan.Delete)
+	}
+	if len(plan.Update) != 1 || plan.Update[0].Name != "API_URL" {
+		t.Errorf("Update = %v, want API_URL", plan.Update)
+	}
+	if plan.Ignored != 1 {
+		t.Errorf("Ignored = %d, want 1", plan.Ignored)
+	}
+}
+
+func TestThreeWayMergeKeepsNamesNeverSyncedFromInput(t *testing.T) {
+	base := []Variable{{Name: "API_URL", Value: "v1"}, {Name: "OLD_FLAG", Value: "on"}, {Name: "TEAM_OWNED", Value: "x"}}
+	local := []Variable{{Name: "API_URL", Value: "v1"}}
+	remote := []Variable{{Name: "API_URL", Value: "v1"}, {Name: "OLD_FLAG", Value: "on"}, {Name: "TEAM_OWNED", Value: "x"}}
+	synced := map[string]bool{"API_URL": true, "OLD_FLAG": true}
+
+	plan := ThreeWayMerge(base, local, remote, synced)
+	if len(plan.Delete) != 1 || plan.Delete[0].Name != "OLD_FLAG" {
+		t.Errorf("Delete = %v, want only OLD_FLAG", plan.Delete)
+	}
+	if len(plan.Unmanaged) != 1 || plan.Unmanaged[0].Name != "TEAM_OWNED" {
+		t.Errorf("Unmanaged = %v, want TEAM_OWNED", plan.Unmanaged)
+	}
+
+	*mergePrune = true
+	defer func() { *mergePrune = false }()
+	plan = ThreeWayMerge(base, local, remote, synced)
+	if len(plan.Delete) != 2 || len(plan.Unmanaged) != 0 {
+		t.Errorf("with --merge-prune: Delete = %v, Unmanaged = %v", plan.Delete, plan.Unmanaged)
+	}
+}
+
+func TestApplyMergePlanWritesThroughClient(t *testing.T) {
+	client := NewMemoryClient()
+	client.Seed("o", "r", "", []Variable{{Name: "API_URL", Value: "v1"}, {Name: "OLD_FLAG", Value: "on"}, {Name: "BROKEN", Value: "x"}})
+	client.Errors["delete:BROKEN"] = errors.New("boom")
+
+	plan := MergePlan{
+		Create: []Variable{{Name: "NEW", Value: "n"}},
+		Update: []VariableChange{{Name: "API_URL", OldValue: "v1", NewValue: "v2"}},
+		Delete: []Variable{{Name: "OLD_FLAG", Value: "on"}, {Name: "BROKEN", Value: "x"}},
+	}
+	if failed := applyMergePlan(client, "o", "r", "", plan); failed != 1 {
+		t.Errorf("failed = %d, want 1", failed)
+	}
+	got, _ := client.ListVariables("o", "r", "")
+	want := []Variable{{Name: "API_URL", Value: "v2"}, {Name: "BROKEN", Value: "x"}, {Name: "NEW", Value: "n"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("variables = %v, want %v", got, want)
+	}
+}
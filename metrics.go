@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// metricInfo is the HELP/TYPE metadata of a metric family
+type metricInfo struct {
+	kind string // "counter" or "gauge"
+	help string
+}
+
+// metricFamilies lists every exported metric
+var metricFamilies = map[string]metricInfo{
+	"syncvars_api_requests_total":           {"counter", "GitHub API requests by method and status code"},
+	"syncvars_rate_limit_remaining":         {"gauge", "Remaining GitHub API rate limit reported by the last response"},
+	"syncvars_drift_checks_total":           {"counter", "Drift checks by result (success or error)"},
+	"syncvars_drift_variables":              {"gauge", "Drifted variables found by the last successful check, by kind"},
+	"syncvars_variables_synced_total":       {"counter", "Variables written by result (created, updated, failed)"},
+	"syncvars_last_check_timestamp_seconds": {"gauge", "Unix time of the last successful drift check"},
+	"syncvars_last_sync_timestamp_seconds":  {"gauge", "Unix time of the last sync that wrote variables"},
+}
+
+// metricsRegistry holds metric values keyed by name and rendered label set
+type metricsRegistry struct {
+	mu     sync.Mutex
+	values map[string]map[string]float64
+}
+
+// metrics collects values exposed on /metrics in watch mode
+var metrics = &metricsRegistry{values: map[string]map[string]float64{}}
+
+// promLabels renders label pairs ("key", "value", ...) in Prometheus text format
+func promLabels(pairs ...string) string {
+	parts := []string{}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(pairs[i+1])
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, pairs[i], value))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// Add increments a counter
+func (m *metricsRegistry) Add(name, labels string, delta float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.values[name] == nil {
+		m.values[name] = map[string]float64{}
+	}
+	m.values[name][labels] += delta
+}
+
+// Set sets a gauge
+func (m *metricsRegistry) Set(name, labels string, value float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.values[name] == nil {
+		m.values[name] = map[string]float64{}
+	}
+	m.values[name][labels] = value
+}
+
+// ServeHTTP writes every metric in the Prometheus text exposition format
+func (m *metricsRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(metricFamilies))
+	for name := range metricFamilies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	for _, name := range names {
+		series := m.values[name]
+		if len(series) == 0 {
+			continue
+		}
+		info := metricFamilies[name]
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, info.help, name, info.kind)
+
+		labels := make([]string, 0, len(series))
+		for l := range series {
+			labels = append(labels, l)
+		}
+		sort.Strings(labels)
+		for _, l := range labels {
+			fmt.Fprintf(w, "%s%s %s\n", name, l, strconv.FormatFloat(series[l], 'g', -1, 64))
+		}
+	}
+}
+
+// metricsTransport counts GitHub API requests and tracks the remaining rate limit
+type metricsTransport struct {
+	base http.RoundTripper
+}
+
+func (t *metricsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if !isGitHubAPIRequest(req) {
+		return resp, err
+	}
+
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+		if remaining, convErr := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining")); convErr == nil {
+			metrics.Set("syncvars_rate_limit_remaining", "", float64(remaining))
+		}
+	}
+	metrics.Add("syncvars_api_requests_total", promLabels("method", req.Method, "status", status), 1)
+	return resp, err
+}
+
+// recordCheckMetrics records the outcome of one drift check for a target
+func recordCheckMetrics(target string, drift *SyncReport, err error) {
+	if err != nil {
+		metrics.Add("syncvars_drift_checks_total", promLabels("target", target, "result", "error"), 1)
+		return
+	}
+	metrics.Add("syncvars_drift_checks_total", promLabels("target", target, "result", "success"), 1)
+	metrics.Set("syncvars_drift_variables", promLabels("target", target, "kind", "missing"), float64(len(drift.Created)))
+	metrics.Set("syncvars_drift_variables", promLabels("target", target, "kind", "changed"), float64(len(drift.Updated)))
+	metrics.Set("syncvars_drift_variables", promLabels("target", target, "kind", "remote_only"), float64(len(drift.RemoteOnly)))
+	metrics.Set("syncvars_last_check_timestamp_seconds", promLabels("target", target), float64(time.Now().Unix()))
+}
+
+// recordSyncMetrics records the variables written by a sync
+func recordSyncMetrics(report *SyncReport) {
+	target := report.Target()
+	metrics.Add("syncvars_variables_synced_total", promLabels("target", target, "result", "created"), float64(len(report.Created)))
+	metrics.Add("syncvars_variables_synced_total", promLabels("target", target, "result", "updated"), float64(len(report.Updated)))
+	metrics.Add("syncvars_variables_synced_total", promLabels("target", target, "result", "failed"), float64(len(report.Failed)))
+	metrics.Set("syncvars_last_sync_timestamp_seconds", promLabels("target", target), float64(time.Now().Unix()))
+}
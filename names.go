@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Name case policies, selected with --name-case. GitHub matches variable names
+// case-insensitively and returns them uppercase, so a mixed-case input name would
+// otherwise show up as one variable to create and another only in GitHub.
+const (
+	NameCaseUpper    = "upper"    // Uppercase input names, as GitHub stores them
+	NameCasePreserve = "preserve" // Keep input names as written, but match GitHub names regardless of case
+	NameCaseExact    = "exact"    // Keep input names and match them case-sensitively
+)
+
+func validNameCase(policy string) bool {
+	return policy == NameCaseUpper || policy == NameCasePreserve || policy == NameCaseExact
+}
+
+// normalizeName applies the --name-case policy to a variable name
+func normalizeName(name string) string {
+	if *nameCase == NameCaseUpper {
+		return strings.ToUpper(name)
+	}
+	return name
+}
+
+// nameKey is the key names are matched on when comparing against GitHub
+func nameKey(name string) string {
+	if *nameCase == NameCaseExact {
+		return name
+	}
+	return strings.ToUpper(name)
+}
+
+// NormalizeNames applies the --name-case policy to the desired variables. Two input names
+// that GitHub would treat as the same variable are an error.
+func NormalizeNames(variables []Variable) ([]Variable, error) {
+	result := make([]Variable, 0, len(variables))
+	seen := map[string]string{}
+	changed := 0
+	for _, v := range variables {
+		key := nameKey(v.Name)
+		if first, ok := seen[key]; ok && first != v.Name {
+			return nil, fmt.Errorf("%s and %s are the same variable on GitHub (names are case-insensitive)", first, v.Name)
+		}
+		seen[key] = v.Name
+
+		if name := normalizeName(v.Name); name != v.Name {
+			v.Name = name
+			changed++
+		}
+		result = append(result, v)
+	}
+	if changed > 0 {
+		fmt.Printf("🔠 Uppercased %d variable name(s) (use --name-case preserve to keep them as written)\n", changed)
+	}
+	return result, nil
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Normalization rules that can be assigned to variable name patterns in the config file
+const (
+	NormalizeTrim      = "trim"      // Remove leading and trailing whitespace
+	NormalizeLowercase = "lowercase" // Lowercase the value
+	NormalizeUppercase = "uppercase" // Uppercase the value
+	NormalizeBoolean   = "boolean"   // true/yes/on/1 -> true, false/no/off/0 -> false; anything else is an error
+	NormalizeInteger   = "int"       // An integer, written canonically; int:MIN..MAX also checks the range
+	NormalizeURL       = "url"       // An absolute URL with a scheme and host
+)
+
+// normalizeStep is one parsed rule of a normalization list
+type normalizeStep struct {
+	rule     string
+	min, max *int64 // Range of int:MIN..MAX; either end may be open
+}
+
+// parseNormalizeRules parses a comma-separated rule list such as "trim,int:1..65535"
+func parseNormalizeRules(spec string) ([]normalizeStep, error) {
+	steps := []normalizeStep{}
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		rule, arg, hasArg := strings.Cut(field, ":")
+		step := normalizeStep{rule: rule}
+		switch rule {
+		case NormalizeTrim, NormalizeLowercase, NormalizeUppercase, NormalizeBoolean, NormalizeURL:
+			if hasArg {
+				return nil, fmt.Errorf("rule %q takes no argument", rule)
+			}
+		case NormalizeInteger:
+			if hasArg {
+				low, high, ok := strings.Cut(arg, "..")
+				if !ok {
+					return nil, fmt.Errorf("invalid range %q (use int:MIN..MAX)", arg)
+				}
+				var err error
+				if step.min, err = parseRangeEnd(low); err != nil {
+					return nil, fmt.Errorf("invalid range %q: %w", arg, err)
+				}
+				if step.max, err = parseRangeEnd(high); err != nil {
+					return nil, fmt.Errorf("invalid range %q: %w", arg, err)
+				}
+			}
+		default:
+			return nil, fmt.Errorf("unknown normalization rule %q", field)
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+func parseRangeEnd(s string) (*int64, error) {
+	if s = strings.TrimSpace(s); s == "" {
+		return nil, nil
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// validateNormalizeRules checks every configured pattern and rule list
+func validateNormalizeRules(rules map[string]string) error {
+	for pattern, spec := range rules {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+		if _, err := parseNormalizeRules(spec); err != nil {
+			return fmt.Errorf("%s: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// normalizeRules returns the rule list for a variable name, with the same precedence as
+// comparison modes: an exact name entry wins, otherwise the longest matching glob pattern
+func normalizeRules(name string) []normalizeStep {
+	spec, _ := patternValue(config.Normalize, name)
+	if spec == "" {
+		return nil
+	}
+	steps, _ := parseNormalizeRules(spec) // Validated when the config was loaded
+	return steps
+}
+
+// normalizeValue applies a variable's rules in order. The error never includes the value,
+// since it may be sensitive.
+func normalizeValue(name, value string) (string, error) {
+	for _, step := range normalizeRules(name) {
+		switch step.rule {
+		case NormalizeTrim:
+			value = strings.TrimSpace(value)
+		case NormalizeLowercase:
+			value = strings.ToLower(value)
+		case NormalizeUppercase:
+			value = strings.ToUpper(value)
+		case NormalizeBoolean:
+			switch strings.ToLower(strings.TrimSpace(value)) {
+			case "true", "yes", "on", "1":
+				value = "true"
+			case "false", "no", "off", "0":
+				value = "false"
+			default:
+				return "", fmt.Errorf("is not a boolean (use true or false)")
+			}
+		case NormalizeInteger:
+			n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+			if err != nil {
+				return "", fmt.Errorf("is not an integer")
+			}
+			if (step.min != nil && n < *step.min) || (step.max != nil && n > *step.max) {
+				return "", fmt.Errorf("is out of range %s", formatRange(step))
+			}
+			value = strconv.FormatInt(n, 10)
+		case NormalizeURL:
+			parsed, err := url.Parse(strings.TrimSpace(value))
+			if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+				return "", fmt.Errorf("is not an absolute URL")
+			}
+		}
+	}
+	return value, nil
+}
+
+func formatRange(step normalizeStep) string {
+	low, high := "", ""
+	if step.min != nil {
+		low = strconv.FormatInt(*step.min, 10)
+	}
+	if step.max != nil {
+		high = strconv.FormatInt(*step.max, 10)
+	}
+	return low + ".." + high
+}
+
+// NormalizeValues applies the configured normalization rules to the input's values, so what
+// is compared and written is canonical. Every invalid value is reported at once.
+func NormalizeValues(variables []Variable) ([]Variable, error) {
+	if len(config.Normalize) == 0 {
+		return variables, nil
+	}
+	result := make([]Variable, 0, len(variables))
+	problems := []string{}
+	for _, v := range variables {
+		normalized, err := normalizeValue(v.Name, v.Value)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s %v", v.Name, err))
+			continue
+		}
+		v.Value = normalized
+		result = append(result, v)
+	}
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("invalid values: %s", strings.Join(problems, "; "))
+	}
+	return result, nil
+}
+
+// normalizedEqual reports whether two values are the same once normalized, so a GitHub value
+// differing only cosmetically ("True" vs "true") isn't shown as a change
+func normalizedEqual(name, local, remote string) bool {
+	if len(config.Normalize) == 0 {
+		return false
+	}
+	a, errA := normalizeValue(name, local)
+	b, errB := normalizeValue(name, remote)
+	return errA == nil && errB == nil && a == b
+}
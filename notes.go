@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// defaultNotesFile is the sidecar file notes are kept in, next to the input
+const defaultNotesFile = "variable-notes.json"
+
+// NotesFile is the sidecar metadata store: why each variable exists, per target. GitHub has
+// no field for it, so notes from the input's Note column are kept here and shown in diffs,
+// backups, and exports.
+type NotesFile struct {
+	Targets map[string]map[string]string `json:"targets"` // Target (owner/repo or owner/repo (env)) -> name -> note
+}
+
+// csvNotes maps name keys to the Note column of the input CSV, when it has one
+var csvNotes = map[string]string{}
+
+// targetNotes are the sidecar's notes for the target being synced
+var targetNotes = map[string]string{}
+
+// loadNotesFile reads the sidecar file; a missing file has no notes
+func loadNotesFile(path string) (*NotesFile, error) {
+	notes := &NotesFile{Targets: map[string]map[string]string{}}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return notes, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, notes); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if notes.Targets == nil {
+		notes.Targets = map[string]map[string]string{}
+	}
+	return notes, nil
+}
+
+// notesFor returns a target's notes keyed per --name-case
+func (n *NotesFile) notesFor(owner, repo, environment string) map[string]string {
+	notes := map[string]string{}
+	for name, note := range n.Targets[targetName(owner, repo, environment)] {
+		notes[nameKey(name)] = note
+	}
+	return notes
+}
+
+// loadTargetNotes reads the input's Note column and the sidecar's notes for a target
+func loadTargetNotes(source VariableSource, owner, repo, environment string) error {
+	csvNotes, targetNotes = map[string]string{}, map[string]string{}
+	if fs, ok := source.(fileSource); ok && !strings.Contains(fs.path, "://") && inputFormat(fs.path) == "csv" {
+		notes, err := readCSVColumn(fs.path, "note", "notes", "description")
+		if err != nil {
+			return fmt.Errorf("failed to read notes from %s: %w", fs.path, err)
+		}
+		csvNotes = notes
+	}
+	sidecar, err := loadNotesFile(*notesFile)
+	if err != nil {
+		return fmt.Errorf("failed to read notes: %w", err)
+	}
+	targetNotes = sidecar.notesFor(owner, repo, environment)
+	return nil
+}
+
+// noteOf returns why a variable exists: the input's note, else the one kept in the sidecar
+func noteOf(name string) string {
+	if note, ok := csvNotes[nameKey(name)]; ok {
+		return note
+	}
+	return targetNotes[nameKey(name)]
+}
+
+// noteSuffix formats a variable's note as a gray "# note" suffix for diff lines
+func noteSuffix(name string) string {
+	note := noteOf(name)
+	if note == "" {
+		return ""
+	}
+	return fmt.Sprintf(" %s# %s%s", ColorGray, note, ColorReset)
+}
+
+// saveTargetNotes stores the input's notes for a target in the sidecar, so they survive the
+// round trip through GitHub. Variables not in the input keep their stored notes.
+func saveTargetNotes(owner, repo, environment string) {
+	if len(csvNotes) == 0 {
+		return
+	}
+	sidecar, err := loadNotesFile(*notesFile)
+	if err != nil {
+		fmt.Printf("⚠️  Warning: notes not saved: %v\n", err)
+		return
+	}
+	target := targetName(owner, repo, environment)
+	stored := sidecar.Targets[target]
+	if stored == nil {
+		stored = map[string]string{}
+	}
+	changed := 0
+	for key, note := range csvNotes {
+		if stored[key] != note {
+			stored[key] = note
+			changed++
+		}
+	}
+	if changed == 0 {
+		return
+	}
+	sidecar.Targets[target] = stored
+
+	data, err := json.MarshalIndent(sidecar, "", "  ")
+	if err == nil {
+		err = os.WriteFile(*notesFile, append(data, '\n'), 0644)
+	}
+	if err != nil {
+		fmt.Printf("⚠️  Warning: notes not saved: %v\n", err)
+		return
+	}
+	fmt.Printf("📝 Saved %d note(s) to %s\n", changed, *notesFile)
+}
+
+// noteComment formats a note as a "# note" comment line at the given indentation, or "" when
+// there is no note
+func noteComment(indent, note string) string {
+	if note == "" {
+		return ""
+	}
+	return indent + "# " + strings.Join(strings.Fields(note), " ") + "\n"
+}
+
+// attachExportNotes adds the sidecar's notes to every exported target, for comments in exports
+func attachExportNotes(owner, repo string, exports []environmentExport) {
+	sidecar, err := loadNotesFile(*notesFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Warning: notes not included: %v\n", err)
+		return
+	}
+	for i := range exports {
+		exports[i].Notes = sidecar.notesFor(owner, repo, exports[i].Environment)
+	}
+}
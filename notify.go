@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Notifier formats
+const (
+	NotifySlack   = "slack"
+	NotifyTeams   = "teams"
+	NotifyDiscord = "discord"
+	NotifyJSON    = "json"
+)
+
+// maxNotifyNames caps how many variable names are listed per category in a message
+const maxNotifyNames = 20
+
+// NotifierConfig is a webhook from the config file. The URL may reference environment
+// variables (${SLACK_WEBHOOK_URL}) so it doesn't have to be committed.
+type NotifierConfig struct {
+	URL    string `json:"url"`
+	Format string `json:"format"` // slack, teams, discord, or json; detected from the URL when empty
+}
+
+// notifiers returns the webhooks from --notify-webhook and the config file
+func notifiers() []NotifierConfig {
+	result := []NotifierConfig{}
+	for _, u := range strings.Split(*notifyWebhook, ",") {
+		if u = strings.TrimSpace(u); u != "" {
+			result = append(result, NotifierConfig{URL: u})
+		}
+	}
+	for _, n := range config.Notify {
+		result = append(result, NotifierConfig{URL: os.ExpandEnv(n.URL), Format: n.Format})
+	}
+
+	// Webhook URLs embed their credential
+	for i := range result {
+		redactor.Add(result[i].URL)
+		if result[i].Format == "" {
+			result[i].Format = detectNotifyFormat(result[i].URL)
+		}
+	}
+	return result
+}
+
+// detectNotifyFormat picks the payload format from the webhook host
+func detectNotifyFormat(webhook string) string {
+	parsed, err := url.Parse(webhook)
+	if err != nil {
+		return NotifyJSON
+	}
+	host := strings.ToLower(parsed.Host)
+	switch {
+	case host == "hooks.slack.com":
+		return NotifySlack
+	case strings.HasSuffix(host, ".webhook.office.com"), host == "outlook.office.com",
+		strings.HasSuffix(host, ".logic.azure.com"):
+		return NotifyTeams
+	case (host == "discord.com" || host == "discordapp.com") && strings.HasPrefix(parsed.Path, "/api/webhooks/"):
+		return NotifyDiscord
+	default:
+		return NotifyJSON
+	}
+}
+
+// sendNotifications posts the report to every configured webhook. Failures are warnings:
+// the sync itself already happened.
+func sendNotifications(report *SyncReport) {
+	targets := notifiers()
+	if len(targets) == 0 {
+		return
+	}
+
+	for _, n := range targets {
+		payload, err := notificationPayload(n.Format, report)
+		if err != nil {
+			fmt.Printf("⚠️  Notification (%s): %v\n", n.Format, err)
+			continue
+		}
+		err = postWebhook(n.URL, payload)
+		if err != nil {
+			fmt.Printf("⚠️  Notification (%s) failed: %v\n", n.Format, err)
+			continue
+		}
+		fmt.Printf("📣 Sent %s notification\n", n.Format)
+	}
+}
+
+// notificationTitle is the headline shared by every format
+func notificationTitle(report *SyncReport) string {
+	if report.Mode == "diff" {
+		return fmt.Sprintf("Drift detected in %s", report.Target())
+	}
+	if len(report.Failed) > 0 || len(report.NotSynced) > 0 {
+		return fmt.Sprintf("Variable sync to %s finished with errors", report.Target())
+	}
+	return fmt.Sprintf("Variable sync to %s completed", report.Target())
+}
+
+// notificationSection is one labelled list of names in a message
+type notificationSection struct {
+	Label string
+	Names []string
+}
+
+// notificationSections returns the non-empty name lists of a report
+func notificationSections(report *SyncReport) []notificationSection {
+	failed := []string{}
+	for _, f := range report.Failed {
+		failed = append(failed, f.Name)
+	}
+
+	var sections []notificationSection
+	if report.Mode == "diff" {
+		sections = []notificationSection{
+			{"To create", report.Created},
+			{"To update", report.Updated},
+			{"Only in GitHub", report.RemoteOnly},
+		}
+	} else {
+		sections = []notificationSection{
+			{"Created", report.Created},
+			{"Updated", report.Updated},
+			{"Deleted", report.Deleted},
+			{"Failed", failed},
+			{"Not synced", report.NotSynced},
+		}
+	}
+
+	result := []notificationSection{}
+	for _, s := range sections {
+		if len(s.Names) > 0 {
+			result = append(result, s)
+		}
+	}
+	return result
+}
+
+// joinNames lists names up to maxNotifyNames, wrapping each with quote
+func joinNames(names []string, quote string) string {
+	shown := names
+	if len(shown) > maxNotifyNames {
+		shown = shown[:maxNotifyNames]
+	}
+	parts := make([]string, len(shown))
+	for i, n := range shown {
+		parts[i] = quote + n + quote
+	}
+	text := strings.Join(parts, ", ")
+	if len(names) > len(shown) {
+		text += fmt.Sprintf(" and %d more", len(names)-len(shown))
+	}
+	return text
+}
+
+// notificationPayload renders the report in a webhook format. Only names and counts are sent, never values.
+func notificationPayload(format string, report *SyncReport) (interface{}, error) {
+	title := notificationTitle(report)
+	sections := notificationSections(report)
+
+	switch format {
+	case NotifySlack:
+		blocks := []interface{}{
+			map[string]interface{}{"type": "header", "text": map[string]string{"type": "plain_text", "text": title}},
+		}
+		for _, s := range sections {
+			blocks = append(blocks, map[string]interface{}{
+				"type": "section",
+				"text": map[string]string{"type": "mrkdwn", "text": fmt.Sprintf("*%s (%d)*\n%s", s.Label, len(s.Names), joinNames(s.Names, "`"))},
+			})
+		}
+		return map[string]interface{}{"text": title, "blocks": blocks}, nil
+
+	case NotifyTeams:
+		facts := []map[string]string{}
+		for _, s := range sections {
+			facts = append(facts, map[string]string{"title": fmt.Sprintf("%s (%d)", s.Label, len(s.Names)), "value": joinNames(s.Names, "")})
+		}
+		card := map[string]interface{}{
+			"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+			"type":    "AdaptiveCard",
+			"version": "1.4",
+			"body": []interface{}{
+				map[string]interface{}{"type": "TextBlock", "size": "Medium", "weight": "Bolder", "wrap": true, "text": title},
+				map[string]interface{}{"type": "FactSet", "facts": facts},
+			},
+		}
+		return map[string]interface{}{
+			"type": "message",
+			"attachments": []interface{}{
+				map[string]interface{}{"contentType": "application/vnd.microsoft.card.adaptive", "content": card},
+			},
+		}, nil
+
+	case NotifyDiscord:
+		color := 0x2EB67D // green
+		if report.Mode == "diff" {
+			color = 0xECB22E // amber
+		} else if len(report.Failed) > 0 || len(report.NotSynced) > 0 {
+			color = 0xE01E5A // red
+		}
+		fields := []map[string]interface{}{}
+		for _, s := range sections {
+			fields = append(fields, map[string]interface{}{"name": fmt.Sprintf("%s (%d)", s.Label, len(s.Names)), "value": joinNames(s.Names, "`")})
+		}
+		return map[string]interface{}{
+			"embeds": []interface{}{map[string]interface{}{"title": title, "color": color, "fields": fields}},
+		}, nil
+
+	case NotifyJSON:
+		return map[string]interface{}{"title": title, "report": report}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown notification format %q (use slack, teams, discord, or json)", format)
+	}
+}
+
+// postWebhook sends a JSON payload to a webhook URL
+func postWebhook(webhook string, payload interface{}) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	resp, err := httpClient.Post(webhook, "application/json", bytes.NewReader(data))
+	if err != nil {
+		// The error text includes the URL, which carries the webhook's credential
+		return fmt.Errorf("%s", safeValue(err.Error()))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// azureBlobAPIVersion is the Blob service version sent with Azure Storage requests
+const azureBlobAPIVersion = "2021-08-06"
+
+// isObjectStoreURL reports whether a location is an s3://, gs://, or az:// URL
+func isObjectStoreURL(location string) bool {
+	for _, scheme := range []string{"s3://", "gs://", "az://"} {
+		if strings.HasPrefix(location, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// splitObjectURL splits scheme://first/rest into its parts
+func splitObjectURL(location string) (scheme, first, rest string, err error) {
+	scheme, path, _ := strings.Cut(location, "://")
+	first, rest, _ = strings.Cut(path, "/")
+	if first == "" || rest == "" {
+		return "", "", "", fmt.Errorf("invalid object URL %q", location)
+	}
+	return scheme, first, rest, nil
+}
+
+// readObject downloads an object from S3 (s3://bucket/key), Google Cloud Storage
+// (gs://bucket/object), or Azure Blob Storage (az://account/container/blob)
+func readObject(location string) ([]byte, error) {
+	req, err := objectRequest("GET", location, nil)
+	if err != nil {
+		return nil, err
+	}
+	return doObjectRequest(req, location, http.StatusOK)
+}
+
+// writeObject uploads data to an object URL, replacing any existing object
+func writeObject(location string, data []byte) error {
+	req, err := objectRequest("PUT", location, data)
+	if err != nil {
+		return err
+	}
+	want := http.StatusOK
+	if strings.HasPrefix(location, "az://") {
+		want = http.StatusCreated
+	}
+	_, err = doObjectRequest(req, location, want)
+	return err
+}
+
+// objectRequest builds an authenticated request for the object store behind location
+func objectRequest(method, location string, data []byte) (*http.Request, error) {
+	scheme, first, rest, err := splitObjectURL(location)
+	if err != nil {
+		return nil, err
+	}
+
+	var body io.Reader
+	if data != nil {
+		body = bytes.NewReader(data)
+	}
+
+	switch scheme {
+	case "s3":
+		creds, err := loadAWSCredentials()
+		if err != nil {
+			return nil, err
+		}
+		endpoint := fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", first, creds.Region, escapeObjectPath(rest))
+		req, err := http.NewRequest(method, endpoint, body)
+		if err != nil {
+			return nil, err
+		}
+		signAWSRequest(req, data, "s3", creds, time.Now())
+		return req, nil
+
+	case "gs":
+		accessToken, err := gcpAccessToken()
+		if err != nil {
+			return nil, err
+		}
+		endpoint := fmt.Sprintf("https://storage.googleapis.com/storage/v1/b/%s/o/%s?alt=media", first, url.PathEscape(rest))
+		if method == "PUT" {
+			// Uploads go to the media upload endpoint
+			method = "POST"
+			endpoint = fmt.Sprintf("https://storage.googleapis.com/upload/storage/v1/b/%s/o?uploadType=media&name=%s", first, url.QueryEscape(rest))
+		}
+		req, err := http.NewRequest(method, endpoint, body)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		if data != nil {
+			req.Header.Set("Content-Type", "application/octet-stream")
+		}
+		return req, nil
+
+	case "az":
+		accessToken, err := azureAccessToken("https://storage.azure.com/")
+		if err != nil {
+			return nil, err
+		}
+		endpoint := fmt.Sprintf("https://%s.blob.core.windows.net/%s", first, escapeObjectPath(rest))
+		req, err := http.NewRequest(method, endpoint, body)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+		req.Header.Set("x-ms-version", azureBlobAPIVersion)
+		if data != nil {
+			req.Header.Set("x-ms-blob-type", "BlockBlob")
+		}
+		return req, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported object URL scheme %q", scheme)
+	}
+}
+
+// doObjectRequest sends an object store request and returns the body on the expected status
+func doObjectRequest(req *http.Request, location string, want int) ([]byte, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != want {
+		return nil, fmt.Errorf("%s: storage returned status %d: %s", location, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// escapeObjectPath escapes each segment of a key while keeping the slashes
+func escapeObjectPath(key string) string {
+	segments := strings.Split(key, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// objectBackupDestination uploads backups under an object store prefix
+type objectBackupDestination struct {
+	prefix string // e.g. s3://bucket/backups
+}
+
+func (d objectBackupDestination) Describe() string { return d.prefix }
+
+func (d objectBackupDestination) Store(name string, data []byte) (string, error) {
+	location := strings.TrimRight(d.prefix, "/") + "/" + name
+	err := writeObject(location, data)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload backup: %w", err)
+	}
+	return location, nil
+}
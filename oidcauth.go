@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"os"
+	"sync"
+	"time"
+)
+
+// oidcTokenProvider exchanges the workflow's GitHub Actions OIDC token for a short-lived
+// GitHub token at a token broker, and exchanges again when that token nears expiry
+type oidcTokenProvider struct {
+	brokerURL string
+	audience  string
+	owner     string
+	repo      string
+	base      http.RoundTripper // Transport used for the exchange (bypasses appAuthTransport)
+
+	mu        sync.Mutex
+	token     string
+	expiresAt time.Time
+}
+
+// newOIDCTokenProviderFromEnv configures OIDC auth from --oidc-broker or SYNC_OIDC_BROKER_URL.
+// It returns nil when no broker is configured.
+func newOIDCTokenProviderFromEnv(owner, repo string) (*oidcTokenProvider, error) {
+	broker := *oidcBroker
+	if broker == "" {
+		broker = os.Getenv("SYNC_OIDC_BROKER_URL")
+	}
+	if broker == "" {
+		return nil, nil
+	}
+	if os.Getenv("ACTIONS_ID_TOKEN_REQUEST_URL") == "" || os.Getenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN") == "" {
+		return nil, fmt.Errorf("no OIDC token available; run inside GitHub Actions with `permissions: id-token: write`")
+	}
+	audience := os.Getenv("SYNC_OIDC_AUDIENCE")
+	if audience == "" {
+		audience = broker
+	}
+	return &oidcTokenProvider{brokerURL: broker, audience: audience, owner: owner, repo: repo}, nil
+}
+
+// Token returns a valid GitHub token, exchanging a new OIDC token when close to expiry
+func (p *oidcTokenProvider) Token() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.token != "" && (p.expiresAt.IsZero() || time.Until(p.expiresAt) > appTokenRefreshMargin) {
+		return p.token, nil
+	}
+	return p.refreshLocked()
+}
+
+// ForceRefresh discards the current token (e.g. after a 401) and exchanges a new one
+func (p *oidcTokenProvider) ForceRefresh() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.refreshLocked()
+}
+
+func (p *oidcTokenProvider) refreshLocked() (string, error) {
+	idToken, err := p.requestIDToken()
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := json.Marshal(map[string]string{"owner": p.owner, "repo": p.repo})
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequest("POST", p.brokerURL, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+idToken)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	body, status, err := p.do(req)
+	if err != nil {
+		return "", fmt.Errorf("token broker: %w", err)
+	}
+	if status != 200 && status != 201 {
+		return "", fmt.Errorf("token broker returned status %d: %s", status, string(body))
+	}
+
+	// The broker answers like GitHub's installation token endpoint, so it can pass that through
+	var response struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return "", fmt.Errorf("token broker: %w", err)
+	}
+	if response.Token == "" {
+		return "", fmt.Errorf("token broker returned no token")
+	}
+
+	p.token = response.Token
+	p.expiresAt = response.ExpiresAt
+	redactor.Add(p.token)
+	return p.token, nil
+}
+
+// requestIDToken asks the Actions runtime for an OIDC token with the broker as audience
+func (p *oidcTokenProvider) requestIDToken() (string, error) {
+	url, err := neturl.Parse(os.Getenv("ACTIONS_ID_TOKEN_REQUEST_URL"))
+	if err != nil {
+		return "", fmt.Errorf("invalid ACTIONS_ID_TOKEN_REQUEST_URL: %w", err)
+	}
+	query := url.Query()
+	query.Set("audience", p.audience)
+	url.RawQuery = query.Encode()
+
+	req, err := http.NewRequest("GET", url.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+os.Getenv("ACTIONS_ID_TOKEN_REQUEST_TOKEN"))
+	req.Header.Set("Accept", "application/json")
+
+	body, status, err := p.do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the OIDC token: %w", err)
+	}
+	if status != 200 {
+		return "", fmt.Errorf("failed to get the OIDC token: status %d: %s", status, string(body))
+	}
+	var response struct {
+		Value string `json:"value"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil || response.Value == "" {
+		return "", fmt.Errorf("failed to get the OIDC token: unexpected response")
+	}
+	redactor.Add(response.Value)
+	return response.Value, nil
+}
+
+// do sends a request over the base transport and reads the whole response
+func (p *oidcTokenProvider) do(req *http.Request) ([]byte, int, error) {
+	base := p.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := (&http.Client{Transport: base, Timeout: httpClient.Timeout}).Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return body, resp.StatusCode, err
+}
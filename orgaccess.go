@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	neturl "net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// defaultOrgAccessFile declares which repositories may use each organization variable
+const defaultOrgAccessFile = "org-access.yaml"
+
+// orgRepository is a repository in the selected-repositories API
+type orgRepository struct {
+	ID   int64  `json:"id"`
+	Name string `json:"name"`
+}
+
+// orgAccessChange is the reconciliation of one organization variable's repository list
+type orgAccessChange struct {
+	Name    string
+	Add     []orgRepository
+	Remove  []orgRepository
+	Desired []int64
+}
+
+// handleOrgAccess reconciles which repositories can use organization variables with
+// visibility "selected", from a file mapping each variable name to its repository names
+func handleOrgAccess(args []string, token, owner string) {
+	fs := flag.NewFlagSet("org-access", flag.ExitOnError)
+	org := fs.String("org", owner, "Organization (defaults to GITHUB_OWNER)")
+	file := fs.String("file", defaultOrgAccessFile, "YAML or JSON file mapping variable names to repository names")
+	fs.Parse(args)
+
+	declared, err := loadOrgAccessFile(*file)
+	if err != nil {
+		fatal(exitValidation, "Error reading %s: %v", *file, err)
+	}
+	fmt.Printf("🏢 Reconciling repository access of %d organization variable(s) in %s\n", len(declared), *org)
+
+	names := make([]string, 0, len(declared))
+	for name := range declared {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	repoIDs := map[string]int64{}
+	changes := []orgAccessChange{}
+	for _, name := range names {
+		change, err := planOrgAccess(token, *org, name, declared[name], repoIDs)
+		if err != nil {
+			fatal(exitFailure, "Error: %s: %v", name, err)
+		}
+		if len(change.Add)+len(change.Remove) == 0 {
+			fmt.Printf("✅ %s: %d repositories, up to date\n", name, len(change.Desired))
+			continue
+		}
+		fmt.Printf("%s~ %s:%s\n", ColorYellow, name, ColorReset)
+		for _, r := range change.Add {
+			fmt.Printf("  %s+ %s%s\n", ColorGreen, r.Name, ColorReset)
+		}
+		for _, r := range change.Remove {
+			fmt.Printf("  %s- %s%s\n", ColorRed, r.Name, ColorReset)
+		}
+		changes = append(changes, change)
+	}
+
+	if len(changes) == 0 {
+		fmt.Println("\n✅ Repository access matches the file")
+		return
+	}
+	if *diffMode {
+		fmt.Println("ℹ️  Diff mode: No changes were made")
+		return
+	}
+	if !askYesNo(fmt.Sprintf("\n⚠️  Change repository access of %d variable(s)?", len(changes))) {
+		fmt.Println("\n❌ Cancelled by user")
+		exit(exitCancelled)
+	}
+
+	failed := 0
+	for _, change := range changes {
+		url := fmt.Sprintf("%s/orgs/%s/actions/variables/%s/repositories", githubAPIURL, *org, neturl.PathEscape(change.Name))
+		err := githubSendJSON(token, "PUT", url, map[string][]int64{"selected_repository_ids": change.Desired}, 204, nil)
+		if err != nil {
+			fmt.Printf("❌ Error updating %s: %v\n", change.Name, err)
+			failed++
+			continue
+		}
+		fmt.Printf("✅ Updated %s: +%d -%d repositories\n", change.Name, len(change.Add), len(change.Remove))
+	}
+	if failed > 0 {
+		runError = fmt.Sprintf("%d variable(s) failed", failed)
+		exit(exitPartial)
+	}
+}
+
+// loadOrgAccessFile reads NAME: [repo, ...] entries
+func loadOrgAccessFile(path string) (map[string][]string, error) {
+	data, _, err := readTextFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var parsed interface{}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &parsed)
+	} else {
+		parsed, err = ParseYAML(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entries, ok := parsed.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("top level must map variable names to lists of repositories")
+	}
+	declared := map[string][]string{}
+	for name, value := range entries {
+		list, ok := value.([]interface{})
+		if !ok && value != nil {
+			return nil, fmt.Errorf("%s must be a list of repository names", name)
+		}
+		repos := []string{}
+		for _, item := range list {
+			repo, ok := item.(string)
+			if !ok || repo == "" {
+				return nil, fmt.Errorf("%s: repository names must be strings", name)
+			}
+			// owner/repo is accepted; the owner is the organization anyway
+			if _, short, found := strings.Cut(repo, "/"); found {
+				repo = short
+			}
+			repos = append(repos, repo)
+		}
+		declared[strings.ToUpper(name)] = repos
+	}
+	return declared, nil
+}
+
+// planOrgAccess compares a variable's selected repositories with the declared ones.
+// repoIDs caches repository IDs across variables.
+func planOrgAccess(token, org, name string, repos []string, repoIDs map[string]int64) (orgAccessChange, error) {
+	change := orgAccessChange{Name: name, Desired: []int64{}}
+	orgVariableURL := fmt.Sprintf("%s/orgs/%s/actions/variables/%s", githubAPIURL, org, neturl.PathEscape(name))
+
+	var variable struct {
+		Visibility string `json:"visibility"`
+	}
+	if err := githubGetJSON(token, orgVariableURL, &variable); err != nil {
+		return change, err
+	}
+	if variable.Visibility != "selected" {
+		return change, fmt.Errorf("visibility is %q; a repository list only applies to visibility \"selected\"", variable.Visibility)
+	}
+
+	current, err := listSelectedRepositories(token, orgVariableURL+"/repositories")
+	if err != nil {
+		return change, err
+	}
+
+	wanted := map[int64]bool{}
+	for _, repo := range repos {
+		id, ok := repoIDs[strings.ToLower(repo)]
+		if !ok {
+			var info orgRepository
+			if err := githubGetJSON(token, fmt.Sprintf("%s/repos/%s/%s", githubAPIURL, org, repo), &info); err != nil {
+				return change, fmt.Errorf("repository %s: %w", repo, err)
+			}
+			id = info.ID
+			repoIDs[strings.ToLower(repo)] = id
+		}
+		if wanted[id] {
+			continue
+		}
+		wanted[id] = true
+		change.Desired = append(change.Desired, id)
+	}
+
+	have := map[int64]bool{}
+	for _, r := range current {
+		have[r.ID] = true
+		if !wanted[r.ID] {
+			change.Remove = append(change.Remove, r)
+		}
+	}
+	for _, repo := range repos {
+		if id := repoIDs[strings.ToLower(repo)]; !have[id] {
+			have[id] = true
+			change.Add = append(change.Add, orgRepository{ID: id, Name: repo})
+		}
+	}
+	return change, nil
+}
+
+// listSelectedRepositories returns every repository selected for an organization variable
+func listSelectedRepositories(token, baseURL string) ([]orgRepository, error) {
+	repos := []orgRepository{}
+	url := baseURL + "?per_page=100"
+	for {
+		resp, body, err := preflightGet(token, url)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != 200 {
+			return nil, newAPIError(resp, body)
+		}
+		var page struct {
+			Repositories []orgRepository `json:"repositories"`
+		}
+		if err := json.Unmarshal(body, &page); err != nil {
+			return nil, err
+		}
+		repos = append(repos, page.Repositories...)
+
+		next, _, err := nextPageURL(baseURL, resp.Header)
+		if err != nil {
+			return nil, err
+		}
+		if next == "" {
+			return repos, nil
+		}
+		url = next
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// noOwner labels variables without an owner in grouped output
+const noOwner = "(no owner)"
+
+// OwnershipConfig maps variables to the teams that own them
+type OwnershipConfig struct {
+	Owners      map[string]string `json:"owners"`       // Name or glob pattern -> team; an Owner column in the CSV wins
+	RequireTeam bool              `json:"require_team"` // Syncs must be limited to one team's variables with --team
+}
+
+// csvOwners maps name keys to the Owner column of the input CSV, when it has one
+var csvOwners = map[string]string{}
+
+// loadInputOwners reads the Owner column of a local CSV input, for grouping and --team
+func loadInputOwners(source VariableSource) error {
+	csvOwners = map[string]string{}
+	fs, ok := source.(fileSource)
+	if !ok || strings.Contains(fs.path, "://") || inputFormat(fs.path) != "csv" {
+		return nil
+	}
+	owners, err := readCSVColumn(fs.path, "owner", "team")
+	if err != nil {
+		return fmt.Errorf("failed to read owners from %s: %w", fs.path, err)
+	}
+	csvOwners = owners
+	return nil
+}
+
+// ownershipConfigured reports whether any variable has an owner
+func ownershipConfigured() bool {
+	return len(csvOwners) > 0 || len(config.Ownership.Owners) > 0
+}
+
+// ownerOf returns the team owning a variable: the CSV's Owner column, else the config's
+// exact name entry, else its longest matching pattern, else ""
+func ownerOf(name string) string {
+	if owner, ok := csvOwners[nameKey(name)]; ok {
+		return owner
+	}
+	owner, _ := patternValue(config.Ownership.Owners, name)
+	return owner
+}
+
+// scopeToTeam limits a diff to the variables --team owns. When the config requires a team,
+// a sync without --team stops here; diff mode may still show everything.
+func scopeToTeam(diff DiffResult) DiffResult {
+	if *team == "" {
+		if config.Ownership.RequireTeam && !*diffMode {
+			fatal(exitValidation, "This repository's config requires --team to limit a sync to one team's variables")
+		}
+		return diff
+	}
+
+	keep := func(name string) bool { return strings.EqualFold(ownerOf(name), *team) }
+	scoped := DiffResult{New: []Variable{}, Updated: []VariableChange{}, Unchanged: []Variable{}, Deleted: []Variable{}, Ignored: diff.Ignored}
+	for _, v := range diff.New {
+		if keep(v.Name) {
+			scoped.New = append(scoped.New, v)
+		}
+	}
+	for _, c := range diff.Updated {
+		if keep(c.Name) {
+			scoped.Updated = append(scoped.Updated, c)
+		}
+	}
+	for _, v := range diff.Unchanged {
+		if keep(v.Name) {
+			scoped.Unchanged = append(scoped.Unchanged, v)
+		}
+	}
+	for _, v := range diff.Deleted {
+		if keep(v.Name) {
+			scoped.Deleted = append(scoped.Deleted, v)
+		}
+	}
+	skipped := len(diff.New) + len(diff.Updated) - len(scoped.New) - len(scoped.Updated)
+	fmt.Printf("👥 Team %s: %d change(s) in scope, %d owned by others left alone\n", *team, len(scoped.New)+len(scoped.Updated), skipped)
+	return scoped
+}
+
+// groupDiffByOwner splits a diff into one diff per owner, unowned variables last
+func groupDiffByOwner(diff DiffResult) ([]string, map[string]*DiffResult) {
+	groups := map[string]*DiffResult{}
+	group := func(name string) *DiffResult {
+		owner := ownerOf(name)
+		if owner == "" {
+			owner = noOwner
+		}
+		if groups[owner] == nil {
+			groups[owner] = &DiffResult{}
+		}
+		return groups[owner]
+	}
+	for _, v := range diff.New {
+		g := group(v.Name)
+		g.New = append(g.New, v)
+	}
+	for _, c := range diff.Updated {
+		g := group(c.Name)
+		g.Updated = append(g.Updated, c)
+	}
+	for _, v := range diff.Unchanged {
+		g := group(v.Name)
+		g.Unchanged = append(g.Unchanged, v)
+	}
+	for _, v := range diff.Deleted {
+		g := group(v.Name)
+		g.Deleted = append(g.Deleted, v)
+	}
+
+	owners := make([]string, 0, len(groups))
+	for owner := range groups {
+		if owner != noOwner {
+			owners = append(owners, owner)
+		}
+	}
+	sort.Strings(owners)
+	if groups[noOwner] != nil {
+		owners = append(owners, noOwner)
+	}
+	return owners, groups
+}
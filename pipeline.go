@@ -0,0 +1,65 @@
+package main
+
+import "fmt"
+
+// LoadDesiredVariables reads the desired variables from the configured source (--source,
+// --input, --values) and applies mapping rules, vault resolution, and repo reference expansion
+func LoadDesiredVariables(token, owner, repo, environment string) ([]Variable, VariableSource, error) {
+	source, err := newVariableSource(*sourceSpec, *inputFile, *valuesFile, token, repo, environment)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	variables, err := source.Load()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", source.Describe(), err)
+	}
+
+	if err := loadInputOwners(source); err != nil {
+		return nil, nil, err
+	}
+	if err := loadTargetNotes(source, owner, repo, environment); err != nil {
+		return nil, nil, err
+	}
+
+	// Apply prefix filtering and renames from the config file
+	variables = config.Mapping.Apply(variables)
+	variables, err = NormalizeNames(variables)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fmt.Printf("📝 Read %d variables from %s\n", len(variables), source.Describe())
+
+	// Resolve vault:<path>#<key> references before comparing
+	variables, err = ResolveVaultReferences(variables)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve vault references: %w", err)
+	}
+
+	// Expand {{ repo.NAME }} references against the repository-level variables
+	if hasRepoReferences(variables) {
+		if environment == "" {
+			return nil, nil, fmt.Errorf("{{ repo.NAME }} references are only supported when syncing environment variables")
+		}
+
+		fmt.Println("🔗 Resolving references to repository variables...")
+		repoVariables, err := FetchGitHubVariables(token, owner, repo, "")
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to fetch repository variables: %w", err)
+		}
+		variables, err = ExpandRepoReferences(variables, repoVariables)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to resolve references: %w", err)
+		}
+	}
+
+	// Normalize values last, once references have been replaced by what will be written
+	variables, err = NormalizeValues(variables)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	registerSensitiveValues(variables)
+	return variables, source, nil
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// defaultPolicyFile is loaded automatically when it exists
+const defaultPolicyFile = "sync-policy.yaml"
+
+// Policy is a policy file: rules every variable change must satisfy
+type Policy struct {
+	Rules []PolicyRule `json:"rules"`
+}
+
+// PolicyRule constrains the values of matching variables. Every condition that is set must
+// hold; a rule without environments applies to every target, including repository variables.
+type PolicyRule struct {
+	Name         string   `json:"name"`
+	Message      string   `json:"message"`      // Shown on a violation instead of the generated one
+	Environments []string `json:"environments"` // Glob patterns of environment names the rule applies to
+	Variables    []string `json:"variables"`    // Glob patterns of variable names; all when empty
+
+	Required    bool          `json:"required"`     // Each name in variables (no globs) must be in the desired state
+	Forbidden   bool          `json:"forbidden"`    // Matching variables must not be set at all
+	Equals      *policyValue  `json:"equals"`       // The value must be exactly this
+	OneOf       []policyValue `json:"one_of"`       // The value must be one of these
+	Matches     string        `json:"matches"`      // The value must match this regular expression
+	NotMatches  string        `json:"not_matches"`  // The value must not match this regular expression
+	NotContains []string      `json:"not_contains"` // The value must not contain any of these (case-insensitive)
+	MaxLength   int           `json:"max_length"`   // The value may be at most this many bytes
+
+	matches, notMatches *regexp.Regexp
+}
+
+// policyValue is a value a rule compares variables with. Variable values are text, so an
+// unquoted number or boolean counts as written: equals: false, one_of: [1.10, 1.20].
+type policyValue string
+
+func (v *policyValue) UnmarshalJSON(data []byte) error {
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch value := value.(type) {
+	case string:
+		*v = policyValue(value)
+	case float64, bool:
+		*v = policyValue(data) // The number's text, which decodeConfigFile keeps from the YAML
+	default:
+		return fmt.Errorf("expected a string, number, or boolean, not %s", data)
+	}
+	return nil
+}
+
+// PolicyViolation is one variable breaking one rule
+type PolicyViolation struct {
+	Rule     string `json:"rule"`
+	Variable string `json:"variable"`
+	Message  string `json:"message"`
+}
+
+// policy is the loaded policy; nil when no policy file is present
+var policy *Policy
+
+// LoadPolicy reads and validates a policy file. A missing file is only an error when required is set.
+func LoadPolicy(path string, required bool) (*Policy, error) {
+	p := &Policy{}
+	err := decodeConfigFile(path, p)
+	if err != nil {
+		if os.IsNotExist(err) && !required {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	for i := range p.Rules {
+		r := &p.Rules[i]
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rules[%d]", i)
+		}
+		if r.Required && (len(r.Variables) == 0 || strings.ContainsAny(strings.Join(r.Variables, ""), "*?[")) {
+			return nil, fmt.Errorf("%s: rule %s: required needs variable names without wildcards", path, r.Name)
+		}
+		for _, pattern := range append(append([]string{}, r.Variables...), r.Environments...) {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("%s: rule %s: invalid pattern %q", path, r.Name, pattern)
+			}
+		}
+		if r.Matches != "" {
+			if r.matches, err = regexp.Compile(r.Matches); err != nil {
+				return nil, fmt.Errorf("%s: rule %s: matches: %w", path, r.Name, err)
+			}
+		}
+		if r.NotMatches != "" {
+			if r.notMatches, err = regexp.Compile(r.NotMatches); err != nil {
+				return nil, fmt.Errorf("%s: rule %s: not_matches: %w", path, r.Name, err)
+			}
+		}
+	}
+	return p, nil
+}
+
+// appliesTo reports whether the rule covers a target environment ("" for repository variables)
+func (r PolicyRule) appliesTo(environment string) bool {
+	return len(r.Environments) == 0 || (environment != "" && matchesAnyPattern(environment, r.Environments))
+}
+
+// covers reports whether the rule constrains a variable name
+func (r PolicyRule) covers(name string) bool {
+	return len(r.Variables) == 0 || matchesAnyPattern(name, r.Variables)
+}
+
+// check returns why value breaks the rule, or "" when it doesn't. Values are never quoted
+// in the reason, since they may be sensitive.
+func (r PolicyRule) check(value string) string {
+	switch {
+	case r.Forbidden:
+		return "must not be set"
+	case r.Equals != nil && value != string(*r.Equals):
+		return fmt.Sprintf("must equal %q", *r.Equals)
+	case len(r.OneOf) > 0 && !containsString(r.oneOf(), value):
+		return fmt.Sprintf("must be one of %s", strings.Join(r.oneOf(), ", "))
+	case r.matches != nil && !r.matches.MatchString(value):
+		return fmt.Sprintf("must match %s", r.Matches)
+	case r.notMatches != nil && r.notMatches.MatchString(value):
+		return fmt.Sprintf("must not match %s", r.NotMatches)
+	case r.MaxLength > 0 && len(value) > r.MaxLength:
+		return fmt.Sprintf("must be at most %d bytes (is %d)", r.MaxLength, len(value))
+	}
+	for _, s := range r.NotContains {
+		if strings.Contains(strings.ToLower(value), strings.ToLower(s)) {
+			return fmt.Sprintf("must not contain %q", s)
+		}
+	}
+	return ""
+}
+
+// Evaluate checks changed variables against the rules for a target. Value conditions apply to
+// changed only, so an existing violation doesn't block unrelated changes; required names are
+// checked against the whole desired state.
+func (p *Policy) Evaluate(environment string, desired, changed []Variable) []PolicyViolation {
+	violations := []PolicyViolation{}
+	if p == nil {
+		return violations
+	}
+	present := map[string]bool{}
+	for _, v := range desired {
+		present[nameKey(v.Name)] = true
+	}
+
+	for _, r := range p.Rules {
+		if !r.appliesTo(environment) {
+			continue
+		}
+		if r.Required {
+			for _, name := range r.Variables {
+				if !present[nameKey(name)] {
+					violations = append(violations, r.violation(name, "is required"))
+				}
+			}
+			continue
+		}
+		for _, v := range changed {
+			if !r.covers(v.Name) {
+				continue
+			}
+			if reason := r.check(v.Value); reason != "" {
+				violations = append(violations, r.violation(v.Name, reason))
+			}
+		}
+	}
+	sort.SliceStable(violations, func(i, j int) bool { return violations[i].Variable < violations[j].Variable })
+	return violations
+}
+
+// oneOf is the values one_of allows
+func (r PolicyRule) oneOf() []string {
+	values := make([]string, len(r.OneOf))
+	for i, v := range r.OneOf {
+		values[i] = string(v)
+	}
+	return values
+}
+
+// violation reports name breaking the rule, with the rule's own message when it has one
+func (r PolicyRule) violation(name, reason string) PolicyViolation {
+	message := r.Message
+	if message == "" {
+		message = name + " " + reason
+	}
+	return PolicyViolation{Rule: r.Name, Variable: name, Message: message}
+}
+
+// changedVariables are the new and updated variables of a diff, with their desired values
+func changedVariables(diff DiffResult) []Variable {
+	changed := append([]Variable{}, diff.New...)
+	for _, c := range diff.Updated {
+		changed = append(changed, Variable{Name: c.Name, Value: c.NewValue})
+	}
+	return changed
+}
+
+// enforcePolicy evaluates the policy against a target's diff before anything is applied.
+// Violations are warnings unless --policy-enforce is set, in which case the run stops.
+func enforcePolicy(owner, repo, environment string, desired []Variable, diff DiffResult) {
+	if err := policyRejection(owner, repo, environment, desired, diff); err != nil {
+		runError = err.Error()
+		fmt.Println("❌ Rejected by policy (--policy-enforce). Nothing was changed")
+		exit(exitValidation)
+	}
+}
+
+// policyRejection prints a target's policy violations and, with --policy-enforce, returns an
+// error when there are any
+func policyRejection(owner, repo, environment string, desired []Variable, diff DiffResult) error {
+	violations := displayPolicyViolations(owner, repo, environment, desired, diff)
+	if violations == 0 || !*policyEnforce {
+		return nil
+	}
+	return fmt.Errorf("%d policy violation(s) in %s", violations, targetName(owner, repo, environment))
+}
+
+// displayPolicyViolations evaluates the policy against a target's diff and prints the result.
+// It returns the number of violations.
+func displayPolicyViolations(owner, repo, environment string, desired []Variable, diff DiffResult) int {
+	if policy == nil {
+		return 0
+	}
+	violations := policy.Evaluate(environment, desired, changedVariables(diff))
+	if len(violations) == 0 {
+		fmt.Printf("🛡️  Policy: %d rule(s) passed\n", len(policy.Rules))
+		return 0
+	}
+
+	fmt.Printf("\n%s🛡️  Policy: %d violation(s) in %s%s\n", ColorRed+ColorBold, len(violations), targetName(owner, repo, environment), ColorReset)
+	for _, v := range violations {
+		fmt.Printf("%s✗ [%s] %s%s\n", ColorRed, v.Rule, v.Message, ColorReset)
+	}
+	if !*policyEnforce {
+		fmt.Println("⚠️  Policy violations are warnings; re-run with --policy-enforce to reject them")
+	}
+	return len(violations)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoadPolicyUnquotedScalars(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"sync-policy.yaml": "rules:\n" +
+			"  - name: debug-off\n" +
+			"    variables: [DEBUG]\n" +
+			"    equals: false\n" +
+			"  - name: go-version\n" +
+			"    variables: [GO_VERSION]\n" +
+			"    one_of: [\"1.10\", 1.20, 2]\n" +
+			"    max_length: 4\n" +
+			"  - name: replicas\n" +
+			"    variables: [REPLICAS]\n" +
+			"    one_of:\n" +
+			"      - 3\n" +
+			"      - 1.50\n",
+		"sync-policy.json": `{"rules": [
+			{"name": "debug-off", "variables": ["DEBUG"], "equals": false},
+			{"name": "go-version", "variables": ["GO_VERSION"], "one_of": ["1.10", 1.20, 2], "max_length": 4},
+			{"name": "replicas", "variables": ["REPLICAS"], "one_of": [3, 1.50]}
+		]}`,
+	}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(dir, name)
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			p, err := LoadPolicy(path, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := string(*p.Rules[0].Equals); got != "false" {
+				t.Errorf("equals = %q, want false", got)
+			}
+			if got, want := p.Rules[1].oneOf(), []string{"1.10", "1.20", "2"}; !reflect.DeepEqual(got, want) {
+				t.Errorf("one_of = %q, want %q", got, want)
+			}
+			if p.Rules[1].MaxLength != 4 {
+				t.Errorf("max_length = %d, want 4", p.Rules[1].MaxLength)
+			}
+
+			ok := []Variable{{Name: "DEBUG", Value: "false"}, {Name: "GO_VERSION", Value: "1.20"}, {Name: "REPLICAS", Value: "1.50"}}
+			if violations := p.Evaluate("", ok, ok); len(violations) != 0 {
+				t.Errorf("violations of allowed values: %v", violations)
+			}
+			bad := []Variable{{Name: "DEBUG", Value: "0"}, {Name: "GO_VERSION", Value: "1.2"}, {Name: "REPLICAS", Value: "1.5"}}
+			if violations := p.Evaluate("", bad, bad); len(violations) != 3 {
+				t.Errorf("got %d violations of reformatted values, want 3: %v", len(violations), violations)
+			}
+		})
+	}
+}
+
+func TestLoadPolicyRejectsNestedValues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sync-policy.yaml")
+	if err := os.WriteFile(path, []byte("rules:\n  - one_of: [[1, 2]]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPolicy(path, true); err == nil {
+		t.Error("a nested list in one_of loaded")
+	}
+}
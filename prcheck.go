@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// prCommentMarker identifies the tool's PR comment so it's updated instead of duplicated
+const prCommentMarker = "<!-- sync-github-variable:pr-check -->"
+
+// handlePRCheck validates the input for a pull request, emits GitHub Actions error
+// annotations on offending CSV lines, and renders the diff as a Markdown comment
+func handlePRCheck(args []string, token, owner, repo, environment string) {
+	fs := flag.NewFlagSet("pr-check", flag.ExitOnError)
+	commentFile := fs.String("comment-file", "", "Write the Markdown comment body to this file")
+	post := fs.Bool("post", false, "Post (or update) the comment on the pull request")
+	prNumber := fs.Int("pr", 0, "Pull request number (default: from GITHUB_EVENT_PATH)")
+	fs.Parse(args)
+
+	variables, source, err := LoadDesiredVariables(token, owner, repo, environment)
+	if err != nil {
+		fatal(exitValidation, "Error: %v", err)
+	}
+
+	// Annotations point at the file as checked out in the PR
+	issues := validateRows(inputRows(source, variables))
+	for _, issue := range issues {
+		fmt.Println(annotation("error", *inputFile, issue.Line, "Invalid variable", issue.Message))
+	}
+	// Values that look like credentials are warnings, or errors with --strict-secrets
+	for _, finding := range scanForSecrets(inputRows(source, variables)) {
+		if *strictSecrets {
+			fmt.Println(annotation("error", *inputFile, finding.Line, "Possible secret", finding.Message))
+			issues = append(issues, finding)
+		} else {
+			fmt.Println(annotation("warning", *inputFile, finding.Line, "Possible secret", finding.Message))
+		}
+	}
+
+	remote, err := FetchGitHubVariables(token, owner, repo, environment)
+	if err != nil {
+		fatal(exitFailure, "Error fetching GitHub variables: %v", err)
+	}
+	diff := CompareSets(variables, remote)
+	body := prCommentBody(owner, repo, environment, diff, issues)
+
+	if *commentFile != "" {
+		err = os.WriteFile(*commentFile, []byte(body), 0644)
+		if err != nil {
+			fatal(exitValidation, "Error writing comment file: %v", err)
+		}
+		fmt.Printf("📝 Comment body saved: %s\n", *commentFile)
+	}
+	if summary := os.Getenv("GITHUB_STEP_SUMMARY"); summary != "" {
+		if err := appendLine(summary, []byte(body)); err != nil {
+			fmt.Printf("⚠️  Failed to write job summary: %v\n", err)
+		}
+	}
+	if *post {
+		number := *prNumber
+		if number == 0 {
+			number, err = pullRequestFromEvent()
+			if err != nil {
+				fatal(exitValidation, "Error: %v (use --pr)", err)
+			}
+		}
+		err = upsertPRComment(token, number, body)
+		if err != nil {
+			fatal(exitFailure, "Error posting comment: %v", err)
+		}
+		fmt.Printf("💬 Updated comment on pull request #%d\n", number)
+	}
+	if *commentFile == "" && !*post && os.Getenv("GITHUB_STEP_SUMMARY") == "" {
+		fmt.Println(body)
+	}
+
+	if len(issues) > 0 {
+		fatal(exitValidation, "%d validation error(s)", len(issues))
+	}
+	fmt.Println("✅ No validation errors")
+}
+
+// annotation formats a GitHub Actions workflow command that annotates a file line
+func annotation(level, file string, line int, title, message string) string {
+	return annotationAt(level, file, line, 0, title, message)
+}
+
+// annotationAt is annotation with a column (0 to annotate the whole line)
+func annotationAt(level, file string, line, column int, title, message string) string {
+	escape := strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	props := "file=" + escape.Replace(file)
+	if line > 0 {
+		props += ",line=" + strconv.Itoa(line)
+		if column > 0 {
+			props += ",col=" + strconv.Itoa(column)
+		}
+	}
+	props += ",title=" + strings.NewReplacer(",", "%2C", ":", "%3A").Replace(escape.Replace(title))
+	return fmt.Sprintf("::%s %s::%s", level, props, escape.Replace(message))
+}
+
+// markdownCell makes a value safe for a Markdown table cell (redacted, truncated, single line)
+func markdownCell(name, value string) string {
+	value = truncateValue(shownValue(name, value), valueLimit(60, false))
+	value = strings.NewReplacer("|", `\|`, "\n", "↵", "\r", "", "`", "'").Replace(value)
+	if value == "" {
+		return ""
+	}
+	return "`" + value + "`"
+}
+
+// prCommentBody renders the diff and validation errors as a Markdown PR comment
+func prCommentBody(owner, repo, environment string, diff DiffResult, issues []validationIssue) string {
+	var b strings.Builder
+	target := targetName(owner, repo, environment)
+
+	b.WriteString(prCommentMarker + "\n")
+	fmt.Fprintf(&b, "### Variable changes for `%s`\n\n", target)
+
+	if len(diff.New)+len(diff.Updated)+len(diff.Deleted) == 0 {
+		b.WriteString("No changes: GitHub already matches the input.\n")
+	} else {
+		fmt.Fprintf(&b, "**%d** to create, **%d** to update, %d unchanged", len(diff.New), len(diff.Updated), len(diff.Unchanged))
+		if len(diff.Deleted) > 0 {
+			fmt.Fprintf(&b, ", %d only in GitHub", len(diff.Deleted))
+		}
+		b.WriteString("\n\n| | Variable | Current | Proposed |\n|---|---|---|---|\n")
+		for _, v := range diff.New {
+			fmt.Fprintf(&b, "| ➕ | `%s` | | %s |\n", v.Name, markdownCell(v.Name, v.Value))
+		}
+		for _, c := range diff.Updated {
+			fmt.Fprintf(&b, "| ✏️ | `%s` | %s | %s |\n", c.Name, markdownCell(c.Name, c.OldValue), markdownCell(c.Name, c.NewValue))
+		}
+		for _, v := range diff.Deleted {
+			fmt.Fprintf(&b, "| ⚠️ | `%s` | %s | _not in input (kept)_ |\n", v.Name, markdownCell(v.Name, v.Value))
+		}
+	}
+
+	if len(issues) > 0 {
+		fmt.Fprintf(&b, "\n#### ❌ %d validation error(s)\n\n", len(issues))
+		for _, issue := range issues {
+			if issue.Line > 0 {
+				fmt.Fprintf(&b, "- Line %d: %s\n", issue.Line, issue.Message)
+			} else {
+				fmt.Fprintf(&b, "- %s\n", issue.Message)
+			}
+		}
+	}
+	return b.String()
+}
+
+// pullRequestFromEvent reads the pull request number from the Actions event payload
+func pullRequestFromEvent() (int, error) {
+	path := os.Getenv("GITHUB_EVENT_PATH")
+	if path == "" {
+		return 0, fmt.Errorf("not running in a GitHub Actions pull_request event")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	var event struct {
+		Number      int `json:"number"`
+		PullRequest struct {
+			Number int `json:"number"`
+		} `json:"pull_request"`
+	}
+	err = json.Unmarshal(data, &event)
+	if err != nil {
+		return 0, err
+	}
+	if event.PullRequest.Number != 0 {
+		return event.PullRequest.Number, nil
+	}
+	if event.Number != 0 {
+		return event.Number, nil
+	}
+	return 0, fmt.Errorf("the event payload has no pull request number")
+}
+
+// upsertPRComment updates the tool's previous comment on the pull request, or creates one.
+// The comment goes to the repository running the workflow (GITHUB_REPOSITORY), which
+// may differ from the variables target.
+func upsertPRComment(token string, number int, body string) error {
+	repoPath := os.Getenv("GITHUB_REPOSITORY")
+	if repoPath == "" {
+		repoPath = os.Getenv("GITHUB_OWNER") + "/" + os.Getenv("GITHUB_REPO")
+	}
+
+	var comments []struct {
+		ID   int64  `json:"id"`
+		Body string `json:"body"`
+	}
+	listURL := fmt.Sprintf("%s/repos/%s/issues/%d/comments?per_page=100", githubAPIURL, repoPath, number)
+	err := githubGetJSON(token, listURL, &comments)
+	if err != nil {
+		return err
+	}
+
+	method, url, want := "POST", fmt.Sprintf("%s/repos/%s/issues/%d/comments", githubAPIURL, repoPath, number), 201
+	for _, c := range comments {
+		if strings.HasPrefix(c.Body, prCommentMarker) {
+			method, url, want = "PATCH", fmt.Sprintf("%s/repos/%s/issues/comments/%d", githubAPIURL, repoPath, c.ID), 200
+			break
+		}
+	}
+
+	payload, err := json.Marshal(map[string]string{"body": body})
+	if err != nil {
+		return err
+	}
+	req, err := newGitHubRequest(method, url, token, strings.NewReader(string(payload)))
+	if err != nil {
+		return err
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != want {
+		return fmt.Errorf("GitHub API returned status %d", resp.StatusCode)
+	}
+	return nil
+}
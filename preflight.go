@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// fineGrainedPermissionNames maps X-Accepted-GitHub-Permissions keys to the names shown in the token settings UI
+var fineGrainedPermissionNames = map[string]string{
+	"actions_variables": "Variables",
+	"environments":      "Environments",
+	"metadata":          "Metadata",
+	"contents":          "Contents",
+	"actions":           "Actions",
+}
+
+// PreflightCheck verifies the token can see the target and read its variables, and, when
+// needWrite is set, that it has write access. Failures explain which scope or permission is missing.
+func PreflightCheck(token, owner, repo, environment string, needWrite bool) error {
+	if isOrgTarget(owner, repo) {
+		return preflightOrganization(token, owner)
+	}
+
+	// Repository access: returns the caller's permissions and, for classic tokens, the granted scopes
+	repoURL := fmt.Sprintf("%s/repos/%s/%s", githubAPIURL, owner, repo)
+	resp, body, err := preflightGet(token, repoURL)
+	if err != nil {
+		return err
+	}
+
+	if err := ssoPreflightError(resp, body, owner); err != nil {
+		return err
+	}
+	switch resp.StatusCode {
+	case 200:
+	case 401:
+		return fmt.Errorf("the token is invalid or expired (401 Unauthorized)")
+	case 403, 404:
+		return fmt.Errorf("repository %s/%s is not visible to this token (%d)\n%s",
+			owner, repo, resp.StatusCode, missingPermissionHint(resp, token, "repo", "Metadata: Read-only (and access to this repository)"))
+	default:
+		return fmt.Errorf("GitHub API returned status %d for %s/%s: %s", resp.StatusCode, owner, repo, string(body))
+	}
+
+	var repoInfo struct {
+		Private     bool `json:"private"`
+		Permissions *struct {
+			Admin    bool `json:"admin"`
+			Maintain bool `json:"maintain"`
+			Push     bool `json:"push"`
+		} `json:"permissions"` // Absent for some token types
+	}
+	err = json.Unmarshal(body, &repoInfo)
+	if err != nil {
+		return fmt.Errorf("failed to parse repository response: %w", err)
+	}
+
+	// Classic tokens report their scopes; variables need repo (public_repo suffices for public repositories)
+	if scopes, classic := resp.Header["X-Oauth-Scopes"]; classic {
+		granted := strings.Join(scopes, ",")
+		if !hasScope(granted, "repo") && (repoInfo.Private || !hasScope(granted, "public_repo")) {
+			return fmt.Errorf("the classic token is missing the 'repo' scope (granted: %q)\n"+
+				"   Fix: edit the token under Settings → Developer settings → Personal access tokens and enable 'repo'", granted)
+		}
+	}
+
+	// Variable read access on the actual target
+	listURL := fmt.Sprintf("%s/repos/%s/%s/actions/variables?per_page=1", githubAPIURL, owner, repo)
+	permission := "Variables: Read-only"
+	if environment != "" {
+		listURL = fmt.Sprintf("%s/repos/%s/%s/environments/%s/variables?per_page=1", githubAPIURL, owner, repo, environment)
+		permission = "Environments: Read-only"
+	}
+	resp, body, err = preflightGet(token, listURL)
+	if err != nil {
+		return err
+	}
+
+	if err := ssoPreflightError(resp, body, owner); err != nil {
+		return err
+	}
+	switch resp.StatusCode {
+	case 200:
+	case 403:
+		return fmt.Errorf("the token can't read variables on the target (403)\n%s",
+			missingPermissionHint(resp, token, "repo", permission))
+	case 404:
+		if environment != "" {
+			return fmt.Errorf("environment '%s' does not exist in %s/%s (or the token can't see it)", environment, owner, repo)
+		}
+		return fmt.Errorf("variables on %s/%s are not visible to this token (404)\n%s",
+			owner, repo, missingPermissionHint(resp, token, "repo", permission))
+	default:
+		return fmt.Errorf("GitHub API returned status %d listing variables: %s", resp.StatusCode, string(body))
+	}
+
+	// Writing requires write access to the repository; fine-grained permissions can't be
+	// introspected, so a missing "Variables: Read and write" still surfaces on the first write
+	perms := repoInfo.Permissions
+	if needWrite && perms != nil && !perms.Admin && !perms.Maintain && !perms.Push {
+		write := "Variables: Read and write"
+		if environment != "" {
+			write = "Environments: Read and write"
+		}
+		return fmt.Errorf("the token only has read access to %s/%s, but syncing writes variables\n"+
+			"   Fix: ask a repository admin for write access, or use a token with %s", owner, repo, write)
+	}
+
+	return nil
+}
+
+// preflightOrganization checks that the token can read an organization's variables. Writing
+// needs an organization admin, which only the first write reveals.
+func preflightOrganization(token, org string) error {
+	resp, body, err := preflightGet(token, variableURL(org, "", "", "")+"?per_page=1")
+	if err != nil {
+		return err
+	}
+	if err := ssoPreflightError(resp, body, org); err != nil {
+		return err
+	}
+	switch resp.StatusCode {
+	case 200:
+		return nil
+	case 401:
+		return fmt.Errorf("the token is invalid or expired (401 Unauthorized)")
+	case 403, 404:
+		return fmt.Errorf("variables of organization %s are not visible to this token (%d)\n"+
+			"   Fix: use an organization admin's token with the 'admin:org' scope, or the organization permission Variables", org, resp.StatusCode)
+	default:
+		return fmt.Errorf("GitHub API returned status %d listing variables: %s", resp.StatusCode, string(body))
+	}
+}
+
+// ssoPreflightError explains a refusal because of SAML SSO, which would otherwise look like
+// the token can't see the repository
+func ssoPreflightError(resp *http.Response, body []byte, owner string) error {
+	sso, blocked := ssoRequired(resp, body)
+	if !blocked {
+		return nil
+	}
+	explanation, fix := sso.Explain(owner)
+	return fmt.Errorf("%s (%d)\n   Fix: %s", explanation, resp.StatusCode, fix)
+}
+
+// preflightGet performs a GET request and returns the response with its body already read
+func preflightGet(token, url string) (*http.Response, []byte, error) {
+	req, err := newGitHubRequest("GET", url, token, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resp, body, nil
+}
+
+// missingPermissionHint builds an actionable fix for a denied request, naming the classic
+// scope or fine-grained permission depending on the kind of token in use
+func missingPermissionHint(resp *http.Response, token, classicScope, fineGrained string) string {
+	if accepted := resp.Header.Get("X-Accepted-GitHub-Permissions"); accepted != "" {
+		fineGrained = describeAcceptedPermissions(accepted)
+	}
+
+	switch {
+	case strings.HasPrefix(token, "ghp_"), resp.Header.Get("X-OAuth-Scopes") != "":
+		return fmt.Sprintf("   Fix: the classic token needs the '%s' scope and its user needs access to the repository", classicScope)
+	case strings.HasPrefix(token, "ghs_"):
+		return fmt.Sprintf("   Fix: the GitHub App needs the %s permission and must be installed on the repository", fineGrained)
+	default:
+		return fmt.Sprintf("   Fix: the fine-grained token needs the %s permission and this repository in its repository access list", fineGrained)
+	}
+}
+
+// describeAcceptedPermissions turns "actions_variables=read,environments=read" into display names
+func describeAcceptedPermissions(header string) string {
+	parts := []string{}
+	for _, entry := range strings.FieldsFunc(header, func(r rune) bool { return r == ',' || r == ';' }) {
+		key, level, _ := strings.Cut(strings.TrimSpace(entry), "=")
+		name, ok := fineGrainedPermissionNames[key]
+		if !ok {
+			name = key
+		}
+		switch level {
+		case "read":
+			level = "Read-only"
+		case "write":
+			level = "Read and write"
+		}
+		parts = append(parts, fmt.Sprintf("%s: %s", name, level))
+	}
+	return strings.Join(parts, " + ")
+}
+
+// hasScope reports whether a comma-separated X-OAuth-Scopes value contains scope
+func hasScope(scopes, scope string) bool {
+	for _, s := range strings.Split(scopes, ",") {
+		if strings.TrimSpace(s) == scope {
+			return true
+		}
+	}
+	return false
+}
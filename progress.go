@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// progressThreshold is the number of changes from which a sync shows progress instead of one line per variable
+	progressThreshold = 20
+	progressBarWidth  = 30
+	// progressInterval is how often a non-terminal progress line is printed at most, unless another 10% is done
+	progressInterval = 15 * time.Second
+)
+
+// syncProgress tracks a large sync. On a terminal it redraws one progress bar line; otherwise
+// (CI logs) it prints a plain progress line every 10% or progressInterval. A nil *syncProgress
+// is a small sync, which prints a line per variable instead.
+type syncProgress struct {
+	total    int
+	done     int
+	failed   int
+	start    time.Time
+	terminal bool
+	drawn    time.Time // When the bar was last drawn, to limit redraws
+	printed  time.Time // When the last plain line was printed
+	decile   int       // Last 10% step a plain line was printed for
+	reported int       // Done count of the last plain line
+}
+
+// newSyncProgress returns progress tracking for a sync of total changes, or nil when the
+// sync is small or --no-progress is set
+func newSyncProgress(total int) *syncProgress {
+	if total < progressThreshold || *noProgress {
+		return nil
+	}
+	now := time.Now()
+	p := &syncProgress{total: total, start: now, terminal: isTerminal(os.Stdout), printed: now}
+	p.render(true)
+	return p
+}
+
+// Step records one finished variable and updates the display
+func (p *syncProgress) Step(failed bool) {
+	p.done++
+	if failed {
+		p.failed++
+	}
+	p.render(p.done == p.total)
+}
+
+// Clear removes the progress bar so a message can be printed on its own line; the next
+// Step draws the bar again below it
+func (p *syncProgress) Clear() {
+	if p.terminal {
+		fmt.Print("\r\033[K")
+	}
+}
+
+// Finish ends the progress display after the last variable, or early when the sync stops
+func (p *syncProgress) Finish() {
+	if p.terminal {
+		p.render(true)
+		fmt.Println()
+	} else if p.reported != p.done {
+		p.reported = p.done
+		fmt.Printf("⏳ Progress: %s\n", p.status())
+	}
+}
+
+func (p *syncProgress) render(force bool) {
+	now := time.Now()
+	if p.terminal {
+		if !force && now.Sub(p.drawn) < 100*time.Millisecond {
+			return
+		}
+		p.drawn = now
+		filled := progressBarWidth * p.done / p.total
+		bar := strings.Repeat("█", filled) + strings.Repeat("░", progressBarWidth-filled)
+		fmt.Printf("\r\033[K⏳ %s %s", bar, p.status())
+		return
+	}
+
+	decile := 10 * p.done / p.total
+	if p.done == 0 || (decile == p.decile && now.Sub(p.printed) < progressInterval && p.done < p.total) {
+		return
+	}
+	p.decile, p.printed, p.reported = decile, now, p.done
+	fmt.Printf("⏳ Progress: %s\n", p.status())
+}
+
+// status formats the counts, rate, and ETA, e.g. "120/400 (30%), 2 failed, 12.5/s, ETA 22s"
+func (p *syncProgress) status() string {
+	parts := []string{fmt.Sprintf("%d/%d (%d%%)", p.done, p.total, 100*p.done/p.total)}
+	if p.failed > 0 {
+		parts = append(parts, fmt.Sprintf("%d failed", p.failed))
+	}
+	elapsed := time.Since(p.start)
+	if p.done > 0 && elapsed > 0 {
+		parts = append(parts, fmt.Sprintf("%.1f/s", float64(p.done)/elapsed.Seconds()))
+		if p.done < p.total {
+			eta := elapsed / time.Duration(p.done) * time.Duration(p.total-p.done)
+			parts = append(parts, "ETA "+eta.Round(time.Second).String())
+		}
+	}
+	return strings.Join(parts, ", ")
+}
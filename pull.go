@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// handlePull writes remote state back into the CSV: values changed in GitHub are
+// updated in place and variables that only exist in GitHub are appended. GitHub is not modified.
+func handlePull(source VariableSource, diff DiffResult) {
+	path, err := plainCSVPath(source)
+	if err != nil {
+		fatal(exitValidation, "Error: --pull: %v", err)
+	}
+
+	fmt.Println("\n━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Println("⬇️  PULL FROM GITHUB")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Printf("%s🔄 Values to update in CSV:%s %d\n", ColorYellow, ColorReset, len(diff.Updated))
+	fmt.Printf("%s✨ Rows to add to CSV:%s      %d\n", ColorGreen, ColorReset, len(diff.Deleted))
+	fmt.Printf("%sℹ️  Only in CSV (kept):%s      %d\n", ColorGray, ColorReset, len(diff.New))
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	for _, change := range diff.Updated {
+		fmt.Printf("%s~ %s:%s\n", ColorYellow, change.Name, ColorReset)
+		fmt.Printf("  %s- %s%s\n", ColorRed, truncateValue(shownValue(change.Name, change.NewValue), valueLimit(60, true)), ColorReset)
+		fmt.Printf("  %s+ %s%s\n", ColorGreen, truncateValue(shownValue(change.Name, change.OldValue), valueLimit(60, true)), ColorReset)
+	}
+	for _, v := range diff.Deleted {
+		fmt.Printf("%s+ %s = %s%s\n", ColorGreen, v.Name, truncateValue(shownValue(v.Name, v.Value), valueLimit(80, true)), ColorReset)
+	}
+	fmt.Println()
+
+	if len(diff.Updated) == 0 && len(diff.Deleted) == 0 {
+		fmt.Println("✅ CSV already contains everything in GitHub")
+		exit(0)
+	}
+
+	if *diffMode {
+		fmt.Println("ℹ️  Diff mode: No changes were made")
+		exit(0)
+	}
+
+	if !askYesNo(fmt.Sprintf("⚠️  Write these changes to %s?", path)) {
+		fmt.Println("\n❌ Pull cancelled by user")
+		exit(exitCancelled)
+	}
+
+	if len(diff.Updated) > 0 {
+		err = UpdateCSVValues(path, diff.Updated)
+		if err != nil {
+			fatal(exitValidation, "Error updating CSV file: %v", err)
+		}
+	}
+	if len(diff.Deleted) > 0 {
+		note := "Pulled from GitHub " + time.Now().Format("2006-01-02")
+		err = AppendCSVRows(path, diff.Deleted, note)
+		if err != nil {
+			fatal(exitValidation, "Error adding rows to CSV file: %v", err)
+		}
+	}
+
+	fmt.Printf("\n🎉 Completed! Updated %d and added %d variable(s) in %s\n", len(diff.Updated), len(diff.Deleted), path)
+}
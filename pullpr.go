@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// csvRepoLocation is where the CSV of record lives in a repository
+type csvRepoLocation struct {
+	owner, repo, path, base string // base is empty for the default branch
+}
+
+// csvRepoLocationFor finds the repository file behind the input: a github:// input directly,
+// or a local CSV checked out from --pr-repo (default GITHUB_REPOSITORY) at the same relative path
+func csvRepoLocationFor(source VariableSource) (csvRepoLocation, error) {
+	fs, ok := source.(fileSource)
+	if !ok || fs.valuesFile != "" || inputFormat(fs.path) != "csv" {
+		return csvRepoLocation{}, fmt.Errorf("only supported with a plain CSV input")
+	}
+
+	if strings.HasPrefix(fs.path, githubInputScheme) {
+		owner, repo, path, ref, err := parseGitHubInput(fs.path)
+		if err != nil {
+			return csvRepoLocation{}, err
+		}
+		return csvRepoLocation{owner: owner, repo: repo, path: path, base: ref}, nil
+	}
+
+	repoPath := *prRepo
+	if repoPath == "" {
+		repoPath = os.Getenv("GITHUB_REPOSITORY")
+	}
+	owner, repo, ok := strings.Cut(repoPath, "/")
+	if !ok || owner == "" || repo == "" {
+		return csvRepoLocation{}, fmt.Errorf("set --pr-repo owner/repo to the repository holding %s", fs.path)
+	}
+	path := filepath.ToSlash(filepath.Clean(fs.path))
+	if filepath.IsAbs(fs.path) || strings.HasPrefix(path, "../") {
+		return csvRepoLocation{}, fmt.Errorf("the input must be a path relative to the repository root, not %s", fs.path)
+	}
+	return csvRepoLocation{owner: owner, repo: repo, path: path}, nil
+}
+
+// handlePullPR commits GitHub's state into the CSV on a new branch and opens a pull request,
+// so remote drift is reconciled through code review. Neither GitHub variables nor the local file change.
+func handlePullPR(token string, source VariableSource, diff DiffResult) {
+	loc, err := csvRepoLocationFor(source)
+	if err != nil {
+		fatal(exitValidation, "Error: --open-pr: %v", err)
+	}
+
+	if len(diff.Updated) == 0 && len(diff.Deleted) == 0 {
+		fmt.Println("✅ CSV already contains everything in GitHub; no pull request needed")
+		return
+	}
+
+	fmt.Printf("⬇️  Reconciling %d changed and %d remote-only variable(s) into %s/%s/%s\n",
+		len(diff.Updated), len(diff.Deleted), loc.owner, loc.repo, loc.path)
+	if *diffMode {
+		fmt.Println("ℹ️  Diff mode: No pull request was opened")
+		return
+	}
+
+	url, err := openReconcilePR(token, loc, diff)
+	if err != nil {
+		fmt.Printf("❌ Error opening pull request: %v\n", err)
+		exit(1)
+	}
+	fmt.Printf("🎉 Opened pull request: %s\n", url)
+}
+
+// openReconcilePR creates a branch from the base, commits the updated CSV to it, and opens a pull request
+func openReconcilePR(token string, loc csvRepoLocation, diff DiffResult) (string, error) {
+	repoURL := fmt.Sprintf("%s/repos/%s/%s", githubAPIURL, loc.owner, loc.repo)
+
+	base := loc.base
+	if base == "" {
+		var info struct {
+			DefaultBranch string `json:"default_branch"`
+		}
+		if err := githubGetJSON(token, repoURL, &info); err != nil {
+			return "", err
+		}
+		base = info.DefaultBranch
+	}
+
+	// Apply the changes to the file as it is on the base branch, not the local copy
+	content, blobSHA, err := fetchRepoFileWithSHA(token, loc.owner, loc.repo, loc.path, base)
+	if err != nil {
+		return "", err
+	}
+	content, encoding, err := decodeText(content)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", loc.path, err)
+	}
+	records, err := parseCSVRecords(content)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", loc.path, err)
+	}
+	if len(diff.Updated) > 0 {
+		if err := updateCSVRecordValues(records, diff.Updated); err != nil {
+			return "", err
+		}
+	}
+	records = appendCSVRecords(records, diff.Deleted, "Pulled from GitHub "+time.Now().Format("2006-01-02"))
+
+	var updated bytes.Buffer
+	if err := writeCSV(&updated, records); err != nil {
+		return "", err
+	}
+
+	// Branch from the current head of the base
+	var head struct {
+		Object struct {
+			SHA string `json:"sha"`
+		} `json:"object"`
+	}
+	if err := githubGetJSON(token, repoURL+"/git/ref/heads/"+base, &head); err != nil {
+		return "", fmt.Errorf("failed to read branch %s: %w", base, err)
+	}
+	branch := "sync-variables/reconcile-" + time.Now().Format("20060102-150405")
+	err = githubSendJSON(token, "POST", repoURL+"/git/refs",
+		map[string]string{"ref": "refs/heads/" + branch, "sha": head.Object.SHA}, 201, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create branch: %w", err)
+	}
+
+	err = githubSendJSON(token, "PUT", repoURL+"/contents/"+loc.path, map[string]string{
+		"message": "Reconcile variables from GitHub",
+		"content": base64.StdEncoding.EncodeToString(encodeText(updated.Bytes(), encoding)),
+		"sha":     blobSHA,
+		"branch":  branch,
+	}, 200, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to commit %s: %w", loc.path, err)
+	}
+
+	var pr struct {
+		HTMLURL string `json:"html_url"`
+	}
+	err = githubSendJSON(token, "POST", repoURL+"/pulls", map[string]string{
+		"title": "Reconcile variables from GitHub",
+		"head":  branch,
+		"base":  base,
+		"body":  reconcilePRBody(loc, diff),
+	}, 201, &pr)
+	if err != nil {
+		return "", fmt.Errorf("failed to open pull request: %w", err)
+	}
+	return pr.HTMLURL, nil
+}
+
+// reconcilePRBody describes the drift being pulled into the CSV
+func reconcilePRBody(loc csvRepoLocation, diff DiffResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Variables were changed directly in GitHub. This updates `%s` to match, so the file of record stays accurate.\n\n", loc.path)
+	b.WriteString("| | Variable | In CSV | In GitHub |\n|---|---|---|---|\n")
+	for _, c := range diff.Updated {
+		fmt.Fprintf(&b, "| ✏️ | `%s` | %s | %s |\n", c.Name, markdownCell(c.Name, c.NewValue), markdownCell(c.Name, c.OldValue))
+	}
+	for _, v := range diff.Deleted {
+		fmt.Fprintf(&b, "| ➕ | `%s` | | %s |\n", v.Name, markdownCell(v.Name, v.Value))
+	}
+	b.WriteString("\nReview the values before merging: closing this pull request and re-running the sync restores the CSV's values instead.\n")
+	return b.String()
+}
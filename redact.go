@@ -0,0 +1,243 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// redactedPlaceholder replaces sensitive values in output
+const redactedPlaceholder = "[REDACTED]"
+
+// minRedactLength avoids redacting trivially short values such as "1" or "true"
+const minRedactLength = 4
+
+// Redactor replaces known sensitive values in text with a placeholder
+type Redactor struct {
+	mu     sync.RWMutex
+	values map[string]bool
+}
+
+// redactor holds every sensitive value seen during this run (token, sensitive variables)
+var redactor = NewRedactor()
+
+func NewRedactor() *Redactor {
+	return &Redactor{values: make(map[string]bool)}
+}
+
+// Add registers values as sensitive
+func (r *Redactor) Add(values ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, v := range values {
+		if len(v) >= minRedactLength {
+			r.values[v] = true
+		}
+	}
+}
+
+// snapshot returns the registered values, longest first so overlapping values redact fully
+func (r *Redactor) snapshot() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	values := make([]string, 0, len(r.values))
+	for v := range r.values {
+		values = append(values, v)
+	}
+	sortByLengthDesc(values)
+	return values
+}
+
+// Redact replaces every sensitive value in s and returns the number of replacements
+func (r *Redactor) Redact(s string) (string, int) {
+	count := 0
+	for _, v := range r.snapshot() {
+		if n := strings.Count(s, v); n > 0 {
+			count += n
+			s = strings.ReplaceAll(s, v, redactedPlaceholder)
+		}
+	}
+	return s, count
+}
+
+// safeValue returns a value with any sensitive content replaced, for display
+func safeValue(value string) string {
+	redacted, _ := redactor.Redact(value)
+	return redacted
+}
+
+// hashValue returns a short, stable fingerprint of a value that can be shared without exposing it
+func hashValue(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return "sha256:" + hex.EncodeToString(sum[:])[:16]
+}
+
+// partialSuffix returns the length of the longest suffix of s that is a proper
+// prefix of a sensitive value, i.e. output that must be held back until more arrives
+func (r *Redactor) partialSuffix(s string) int {
+	longest := 0
+	for _, v := range r.snapshot() {
+		max := len(v) - 1
+		if max > len(s) {
+			max = len(s)
+		}
+		for n := max; n > longest; n-- {
+			if strings.HasSuffix(s, v[:n]) {
+				longest = n
+				break
+			}
+		}
+	}
+	return longest
+}
+
+// sensitivePatterns returns the glob patterns of sensitive variable names from --sensitive and the config file
+func sensitivePatterns() []string {
+	patterns := append([]string{}, config.Sensitive...)
+	for _, p := range strings.Split(*sensitiveNames, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			patterns = append(patterns, p)
+		}
+	}
+	return patterns
+}
+
+// registerSensitiveValues marks the values of variables with sensitive names for redaction
+func registerSensitiveValues(variables []Variable) {
+	patterns := sensitivePatterns()
+	if len(patterns) == 0 {
+		return
+	}
+	for _, v := range variables {
+		if matchesAnyPattern(strings.ToUpper(v.Name), upperAll(patterns)) {
+			redactor.Add(v.Value)
+		}
+	}
+}
+
+func upperAll(values []string) []string {
+	result := make([]string, len(values))
+	for i, v := range values {
+		result[i] = strings.ToUpper(v)
+	}
+	return result
+}
+
+func sortByLengthDesc(values []string) {
+	sort.Slice(values, func(i, j int) bool {
+		return len(values[i]) > len(values[j])
+	})
+}
+
+// outputGuard routes stdout and stderr through the redactor and counts leaks
+type outputGuard struct {
+	realStdout *os.File
+	realStderr *os.File
+	writers    []*os.File
+	wg         sync.WaitGroup
+	mu         sync.Mutex
+	violations int
+}
+
+// guard is non-nil while --guard-output is active
+var guard *outputGuard
+
+// startOutputGuard replaces os.Stdout and os.Stderr with pipes whose contents are redacted
+func startOutputGuard() error {
+	g := &outputGuard{realStdout: os.Stdout, realStderr: os.Stderr}
+
+	for _, target := range []**os.File{&os.Stdout, &os.Stderr} {
+		r, w, err := os.Pipe()
+		if err != nil {
+			return err
+		}
+		dst := *target
+		*target = w
+		g.writers = append(g.writers, w)
+		g.wg.Add(1)
+		go g.pump(r, dst)
+	}
+
+	guard = g
+	return nil
+}
+
+// pump copies from src to dst, redacting sensitive values even when they span reads
+func (g *outputGuard) pump(src, dst *os.File) {
+	defer g.wg.Done()
+	defer src.Close()
+
+	pending := ""
+	buf := make([]byte, 4096)
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			pending += string(buf[:n])
+			redacted, count := redactor.Redact(pending)
+			if count > 0 {
+				g.mu.Lock()
+				g.violations += count
+				g.mu.Unlock()
+			}
+			hold := redactor.partialSuffix(redacted)
+			dst.WriteString(redacted[:len(redacted)-hold])
+			pending = redacted[len(redacted)-hold:]
+		}
+		if err != nil {
+			break
+		}
+	}
+	dst.WriteString(pending)
+}
+
+// stop restores the real stdout/stderr after flushing, and returns the number of leaks caught
+func (g *outputGuard) stop() int {
+	for _, w := range g.writers {
+		w.Close()
+	}
+	g.wg.Wait()
+	os.Stdout = g.realStdout
+	os.Stderr = g.realStderr
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.violations
+}
+
+// exitHooks run before the program exits, most recently registered first
+var exitHooks []func()
+
+// exitStatus is the code the program is exiting with, for exit hooks that report it
+var exitStatus int
+
+// atExit registers cleanup (e.g. releasing locks) to run in exit
+func atExit(hook func()) {
+	exitHooks = append(exitHooks, hook)
+}
+
+// exit terminates the program, first running exit hooks and flushing guarded output. A run
+// that would have leaked a sensitive value fails even if it otherwise succeeded.
+func exit(code int) {
+	exitStatus = code
+	for i := len(exitHooks) - 1; i >= 0; i-- {
+		exitHooks[i]()
+	}
+	exitHooks = nil
+
+	if guard != nil {
+		violations := guard.stop()
+		guard = nil
+		if violations > 0 {
+			fmt.Fprintf(os.Stderr, "❌ Output guard: %d sensitive value occurrence(s) were redacted from output; failing run\n", violations)
+			if code == 0 {
+				code = 1
+			}
+		}
+	}
+	runCancel()
+	os.Exit(code)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// repoReferencePattern matches {{ repo.NAME }} references to repository-level variables
+var repoReferencePattern = regexp.MustCompile(`\{\{\s*repo\.([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// hasRepoReferences reports whether any value references a repository-level variable
+func hasRepoReferences(variables []Variable) bool {
+	for _, v := range variables {
+		if repoReferencePattern.MatchString(v.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExpandRepoReferences replaces {{ repo.NAME }} in values with the value of the
+// repository-level variable NAME. Names are matched case-insensitively, as GitHub does.
+func ExpandRepoReferences(variables, repoVariables []Variable) ([]Variable, error) {
+	repoValues := make(map[string]string)
+	for _, v := range repoVariables {
+		repoValues[strings.ToUpper(v.Name)] = v.Value
+	}
+
+	expanded := make([]Variable, len(variables))
+	for i, v := range variables {
+		expanded[i] = v
+
+		var missing []string
+		expanded[i].Value = repoReferencePattern.ReplaceAllStringFunc(v.Value, func(ref string) string {
+			name := repoReferencePattern.FindStringSubmatch(ref)[1]
+			value, ok := repoValues[strings.ToUpper(name)]
+			if !ok {
+				missing = append(missing, name)
+				return ref
+			}
+			return value
+		})
+
+		if len(missing) > 0 {
+			return nil, fmt.Errorf("variable %s references repository variable(s) that don't exist: %s",
+				v.Name, strings.Join(missing, ", "))
+		}
+	}
+
+	return expanded, nil
+}
+
+// escapeRepoReferences rewrites repo references as template string literals so
+// Go template rendering passes them through untouched
+func escapeRepoReferences(content string) string {
+	return repoReferencePattern.ReplaceAllStringFunc(content, func(ref string) string {
+		return fmt.Sprintf("{{%q}}", ref)
+	})
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rename moves a variable's value from Old to New
+type Rename struct {
+	Old   string
+	New   string
+	Value string // The value of Old in GitHub, carried over to New
+}
+
+// LoadRenames reads a renames file: CSV rows of old,new names, with an optional old,new header
+func LoadRenames(path string) ([]Rename, error) {
+	records, _, err := readCSVRecords(path)
+	if err != nil {
+		return nil, err
+	}
+
+	renames := []Rename{}
+	seen := map[string]int{}
+	for i, record := range records {
+		if i == 0 && len(record) > 0 && strings.EqualFold(strings.TrimSpace(record[0]), "old") {
+			continue
+		}
+		if len(record) < 2 {
+			if len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+				continue
+			}
+			return nil, fmt.Errorf("line %d: expected old,new", i+1)
+		}
+		r := Rename{Old: normalizeName(strings.TrimSpace(record[0])), New: normalizeName(strings.TrimSpace(record[1]))}
+		for _, name := range []string{r.Old, r.New} {
+			if err := validateVariableName(name); err != nil {
+				return nil, fmt.Errorf("line %d: %v", i+1, err)
+			}
+		}
+		if nameKey(r.Old) == nameKey(r.New) {
+			return nil, fmt.Errorf("line %d: %s is renamed to itself", i+1, r.Old)
+		}
+		for _, name := range []string{r.Old, r.New} {
+			if first, ok := seen[nameKey(name)]; ok {
+				return nil, fmt.Errorf("line %d: %s is already part of the rename on line %d", i+1, name, first)
+			}
+			seen[nameKey(name)] = i + 1
+		}
+		renames = append(renames, r)
+	}
+	return renames, nil
+}
+
+// PlanRenames works out which renames apply to the remote variables and returns the remote
+// variables as they'll be after renaming, so the diff only shows changes beyond the renames.
+// Renames already done, or whose old name doesn't exist, are skipped.
+func PlanRenames(path string, remote []Variable) ([]Rename, []Variable, error) {
+	renames, err := LoadRenames(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	remoteByKey := map[string]Variable{}
+	for _, v := range remote {
+		remoteByKey[nameKey(v.Name)] = v
+	}
+
+	planned := []Rename{}
+	renamed := map[string]string{} // Old key -> new name
+	for _, r := range renames {
+		oldVar, hasOld := remoteByKey[nameKey(r.Old)]
+		_, hasNew := remoteByKey[nameKey(r.New)]
+		switch {
+		case hasOld && hasNew:
+			fmt.Printf("⚠️  Rename %s → %s skipped: both exist in GitHub. Delete %s once nothing uses it\n", r.Old, r.New, r.Old)
+		case hasOld:
+			r.Old, r.Value = oldVar.Name, oldVar.Value
+			planned = append(planned, r)
+			renamed[nameKey(r.Old)] = r.New
+		}
+	}
+
+	after := make([]Variable, 0, len(remote))
+	for _, v := range remote {
+		if name, ok := renamed[nameKey(v.Name)]; ok {
+			v.Name = name
+		}
+		after = append(after, v)
+	}
+	return planned, after, nil
+}
+
+// DisplayRenames lists the planned renames with the diff
+func DisplayRenames(renames []Rename) {
+	if len(renames) == 0 {
+		return
+	}
+	fmt.Printf("\n%s%s[RENAMED VARIABLES]%s\n", ColorBlue, ColorBold, ColorReset)
+	for _, r := range renames {
+		fmt.Printf("%s» %s → %s%s %s(value kept: %s)%s\n", ColorBlue, r.Old, r.New, ColorReset,
+			ColorGray, truncateValue(shownValue(r.New, r.Value), valueLimit(60, true)), ColorReset)
+	}
+}
+
+// ApplyRenames creates each new variable with the old value, verifies it reads back, and only
+// then deletes the old one. It stops at the first failure, leaving the old variable in place.
+func ApplyRenames(client GitHubClient, owner, repo, environment string, renames []Rename) error {
+	for _, r := range renames {
+		err := createOrUpdateVariable(client, owner, repo, environment, Variable{Name: r.New, Value: r.Value})
+		if err != nil {
+			return fmt.Errorf("creating %s: %w", r.New, err)
+		}
+
+		current, err := client.ListVariables(owner, repo, environment)
+		if err != nil {
+			return fmt.Errorf("verifying %s: %w", r.New, err)
+		}
+		verified := false
+		for _, v := range current {
+			if nameKey(v.Name) == nameKey(r.New) && v.Value == r.Value {
+				verified = true
+			}
+		}
+		if !verified {
+			return fmt.Errorf("%s did not read back with the value of %s; %s was kept", r.New, r.Old, r.Old)
+		}
+
+		err = client.DeleteVariable(owner, repo, environment, r.Old)
+		if err != nil {
+			return fmt.Errorf("deleting %s after creating %s: %w", r.Old, r.New, err)
+		}
+		fmt.Printf("✅ Renamed variable: %s → %s\n", r.Old, r.New)
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+// SyncFailure is a variable that couldn't be written and why
+type SyncFailure struct {
+	Name  string `json:"name"`
+	Error string `json:"error"`
+}
+
+// SyncReport is the outcome of a run, shared by notifications and other result outputs
+type SyncReport struct {
+	Owner       string        `json:"owner"`
+	Repo        string        `json:"repo"`
+	Environment string        `json:"environment,omitempty"`
+	Mode        string        `json:"mode"` // "sync" or "diff"
+	Created     []string      `json:"created"`
+	Updated     []string      `json:"updated"`
+	Failed      []SyncFailure `json:"failed"`
+	Deleted     []string      `json:"deleted,omitempty"`
+	NotSynced   []string      `json:"not_synced,omitempty"`  // Skipped because the run stopped early
+	RemoteOnly  []string      `json:"remote_only,omitempty"` // Diff mode: in GitHub but not in the input
+}
+
+// newSyncReport starts an empty report for a target
+func newSyncReport(owner, repo, environment, mode string) *SyncReport {
+	return &SyncReport{
+		Owner:       owner,
+		Repo:        repo,
+		Environment: environment,
+		Mode:        mode,
+		Created:     []string{},
+		Updated:     []string{},
+		Failed:      []SyncFailure{},
+	}
+}
+
+// driftReport describes what a sync would change, for diff mode
+func driftReport(owner, repo, environment string, diff DiffResult) *SyncReport {
+	report := newSyncReport(owner, repo, environment, "diff")
+	for _, v := range diff.New {
+		report.Created = append(report.Created, v.Name)
+	}
+	for _, c := range diff.Updated {
+		report.Updated = append(report.Updated, c.Name)
+	}
+	for _, v := range diff.Deleted {
+		report.RemoteOnly = append(report.RemoteOnly, v.Name)
+	}
+	return report
+}
+
+// Target describes the report's target for display
+func (r *SyncReport) Target() string {
+	return targetName(r.Owner, r.Repo, r.Environment)
+}
+
+// targetName describes a repository or environment as owner/repo (environment), and an
+// organization target by its name
+func targetName(owner, repo, environment string) string {
+	if isOrgTarget(owner, repo) {
+		return owner + " (organization)"
+	}
+	if environment != "" {
+		return owner + "/" + repo + " (" + environment + ")"
+	}
+	return owner + "/" + repo
+}
+
+// HasDrift reports whether a diff-mode report found differences
+func (r *SyncReport) HasDrift() bool {
+	return len(r.Created)+len(r.Updated)+len(r.RemoteOnly) > 0
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ResumeState is written when a sync ends with variables failed or not attempted, so
+// --resume can retry just those against the same target
+type ResumeState struct {
+	Owner       string           `json:"owner"`
+	Repo        string           `json:"repo"`
+	Environment string           `json:"environment,omitempty"`
+	Source      string           `json:"source"`
+	CreatedAt   time.Time        `json:"created_at"`
+	Variables   []ResumeVariable `json:"variables"`
+}
+
+// ResumeVariable is a variable still to be written, with the value the sync was writing
+type ResumeVariable struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Action string `json:"action"`          // "create" or "update"
+	Error  string `json:"error,omitempty"` // Why the last attempt failed; empty if it wasn't attempted
+}
+
+// resumeFilePath is the resume file of a target, in the working directory like backups/
+func resumeFilePath(owner, repo, environment string) string {
+	if environment != "" {
+		return fmt.Sprintf("resume_%s_%s_%s.json", owner, repo, environment)
+	}
+	return fmt.Sprintf("resume_%s_%s.json", owner, repo)
+}
+
+// saveResumeState writes the variables a sync didn't finish, or removes the target's resume
+// file once nothing is left
+func saveResumeState(owner, repo, environment, source string, remaining []ResumeVariable) {
+	path := resumeFilePath(owner, repo, environment)
+	if len(remaining) == 0 {
+		if err := os.Remove(path); err == nil {
+			fmt.Printf("🧹 Removed %s; nothing is left to resume\n", path)
+		}
+		return
+	}
+
+	state := ResumeState{Owner: owner, Repo: repo, Environment: environment, Source: source,
+		CreatedAt: time.Now().UTC(), Variables: remaining}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err == nil {
+		err = os.WriteFile(path, append(data, '\n'), 0600)
+	}
+	if err != nil {
+		fmt.Printf("⚠️  Warning: Failed to write resume file %s: %v\n", path, err)
+		return
+	}
+	fmt.Printf("💾 Resume file saved: %s\n", path)
+	fmt.Printf("   Re-run with --resume to retry only these %d variable(s)\n", len(remaining))
+}
+
+// unfinishedVariables lists the variables of a sync that failed or weren't attempted
+func unfinishedVariables(report *SyncReport, toSync []Variable, newVars map[string]bool) []ResumeVariable {
+	errs := map[string]string{}
+	for _, f := range report.Failed {
+		errs[f.Name] = f.Error
+	}
+	for _, name := range report.NotSynced {
+		errs[name] = ""
+	}
+
+	remaining := []ResumeVariable{}
+	for _, v := range toSync {
+		if msg, ok := errs[v.Name]; ok {
+			remaining = append(remaining, ResumeVariable{Name: v.Name, Value: v.Value, Action: syncAction(newVars, v.Name), Error: msg})
+		}
+	}
+	return remaining
+}
+
+// loadResumeState reads the target's resume file
+func loadResumeState(owner, repo, environment string) (*ResumeState, error) {
+	path := resumeFilePath(owner, repo, environment)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no resume file for %s (%s); only a sync that ended with failures leaves one",
+			targetName(owner, repo, environment), path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var state ResumeState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if state.Owner != owner || state.Repo != repo || state.Environment != environment {
+		return nil, fmt.Errorf("%s is for %s, not %s", path,
+			targetName(state.Owner, state.Repo, state.Environment), targetName(owner, repo, environment))
+	}
+	return &state, nil
+}
+
+// handleResume retries only the variables a previous sync of this target left unfinished,
+// with the values that sync was writing. The input is not read again.
+func handleResume(token, owner, repo, environment string) {
+	state, err := loadResumeState(owner, repo, environment)
+	if err != nil {
+		fatal(exitValidation, "Error: --resume: %v", err)
+	}
+	fmt.Printf("🔁 Resuming the sync of %s from %s (%s)\n", targetName(owner, repo, environment),
+		state.Source, state.CreatedAt.Local().Format("2006-01-02 15:04"))
+
+	registerSensitiveValues(resumeVariables(state.Variables))
+	remote, err := FetchGitHubVariables(token, owner, repo, environment)
+	if err != nil {
+		fatal(exitFailure, "Error fetching GitHub variables: %v", err)
+	}
+	current := map[string]string{}
+	for _, v := range remote {
+		current[nameKey(v.Name)] = v.Value
+	}
+
+	// Variables someone already fixed by hand don't need another write
+	pending := []ResumeVariable{}
+	for _, v := range state.Variables {
+		if value, ok := current[nameKey(v.Name)]; ok && value == v.Value {
+			fmt.Printf("✅ %s is already up to date\n", v.Name)
+			continue
+		}
+		pending = append(pending, v)
+		reason := "not attempted"
+		if v.Error != "" {
+			reason = v.Error
+		}
+		fmt.Printf("%s• %s%s (%s) %s— last time: %s%s\n", ColorYellow, v.Name, ColorReset, v.Action, ColorGray, reason, ColorReset)
+	}
+	if len(pending) == 0 {
+		saveResumeState(owner, repo, environment, state.Source, nil)
+		fmt.Println("\n✅ Nothing left to resume")
+		return
+	}
+	retry := DiffResult{}
+	for _, v := range pending {
+		if value, ok := current[nameKey(v.Name)]; ok {
+			retry.Updated = append(retry.Updated, VariableChange{Name: v.Name, OldValue: value, NewValue: v.Value})
+		} else {
+			retry.New = append(retry.New, Variable{Name: v.Name, Value: v.Value})
+		}
+	}
+	requireApproval(token, owner, repo, environment, retry)
+	if !askYesNo(fmt.Sprintf("\n⚠️  Retry %d variable(s) in %s?", len(pending), targetName(owner, repo, environment))) {
+		fmt.Println("\n❌ Resume cancelled by user")
+		exit(exitCancelled)
+	}
+
+	fmt.Print("\n🚀 Retrying...\n\n")
+	client := newRESTClient(token)
+	report := newSyncReport(owner, repo, environment, "sync")
+	runReport = report
+	setSummaryReport(report, DiffResult{})
+	applyDiff(client, report, retry, func(format string, args ...interface{}) {
+		fmt.Printf(format+"\n", args...)
+	}, func(name, action string, err error, duration time.Duration) {
+		recordOutcome(name, action, err, duration)
+		if err == nil && action == "create" {
+			fmt.Printf("✅ Created variable: %s\n", name)
+		} else if err == nil {
+			fmt.Printf("✅ Updated variable: %s\n", name)
+		}
+	})
+	newVars := map[string]bool{}
+	for _, v := range retry.New {
+		newVars[v.Name] = true
+	}
+	for _, name := range report.NotSynced {
+		recordNotSynced(name, syncAction(newVars, name))
+	}
+	remaining := unfinishedVariables(report, resumeVariables(pending), newVars)
+
+	fmt.Printf("\n🎉 Resumed! Created %d, Updated %d, Failed %d\n", len(report.Created), len(report.Updated), len(report.Failed))
+	saveResumeState(owner, repo, environment, state.Source, remaining)
+	writeSyncManifest(token, report)
+	sendNotifications(report)
+	runPostSyncHooks(report)
+	if len(report.Failed) > 0 || len(report.NotSynced) > 0 {
+		exit(exitPartial)
+	}
+}
+
+func resumeVariables(list []ResumeVariable) []Variable {
+	variables := make([]Variable, len(list))
+	for i, v := range list {
+		variables[i] = Variable{Name: v.Name, Value: v.Value}
+	}
+	return variables
+}
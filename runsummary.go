@@ -0,0 +1,231 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RunSummary is written by --report: everything about a run worth archiving as a CI
+// artifact or attaching to a change ticket
+type RunSummary struct {
+	Target          string            `json:"target,omitempty"`
+	Owner           string            `json:"owner,omitempty"`
+	Repo            string            `json:"repo,omitempty"`
+	Environment     string            `json:"environment,omitempty"`
+	Mode            string            `json:"mode,omitempty"` // "sync" or "diff"
+	ExitCode        int               `json:"exit_code"`
+	Result          string            `json:"result"`
+	Error           string            `json:"error,omitempty"`
+	StartedAt       time.Time         `json:"started_at"`
+	FinishedAt      time.Time         `json:"finished_at"`
+	DurationSeconds float64           `json:"duration_seconds"`
+	Counts          RunCounts         `json:"counts"`
+	Variables       []VariableOutcome `json:"variables"`
+	RateLimit       RateLimitUsage    `json:"rate_limit"`
+	Backup          string            `json:"backup,omitempty"`
+	report          *SyncReport       // Sync or drift report the counts come from
+}
+
+// RunCounts are the per-outcome variable counts of a run
+type RunCounts struct {
+	Created    int `json:"created"`
+	Updated    int `json:"updated"`
+	Failed     int `json:"failed"`
+	NotSynced  int `json:"not_synced"`
+	Unchanged  int `json:"unchanged"`
+	RemoteOnly int `json:"remote_only"`
+}
+
+// VariableOutcome is what happened to one variable during the sync
+type VariableOutcome struct {
+	Name       string `json:"name"`
+	Action     string `json:"action"` // "create" or "update"
+	Result     string `json:"result"` // "ok", "failed", or "not_synced"
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// RateLimitUsage is how much of the API rate limit the run consumed
+type RateLimitUsage struct {
+	Requests        int `json:"requests"`
+	Limit           int `json:"limit,omitempty"`
+	RemainingBefore int `json:"remaining_before,omitempty"`
+	RemainingAfter  int `json:"remaining_after,omitempty"`
+	Used            int `json:"used"`
+}
+
+// runSummary collects the run's details as it goes, for --report
+var runSummary = RunSummary{StartedAt: time.Now().UTC(), Variables: []VariableOutcome{}}
+
+// setSummaryTarget records the run's target
+func setSummaryTarget(owner, repo, environment string) {
+	runSummary.Target = targetName(owner, repo, environment)
+	runSummary.Owner, runSummary.Repo, runSummary.Environment = owner, repo, environment
+}
+
+// setSummaryReport records the sync or drift report the counts are taken from
+func setSummaryReport(report *SyncReport, diff DiffResult) {
+	runSummary.report = report
+	runSummary.Mode = report.Mode
+	runSummary.Counts.Unchanged = len(diff.Unchanged)
+	runSummary.Counts.RemoteOnly = len(diff.Deleted)
+}
+
+// syncAction names what syncing a variable does, given the names the diff found new
+func syncAction(newVars map[string]bool, name string) string {
+	if newVars[name] {
+		return "create"
+	}
+	return "update"
+}
+
+// recordOutcome records how one variable's write went and how long it took
+func recordOutcome(name, action string, err error, duration time.Duration) {
+	outcome := VariableOutcome{Name: name, Action: action, Result: "ok", DurationMs: duration.Milliseconds()}
+	if err != nil {
+		outcome.Result, outcome.Error = "failed", safeValue(err.Error())
+	}
+	runSummary.Variables = append(runSummary.Variables, outcome)
+}
+
+// recordNotSynced records a variable the run stopped before writing
+func recordNotSynced(name, action string) {
+	runSummary.Variables = append(runSummary.Variables, VariableOutcome{Name: name, Action: action, Result: "not_synced"})
+}
+
+// writeRunSummary writes the summary to path as Markdown (.md) or JSON; it runs as an exit hook
+func writeRunSummary(path string, code int) {
+	s := runSummary
+	s.ExitCode, s.Result, s.Error = code, exitReason(code), runError
+	s.FinishedAt = time.Now().UTC()
+	s.DurationSeconds = s.FinishedAt.Sub(s.StartedAt).Round(time.Millisecond).Seconds()
+	s.RateLimit = rateLimits.usage()
+	if s.report != nil {
+		s.Counts.Created, s.Counts.Updated = len(s.report.Created), len(s.report.Updated)
+		s.Counts.Failed, s.Counts.NotSynced = len(s.report.Failed), len(s.report.NotSynced)
+	}
+
+	var data []byte
+	var err error
+	if strings.EqualFold(filepath.Ext(path), ".md") {
+		data = []byte(s.Markdown())
+	} else {
+		data, err = json.MarshalIndent(s, "", "  ")
+		data = append(data, '\n')
+	}
+	if err == nil {
+		err = os.WriteFile(path, data, 0644)
+	}
+	if err != nil {
+		fmt.Printf("⚠️  Warning: Failed to write report %s: %v\n", path, err)
+	}
+}
+
+// Markdown renders the summary for a change ticket
+func (s RunSummary) Markdown() string {
+	var b strings.Builder
+	title := "Sync report"
+	if s.Mode == "diff" {
+		title = "Drift report"
+	}
+	if s.Target != "" {
+		fmt.Fprintf(&b, "## %s for `%s`\n\n", title, s.Target)
+	} else {
+		fmt.Fprintf(&b, "## %s\n\n", title)
+	}
+
+	fmt.Fprintf(&b, "- **Result:** %s (exit code %d)\n", s.Result, s.ExitCode)
+	if s.Error != "" {
+		fmt.Fprintf(&b, "- **Error:** %s\n", s.Error)
+	}
+	fmt.Fprintf(&b, "- **Started:** %s\n", s.StartedAt.Format(time.RFC3339))
+	fmt.Fprintf(&b, "- **Duration:** %s\n", time.Duration(s.DurationSeconds*float64(time.Second)).Round(time.Millisecond))
+	if s.Backup != "" {
+		fmt.Fprintf(&b, "- **Backup:** `%s`\n", s.Backup)
+	}
+	fmt.Fprintf(&b, "- **API requests:** %d", s.RateLimit.Requests)
+	if s.RateLimit.Limit > 0 {
+		fmt.Fprintf(&b, " (rate limit used: %d, %d of %d remaining)", s.RateLimit.Used, s.RateLimit.RemainingAfter, s.RateLimit.Limit)
+	}
+	b.WriteString("\n")
+
+	created, updated := "Created", "Updated"
+	if s.Mode == "diff" {
+		created, updated = "To create", "To update"
+	}
+	c := s.Counts
+	fmt.Fprintf(&b, "\n| %s | %s | Failed | Not synced | Unchanged | Only in GitHub |\n|---|---|---|---|---|---|\n", created, updated)
+	fmt.Fprintf(&b, "| %d | %d | %d | %d | %d | %d |\n", c.Created, c.Updated, c.Failed, c.NotSynced, c.Unchanged, c.RemoteOnly)
+
+	if len(s.Variables) > 0 {
+		b.WriteString("\n### Variables\n\n| Variable | Action | Result | Duration | Error |\n|---|---|---|---|---|\n")
+		for _, v := range s.Variables {
+			fmt.Fprintf(&b, "| `%s` | %s | %s | %dms | %s |\n", v.Name, v.Action, v.Result, v.DurationMs, strings.ReplaceAll(v.Error, "|", "\\|"))
+		}
+	}
+	return b.String()
+}
+
+// rateLimitTracker counts API requests and the rate limit remaining before and after the run
+type rateLimitTracker struct {
+	mu              sync.Mutex
+	requests        int
+	limit           int
+	remainingBefore int
+	remainingAfter  int
+	seen            bool
+}
+
+var rateLimits = &rateLimitTracker{}
+
+func (t *rateLimitTracker) observe(resp *http.Response) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.requests++
+	if resp == nil {
+		return
+	}
+	remaining, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Remaining"))
+	if err != nil {
+		return
+	}
+	if !t.seen {
+		// The first response already paid for itself
+		t.remainingBefore, t.seen = remaining+1, true
+	}
+	t.remainingAfter = remaining
+	if limit, err := strconv.Atoi(resp.Header.Get("X-RateLimit-Limit")); err == nil {
+		t.limit = limit
+	}
+}
+
+func (t *rateLimitTracker) usage() RateLimitUsage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	usage := RateLimitUsage{Requests: t.requests, Limit: t.limit, RemainingBefore: t.remainingBefore, RemainingAfter: t.remainingAfter}
+	if t.seen && t.remainingBefore >= t.remainingAfter {
+		// The limit is shared with anything else using the token, so this is an upper bound
+		usage.Used = t.remainingBefore - t.remainingAfter
+	}
+	return usage
+}
+
+// rateLimitTransport feeds GitHub API responses that reached the network to rateLimits
+type rateLimitTransport struct {
+	base http.RoundTripper
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if isGitHubAPIRequest(req) {
+		rateLimits.observe(resp)
+	}
+	return resp, err
+}
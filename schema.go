@@ -0,0 +1,251 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+)
+
+// defaultSchemaFile is loaded automatically when it exists
+const defaultSchemaFile = "sync-schema.yaml"
+
+// Schema is a schema file: the variables each target must have
+type Schema struct {
+	Repository   []SchemaVariable            `json:"repository"`   // Required repository variables
+	Environments map[string][]SchemaVariable `json:"environments"` // Environment name or glob pattern -> required variables
+	JSON         map[string]string           `json:"json"`         // Variable name glob -> JSON Schema file its value must satisfy
+
+	jsonSchemas map[string]*JSONSchema
+}
+
+// SchemaVariable is a required variable, written as its name or as an object with a value pattern
+type SchemaVariable struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"` // Regular expression the whole value must match
+
+	pattern *regexp.Regexp
+}
+
+// UnmarshalJSON accepts a plain name as well as {name, pattern}
+func (v *SchemaVariable) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &v.Name); err == nil {
+		return nil
+	}
+	type plain SchemaVariable
+	return json.Unmarshal(data, (*plain)(v))
+}
+
+// Schema problem severities: errors fail diff and sync, warnings are only shown
+const (
+	SchemaError   = "error"
+	SchemaWarning = "warning"
+)
+
+// SchemaProblem is one required variable that is missing or has an invalid value
+type SchemaProblem struct {
+	Variable string `json:"variable"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// schema is the loaded schema; nil when no schema file is present
+var schema *Schema
+
+// LoadSchema reads and validates a schema file. A missing file is only an error when required is set.
+func LoadSchema(path string, required bool) (*Schema, error) {
+	s := &Schema{}
+	if err := decodeConfigFile(path, s); err != nil {
+		if os.IsNotExist(err) && !required {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	compile := func(where string, variables []SchemaVariable) error {
+		for i := range variables {
+			v := &variables[i]
+			if err := validateVariableName(v.Name); err != nil {
+				return fmt.Errorf("%s: %s: %v", path, where, err)
+			}
+			if v.Pattern != "" {
+				pattern, err := regexp.Compile("^(?:" + v.Pattern + ")$")
+				if err != nil {
+					return fmt.Errorf("%s: %s: %s: pattern: %w", path, where, v.Name, err)
+				}
+				v.pattern = pattern
+			}
+		}
+		return nil
+	}
+	if err := compile("repository", s.Repository); err != nil {
+		return nil, err
+	}
+	for pattern, variables := range s.Environments {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%s: environments: invalid pattern %q", path, pattern)
+		}
+		if err := compile("environments."+pattern, variables); err != nil {
+			return nil, err
+		}
+	}
+
+	// JSON Schema paths are relative to the schema file
+	s.jsonSchemas = map[string]*JSONSchema{}
+	for pattern, schemaPath := range s.JSON {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("%s: json: invalid pattern %q", path, pattern)
+		}
+		if !filepath.IsAbs(schemaPath) {
+			schemaPath = filepath.Join(filepath.Dir(path), schemaPath)
+		}
+		jsonSchema, err := LoadJSONSchema(schemaPath)
+		if err != nil {
+			return nil, fmt.Errorf("%s: json: %s: %w", path, pattern, err)
+		}
+		s.jsonSchemas[pattern] = jsonSchema
+	}
+	return s, nil
+}
+
+// requiredFor returns the required variables of a target ("" for repository variables).
+// Every environment entry whose pattern matches contributes; a name listed twice keeps
+// its first definition, in pattern order.
+func (s *Schema) requiredFor(environment string) []SchemaVariable {
+	if environment == "" {
+		return s.Repository
+	}
+	patterns := []string{}
+	for pattern := range s.Environments {
+		if matched, _ := filepath.Match(pattern, environment); matched {
+			patterns = append(patterns, pattern)
+		}
+	}
+	sort.Strings(patterns)
+
+	required := []SchemaVariable{}
+	seen := map[string]bool{}
+	for _, pattern := range patterns {
+		for _, v := range s.Environments[pattern] {
+			if !seen[nameKey(v.Name)] {
+				seen[nameKey(v.Name)] = true
+				required = append(required, v)
+			}
+		}
+	}
+	return required
+}
+
+// Check compares a target's input and current remote state with its required variables.
+// A variable missing from the input but still set in GitHub is a warning: the sync leaves it
+// alone. Missing from both, or a value (the input's, else GitHub's) not matching its pattern,
+// is an error.
+func (s *Schema) Check(environment string, desired, remote []Variable) []SchemaProblem {
+	problems := []SchemaProblem{}
+	if s == nil {
+		return problems
+	}
+	desiredValues := map[string]string{}
+	for _, v := range desired {
+		desiredValues[nameKey(v.Name)] = v.Value
+	}
+	remoteValues := map[string]string{}
+	for _, v := range remote {
+		remoteValues[nameKey(v.Name)] = v.Value
+	}
+
+	for _, required := range s.requiredFor(environment) {
+		key := nameKey(required.Name)
+		value, inInput := desiredValues[key]
+		remoteValue, inRemote := remoteValues[key]
+		switch {
+		case !inInput && !inRemote:
+			problems = append(problems, SchemaProblem{required.Name, SchemaError, "is required but missing from the input and unset in GitHub"})
+			continue
+		case !inInput:
+			problems = append(problems, SchemaProblem{required.Name, SchemaWarning, "is required but missing from the input (still set in GitHub)"})
+			value = remoteValue
+		}
+		if required.pattern != nil && !required.pattern.MatchString(value) {
+			problems = append(problems, SchemaProblem{required.Name, SchemaError, fmt.Sprintf("does not match the required pattern %s", required.Pattern)})
+		}
+	}
+	return append(problems, s.checkJSON(desired)...)
+}
+
+// checkJSON validates the input's values of variables that have a JSON Schema. Each failing
+// path is its own error, so a broken flag config is pinpointed rather than just rejected.
+func (s *Schema) checkJSON(desired []Variable) []SchemaProblem {
+	patterns := make([]string, 0, len(s.jsonSchemas))
+	for pattern := range s.jsonSchemas {
+		patterns = append(patterns, pattern)
+	}
+	sort.Strings(patterns)
+
+	problems := []SchemaProblem{}
+	for _, v := range desired {
+		for _, pattern := range patterns {
+			if matched, _ := filepath.Match(pattern, v.Name); !matched {
+				continue
+			}
+			for _, message := range s.jsonSchemas[pattern].ValidateJSON(v.Value) {
+				problems = append(problems, SchemaProblem{v.Name, SchemaError, fmt.Sprintf("JSON (%s): %s", s.JSON[pattern], message)})
+			}
+		}
+	}
+	return problems
+}
+
+// schemaErrors counts the problems that fail a run
+func schemaErrors(problems []SchemaProblem) int {
+	errors := 0
+	for _, p := range problems {
+		if p.Severity == SchemaError {
+			errors++
+		}
+	}
+	return errors
+}
+
+// displaySchemaProblems checks a target against the schema and prints the problems.
+// It returns the number of errors.
+func displaySchemaProblems(owner, repo, environment string, desired, remote []Variable) int {
+	if schema == nil {
+		return 0
+	}
+	problems := schema.Check(environment, desired, remote)
+	if len(problems) == 0 {
+		fmt.Printf("📐 Schema: passed (%d required variable(s))\n", len(schema.requiredFor(environment)))
+		return 0
+	}
+
+	fmt.Printf("\n%s📐 Schema: %d problem(s) in %s%s\n", ColorBold, len(problems), targetName(owner, repo, environment), ColorReset)
+	for _, p := range problems {
+		if p.Severity == SchemaError {
+			fmt.Printf("%s✗ %s %s%s\n", ColorRed, p.Variable, p.Message, ColorReset)
+		} else {
+			fmt.Printf("%s⚠ %s %s%s\n", ColorYellow, p.Variable, p.Message, ColorReset)
+		}
+	}
+	return schemaErrors(problems)
+}
+
+// enforceSchema stops the run before anything is applied when a target breaks the schema
+func enforceSchema(owner, repo, environment string, desired, remote []Variable) {
+	if err := schemaRejection(owner, repo, environment, desired, remote); err != nil {
+		runError = err.Error()
+		fmt.Println("❌ Required variables are missing or invalid. Nothing was changed")
+		exit(exitValidation)
+	}
+}
+
+// schemaRejection prints a target's schema problems and returns an error when any is an error
+func schemaRejection(owner, repo, environment string, desired, remote []Variable) error {
+	errors := displaySchemaProblems(owner, repo, environment, desired, remote)
+	if errors == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d schema error(s) in %s", errors, targetName(owner, repo, environment))
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+)
+
+// secretPattern is a recognizable credential format
+type secretPattern struct {
+	kind    string
+	pattern *regexp.Regexp
+}
+
+// secretPatterns are credential formats that should never be stored as plaintext variables
+var secretPatterns = []secretPattern{
+	{"an AWS access key ID", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"a GitHub token", regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"a private key", regexp.MustCompile(`-----BEGIN ([A-Z0-9]+ )*PRIVATE KEY( BLOCK)?-----`)},
+	{"a Slack token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{"a Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"a Stripe secret key", regexp.MustCompile(`\b[rs]k_live_[0-9A-Za-z]{20,}\b`)},
+	{"a JSON Web Token", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]+`)},
+	{"a URL with an embedded password", regexp.MustCompile(`[a-z][a-z0-9+.-]*://[^/\s:@]+:[^/\s@]+@`)},
+}
+
+// High-entropy detection thresholds: random tokens have long, space-free runs of mixed
+// characters. Hex digests (e.g. commit SHAs) stay under the entropy bar.
+const (
+	minEntropyLength = 24
+	minEntropyBits   = 4.3
+)
+
+// highEntropyToken finds candidate random strings inside a value
+var highEntropyToken = regexp.MustCompile(`[A-Za-z0-9+/=_-]{24,}`)
+
+// detectSecret reports what kind of credential a value looks like, if any
+func detectSecret(value string) (string, bool) {
+	for _, p := range secretPatterns {
+		if p.pattern.MatchString(value) {
+			return p.kind, true
+		}
+	}
+	for _, token := range highEntropyToken.FindAllString(value, -1) {
+		if len(token) >= minEntropyLength && mixedCharacterClasses(token) && shannonEntropy(token) >= minEntropyBits {
+			return "a high-entropy string (possibly a key or token)", true
+		}
+	}
+	return "", false
+}
+
+// shannonEntropy returns the entropy of s in bits per character
+func shannonEntropy(s string) float64 {
+	counts := map[rune]int{}
+	total := 0
+	for _, r := range s {
+		counts[r]++
+		total++
+	}
+	entropy := 0.0
+	for _, n := range counts {
+		p := float64(n) / float64(total)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// mixedCharacterClasses reports whether s has at least three of: lowercase, uppercase, digits, symbols
+func mixedCharacterClasses(s string) bool {
+	var lower, upper, digit, other bool
+	for _, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		case r >= '0' && r <= '9':
+			digit = true
+		default:
+			other = true
+		}
+	}
+	classes := 0
+	for _, present := range []bool{lower, upper, digit, other} {
+		if present {
+			classes++
+		}
+	}
+	return classes >= 3
+}
+
+// scanForSecrets flags input rows whose values look like credentials. Names matching the
+// config's allow_secrets patterns are skipped. Messages never include the value.
+func scanForSecrets(rows []inputRow) []validationIssue {
+	allowed := upperAll(config.AllowSecrets)
+	issues := []validationIssue{}
+	for _, row := range rows {
+		if matchesAnyPattern(strings.ToUpper(row.Name), allowed) {
+			continue
+		}
+		if kind, found := detectSecret(row.Value); found {
+			issues = append(issues, validationIssue{Line: row.Line, Name: row.Name,
+				Message: fmt.Sprintf("value of %s looks like %s; credentials belong in Actions secrets, not variables", row.Name, kind)})
+		}
+	}
+	return issues
+}
+
+// checkSecretLeaks warns about values that look like credentials, or fails the run with --strict-secrets
+func checkSecretLeaks(source VariableSource, variables []Variable) {
+	findings := scanForSecrets(inputRows(source, variables))
+	if len(findings) == 0 {
+		return
+	}
+
+	fmt.Printf("\n%s⚠️  %d value(s) look like credentials:%s\n", ColorYellow, len(findings), ColorReset)
+	for _, finding := range findings {
+		if finding.Line > 0 {
+			fmt.Printf("   Line %d: %s\n", finding.Line, finding.Message)
+		} else {
+			fmt.Printf("   %s\n", finding.Message)
+		}
+	}
+	fmt.Println("   Variables are stored and shown in plaintext. If a value is not a secret, allow it with allow_secrets in the config file")
+
+	if *strictSecrets {
+		runError = fmt.Sprintf("%d value(s) look like credentials", len(findings))
+		fmt.Printf("❌ Refusing to sync with --strict-secrets\n")
+		exit(exitValidation)
+	}
+}
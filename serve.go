@@ -0,0 +1,314 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// apiServer exposes diff, sync, backup, and restore for one target over HTTP
+type apiServer struct {
+	token, owner, repo, environment string
+	apiToken                        string     // Bearer token clients must send
+	mu                              sync.Mutex // Operations run one at a time
+}
+
+// handleServe runs the REST API until interrupted
+func handleServe(args []string, token, owner, repo, environment string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	listen := fs.String("listen", "127.0.0.1:8080", "Address to listen on")
+	fs.Parse(args)
+
+	s := &apiServer{token: token, owner: owner, repo: repo, environment: environment,
+		apiToken: os.Getenv("SYNC_API_TOKEN")}
+	redactor.Add(s.apiToken)
+
+	// Even on loopback, a browser page or another local user could otherwise drive writes
+	if s.apiToken == "" {
+		fatal(exitValidation, "SYNC_API_TOKEN is required: every request must send it as a bearer token")
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+	})
+	mux.HandleFunc("/diff", s.authorized("GET", s.handleDiff))
+	mux.HandleFunc("/sync", s.authorized("POST", s.handleSync))
+	mux.HandleFunc("/backups", s.authorized("GET", s.handleBackups))
+	mux.HandleFunc("/restore", s.authorized("POST", s.handleRestore))
+
+	target := newSyncReport(owner, repo, environment, "sync").Target()
+	fmt.Printf("🌐 Serving API for %s on http://%s\n", target, *listen)
+	err := http.ListenAndServe(*listen, mux)
+	if err != nil {
+		fmt.Printf("❌ Error: %v\n", err)
+		exit(1)
+	}
+}
+
+// authorized checks the method, bearer token, and (for writes) the JSON content type, and
+// serializes operations. Requiring JSON keeps plain HTML forms from posting to the API.
+func (s *apiServer) authorized(method string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != method {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "use " + method})
+			return
+		}
+		given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(given), []byte(s.apiToken)) != 1 {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid or missing bearer token"})
+			return
+		}
+		if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); method == "POST" && mediaType != "application/json" {
+			writeJSON(w, http.StatusUnsupportedMediaType, map[string]string{"error": "send Content-Type: application/json"})
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		fmt.Printf("[%s] %s %s\n", time.Now().Format("2006-01-02 15:04:05"), r.Method, r.URL.Path)
+		handler(w, r)
+	}
+}
+
+// diff loads the desired state and compares it with GitHub
+func (s *apiServer) diff() (local, remote []Variable, diff DiffResult, err error) {
+	local, _, err = LoadDesiredVariables(s.token, s.owner, s.repo, s.environment)
+	if err != nil {
+		return nil, nil, DiffResult{}, err
+	}
+	remote, err = FetchGitHubVariables(s.token, s.owner, s.repo, s.environment)
+	if err != nil {
+		return nil, nil, DiffResult{}, err
+	}
+	return local, remote, CompareSets(local, remote), nil
+}
+
+// decodeBody reads an optional JSON request body, answering 400 when it's malformed
+func decodeBody(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON body: " + err.Error()})
+		return false
+	}
+	return true
+}
+
+// lock takes the target's lock for one write, answering 409 while another run holds it
+func (s *apiServer) lock(w http.ResponseWriter) *targetLock {
+	lock, err := lockTarget(s.token, s.owner, s.repo, s.environment)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if lockExitCode(err) == exitLocked {
+			status = http.StatusConflict
+		}
+		writeJSON(w, status, map[string]string{"error": safeValue(err.Error())})
+		return nil
+	}
+	return lock
+}
+
+// allowed runs the checks the CLI runs before it writes: the schema, the policy (with
+// --policy-enforce), and approval, with the request's codes as well as the server's. It
+// answers 422 or 403 when the write isn't allowed.
+func (s *apiServer) allowed(w http.ResponseWriter, desired, remote []Variable, diff, plan DiffResult, approval string) bool {
+	for _, err := range []error{
+		schemaRejection(s.owner, s.repo, s.environment, desired, remote),
+		policyRejection(s.owner, s.repo, s.environment, desired, diff),
+	} {
+		if err != nil {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": err.Error()})
+			return false
+		}
+	}
+	codes := append(approvalCodes(), splitApprovalCodes(approval)...)
+	if missing, ok := checkApproval(s.token, s.owner, s.repo, s.environment, plan, codes).(*approvalError); ok {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": missing.Error(), "approval_request": missing.Request})
+		return false
+	}
+	return true
+}
+
+// GET /diff: what a sync would change (names only)
+func (s *apiServer) handleDiff(w http.ResponseWriter, r *http.Request) {
+	_, _, diff, err := s.diff()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"drift":     driftReport(s.owner, s.repo, s.environment, diff),
+		"unchanged": len(diff.Unchanged),
+	})
+}
+
+// POST /sync {"approval": "<code>"}: create and update variables to match the desired state
+// (never deletes)
+func (s *apiServer) handleSync(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Approval string `json:"approval"`
+	}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+	lock := s.lock(w)
+	if lock == nil {
+		return
+	}
+	defer lock.release()
+
+	local, remote, diff, err := s.diff()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	if len(diff.New)+len(diff.Updated) == 0 {
+		writeJSON(w, http.StatusOK, map[string]interface{}{"report": newSyncReport(s.owner, s.repo, s.environment, "sync")})
+		return
+	}
+	if !s.allowed(w, local, remote, diff, syncPlan(diff, nil), req.Approval) {
+		return
+	}
+
+	backupFile, err := s.backup()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	report := applyDiff(newRESTClient(s.token), newSyncReport(s.owner, s.repo, s.environment, "sync"), diff, func(format string, args ...interface{}) {
+		fmt.Printf(format+"\n", args...)
+	}, nil)
+	writeSyncManifest(s.token, report)
+	sendNotifications(report)
+	runPostSyncHooks(report)
+	writeJSON(w, reportStatus(report), map[string]interface{}{"report": report, "backup": backupFile})
+}
+
+// GET /backups: the target's backups, newest first
+func (s *apiServer) handleBackups(w http.ResponseWriter, r *http.Request) {
+	backups, err := ListBackups(s.owner, s.repo, s.environment)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	type backupInfo struct {
+		Name      string    `json:"name"`
+		CreatedAt time.Time `json:"created_at"`
+		Size      int64     `json:"size"`
+	}
+	result := []backupInfo{}
+	for i := len(backups) - 1; i >= 0; i-- {
+		info, err := os.Stat(backups[i])
+		if err != nil {
+			continue
+		}
+		result = append(result, backupInfo{Name: filepath.Base(backups[i]), CreatedAt: info.ModTime(), Size: info.Size()})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"backups": result})
+}
+
+// POST /restore {"backup": "<name>", "prune": false, "approval": "<code>"}: write a backup
+// (default: the latest) back to GitHub
+func (s *apiServer) handleRestore(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Backup   string `json:"backup"`
+		Prune    bool   `json:"prune"`
+		Approval string `json:"approval"`
+	}
+	if !decodeBody(w, r, &req) {
+		return
+	}
+
+	backups, err := ListBackups(s.owner, s.repo, s.environment)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	// Only this target's backups can be restored; names are matched, never used as paths
+	file := ""
+	for _, b := range backups {
+		if req.Backup == "" || filepath.Base(b) == req.Backup {
+			file = b
+		}
+	}
+	if file == "" {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "backup not found for this target"})
+		return
+	}
+	restored, err := readBackup(file)
+	if err != nil {
+		writeError(w, fmt.Errorf("failed to read backup: %w", err))
+		return
+	}
+
+	lock := s.lock(w)
+	if lock == nil {
+		return
+	}
+	defer lock.release()
+	remote, err := FetchGitHubVariables(s.token, s.owner, s.repo, s.environment)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	plan := restorePlan(restored, remote, req.Prune)
+	if !s.allowed(w, restored, remote, plan, plan, req.Approval) {
+		return
+	}
+
+	backupFile, err := s.backup()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	report, err := restoreVariables(newRESTClient(s.token), s.owner, s.repo, s.environment, restored, req.Prune)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	fmt.Printf("♻️  Restored %s: created %d, updated %d, deleted %d, failed %d\n",
+		filepath.Base(file), len(report.Created), len(report.Updated), len(report.Deleted), len(report.Failed))
+	sendNotifications(report)
+	writeJSON(w, reportStatus(report), map[string]interface{}{"report": report, "restored": filepath.Base(file), "backup": backupFile})
+}
+
+// backup saves the current state before a write, unless --no-backup
+func (s *apiServer) backup() (string, error) {
+	if *noBackup {
+		return "", nil
+	}
+	file, err := BackupGitHubVariables(s.token, s.owner, s.repo, s.environment)
+	if err != nil {
+		return "", fmt.Errorf("not writing, backup failed: %w", err)
+	}
+	return filepath.Base(file), nil
+}
+
+// reportStatus is 200 for a clean run and 207 when some variables failed
+func reportStatus(report *SyncReport) int {
+	if len(report.Failed) > 0 {
+		return http.StatusMultiStatus
+	}
+	return http.StatusOK
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	writeJSON(w, http.StatusBadGateway, map[string]string{"error": safeValue(err.Error())})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// startAPIServer serves the API for o/r with the variables of csv as its input, against a
+// fakeGitHub holding remote
+func startAPIServer(t *testing.T, csv string, remote map[string]string) (*httptest.Server, *fakeGitHub) {
+	fake := startFakeGitHub(t, remote)
+	input := filepath.Join(t.TempDir(), "variables.csv")
+	if err := os.WriteFile(input, []byte(csv), 0644); err != nil {
+		t.Fatal(err)
+	}
+	savedInput, savedNoBackup := *inputFile, *noBackup
+	*inputFile, *noBackup = input, true
+	t.Cleanup(func() { *inputFile, *noBackup = savedInput, savedNoBackup })
+
+	s := &apiServer{token: "test-token", owner: "o", repo: "r", apiToken: "api-token"}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sync", s.authorized("POST", s.handleSync))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, fake
+}
+
+func postSync(t *testing.T, server *httptest.Server, token, contentType, body string) int {
+	req, _ := http.NewRequest("POST", server.URL+"/sync", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer "+token)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestServeSyncRejectsUnauthenticatedAndFormRequests(t *testing.T) {
+	server, fake := startAPIServer(t, "NAME,VALUE\nA,1\n", map[string]string{})
+
+	if status := postSync(t, server, "wrong", "application/json", ""); status != http.StatusUnauthorized {
+		t.Errorf("wrong token: status %d, want 401", status)
+	}
+	if status := postSync(t, server, "api-token", "application/x-www-form-urlencoded", "a=1"); status != http.StatusUnsupportedMediaType {
+		t.Errorf("form post: status %d, want 415", status)
+	}
+	if status := postSync(t, server, "api-token", "", ""); status != http.StatusUnsupportedMediaType {
+		t.Errorf("no content type: status %d, want 415", status)
+	}
+	if len(fake.requests) != 0 {
+		t.Errorf("rejected requests reached GitHub: %q", fake.requests)
+	}
+
+	if status := postSync(t, server, "api-token", "application/json; charset=utf-8", ""); status != http.StatusOK {
+		t.Errorf("sync: status %d, want 200", status)
+	}
+	if !reflect.DeepEqual(fake.variables, map[string]string{"A": "1"}) {
+		t.Errorf("variables after sync = %v", fake.variables)
+	}
+}
+
+func TestServeSyncNeedsApprovalForProtectedTarget(t *testing.T) {
+	server, fake := startAPIServer(t, "NAME,VALUE\nA,1\n", map[string]string{})
+	protectTargets(t, "o/r")
+
+	if status := postSync(t, server, "api-token", "application/json", `{"approval": "sga1.not.valid"}`); status != http.StatusForbidden {
+		t.Errorf("status %d, want 403", status)
+	}
+	if len(fake.variables) != 0 {
+		t.Errorf("unapproved sync wrote %v", fake.variables)
+	}
+}
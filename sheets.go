@@ -0,0 +1,201 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// sheetsAPIURL is the Google Sheets API; a variable so it can point at a test server
+var sheetsAPIURL = "https://sheets.googleapis.com/v4"
+
+// sheetsScope is the OAuth scope requested for service-account credentials
+const sheetsScope = "https://www.googleapis.com/auth/spreadsheets.readonly"
+
+// sheetsDefaultRange is read when the source names no range: names in column A, values in column B
+const sheetsDefaultRange = "A:B"
+
+// sheetsSource reads variables from a range of a Google Sheets spreadsheet
+type sheetsSource struct {
+	spreadsheetID string
+	tab           string // Empty for the first tab, or the tab in the range
+	cells         string
+}
+
+// newSheetsSource parses a sheets:<spreadsheet ID>[/<range>] location and picks the
+// environment's tab from --sheet-tab
+func newSheetsSource(location, tabs, environment string) (sheetsSource, error) {
+	id, cells, _ := strings.Cut(location, "/")
+	if cells == "" {
+		cells = sheetsDefaultRange
+	}
+	tab, err := sheetTabFor(tabs, environment)
+	if err != nil {
+		return sheetsSource{}, err
+	}
+	if tab != "" && strings.Contains(cells, "!") {
+		return sheetsSource{}, fmt.Errorf("sheets range %q already names a tab; drop it or --sheet-tab", cells)
+	}
+	return sheetsSource{spreadsheetID: id, tab: tab, cells: cells}, nil
+}
+
+// sheetTabFor resolves --sheet-tab for an environment. The value is a tab name, or
+// comma-separated environment=tab pairs, optionally with a bare tab name for any other target.
+func sheetTabFor(tabs, environment string) (string, error) {
+	if tabs == "" {
+		return "", nil
+	}
+	fallback, mapped := "", false
+	for _, entry := range strings.Split(tabs, ",") {
+		entry = strings.TrimSpace(entry)
+		env, tab, ok := strings.Cut(entry, "=")
+		if !ok {
+			fallback = entry
+			continue
+		}
+		mapped = true
+		if strings.TrimSpace(env) == environment {
+			return strings.TrimSpace(tab), nil
+		}
+	}
+	if fallback == "" && mapped {
+		target := "repository variables"
+		if environment != "" {
+			target = "environment '" + environment + "'"
+		}
+		return "", fmt.Errorf("--sheet-tab %q has no tab for %s", tabs, target)
+	}
+	return fallback, nil
+}
+
+// a1Range is the range in A1 notation, with the tab name quoted
+func (s sheetsSource) a1Range() string {
+	if s.tab == "" {
+		return s.cells
+	}
+	return "'" + strings.ReplaceAll(s.tab, "'", "''") + "'!" + s.cells
+}
+
+func (s sheetsSource) Describe() string {
+	return fmt.Sprintf("Google Sheet %s (%s)", s.spreadsheetID, s.a1Range())
+}
+
+func (s sheetsSource) Load() ([]Variable, error) {
+	return FetchSheetVariables(s.spreadsheetID, s.a1Range())
+}
+
+// FetchSheetVariables reads name/value rows from a spreadsheet range. Cells are read as
+// formatted, as people see them in the sheet. A header row and rows without a name or
+// value are skipped, as in a CSV file.
+func FetchSheetVariables(spreadsheetID, a1Range string) ([]Variable, error) {
+	accessToken, err := sheetsAccessToken()
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Values [][]string `json:"values"`
+	}
+	valuesURL := fmt.Sprintf("%s/spreadsheets/%s/values/%s?majorDimension=ROWS&valueRenderOption=FORMATTED_VALUE",
+		sheetsAPIURL, url.PathEscape(spreadsheetID), url.PathEscape(a1Range))
+	err = gcpGetJSON(valuesURL, accessToken, &response)
+	if err != nil {
+		return nil, err
+	}
+
+	variables := []Variable{}
+	for row, record := range response.Values {
+		if row == 0 && isCSVHeader(record) {
+			continue
+		}
+		if len(record) < 2 {
+			continue
+		}
+		name := strings.TrimSpace(record[0])
+		if name != "" && record[1] != "" {
+			variables = append(variables, Variable{Name: name, Value: record[1]})
+		}
+	}
+	return variables, nil
+}
+
+// serviceAccountKey is the JSON key file of a Google service account
+type serviceAccountKey struct {
+	Type        string `json:"type"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// sheetsAccessToken authenticates as the service account in GOOGLE_APPLICATION_CREDENTIALS,
+// falling back to GOOGLE_OAUTH_ACCESS_TOKEN or gcloud as with Secret Manager
+func sheetsAccessToken() (string, error) {
+	path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	if path == "" {
+		return gcpAccessToken()
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read GOOGLE_APPLICATION_CREDENTIALS: %w", err)
+	}
+	var key serviceAccountKey
+	err = json.Unmarshal(data, &key)
+	if err != nil {
+		return "", fmt.Errorf("GOOGLE_APPLICATION_CREDENTIALS is not a JSON key file: %w", err)
+	}
+	if key.Type != "service_account" || key.ClientEmail == "" || key.PrivateKey == "" {
+		return "", fmt.Errorf("GOOGLE_APPLICATION_CREDENTIALS must be a service account key file")
+	}
+	if key.TokenURI == "" {
+		key.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	privateKey, err := parseRSAPrivateKey([]byte(key.PrivateKey))
+	if err != nil {
+		return "", fmt.Errorf("service account private key: %w", err)
+	}
+
+	now := time.Now()
+	assertion, err := signRS256JWT(privateKey, map[string]interface{}{
+		"iss":   key.ClientEmail,
+		"scope": sheetsScope,
+		"aud":   key.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	resp, err := httpClient.PostForm(key.TokenURI, form)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("service account %s could not get an access token: status %d: %s", key.ClientEmail, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	err = json.Unmarshal(body, &token)
+	if err != nil {
+		return "", err
+	}
+	redactor.Add(token.AccessToken)
+	return token.AccessToken, nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// errVariableNotFound is returned when a single variable doesn't exist on the target
+var errVariableNotFound = errors.New("variable not found")
+
+// getVariable reads one variable from the target
+func getVariable(token, owner, repo, environment, name string) (Variable, error) {
+	resp, body, err := preflightGet(token, variableURL(owner, repo, environment, name))
+	if err != nil {
+		return Variable{}, err
+	}
+	switch resp.StatusCode {
+	case 200:
+	case 404:
+		return Variable{}, errVariableNotFound
+	default:
+		return Variable{}, newAPIError(resp, body)
+	}
+
+	var v Variable
+	if err := json.Unmarshal(body, &v); err != nil {
+		return Variable{}, err
+	}
+	return v, nil
+}
+
+// singleVariableFlags parses the flags shared by set, get, and unset, and checks the argument count
+func singleVariableFlags(command, usage string, args []string, environment string, nargs int) (*flag.FlagSet, string) {
+	fs := flag.NewFlagSet(command, flag.ExitOnError)
+	envName := fs.String("env", environment, "Environment (defaults to GITHUB_ENVIRONMENT; empty for repository variables)")
+	fs.Parse(args)
+	if fs.NArg() != nargs {
+		fmt.Printf("❌ Usage: %s\n", usage)
+		exit(exitFailure)
+	}
+	return fs, *envName
+}
+
+// handleSet creates or updates one variable. A value of "-" is read from stdin.
+func handleSet(args []string, token, owner, repo, environment string) {
+	fs, environment := singleVariableFlags("set", "set [--env NAME] VARIABLE VALUE (use - to read the value from stdin)", args, environment, 2)
+	name, value := normalizeName(fs.Arg(0)), fs.Arg(1)
+	if err := validateVariableName(name); err != nil {
+		fatal(exitValidation, "Error: %v", err)
+	}
+	if value == "-" {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fatal(exitFailure, "Error reading the value from stdin: %v", err)
+		}
+		value = strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+	}
+	if len(value) > maxVariableSize {
+		fatal(exitValidation, "Error: value of %s is %d bytes, over GitHub's %d KB limit", name, len(value), maxVariableSize/1024)
+	}
+	registerSensitiveValues([]Variable{{Name: name, Value: value}})
+
+	lockOrExit(token, owner, repo, environment)
+	target := targetName(owner, repo, environment)
+	current, err := getVariable(token, owner, repo, environment, name)
+	switch {
+	case errors.Is(err, errVariableNotFound):
+		requireApproval(token, owner, repo, environment, DiffResult{New: []Variable{{Name: name, Value: value}}})
+		err = createOrUpdateVariable(newRESTClient(token), owner, repo, environment, Variable{Name: name, Value: value})
+		if err != nil {
+			fatal(exitFailure, "Error creating %s: %v", name, err)
+		}
+		fmt.Printf("✅ Created variable %s in %s\n", name, target)
+	case err != nil:
+		fatal(exitFailure, "Error reading %s: %v", name, err)
+	case current.Value == value:
+		fmt.Printf("✅ %s in %s already has this value\n", name, target)
+	default:
+		requireApproval(token, owner, repo, environment, DiffResult{Updated: []VariableChange{{Name: name, OldValue: current.Value, NewValue: value}}})
+		err = newRESTClient(token).UpdateVariable(owner, repo, environment, Variable{Name: name, Value: value})
+		if err != nil {
+			fatal(exitFailure, "Error updating %s: %v", name, err)
+		}
+		fmt.Printf("✅ Updated variable %s in %s: %s → %s\n", name, target,
+			truncateValue(shownValue(name, current.Value), valueLimit(40, true)), truncateValue(shownValue(name, value), valueLimit(40, true)))
+	}
+}
+
+// handleGet prints one variable's value, exactly and with nothing else, so it can be used in scripts
+func handleGet(args []string, token, owner, repo, environment string) {
+	fs, environment := singleVariableFlags("get", "get [--env NAME] VARIABLE", args, environment, 1)
+	name := normalizeName(fs.Arg(0))
+
+	v, err := getVariable(token, owner, repo, environment, name)
+	if errors.Is(err, errVariableNotFound) {
+		fatal(exitFailure, "Variable %s does not exist in %s", name, targetName(owner, repo, environment))
+	}
+	if err != nil {
+		fatal(exitFailure, "Error reading %s: %v", name, err)
+	}
+	fmt.Println(v.Value)
+}
+
+// handleUnset deletes one variable, printing its last value so it can be restored
+func handleUnset(args []string, token, owner, repo, environment string) {
+	fs, environment := singleVariableFlags("unset", "unset [--env NAME] VARIABLE", args, environment, 1)
+	name := normalizeName(fs.Arg(0))
+
+	lockOrExit(token, owner, repo, environment)
+	target := targetName(owner, repo, environment)
+	current, err := getVariable(token, owner, repo, environment, name)
+	if errors.Is(err, errVariableNotFound) {
+		fmt.Printf("✅ %s does not exist in %s; nothing to delete\n", name, target)
+		return
+	}
+	if err != nil {
+		fatal(exitFailure, "Error reading %s: %v", name, err)
+	}
+
+	requireApproval(token, owner, repo, environment, DiffResult{Deleted: []Variable{current}})
+	err = newRESTClient(token).DeleteVariable(owner, repo, environment, name)
+	if err != nil {
+		fatal(exitFailure, "Error deleting %s: %v", name, err)
+	}
+	fmt.Printf("🗑️  Deleted variable %s from %s (was: %s)\n", name, target, truncateValue(shownValue(name, current.Value), valueLimit(60, true)))
+}
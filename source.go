@@ -0,0 +1,209 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// VariableSource provides the desired set of variables for a sync
+type VariableSource interface {
+	// Describe returns a short human-readable description, e.g. "CSV file"
+	Describe() string
+	// Load returns the variables the target should end up with
+	Load() ([]Variable, error)
+}
+
+// newVariableSource builds the source selected by --source.
+// An empty spec means the input file (--input / --values).
+func newVariableSource(spec, inputFile, valuesFile, token, repo, environment string) (VariableSource, error) {
+	if spec == "" || spec == "csv" {
+		return fileSource{path: inputFile, valuesFile: valuesFile, token: token}, nil
+	}
+
+	kind, location, ok := strings.Cut(spec, ":")
+	if !ok || location == "" {
+		return nil, fmt.Errorf("invalid source %q (expected <kind>:<location>)", spec)
+	}
+
+	switch kind {
+	case "ssm":
+		return ssmSource{path: location}, nil
+	case "secretsmanager":
+		return secretsManagerSource{secretID: location}, nil
+	case "azurekv":
+		return azureKeyVaultSource{vault: location}, nil
+	case "doppler":
+		project, configName, _ := strings.Cut(location, "/")
+		return dopplerSource{project: project, config: configName}, nil
+	case "1password":
+		vault, item, ok := strings.Cut(location, "/")
+		if !ok || vault == "" || item == "" {
+			return nil, fmt.Errorf("invalid 1password source %q (expected 1password:<vault>/<item>)", spec)
+		}
+		return onePasswordSource{vault: vault, item: item}, nil
+	case "terraform":
+		return terraformSource{path: location, repo: repo, environment: environment}, nil
+	case "sheets":
+		return newSheetsSource(location, *sheetTab, environment)
+	case "gcpsm":
+		project, prefix, _ := strings.Cut(location, "/")
+		return gcpSecretSource{project: project, prefix: prefix}, nil
+	default:
+		return nil, fmt.Errorf("unknown source kind %q", kind)
+	}
+}
+
+// fileSource reads variables from the input file (local, github://, or an object store URL)
+type fileSource struct {
+	path       string
+	valuesFile string
+	token      string // For github:// inputs
+}
+
+func (s fileSource) Describe() string {
+	format := inputFormat(s.path)
+	if strings.Contains(s.path, "://") {
+		return fmt.Sprintf("%s file %s", strings.ToUpper(format), redactURL(s.path))
+	}
+	return strings.ToUpper(format) + " file"
+}
+
+func (s fileSource) Load() ([]Variable, error) {
+	return loadVariables(s.path, s.valuesFile, s.token)
+}
+
+// ssmSource reads variables from an AWS SSM Parameter Store path prefix
+type ssmSource struct {
+	path string
+}
+
+func (s ssmSource) Describe() string { return "SSM path " + s.path }
+
+func (s ssmSource) Load() ([]Variable, error) {
+	return FetchSSMParameters(s.path)
+}
+
+// secretsManagerSource reads variables from a JSON AWS Secrets Manager secret
+type secretsManagerSource struct {
+	secretID string
+}
+
+func (s secretsManagerSource) Describe() string { return "Secrets Manager secret " + s.secretID }
+
+func (s secretsManagerSource) Load() ([]Variable, error) {
+	return FetchSecretsManagerSecret(s.secretID)
+}
+
+// azureKeyVaultSource reads variables from the secrets of an Azure Key Vault
+type azureKeyVaultSource struct {
+	vault string
+}
+
+func (s azureKeyVaultSource) Describe() string { return "Azure Key Vault " + s.vault }
+
+func (s azureKeyVaultSource) Load() ([]Variable, error) {
+	return FetchAzureKeyVaultSecrets(s.vault)
+}
+
+// gcpSecretSource reads variables from Google Secret Manager secrets in a project
+type gcpSecretSource struct {
+	project string
+	prefix  string
+}
+
+func (s gcpSecretSource) Describe() string {
+	if s.prefix != "" {
+		return fmt.Sprintf("GCP Secret Manager %s (prefix %s)", s.project, s.prefix)
+	}
+	return "GCP Secret Manager " + s.project
+}
+
+func (s gcpSecretSource) Load() ([]Variable, error) {
+	return FetchGCPSecrets(s.project, s.prefix)
+}
+
+// dopplerSource reads variables from a Doppler project config
+type dopplerSource struct {
+	project string
+	config  string
+}
+
+func (s dopplerSource) Describe() string { return fmt.Sprintf("Doppler %s/%s", s.project, s.config) }
+
+func (s dopplerSource) Load() ([]Variable, error) {
+	return FetchDopplerSecrets(s.project, s.config)
+}
+
+// onePasswordSource reads variables from the fields of a 1Password item via Connect
+type onePasswordSource struct {
+	vault string
+	item  string
+}
+
+func (s onePasswordSource) Describe() string {
+	return fmt.Sprintf("1Password item %s/%s", s.vault, s.item)
+}
+
+func (s onePasswordSource) Load() ([]Variable, error) {
+	return FetchOnePasswordItem(s.vault, s.item)
+}
+
+// sourceKeyToVariableName turns a hierarchical key such as "db/host-name" into DB_HOST_NAME
+func sourceKeyToVariableName(key string) string {
+	key = strings.Trim(key, "/")
+	key = strings.NewReplacer("/", "_", "-", "_", ".", "_").Replace(key)
+	return strings.ToUpper(key)
+}
+
+// variablesFromJSONObject converts a JSON object of key/value pairs into variables
+func variablesFromJSONObject(data, origin string) ([]Variable, error) {
+	var fields map[string]interface{}
+	err := json.Unmarshal([]byte(data), &fields)
+	if err != nil {
+		return nil, fmt.Errorf("%s does not contain a JSON object of key/value pairs: %w", origin, err)
+	}
+
+	variables := []Variable{}
+	for key, value := range fields {
+		v := Variable{Name: sourceKeyToVariableName(key)}
+		if s, ok := value.(string); ok {
+			v.Value = s
+		} else {
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return nil, err
+			}
+			v.Value = string(encoded)
+		}
+		variables = append(variables, v)
+	}
+
+	sortVariables(variables)
+	return variables, nil
+}
+
+// sortVariables orders variables by name for stable output
+func sortVariables(variables []Variable) {
+	sort.Slice(variables, func(i, j int) bool {
+		return variables[i].Name < variables[j].Name
+	})
+}
+
+// terraformSource reads the target's variables from Terraform state or plan JSON
+type terraformSource struct {
+	path        string
+	repo        string
+	environment string
+}
+
+func (s terraformSource) Describe() string { return "Terraform JSON " + s.path }
+
+func (s terraformSource) Load() ([]Variable, error) {
+	data, _, err := readTextFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseTerraformVariables(data, s.repo, s.environment)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	neturl "net/url"
+	"strings"
+)
+
+// ssoRequirement is what GitHub's X-GitHub-SSO header says about an organization that
+// enforces SAML single sign-on for a token that isn't authorized for it
+type ssoRequirement struct {
+	Org          string // From the authorize URL; may be empty
+	AuthorizeURL string
+}
+
+// ssoRequired reports whether a response was refused because the token isn't authorized for
+// an organization's SAML SSO. GitHub sends "X-GitHub-SSO: required; url=<authorize URL>";
+// list endpoints that just leave such organizations out send "partial-results", which isn't
+// a refusal.
+func ssoRequired(resp *http.Response, body []byte) (ssoRequirement, bool) {
+	header := resp.Header.Get("X-GitHub-SSO")
+	if header == "" {
+		// Some endpoints only say so in the message
+		if resp.StatusCode == 403 && strings.Contains(string(body), "SAML enforcement") {
+			return ssoRequirement{}, true
+		}
+		return ssoRequirement{}, false
+	}
+	parts := strings.Split(header, ";")
+	if strings.TrimSpace(parts[0]) != "required" {
+		return ssoRequirement{}, false
+	}
+
+	var sso ssoRequirement
+	for _, part := range parts[1:] {
+		if key, value, ok := strings.Cut(strings.TrimSpace(part), "="); ok && key == "url" {
+			sso.AuthorizeURL = value
+		}
+	}
+	// The URL is https://github.com/orgs/<org>/sso?authorization_request=...
+	if u, err := neturl.Parse(sso.AuthorizeURL); err == nil {
+		if rest, ok := strings.CutPrefix(u.Path, "/orgs/"); ok {
+			sso.Org, _, _ = strings.Cut(rest, "/")
+		}
+	}
+	return sso, true
+}
+
+// Explain describes the requirement for an error message, with the owner to fall back on
+// when the header names no organization
+func (s ssoRequirement) Explain(owner string) (string, string) {
+	org := s.Org
+	if org == "" {
+		org = owner
+	}
+	explanation := "the organization enforces SAML single sign-on and the token isn't authorized for it"
+	if org != "" {
+		explanation = fmt.Sprintf("organization %s enforces SAML single sign-on and the token isn't authorized for it", org)
+	}
+	switch {
+	case s.AuthorizeURL != "":
+		return explanation, fmt.Sprintf("open %s to authorize the token, then run again", s.AuthorizeURL)
+	case org != "":
+		return explanation, fmt.Sprintf("authorize the token for %s under Settings → Developer settings → Personal access tokens → Configure SSO, "+
+			"or sign in at %s/orgs/%s/sso", org, githubWebURL(), org)
+	}
+	return explanation, "authorize the token for the organization under Settings → Developer settings → Personal access tokens → Configure SSO"
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Merge strategies for variables whose values differ between the CSV and GitHub
+const (
+	StrategyLocalWins  = "local-wins"  // Overwrite GitHub with the CSV value (default)
+	StrategyRemoteWins = "remote-wins" // Overwrite the CSV with the GitHub value
+	StrategyNewestWins = "newest-wins" // Whichever changed last: GitHub updated_at vs CSV modification time
+)
+
+// validStrategy reports whether s is a known --strategy value
+func validStrategy(s string) bool {
+	return s == StrategyLocalWins || s == StrategyRemoteWins || s == StrategyNewestWins
+}
+
+// ApplyStrategy splits the updated variables into those that will be written to GitHub
+// (kept in the returned diff) and those whose GitHub value should be written to the CSV
+func ApplyStrategy(strategy string, diff DiffResult, csvModTime time.Time) (DiffResult, []VariableChange) {
+	toGitHub := []VariableChange{}
+	toCSV := []VariableChange{}
+
+	for _, change := range diff.Updated {
+		remoteWins := false
+		switch strategy {
+		case StrategyRemoteWins:
+			remoteWins = true
+		case StrategyNewestWins:
+			remoteWins = change.RemoteUpdatedAt.After(csvModTime)
+		}
+
+		if remoteWins {
+			toCSV = append(toCSV, change)
+		} else {
+			toGitHub = append(toGitHub, change)
+		}
+	}
+
+	diff.Updated = toGitHub
+	return diff, toCSV
+}
+
+// csvModificationTime returns when the CSV input was last modified. Strategies that
+// write to the CSV only work with a plain CSV file source.
+func csvModificationTime(source VariableSource) (time.Time, error) {
+	path, err := plainCSVPath(source)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// plainCSVPath returns the CSV file behind a source, or an error if the source can't be written back to
+func plainCSVPath(source VariableSource) (string, error) {
+	fs, ok := source.(fileSource)
+	if !ok {
+		return "", fmt.Errorf("only supported with a CSV file input, not %s", source.Describe())
+	}
+	if fs.valuesFile != "" {
+		return "", fmt.Errorf("not supported with templated input (--values)")
+	}
+	if strings.Contains(fs.path, "://") {
+		return "", fmt.Errorf("not supported with a remote input (%s)", fs.path)
+	}
+	if inputFormat(fs.path) != "csv" {
+		return "", fmt.Errorf("only supported with a CSV input, not %s", fs.path)
+	}
+	if content, err := os.ReadFile(fs.path); err == nil && sopsType(fs.path, content) != "" {
+		return "", fmt.Errorf("not supported with a SOPS-encrypted input (%s); edit it with sops instead", fs.path)
+	}
+	return fs.path, nil
+}
+
+// DisplayCSVUpdates lists values that will be written back to the CSV from GitHub
+func DisplayCSVUpdates(changes []VariableChange, strategy string) {
+	if len(changes) == 0 {
+		return
+	}
+
+	fmt.Printf("%s[CSV WILL BE UPDATED - %s]%s\n", ColorYellow+ColorBold, strategy, ColorReset)
+	for _, change := range changes {
+		fmt.Printf("%s~ %s:%s\n", ColorYellow, change.Name, ColorReset)
+		fmt.Printf("  %s- %s (CSV)%s\n", ColorRed, truncateValue(shownValue(change.Name, change.NewValue), valueLimit(60, true)), ColorReset)
+		fmt.Printf("  %s+ %s (GitHub, updated %s)%s\n", ColorGreen, truncateValue(shownValue(change.Name, change.OldValue), valueLimit(60, true)),
+			change.RemoteUpdatedAt.Local().Format("2006-01-02 15:04"), ColorReset)
+	}
+	fmt.Println()
+}
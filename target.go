@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	neturl "net/url"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Target kinds of --target
+const (
+	TargetRepo = "repo" // Repository variables: repo:owner/name
+	TargetEnv  = "env"  // Environment variables: env:owner/name/environment
+	TargetOrg  = "org"  // Organization variables: org:name
+)
+
+// Target is where variables are read and written. Organization targets have no Repo; the API
+// URLs, names, and backups of a target all follow from Owner, Repo, and Environment.
+type Target struct {
+	Kind        string
+	Owner       string
+	Repo        string
+	Environment string
+	Inferred    string // Where the repository was found when nothing named it
+}
+
+// ParseTarget parses the --target syntax. Without a kind prefix, owner/name is a repository
+// and owner/name/environment an environment.
+func ParseTarget(spec string) (Target, error) {
+	kind, rest, found := strings.Cut(spec, ":")
+	if !found {
+		kind, rest = "", spec
+	}
+	parts := strings.Split(rest, "/")
+	for _, part := range parts {
+		if part == "" {
+			return Target{}, fmt.Errorf("%q has an empty part", spec)
+		}
+	}
+
+	switch kind {
+	case TargetRepo:
+		if len(parts) != 2 {
+			return Target{}, fmt.Errorf("%q must be repo:owner/name", spec)
+		}
+	case TargetEnv:
+		if len(parts) != 3 {
+			return Target{}, fmt.Errorf("%q must be env:owner/name/environment", spec)
+		}
+	case TargetOrg:
+		if len(parts) != 1 {
+			return Target{}, fmt.Errorf("%q must be org:name", spec)
+		}
+		return Target{Kind: TargetOrg, Owner: parts[0]}, nil
+	case "dependabot", "codespaces":
+		return Target{}, fmt.Errorf("GitHub has no %s variables, only %s secrets; use a repo, env, or org target", kind, kind)
+	case "":
+		switch len(parts) {
+		case 2:
+			kind = TargetRepo
+		case 3:
+			kind = TargetEnv
+		default:
+			return Target{}, fmt.Errorf("%q must be owner/name or owner/name/environment", spec)
+		}
+	default:
+		return Target{}, fmt.Errorf("unknown target kind %q (use repo, env, or org)", kind)
+	}
+
+	target := Target{Kind: kind, Owner: parts[0], Repo: parts[1]}
+	if kind == TargetEnv {
+		target.Environment = parts[2]
+	}
+	return target, nil
+}
+
+// String formats a target in the --target syntax
+func (t Target) String() string {
+	switch t.Kind {
+	case TargetOrg:
+		return TargetOrg + ":" + t.Owner
+	case TargetEnv:
+		return TargetEnv + ":" + t.Owner + "/" + t.Repo + "/" + t.Environment
+	}
+	return TargetRepo + ":" + t.Owner + "/" + t.Repo
+}
+
+// resolveTarget is the one place the target of a run is decided: --target when given,
+// otherwise --owner, --repo, and --environment, each falling back to GITHUB_OWNER,
+// GITHUB_REPO, and GITHUB_ENVIRONMENT. Fields may be empty when nothing names them; main
+// reports what's missing.
+func resolveTarget() (Target, error) {
+	if *targetSpec != "" {
+		if *ownerFlag != "" || *repoFlag != "" || *environmentFlag != "" {
+			return Target{}, fmt.Errorf("--target can't be combined with --owner, --repo, or --environment")
+		}
+		target, err := ParseTarget(*targetSpec)
+		if err != nil {
+			return Target{}, fmt.Errorf("--target: %w", err)
+		}
+		return target, nil
+	}
+	target := Target{Kind: TargetRepo, Owner: flagOrEnv(*ownerFlag, "GITHUB_OWNER"), Repo: flagOrEnv(*repoFlag, "GITHUB_REPO"),
+		Environment: flagOrEnv(*environmentFlag, "GITHUB_ENVIRONMENT")}
+	// --repo owner/name names both, as gh's --repo does
+	if owner, repo, found := strings.Cut(*repoFlag, "/"); found {
+		if owner == "" || repo == "" || (*ownerFlag != "" && *ownerFlag != owner) {
+			return Target{}, fmt.Errorf("--repo %q must be a name, or owner/name matching --owner", *repoFlag)
+		}
+		target.Owner, target.Repo = owner, repo
+	}
+	if target.Owner == "" && target.Repo == "" {
+		target.Owner, target.Repo, target.Inferred = inferRepository()
+	}
+	if target.Environment != "" {
+		target.Kind = TargetEnv
+	}
+	return target, nil
+}
+
+// flagOrEnv returns a flag's value, or the environment variable's when the flag isn't given
+func flagOrEnv(value, name string) string {
+	if value != "" {
+		return value
+	}
+	return os.Getenv(name)
+}
+
+// inferRepository finds the repository when GITHUB_OWNER and GITHUB_REPO aren't set: from
+// GITHUB_REPOSITORY in GitHub Actions, or from the origin remote when run inside a clone. It
+// also returns where the repository was found.
+func inferRepository() (string, string, string) {
+	if owner, repo, ok := strings.Cut(os.Getenv("GITHUB_REPOSITORY"), "/"); ok && owner != "" && repo != "" {
+		return owner, repo, "GITHUB_REPOSITORY"
+	}
+	out, err := exec.Command("git", "remote", "get-url", "origin").Output()
+	if err != nil {
+		return "", "", ""
+	}
+	host, owner, repo := parseGitRemote(strings.TrimSpace(string(out)))
+	if repo == "" || !strings.EqualFold(host, githubHost()) {
+		return "", "", ""
+	}
+	return owner, repo, "the git remote origin"
+}
+
+// parseGitRemote splits a remote URL into host, owner, and repository. It understands
+// https://host/owner/repo.git, git@host:owner/repo.git, and ssh://git@host/owner/repo.git.
+func parseGitRemote(remote string) (string, string, string) {
+	var host, path string
+	if u, err := neturl.Parse(remote); err == nil && u.Host != "" {
+		host, path = u.Hostname(), u.Path
+	} else if at, rest, ok := strings.Cut(remote, ":"); ok && !strings.Contains(at, "/") {
+		// scp-like syntax: [user@]host:owner/repo.git
+		if _, h, found := strings.Cut(at, "@"); found {
+			at = h
+		}
+		host, path = at, rest
+	} else {
+		return "", "", ""
+	}
+	owner, repo, ok := strings.Cut(strings.Trim(strings.TrimSuffix(path, ".git"), "/"), "/")
+	if !ok || owner == "" || repo == "" || strings.Contains(repo, "/") {
+		return "", "", ""
+	}
+	return host, owner, repo
+}
+
+// isOrgTarget reports whether owner/repo name an organization target, which has no repository
+func isOrgTarget(owner, repo string) bool {
+	return owner != "" && repo == ""
+}
+
+// confirmationName is what confirmDeletion asks to be typed for a target: owner/repo, or the
+// organization's name
+func confirmationName(owner, repo string) string {
+	if isOrgTarget(owner, repo) {
+		return owner
+	}
+	return owner + "/" + repo
+}
+
+// orgTargetCommands are the subcommands that work on organization targets; the others
+// need a repository (environments, pull requests, workflow runs)
+var orgTargetCommands = map[string]bool{
+	"set": true, "get": true, "unset": true, "list": true, "delete": true, "export": true,
+	"history": true, "rollback": true, "diff-backups": true, "approve": true,
+}
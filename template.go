@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// loadVariables reads variables from the input (a local path or github:// URL), rendering
+// it as a Go template with the given values file first when valuesFile is set
+func loadVariables(filename, valuesFile, token string) ([]Variable, error) {
+	content, err := readInput(filename, token)
+	if err != nil {
+		return nil, err
+	}
+	content, err = decodeInput(filename, content)
+	if err != nil {
+		return nil, err
+	}
+	content, err = decryptSOPS(filename, content)
+	if err != nil {
+		return nil, err
+	}
+
+	if valuesFile != "" {
+		content, err = RenderTemplate(filepath.Base(filename), content, valuesFile)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return parseVariables(filename, content)
+}
+
+// RenderTemplate renders Go template input content with values loaded from a YAML or JSON file
+func RenderTemplate(name string, content []byte, valuesFile string) ([]byte, error) {
+	values, err := loadValuesFile(valuesFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load values file: %w", err)
+	}
+
+	tmpl, err := template.New(name).
+		Funcs(templateFuncs()).
+		Option("missingkey=error").
+		Parse(escapeRepoReferences(string(content)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render template: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// loadValuesFile parses a values file as JSON (.json) or YAML (anything else)
+func loadValuesFile(filename string) (map[string]interface{}, error) {
+	data, _, err := readTextFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var parsed interface{}
+	if strings.EqualFold(filepath.Ext(filename), ".json") {
+		err = json.Unmarshal(data, &parsed)
+	} else {
+		parsed, err = ParseYAML(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	values, ok := parsed.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("top level of %s must be a mapping", filename)
+	}
+	return values, nil
+}
+
+// templateFuncs returns the helper functions available inside input templates
+func templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"default": func(def, value interface{}) interface{} {
+			if value == nil || value == "" {
+				return def
+			}
+			return value
+		},
+		"required": func(name string, value interface{}) (interface{}, error) {
+			if value == nil || value == "" {
+				return nil, fmt.Errorf("value %q is required", name)
+			}
+			return value, nil
+		},
+		"env":   os.Getenv,
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+		"quote": func(value interface{}) string {
+			// CSV-style quoting so values with commas stay in one column
+			return `"` + strings.ReplaceAll(fmt.Sprint(value), `"`, `""`) + `"`
+		},
+	}
+}
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Terraform resource types of the integrations/github provider
+const (
+	tfRepoVariable        = "github_actions_variable"
+	tfEnvironmentVariable = "github_actions_environment_variable"
+)
+
+// tfResource is one variable as a Terraform resource
+type tfResource struct {
+	Type        string
+	Name        string // Resource name, unique per type
+	Repo        string
+	Environment string
+	Variable    Variable
+	Note        string // From the notes sidecar, written as a comment
+}
+
+// Address is the resource's Terraform address, e.g. github_actions_variable.api_url
+func (r tfResource) Address() string {
+	return r.Type + "." + r.Name
+}
+
+// ImportID is the ID terraform import expects: repo:name, or repo:environment:name
+func (r tfResource) ImportID() string {
+	if r.Environment != "" {
+		return r.Repo + ":" + r.Environment + ":" + r.Variable.Name
+	}
+	return r.Repo + ":" + r.Variable.Name
+}
+
+// tfResources turns exported variables into resources with unique, valid resource names
+func tfResources(repo string, exports []environmentExport) []tfResource {
+	resources := []tfResource{}
+	used := map[string]bool{}
+	for _, export := range exports {
+		resourceType := tfRepoVariable
+		if export.Environment != "" {
+			resourceType = tfEnvironmentVariable
+		}
+		for _, v := range export.Variables {
+			base := v.Name
+			if export.Environment != "" {
+				base = export.Environment + "_" + v.Name
+			}
+			name := tfIdentifier(base)
+			for i := 2; used[resourceType+"."+name]; i++ {
+				name = fmt.Sprintf("%s_%d", tfIdentifier(base), i)
+			}
+			used[resourceType+"."+name] = true
+			resources = append(resources, tfResource{Type: resourceType, Name: name, Repo: repo, Environment: export.Environment, Variable: v, Note: export.note(v.Name)})
+		}
+	}
+	return resources
+}
+
+// tfIdentifier makes a lowercase Terraform identifier out of s
+func tfIdentifier(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' {
+			b.WriteRune(r)
+		} else {
+			b.WriteByte('_')
+		}
+	}
+	id := b.String()
+	if id == "" || (id[0] >= '0' && id[0] <= '9') {
+		id = "v_" + id
+	}
+	return id
+}
+
+// tfString quotes s as an HCL string, escaping template sequences so values are taken literally
+func tfString(s string) string {
+	replacer := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`, "\r", `\r`, "\t", `\t`, "${", "$${", "%{", "%%{")
+	return `"` + replacer.Replace(s) + `"`
+}
+
+// TerraformHCL renders variables as github_actions_variable and github_actions_environment_variable resources
+func TerraformHCL(owner, repo string, exports []environmentExport) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Actions variables of %s/%s, exported by sync-variables\n", owner, repo)
+	for _, r := range tfResources(repo, exports) {
+		b.WriteString("\n" + noteComment("", r.Note))
+		fmt.Fprintf(&b, "resource %q %q {\n", r.Type, r.Name)
+		fmt.Fprintf(&b, "  repository    = %s\n", tfString(r.Repo))
+		if r.Environment != "" {
+			fmt.Fprintf(&b, "  environment   = %s\n", tfString(r.Environment))
+		}
+		fmt.Fprintf(&b, "  variable_name = %s\n", tfString(r.Variable.Name))
+		fmt.Fprintf(&b, "  value         = %s\n", tfString(r.Variable.Value))
+		b.WriteString("}\n")
+	}
+	return b.String()
+}
+
+// TerraformImportScript renders the terraform import commands that adopt the exported
+// resources into state, so the first apply after migrating doesn't try to recreate them
+func TerraformImportScript(owner, repo string, exports []environmentExport) string {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	fmt.Fprintf(&b, "# Imports the Actions variables of %s/%s into Terraform state\n", owner, repo)
+	b.WriteString("set -e\n")
+	for _, r := range tfResources(repo, exports) {
+		fmt.Fprintf(&b, "terraform import %s %s\n", r.Address(), shellQuote(r.ImportID()))
+	}
+	return b.String()
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// tfStateResource is a resource in raw state (.tfstate) or in terraform show -json output
+type tfStateResource struct {
+	Mode      string                 `json:"mode"`
+	Type      string                 `json:"type"`
+	Values    map[string]interface{} `json:"values"` // terraform show -json
+	Instances []struct {
+		Attributes map[string]interface{} `json:"attributes"`
+	} `json:"instances"` // Raw state
+}
+
+// tfModule is a module in terraform show -json output
+type tfModule struct {
+	Resources    []tfStateResource `json:"resources"`
+	ChildModules []tfModule        `json:"child_modules"`
+}
+
+// tfDocument covers raw state, terraform show -json of a state, and terraform show -json of a plan
+type tfDocument struct {
+	Resources []tfStateResource `json:"resources"`
+	Values    *struct {
+		RootModule tfModule `json:"root_module"`
+	} `json:"values"`
+	PlannedValues *struct {
+		RootModule tfModule `json:"root_module"`
+	} `json:"planned_values"`
+}
+
+// ParseTerraformVariables extracts the github_actions_variable (environment "") or
+// github_actions_environment_variable resources of one target from Terraform state or plan
+// JSON. For a plan, the planned values are used: what Terraform will make GitHub look like.
+func ParseTerraformVariables(data []byte, repo, environment string) ([]Variable, error) {
+	var doc tfDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("not Terraform state or plan JSON: %w", err)
+	}
+
+	var attributes []map[string]interface{}
+	var walk func(m tfModule)
+	walk = func(m tfModule) {
+		for _, r := range m.Resources {
+			if r.Mode != "data" {
+				attributes = append(attributes, tfTyped(r.Type, r.Values))
+			}
+		}
+		for _, child := range m.ChildModules {
+			walk(child)
+		}
+	}
+	switch {
+	case doc.PlannedValues != nil:
+		walk(doc.PlannedValues.RootModule)
+	case doc.Values != nil:
+		walk(doc.Values.RootModule)
+	default:
+		for _, r := range doc.Resources {
+			if r.Mode == "data" {
+				continue
+			}
+			for _, instance := range r.Instances {
+				attributes = append(attributes, tfTyped(r.Type, instance.Attributes))
+			}
+		}
+	}
+
+	wantType := tfRepoVariable
+	if environment != "" {
+		wantType = tfEnvironmentVariable
+	}
+	variables := []Variable{}
+	for _, attrs := range attributes {
+		if attrs["@type"] != wantType || !tfSameRepository(tfAttribute(attrs, "repository"), repo) {
+			continue
+		}
+		if environment != "" && tfAttribute(attrs, "environment") != environment {
+			continue
+		}
+		name := tfAttribute(attrs, "variable_name")
+		value, ok := attrs["value"].(string)
+		if !ok {
+			// Unknown until apply in a plan; there's nothing to compare yet
+			fmt.Printf("⚠️  Warning: %s has no known value in the Terraform JSON; skipped\n", name)
+			continue
+		}
+		variables = append(variables, Variable{Name: name, Value: value})
+	}
+	return variables, nil
+}
+
+// tfTyped tags a resource's attributes with its type
+func tfTyped(resourceType string, attrs map[string]interface{}) map[string]interface{} {
+	typed := map[string]interface{}{"@type": resourceType}
+	for k, v := range attrs {
+		typed[k] = v
+	}
+	return typed
+}
+
+func tfAttribute(attrs map[string]interface{}, name string) string {
+	s, _ := attrs[name].(string)
+	return s
+}
+
+// tfSameRepository matches the provider's repository attribute, a name or owner/name, against repo
+func tfSameRepository(attribute, repo string) bool {
+	if _, name, ok := strings.Cut(attribute, "/"); ok {
+		attribute = name
+	}
+	return strings.EqualFold(attribute, repo)
+}
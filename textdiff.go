@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// maxDiffCells bounds the LCS table size; larger values fall back to a whole-value replacement
+const maxDiffCells = 4_000_000
+
+// diffLine is a single line of a line-level diff: ' ' unchanged, '-' removed, '+' added
+type diffLine struct {
+	Kind byte
+	Text string
+}
+
+// isStructuredValue reports whether a value is multi-line or a JSON object/array,
+// in which case a line diff is more useful than whole-value before/after
+func isStructuredValue(value string) bool {
+	if strings.Contains(value, "\n") {
+		return true
+	}
+	_, ok := prettyJSON(value)
+	return ok
+}
+
+// prettyJSON re-indents a JSON object or array so it can be diffed line by line
+func prettyJSON(value string) (string, bool) {
+	trimmed := strings.TrimSpace(value)
+	if !strings.HasPrefix(trimmed, "{") && !strings.HasPrefix(trimmed, "[") {
+		return "", false
+	}
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, []byte(trimmed), "", "  "); err != nil {
+		return "", false
+	}
+	return buf.String(), true
+}
+
+// diffLines computes a minimal line diff between a and b using longest common subsequence
+func diffLines(a, b []string) []diffLine {
+	if len(a)*len(b) > maxDiffCells {
+		result := make([]diffLine, 0, len(a)+len(b))
+		for _, line := range a {
+			result = append(result, diffLine{'-', line})
+		}
+		for _, line := range b {
+			result = append(result, diffLine{'+', line})
+		}
+		return result
+	}
+
+	// lcs[i][j] is the LCS length of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	result := []diffLine{}
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			result = append(result, diffLine{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			result = append(result, diffLine{'-', a[i]})
+			i++
+		default:
+			result = append(result, diffLine{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		result = append(result, diffLine{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		result = append(result, diffLine{'+', b[j]})
+	}
+	return result
+}
+
+// UnifiedValueDiff renders a unified-diff style comparison of two values with the
+// given number of context lines around each change. JSON values are pretty-printed first.
+func UnifiedValueDiff(oldValue, newValue string, context int) []string {
+	if context < 0 {
+		context = 0
+	}
+
+	oldPretty, oldIsJSON := prettyJSON(oldValue)
+	newPretty, newIsJSON := prettyJSON(newValue)
+	if oldIsJSON && newIsJSON {
+		oldValue, newValue = oldPretty, newPretty
+	}
+
+	lines := diffLines(strings.Split(oldValue, "\n"), strings.Split(newValue, "\n"))
+
+	// Mark which lines are within context of a change
+	show := make([]bool, len(lines))
+	for idx, line := range lines {
+		if line.Kind == ' ' {
+			continue
+		}
+		for k := idx - context; k <= idx+context; k++ {
+			if k >= 0 && k < len(lines) {
+				show[k] = true
+			}
+		}
+	}
+
+	output := []string{}
+	oldLine, newLine := 1, 1
+	for idx := 0; idx < len(lines); {
+		if !show[idx] {
+			if lines[idx].Kind != '+' {
+				oldLine++
+			}
+			if lines[idx].Kind != '-' {
+				newLine++
+			}
+			idx++
+			continue
+		}
+
+		// Collect one hunk of consecutive visible lines
+		end := idx
+		oldCount, newCount := 0, 0
+		for end < len(lines) && show[end] {
+			if lines[end].Kind != '+' {
+				oldCount++
+			}
+			if lines[end].Kind != '-' {
+				newCount++
+			}
+			end++
+		}
+
+		output = append(output, fmt.Sprintf("@@ -%d,%d +%d,%d @@", oldLine, oldCount, newLine, newCount))
+		for ; idx < end; idx++ {
+			output = append(output, string(lines[idx].Kind)+lines[idx].Text)
+		}
+		oldLine += oldCount
+		newLine += newCount
+	}
+
+	return output
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// keychainService is the service name the token is stored under in the OS keychain
+const keychainService = "sync-github-variable"
+
+// resolveToken reads the GitHub token from the selected --token-source
+func resolveToken(source string) (string, error) {
+	switch source {
+	case "", "env":
+		// GITHUB_TOKEN first, then the variables the gh CLI reads
+		names := []string{"GITHUB_TOKEN", "GH_TOKEN"}
+		if githubHost() != "github.com" {
+			names = append(names, "GH_ENTERPRISE_TOKEN", "GITHUB_ENTERPRISE_TOKEN")
+		}
+		for _, name := range names {
+			if token := os.Getenv(name); token != "" {
+				return token, nil
+			}
+		}
+		if path := os.Getenv("GITHUB_TOKEN_FILE"); path != "" {
+			return tokenFromFile(path)
+		}
+		return loginToken(githubHost())
+	case "gh":
+		return ghCLIToken(githubHost())
+	case "keychain":
+		return keychainToken(githubHost())
+	default:
+		return "", fmt.Errorf("unknown --token-source %q (use env, gh, or keychain)", source)
+	}
+}
+
+// apiURLFromEnv returns the API URL from GITHUB_API_URL, or for GH_HOST as the gh CLI uses it
+// (a GitHub Enterprise Server host); "" means api.github.com
+func apiURLFromEnv() string {
+	if apiURL := os.Getenv("GITHUB_API_URL"); apiURL != "" {
+		return strings.TrimRight(apiURL, "/")
+	}
+	if host := os.Getenv("GH_HOST"); host != "" && host != "github.com" {
+		return "https://" + host + "/api/v3"
+	}
+	return ""
+}
+
+// githubHost returns the web host for the configured API URL (api.github.com → github.com)
+func githubHost() string {
+	api, err := url.Parse(githubAPIURL)
+	if err != nil || api.Host == "" || api.Host == "api.github.com" {
+		return "github.com"
+	}
+	return api.Host
+}
+
+// ghCLIToken asks the gh CLI for its token, falling back to the token in its hosts.yml
+// (older gh versions, or installs without a keyring)
+func ghCLIToken(host string) (string, error) {
+	out, err := exec.Command("gh", "auth", "token", "--hostname", host).Output()
+	if err == nil {
+		if token := strings.TrimSpace(string(out)); token != "" {
+			return token, nil
+		}
+	}
+
+	token, fileErr := ghConfigToken(host)
+	if fileErr != nil || token == "" {
+		return "", fmt.Errorf("no gh token for %s (run 'gh auth login --hostname %s')", host, host)
+	}
+	return token, nil
+}
+
+// ghConfigToken reads oauth_token for host from gh's hosts.yml
+func ghConfigToken(host string) (string, error) {
+	dir := os.Getenv("GH_CONFIG_DIR")
+	if dir == "" {
+		if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+			dir = filepath.Join(xdg, "gh")
+		} else {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return "", err
+			}
+			dir = filepath.Join(home, ".config", "gh")
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "hosts.yml"))
+	if err != nil {
+		return "", err
+	}
+	parsed, err := ParseYAML(data)
+	if err != nil {
+		return "", err
+	}
+
+	hosts, _ := parsed.(map[string]interface{})
+	entry, _ := hosts[host].(map[string]interface{})
+	token, _ := entry["oauth_token"].(string)
+	return token, nil
+}
+
+// keychainToken reads the token stored for host in the OS keychain
+// (macOS Keychain via security, Linux Secret Service via secret-tool)
+func keychainToken(host string) (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", keychainService, "-a", host, "-w")
+	case "linux":
+		cmd = exec.Command("secret-tool", "lookup", "service", keychainService, "account", host)
+	default:
+		return "", fmt.Errorf("keychain token source is not supported on %s", runtime.GOOS)
+	}
+
+	out, err := cmd.Output()
+	token := strings.TrimSpace(string(out))
+	if err != nil || token == "" {
+		return "", fmt.Errorf("no token for %s in the keychain under service %q (see README for how to store one)", host, keychainService)
+	}
+	return token, nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// wrapTransport layers a RoundTripper around the shared HTTP client's current transport
+func wrapTransport(wrap func(base http.RoundTripper) http.RoundTripper) {
+	base := httpClient.Transport
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	httpClient.Transport = wrap(base)
+}
+
+// isGitHubAPIRequest reports whether a request is addressed to the configured GitHub API host
+func isGitHubAPIRequest(req *http.Request) bool {
+	api, err := url.Parse(githubAPIURL)
+	if err != nil {
+		return false
+	}
+	return req.URL.Host == api.Host
+}
+
+// configureTransport replaces the shared client's base transport with one that honors
+// proxy settings, trusts an additional CA bundle, and presents a client certificate.
+// It must run before any wrapping transports are layered on top.
+func configureTransport(proxyURL, caCertFile, clientCertFile, clientKeyFile string) error {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	// HTTPS_PROXY / HTTP_PROXY / NO_PROXY are honored unless --proxy overrides them
+	if proxyURL != "" {
+		parsed, err := url.Parse(proxyURL)
+		if err != nil || parsed.Host == "" {
+			return fmt.Errorf("invalid proxy URL %q", proxyURL)
+		}
+		transport.Proxy = http.ProxyURL(parsed)
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if caCertFile != "" {
+		pem, err := os.ReadFile(caCertFile)
+		if err != nil {
+			return fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		// Trust the system roots plus the custom CA, so public endpoints keep working
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no PEM certificates found in %s", caCertFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if clientCertFile != "" || clientKeyFile != "" {
+		if clientCertFile == "" || clientKeyFile == "" {
+			return fmt.Errorf("--client-cert and --client-key must be used together")
+		}
+		cert, err := tls.LoadX509KeyPair(clientCertFile, clientKeyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	transport.TLSClientConfig = tlsConfig
+	httpClient.Transport = transport
+	return nil
+}
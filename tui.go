@@ -0,0 +1,318 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// tuiItem is one reviewable change: a new or an updated variable
+type tuiItem struct {
+	name     string
+	oldValue string // Empty for new variables
+	newValue string
+	isNew    bool
+	selected bool
+	expanded bool
+}
+
+// diffTUI is the state of the interactive review screen
+type diffTUI struct {
+	target   string
+	items    []tuiItem
+	cursor   int
+	offset   int // First visible line
+	width    int
+	height   int
+	query    string
+	typing   bool // Entering a search query
+	readOnly bool
+	out      *bufio.Writer
+}
+
+// tuiAvailable reports whether the interactive review can run: stdin and stdout must be terminals
+func tuiAvailable() bool {
+	return isTerminal(os.Stdin) && isTerminal(os.Stdout) && guard == nil
+}
+
+// isTerminal reports whether f is a character device (a TTY) rather than a pipe or file
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// ReviewDiffTUI shows new and updated variables in a scrollable, searchable list where each
+// change can be toggled. It returns the diff reduced to the selected changes, and whether
+// the user confirmed. In read-only mode (--diff) there is nothing to confirm.
+func ReviewDiffTUI(target string, diff DiffResult, readOnly bool) (DiffResult, bool, error) {
+	t := &diffTUI{target: target, readOnly: readOnly, out: bufio.NewWriter(os.Stdout)}
+	for _, v := range diff.New {
+		t.items = append(t.items, tuiItem{name: v.Name, newValue: shownValue(v.Name, v.Value), isNew: true, selected: true})
+	}
+	for _, c := range diff.Updated {
+		t.items = append(t.items, tuiItem{name: c.Name, oldValue: shownValue(c.Name, c.OldValue), newValue: shownValue(c.Name, c.NewValue), selected: true})
+	}
+
+	restore, err := enableRawMode()
+	if err != nil {
+		return diff, false, err
+	}
+	// Alternate screen, hidden cursor; both undone on the way out
+	t.out.WriteString("\x1b[?1049h\x1b[?25l")
+	defer func() {
+		t.out.WriteString("\x1b[?25h\x1b[?1049l")
+		t.out.Flush()
+		restore()
+	}()
+
+	confirmed := t.loop()
+	if !confirmed {
+		return diff, false, nil
+	}
+
+	selected := diff
+	selected.New, selected.Updated = []Variable{}, []VariableChange{}
+	for i, item := range t.items {
+		if !item.selected {
+			continue
+		}
+		if item.isNew {
+			selected.New = append(selected.New, diff.New[i])
+		} else {
+			selected.Updated = append(selected.Updated, diff.Updated[i-len(diff.New)])
+		}
+	}
+	return selected, true, nil
+}
+
+// loop handles keys until the user confirms (true) or quits (false)
+func (t *diffTUI) loop() bool {
+	buf := make([]byte, 16)
+	for {
+		t.width, t.height = terminalSize()
+		t.render()
+
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return false
+		}
+		key := string(buf[:n])
+
+		if t.typing {
+			switch key {
+			case "\r", "\n":
+				t.typing = false
+				t.findNext(t.cursor)
+			case "\x1b":
+				t.typing, t.query = false, ""
+			case "\x7f", "\b":
+				if len(t.query) > 0 {
+					_, size := utf8.DecodeLastRuneInString(t.query)
+					t.query = t.query[:len(t.query)-size]
+				}
+			default:
+				if key[0] >= ' ' {
+					t.query += key
+				}
+			}
+			continue
+		}
+
+		switch key {
+		case "q", "\x1b", "\x03":
+			return false
+		case "y", "c":
+			if !t.readOnly {
+				return true
+			}
+		case "j", "\x1b[B":
+			t.move(1)
+		case "k", "\x1b[A":
+			t.move(-1)
+		case "\x1b[6~", "f":
+			t.move(t.height - 4)
+		case "\x1b[5~", "b":
+			t.move(-(t.height - 4))
+		case "g", "\x1b[H":
+			t.cursor = 0
+		case "G", "\x1b[F":
+			t.cursor = len(t.items) - 1
+		case " ":
+			if !t.readOnly && len(t.items) > 0 {
+				t.items[t.cursor].selected = !t.items[t.cursor].selected
+			}
+		case "a":
+			if !t.readOnly {
+				all := !t.allSelected()
+				for i := range t.items {
+					t.items[i].selected = all
+				}
+			}
+		case "\r", "\n", "e":
+			if len(t.items) > 0 {
+				t.items[t.cursor].expanded = !t.items[t.cursor].expanded
+			}
+		case "/":
+			t.typing, t.query = true, ""
+		case "n":
+			t.findNext(t.cursor + 1)
+		}
+	}
+}
+
+func (t *diffTUI) move(delta int) {
+	t.cursor += delta
+	if t.cursor >= len(t.items) {
+		t.cursor = len(t.items) - 1
+	}
+	if t.cursor < 0 {
+		t.cursor = 0
+	}
+}
+
+func (t *diffTUI) allSelected() bool {
+	for _, item := range t.items {
+		if !item.selected {
+			return false
+		}
+	}
+	return true
+}
+
+// findNext moves the cursor to the first item at or after from (wrapping) whose name matches the query
+func (t *diffTUI) findNext(from int) {
+	if t.query == "" || len(t.items) == 0 {
+		return
+	}
+	query := strings.ToLower(t.query)
+	for i := 0; i < len(t.items); i++ {
+		index := (from + i) % len(t.items)
+		if strings.Contains(strings.ToLower(t.items[index].name), query) {
+			t.cursor = index
+			return
+		}
+	}
+}
+
+// lines renders every item, returning the lines and the index of the cursor item's first line
+func (t *diffTUI) lines() ([]string, int) {
+	var lines []string
+	cursorLine := 0
+	valueWidth := t.width - 12
+	if valueWidth < 20 {
+		valueWidth = 20
+	}
+
+	for i, item := range t.items {
+		if i == t.cursor {
+			cursorLine = len(lines)
+		}
+		check := "[x]"
+		if !item.selected {
+			check = "[ ]"
+		}
+		marker, color := "~", ColorYellow
+		if item.isNew {
+			marker, color = "+", ColorGreen
+		}
+
+		line := fmt.Sprintf("%s %s%s %s%s", check, color, marker, item.name, ColorReset)
+		if !item.expanded {
+			summary := item.newValue
+			if !item.isNew {
+				summary = item.oldValue + " → " + item.newValue
+			}
+			line += " " + ColorGray + truncateValue(strings.ReplaceAll(summary, "\n", "⏎"), valueWidth-len(item.name)) + ColorReset
+		}
+		if i == t.cursor {
+			line = "\x1b[7m>\x1b[27m " + line
+		} else {
+			line = "  " + line
+		}
+		if t.query != "" && !t.typing && strings.Contains(strings.ToLower(item.name), strings.ToLower(t.query)) {
+			line += " " + ColorBold + "•" + ColorReset
+		}
+		lines = append(lines, line)
+
+		if item.expanded {
+			if !item.isNew {
+				for _, l := range wrapValue(item.oldValue, valueWidth) {
+					lines = append(lines, "      "+ColorRed+"- "+l+ColorReset)
+				}
+			}
+			for _, l := range wrapValue(item.newValue, valueWidth) {
+				lines = append(lines, "      "+ColorGreen+"+ "+l+ColorReset)
+			}
+		}
+	}
+	return lines, cursorLine
+}
+
+// wrapValue splits a value into lines of at most width bytes, honoring its own newlines
+func wrapValue(value string, width int) []string {
+	var wrapped []string
+	for _, line := range strings.Split(value, "\n") {
+		for len(line) > width {
+			cut := width
+			for cut > 0 && !utf8.RuneStart(line[cut]) {
+				cut--
+			}
+			wrapped = append(wrapped, line[:cut])
+			line = line[cut:]
+		}
+		wrapped = append(wrapped, line)
+	}
+	return wrapped
+}
+
+func (t *diffTUI) render() {
+	lines, cursorLine := t.lines()
+	view := t.height - 3 // Header and two footer lines
+	if view < 1 {
+		view = 1
+	}
+	if cursorLine < t.offset {
+		t.offset = cursorLine
+	}
+	if cursorLine >= t.offset+view {
+		t.offset = cursorLine - view + 1
+	}
+	// Keep an expanded item's value on screen where possible
+	if t.cursor < len(t.items) && t.items[t.cursor].expanded {
+		end := len(lines)
+		if t.cursor+1 < len(t.items) {
+			next, _ := (&diffTUI{items: t.items[:t.cursor+1], width: t.width}).lines()
+			end = len(next)
+		}
+		if end > t.offset+view && end-view <= cursorLine {
+			t.offset = end - view
+		}
+	}
+
+	selected := 0
+	for _, item := range t.items {
+		if item.selected {
+			selected++
+		}
+	}
+
+	t.out.WriteString("\x1b[H\x1b[2J")
+	fmt.Fprintf(t.out, "%s📋 %s%s  %d change(s), %d selected\r\n", ColorBold, t.target, ColorReset, len(t.items), selected)
+	for i := t.offset; i < len(lines) && i < t.offset+view; i++ {
+		t.out.WriteString(lines[i] + "\r\n")
+	}
+	for i := len(lines) - t.offset; i < view; i++ {
+		t.out.WriteString("\r\n")
+	}
+
+	if t.typing {
+		fmt.Fprintf(t.out, "\r\n/%s▏", t.query)
+	} else if t.readOnly {
+		fmt.Fprintf(t.out, "\r\n%s↑/↓ move  PgUp/PgDn page  enter expand  / search  n next  q quit%s", ColorGray, ColorReset)
+	} else {
+		fmt.Fprintf(t.out, "\r\n%s↑/↓ move  space toggle  a all  enter expand  / search  n next  y confirm  q cancel%s", ColorGray, ColorReset)
+	}
+	t.out.Flush()
+}
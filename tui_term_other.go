@@ -0,0 +1,14 @@
+//go:build windows || plan9
+
+package main
+
+import "errors"
+
+// enableRawMode isn't implemented here, so --tui falls back to plain output
+func enableRawMode() (func(), error) {
+	return nil, errors.New("the interactive review isn't supported on this platform")
+}
+
+func terminalSize() (int, int) {
+	return 80, 24
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// GitHub limits on Actions variables
+const (
+	maxVariableSize = 48 * 1024  // Per variable value
+	maxTotalSize    = 256 * 1024 // Combined values per repository or environment
+)
+
+// variableNamePattern is GitHub's rule for variable names: letters, digits, and underscores, not starting with a digit
+var variableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// inputRow is a desired variable with the input line it came from (0 when unknown)
+type inputRow struct {
+	Variable
+	Line int
+}
+
+// validationIssue is a problem with one input row
+type validationIssue struct {
+	Line    int
+	Name    string
+	Message string
+}
+
+// validateVariableName checks a name against GitHub's naming rules
+func validateVariableName(name string) error {
+	switch {
+	case !variableNamePattern.MatchString(name):
+		return fmt.Errorf("invalid name %q: only letters, digits, and underscores are allowed, and it can't start with a digit", name)
+	case strings.HasPrefix(strings.ToUpper(name), "GITHUB_"):
+		return fmt.Errorf("invalid name %q: the GITHUB_ prefix is reserved", name)
+	}
+	return nil
+}
+
+// validateRows checks names, value sizes, duplicates, and the combined size limit
+func validateRows(rows []inputRow) []validationIssue {
+	issues := []validationIssue{}
+	seen := map[string]int{}
+	total := 0
+	for _, row := range rows {
+		if err := validateVariableName(row.Name); err != nil {
+			issues = append(issues, validationIssue{Line: row.Line, Name: row.Name, Message: err.Error()})
+		}
+		if len(row.Value) > maxVariableSize {
+			issues = append(issues, validationIssue{Line: row.Line, Name: row.Name,
+				Message: fmt.Sprintf("value of %s is %d bytes, over GitHub's %d KB limit", row.Name, len(row.Value), maxVariableSize/1024)})
+		}
+		// Names are case-insensitive on GitHub
+		upper := strings.ToUpper(row.Name)
+		if first, ok := seen[upper]; ok {
+			message := fmt.Sprintf("duplicate variable %s", row.Name)
+			if first > 0 {
+				message += fmt.Sprintf(" (first defined on line %d)", first)
+			}
+			issues = append(issues, validationIssue{Line: row.Line, Name: row.Name, Message: message})
+		} else {
+			seen[upper] = row.Line
+		}
+		total += len(row.Value)
+	}
+	if total > maxTotalSize {
+		issues = append(issues, validationIssue{
+			Message: fmt.Sprintf("combined size of all values is %d bytes, over GitHub's %d KB limit", total, maxTotalSize/1024)})
+	}
+	return issues
+}
+
+// inputRows returns the desired variables with their CSV line numbers when the input is a
+// plain local CSV file; other inputs get line 0
+func inputRows(source VariableSource, variables []Variable) []inputRow {
+	lines := map[string][]int{}
+	if path, err := plainCSVPath(source); err == nil {
+		if found, err := readCSVLineNumbers(path); err == nil {
+			for name, l := range found {
+				lines[normalizeName(name)] = append(lines[normalizeName(name)], l...)
+			}
+		}
+	}
+
+	rows := make([]inputRow, len(variables))
+	used := map[string]int{}
+	for i, v := range variables {
+		row := inputRow{Variable: v}
+		// Duplicates keep their own lines, in file order
+		if l := lines[v.Name]; used[v.Name] < len(l) {
+			row.Line = l[used[v.Name]]
+			used[v.Name]++
+		}
+		rows[i] = row
+	}
+	return rows
+}
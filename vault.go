@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// vaultPrefix marks a CSV value that should be resolved from HashiCorp Vault
+const vaultPrefix = "vault:"
+
+// ResolveVaultReferences replaces values of the form vault:<path>#<key> with the
+// referenced field read from Vault (VAULT_ADDR / VAULT_TOKEN)
+func ResolveVaultReferences(variables []Variable) ([]Variable, error) {
+	// Each secret path is read only once, even if several keys reference it
+	cache := make(map[string]map[string]interface{})
+
+	resolved := make([]Variable, len(variables))
+	for i, v := range variables {
+		resolved[i] = v
+		if !strings.HasPrefix(v.Value, vaultPrefix) {
+			continue
+		}
+
+		ref := strings.TrimPrefix(v.Value, vaultPrefix)
+		path, key, ok := strings.Cut(ref, "#")
+		if !ok || path == "" || key == "" {
+			return nil, fmt.Errorf("variable %s: invalid vault reference %q (expected vault:<path>#<key>)", v.Name, v.Value)
+		}
+
+		data, exists := cache[path]
+		if !exists {
+			var err error
+			data, err = readVaultSecret(path)
+			if err != nil {
+				return nil, fmt.Errorf("variable %s: %w", v.Name, err)
+			}
+			cache[path] = data
+		}
+
+		value, exists := data[key]
+		if !exists {
+			return nil, fmt.Errorf("variable %s: key %q not found in vault secret %s", v.Name, key, path)
+		}
+		if s, isString := value.(string); isString {
+			resolved[i].Value = s
+		} else {
+			// Non-string fields (numbers, booleans, objects) are synced as their JSON form
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return nil, fmt.Errorf("variable %s: %w", v.Name, err)
+			}
+			resolved[i].Value = string(encoded)
+		}
+	}
+
+	return resolved, nil
+}
+
+// readVaultSecret reads a secret and returns its fields, unwrapping KV v2 responses
+func readVaultSecret(path string) (map[string]interface{}, error) {
+	addr := strings.TrimRight(os.Getenv("VAULT_ADDR"), "/")
+	vaultToken := os.Getenv("VAULT_TOKEN")
+	if addr == "" || vaultToken == "" {
+		return nil, fmt.Errorf("VAULT_ADDR and VAULT_TOKEN must be set to resolve vault references")
+	}
+
+	url := fmt.Sprintf("%s/v1/%s", addr, strings.TrimLeft(path, "/"))
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("X-Vault-Token", vaultToken)
+	if namespace := os.Getenv("VAULT_NAMESPACE"); namespace != "" {
+		req.Header.Set("X-Vault-Namespace", namespace)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("vault returned status %d for %s: %s", resp.StatusCode, path, strings.TrimSpace(string(body)))
+	}
+
+	var response struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	err = json.Unmarshal(body, &response)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse vault response for %s: %w", path, err)
+	}
+
+	// KV v2 nests the secret fields under data.data alongside data.metadata
+	if inner, ok := response.Data["data"].(map[string]interface{}); ok {
+		if _, hasMetadata := response.Data["metadata"]; hasMetadata {
+			return inner, nil
+		}
+	}
+
+	return response.Data, nil
+}
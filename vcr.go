@@ -0,0 +1,206 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+// replayToken stands in for a token during --replay, which never reaches GitHub
+const replayToken = "offline-replay-token"
+
+// recordedHeaders are the response headers kept in fixtures; the rest are noise for replay
+var recordedHeaders = []string{"Content-Type", "Link", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-OAuth-Scopes", "X-Accepted-GitHub-Permissions"}
+
+// Interaction is one recorded request/response pair
+type Interaction struct {
+	Method      string            `json:"method"`
+	URL         string            `json:"url"` // Path and query; the host is dropped so fixtures replay against any API URL
+	RequestBody string            `json:"request_body,omitempty"`
+	Status      int               `json:"status"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	Body        string            `json:"body"`
+}
+
+// Cassette is a fixture file of recorded interactions, in request order
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// requestKey identifies requests for matching during replay
+func requestKey(method, url string) string {
+	return method + " " + url
+}
+
+// sanitizeFixture removes credentials and sensitive values from recorded text
+func sanitizeFixture(text string) string {
+	return safeValue(text)
+}
+
+// recordTransport passes requests through and captures them for --record
+type recordTransport struct {
+	base     http.RoundTripper
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+func (t *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var requestBody []byte
+	if req.Body != nil && req.Body != http.NoBody {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		requestBody = data
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(data))
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return resp, err
+	}
+
+	interaction := Interaction{
+		Method:      req.Method,
+		URL:         req.URL.RequestURI(),
+		RequestBody: string(requestBody),
+		Status:      resp.StatusCode,
+		Headers:     map[string]string{},
+		Body:        string(redactTokenFields(body)),
+	}
+	for _, name := range recordedHeaders {
+		if value := resp.Header.Get(name); value != "" {
+			interaction.Headers[name] = value
+		}
+	}
+
+	t.mu.Lock()
+	t.cassette.Interactions = append(t.cassette.Interactions, interaction)
+	t.mu.Unlock()
+	return resp, nil
+}
+
+// save writes the recorded interactions to path. Sanitizing happens here rather than while
+// recording, because sensitive values are only known once the responses holding them are parsed.
+func (t *recordTransport) save(path string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	cassette := Cassette{Interactions: make([]Interaction, 0, len(t.cassette.Interactions))}
+	for _, interaction := range t.cassette.Interactions {
+		interaction.URL = sanitizeFixture(interaction.URL)
+		interaction.RequestBody = sanitizeFixture(interaction.RequestBody)
+		interaction.Body = sanitizeFixture(interaction.Body)
+		headers := map[string]string{}
+		for name, value := range interaction.Headers {
+			headers[name] = sanitizeFixture(value)
+		}
+		interaction.Headers = headers
+		cassette.Interactions = append(cassette.Interactions, interaction)
+	}
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(cassette); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0600)
+}
+
+// redactTokenFields blanks minted tokens (GitHub App installation tokens) in a JSON response,
+// which aren't registered with the redactor until after the response is read
+func redactTokenFields(body []byte) []byte {
+	var parsed map[string]interface{}
+	if json.Unmarshal(body, &parsed) != nil {
+		return body
+	}
+	if _, ok := parsed["token"].(string); !ok {
+		return body
+	}
+	parsed["token"] = redactedPlaceholder
+	redacted, err := json.Marshal(parsed)
+	if err != nil {
+		return body
+	}
+	return redacted
+}
+
+// replayTransport answers requests from a cassette without touching the network, for --replay.
+// Repeated requests are answered in recorded order; the last answer repeats once they run out.
+type replayTransport struct {
+	mu     sync.Mutex
+	byKey  map[string][]Interaction
+	served map[string]int
+	path   string
+}
+
+// loadReplayTransport reads a cassette written by --record
+func loadReplayTransport(path string) (*replayTransport, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("invalid fixture %s: %w", path, err)
+	}
+
+	t := &replayTransport{byKey: map[string][]Interaction{}, served: map[string]int{}, path: path}
+	for _, interaction := range cassette.Interactions {
+		key := requestKey(interaction.Method, interaction.URL)
+		t.byKey[key] = append(t.byKey[key], interaction)
+	}
+	return t, nil
+}
+
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+	key := requestKey(req.Method, sanitizeFixture(req.URL.RequestURI()))
+
+	t.mu.Lock()
+	candidates := t.byKey[key]
+	if len(candidates) == 0 {
+		t.mu.Unlock()
+		return nil, fmt.Errorf("replay: no recorded interaction for %s in %s", key, t.path)
+	}
+	i := t.served[key]
+	if i >= len(candidates) {
+		i = len(candidates) - 1
+	}
+	t.served[key]++
+	interaction := candidates[i]
+	t.mu.Unlock()
+
+	header := http.Header{}
+	for name, value := range interaction.Headers {
+		header.Set(name, value)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Status, http.StatusText(interaction.Status)),
+		StatusCode:    interaction.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(interaction.Body)),
+		ContentLength: int64(len(interaction.Body)),
+		Request:       req,
+	}, nil
+}
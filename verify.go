@@ -0,0 +1,540 @@
+package main
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// workflowVarPattern matches vars.NAME and vars['NAME'] expressions in workflow files
+var workflowVarPattern = regexp.MustCompile(`\bvars(?:\.([A-Za-z_][A-Za-z0-9_]*)|\[\s*['"]([A-Za-z_][A-Za-z0-9_]*)['"]\s*\])`)
+
+// Verification check statuses
+const (
+	CheckPass    = "pass"
+	CheckFail    = "fail"
+	CheckSkipped = "skipped"
+)
+
+// verifyCheck is the outcome of one read-only verification check
+type verifyCheck struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Summary string `json:"summary"`
+	File    string `json:"file,omitempty"` // Evidence file in the bundle
+}
+
+// verifyContext is the shared state every verification check reads from
+type verifyContext struct {
+	token, owner, repo, environment string
+	local                           []Variable
+	remote                          []Variable
+	manifest, manifestPublicKey     string // Sync manifest to check, and the key it must be signed with
+}
+
+// verifyCheckFunc runs one check and returns its outcome plus the evidence to store in the bundle
+type verifyCheckFunc func(ctx *verifyContext) (verifyCheck, interface{})
+
+// verifyChecks are run in order by the verify command
+var verifyChecks = []verifyCheckFunc{
+	checkDrift,
+	checkWorkflowReferences,
+	checkPolicy,
+	checkSchema,
+	checkAuditLog,
+	checkManifest,
+}
+
+// handleVerify runs every read-only check and optionally writes an evidence bundle
+func handleVerify(args []string, token, owner, repo, environment string) {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	bundle := fs.String("bundle", "", "Write an evidence bundle (zip) to this path")
+	manifest := fs.String("manifest", "", "Sync manifest to check live state against (defaults to the target's latest in manifests/)")
+	publicKey := fs.String("manifest-public-key", config.Manifest.PublicKey, "Ed25519 public key (PEM) the manifest must be signed with")
+	fs.Parse(args)
+
+	fmt.Println("🔎 Verify Mode: read-only compliance checks")
+	if environment != "" {
+		fmt.Printf("🎯 Target: Environment '%s' in %s/%s\n", environment, owner, repo)
+	} else {
+		fmt.Printf("🎯 Target: Repository %s/%s\n", owner, repo)
+	}
+
+	local, _, err := LoadDesiredVariables(token, owner, repo, environment)
+	if err != nil {
+		fatal(exitValidation, "Error: %v", err)
+	}
+	remote, err := FetchGitHubVariables(token, owner, repo, environment)
+	if err != nil {
+		fmt.Printf("❌ Error fetching GitHub variables: %v\n", err)
+		exit(1)
+	}
+
+	ctx := &verifyContext{token: token, owner: owner, repo: repo, environment: environment, local: local, remote: remote,
+		manifest: *manifest, manifestPublicKey: *publicKey}
+	checks := []verifyCheck{}
+	evidence := map[string]interface{}{}
+	failed := 0
+	for _, run := range verifyChecks {
+		check, data := run(ctx)
+		checks = append(checks, check)
+		if data != nil && check.File != "" {
+			evidence[check.File] = data
+		}
+
+		icon := "✅"
+		switch check.Status {
+		case CheckFail:
+			icon = "❌"
+			failed++
+		case CheckSkipped:
+			icon = "⏭️ "
+		}
+		fmt.Printf("%s %-22s %s\n", icon, check.Name, check.Summary)
+	}
+
+	if *bundle != "" {
+		err = writeVerifyBundle(*bundle, ctx, checks, evidence)
+		if err != nil {
+			fmt.Printf("❌ Error writing evidence bundle: %v\n", err)
+			exit(1)
+		}
+		fmt.Printf("📦 Evidence bundle saved: %s\n", *bundle)
+	}
+
+	if failed > 0 {
+		fmt.Printf("\n❌ Verification failed: %d check(s) failed\n", failed)
+		exit(1)
+	}
+	fmt.Println("\n✅ Verification passed")
+}
+
+// checkDrift compares the desired variables with GitHub (values recorded only as hashes)
+func checkDrift(ctx *verifyContext) (verifyCheck, interface{}) {
+	diff := CompareSets(ctx.local, ctx.remote)
+
+	type hashed struct {
+		Name       string `json:"name"`
+		LocalHash  string `json:"local_hash,omitempty"`
+		RemoteHash string `json:"remote_hash,omitempty"`
+	}
+	evidence := map[string]interface{}{
+		"unchanged": len(diff.Unchanged),
+	}
+	missing := []hashed{}
+	for _, v := range diff.New {
+		missing = append(missing, hashed{Name: v.Name, LocalHash: hashValue(v.Value)})
+	}
+	changed := []hashed{}
+	for _, c := range diff.Updated {
+		changed = append(changed, hashed{Name: c.Name, LocalHash: hashValue(c.NewValue), RemoteHash: hashValue(c.OldValue)})
+	}
+	extra := []hashed{}
+	for _, v := range diff.Deleted {
+		extra = append(extra, hashed{Name: v.Name, RemoteHash: hashValue(v.Value)})
+	}
+	evidence["missing_in_github"] = missing
+	evidence["different_in_github"] = changed
+	evidence["only_in_github"] = extra
+
+	check := verifyCheck{Name: "Drift", File: "drift.json"}
+	if len(diff.New) > 0 || len(diff.Updated) > 0 {
+		check.Status = CheckFail
+	} else {
+		check.Status = CheckPass
+	}
+	check.Summary = fmt.Sprintf("%d missing, %d different, %d only in GitHub, %d matching",
+		len(diff.New), len(diff.Updated), len(diff.Deleted), len(diff.Unchanged))
+	return check, evidence
+}
+
+// checkWorkflowReferences checks that every vars.NAME used by the repository's workflows is defined
+func checkWorkflowReferences(ctx *verifyContext) (verifyCheck, interface{}) {
+	check := verifyCheck{Name: "Workflow references", File: "workflow-references.json"}
+
+	var entries []struct {
+		Name string `json:"name"`
+		Path string `json:"path"`
+		Type string `json:"type"`
+	}
+	err := githubGetJSON(ctx.token, fmt.Sprintf("%s/repos/%s/%s/contents/.github/workflows", githubAPIURL, ctx.owner, ctx.repo), &entries)
+	if err != nil {
+		check.Status = CheckSkipped
+		check.Summary = "could not list .github/workflows: " + err.Error()
+		return check, nil
+	}
+
+	references := map[string][]string{}
+	for _, entry := range entries {
+		if entry.Type != "file" || !(strings.HasSuffix(entry.Name, ".yml") || strings.HasSuffix(entry.Name, ".yaml")) {
+			continue
+		}
+		content, err := fetchRepoFile(ctx.token, ctx.owner, ctx.repo, entry.Path, "")
+		if err != nil {
+			check.Status = CheckFail
+			check.Summary = err.Error()
+			return check, nil
+		}
+		for _, match := range workflowVarPattern.FindAllStringSubmatch(string(content), -1) {
+			name := strings.ToUpper(match[1] + match[2])
+			if !containsString(references[name], entry.Path) {
+				references[name] = append(references[name], entry.Path)
+			}
+		}
+	}
+
+	// Variables visible to workflows: this target, plus repo-level and org-level ones
+	defined := map[string]bool{}
+	for _, v := range ctx.remote {
+		defined[strings.ToUpper(v.Name)] = true
+	}
+	if ctx.environment != "" {
+		if repoVars, err := FetchGitHubVariables(ctx.token, ctx.owner, ctx.repo, ""); err == nil {
+			for _, v := range repoVars {
+				defined[strings.ToUpper(v.Name)] = true
+			}
+		}
+	}
+	var orgVars GitHubVariablesResponse
+	if err := githubGetJSON(ctx.token, fmt.Sprintf("%s/repos/%s/%s/actions/organization-variables?per_page=100", githubAPIURL, ctx.owner, ctx.repo), &orgVars); err == nil {
+		for _, v := range orgVars.Variables {
+			defined[strings.ToUpper(v.Name)] = true
+		}
+	}
+
+	undefined := []string{}
+	for name := range references {
+		if !defined[name] {
+			undefined = append(undefined, name)
+		}
+	}
+	sort.Strings(undefined)
+
+	if len(undefined) > 0 {
+		check.Status = CheckFail
+		check.Summary = fmt.Sprintf("%d referenced variable(s) not defined: %s", len(undefined), strings.Join(undefined, ", "))
+	} else {
+		check.Status = CheckPass
+		check.Summary = fmt.Sprintf("all %d referenced variable(s) are defined", len(references))
+	}
+	return check, map[string]interface{}{
+		"references": references,
+		"undefined":  undefined,
+	}
+}
+
+// checkPolicy evaluates policy rules against the desired state
+func checkPolicy(ctx *verifyContext) (verifyCheck, interface{}) {
+	check := verifyCheck{Name: "Policy", File: "policy.json"}
+	if policy == nil {
+		check.Status = CheckSkipped
+		check.Summary = "no policy configured"
+		return check, nil
+	}
+
+	violations := policy.Evaluate(ctx.environment, ctx.local, ctx.local)
+	if len(violations) > 0 {
+		messages := []string{}
+		for _, v := range violations {
+			messages = append(messages, v.Message)
+		}
+		check.Status = CheckFail
+		check.Summary = fmt.Sprintf("%d violation(s): %s", len(violations), strings.Join(messages, "; "))
+	} else {
+		check.Status = CheckPass
+		check.Summary = fmt.Sprintf("all %d rule(s) pass", len(policy.Rules))
+	}
+	return check, map[string]interface{}{
+		"rules":      policy.Rules,
+		"violations": violations,
+	}
+}
+
+// checkSchema checks the target's required variables against the desired and remote state
+func checkSchema(ctx *verifyContext) (verifyCheck, interface{}) {
+	check := verifyCheck{Name: "Schema", File: "schema.json"}
+	if schema == nil {
+		check.Status = CheckSkipped
+		check.Summary = "no schema configured"
+		return check, nil
+	}
+
+	problems := schema.Check(ctx.environment, ctx.local, ctx.remote)
+	if errors := schemaErrors(problems); errors > 0 {
+		check.Status = CheckFail
+		check.Summary = fmt.Sprintf("%d of %d required variable(s) missing or invalid", errors, len(schema.requiredFor(ctx.environment)))
+	} else {
+		check.Status = CheckPass
+		check.Summary = fmt.Sprintf("all %d required variable(s) present", len(schema.requiredFor(ctx.environment)))
+	}
+	return check, map[string]interface{}{
+		"required": schema.requiredFor(ctx.environment),
+		"problems": problems,
+	}
+}
+
+// checkAuditLog cross-checks remote values against the audit trail: every variable's
+// current value must match the last successful change recorded for it
+func checkAuditLog(ctx *verifyContext) (verifyCheck, interface{}) {
+	check := verifyCheck{Name: "Audit log", File: "audit-log.json"}
+	path := auditLogPath()
+	if path == "" {
+		check.Status = CheckSkipped
+		check.Summary = "no audit log configured"
+		return check, nil
+	}
+
+	entries, err := ReadAuditLog(path)
+	if err != nil {
+		check.Status = CheckFail
+		check.Summary = fmt.Sprintf("failed to read %s: %v", path, err)
+		return check, nil
+	}
+
+	// Last successful entry per variable for this target
+	latest := map[string]AuditEntry{}
+	for _, e := range entries {
+		if e.Owner == ctx.owner && e.Repo == ctx.repo && e.Environment == ctx.environment && e.Result == "success" {
+			latest[e.Name] = e
+		}
+	}
+
+	type finding struct {
+		Name         string `json:"name"`
+		RemoteHash   string `json:"remote_hash,omitempty"`
+		RecordedHash string `json:"recorded_hash,omitempty"`
+		LastAction   string `json:"last_action,omitempty"`
+		LastActor    string `json:"last_actor,omitempty"`
+	}
+	mismatched := []finding{}
+	unrecorded := []string{}
+	remoteNames := map[string]bool{}
+	for _, v := range ctx.remote {
+		remoteNames[v.Name] = true
+		e, ok := latest[v.Name]
+		if !ok {
+			unrecorded = append(unrecorded, v.Name)
+			continue
+		}
+		if e.Action == AuditDelete || e.NewHash != hashValue(v.Value) {
+			mismatched = append(mismatched, finding{Name: v.Name, RemoteHash: hashValue(v.Value),
+				RecordedHash: e.NewHash, LastAction: e.Action, LastActor: e.Actor})
+		}
+	}
+	// Recorded as present but gone from GitHub
+	for name, e := range latest {
+		if !remoteNames[name] && e.Action != AuditDelete {
+			mismatched = append(mismatched, finding{Name: name, RecordedHash: e.NewHash, LastAction: e.Action, LastActor: e.Actor})
+		}
+	}
+	sort.Slice(mismatched, func(i, j int) bool { return mismatched[i].Name < mismatched[j].Name })
+	sort.Strings(unrecorded)
+
+	evidence := map[string]interface{}{
+		"audit_log":           path,
+		"entries":             len(entries),
+		"changed_outside_log": mismatched,
+		"no_audit_record":     unrecorded,
+	}
+	if len(mismatched) > 0 {
+		check.Status = CheckFail
+		check.Summary = fmt.Sprintf("%d variable(s) changed outside the audit trail", len(mismatched))
+	} else {
+		check.Status = CheckPass
+		check.Summary = fmt.Sprintf("remote values match the audit trail (%d without records)", len(unrecorded))
+	}
+	return check, evidence
+}
+
+// checkManifest checks live state against the latest signed sync manifest: the signature must
+// be valid and every variable's value must hash to what was applied
+func checkManifest(ctx *verifyContext) (verifyCheck, interface{}) {
+	check := verifyCheck{Name: "Sync manifest", File: "sync-manifest.json"}
+	path := ctx.manifest
+	if path == "" {
+		var err error
+		if path, err = findLatestManifest(ctx.owner, ctx.repo, ctx.environment); err != nil {
+			check.Status = CheckFail
+			check.Summary = fmt.Sprintf("failed to list %s: %v", manifestDir, err)
+			return check, nil
+		}
+	}
+	if path == "" {
+		check.Status = CheckSkipped
+		check.Summary = "no sync manifest for this target"
+		return check, nil
+	}
+	if ctx.manifestPublicKey == "" {
+		check.Status = CheckFail
+		check.Summary = fmt.Sprintf("%s can't be checked without --manifest-public-key (or manifest.public_key)", path)
+		return check, nil
+	}
+	key, err := loadManifestPublicKey(ctx.manifestPublicKey)
+	if err != nil {
+		check.Status = CheckFail
+		check.Summary = err.Error()
+		return check, nil
+	}
+	manifest, err := readSyncManifest(path, key)
+	if err != nil {
+		check.Status = CheckFail
+		check.Summary = err.Error()
+		return check, nil
+	}
+	if manifest.Owner != ctx.owner || manifest.Repo != ctx.repo || manifest.Environment != ctx.environment {
+		check.Status = CheckFail
+		check.Summary = fmt.Sprintf("%s is for %s, not this target", path, targetName(manifest.Owner, manifest.Repo, manifest.Environment))
+		return check, nil
+	}
+
+	live, _ := withoutIgnored(ctx.remote)
+	liveEntries, liveDigest := manifestVariables(live)
+	applied := map[string]string{}
+	for _, e := range manifest.Variables {
+		applied[e.Name] = e.Hash
+	}
+	changed, extra := []string{}, []string{}
+	for _, e := range liveEntries {
+		hash, ok := applied[e.Name]
+		switch {
+		case !ok:
+			extra = append(extra, e.Name)
+		case hash != e.Hash:
+			changed = append(changed, e.Name)
+		}
+		delete(applied, e.Name)
+	}
+	missing := make([]string, 0, len(applied))
+	for name := range applied {
+		missing = append(missing, name)
+	}
+	sort.Strings(missing)
+
+	evidence := map[string]interface{}{
+		"manifest":        path,
+		"key_id":          manifest.KeyID,
+		"applied_by":      manifest.Actor,
+		"applied_at":      manifest.Timestamp,
+		"run_id":          manifest.RunID,
+		"digest":          manifest.Digest,
+		"live_digest":     liveDigest,
+		"changed":         changed,
+		"missing":         missing,
+		"not_in_manifest": extra,
+	}
+	if liveDigest != manifest.Digest {
+		check.Status = CheckFail
+		check.Summary = fmt.Sprintf("live state differs from the manifest of %s: %d changed, %d missing, %d added",
+			manifest.Timestamp.Local().Format("2006-01-02 15:04"), len(changed), len(missing), len(extra))
+	} else {
+		check.Status = CheckPass
+		check.Summary = fmt.Sprintf("live state matches the signed manifest of %s by %s (%d variables)",
+			manifest.Timestamp.Local().Format("2006-01-02 15:04"), manifest.Actor, len(manifest.Variables))
+	}
+	return check, evidence
+}
+
+// writeVerifyBundle writes the checks, evidence files, a Markdown summary, and a
+// manifest of SHA-256 checksums into a zip archive
+func writeVerifyBundle(path string, ctx *verifyContext, checks []verifyCheck, evidence map[string]interface{}) error {
+	files := map[string][]byte{}
+
+	for name, data := range evidence {
+		encoded, err := json.MarshalIndent(data, "", "  ")
+		if err != nil {
+			return err
+		}
+		files[name] = encoded
+	}
+
+	encodedChecks, err := json.MarshalIndent(checks, "", "  ")
+	if err != nil {
+		return err
+	}
+	files["checks.json"] = encodedChecks
+
+	target := ctx.owner + "/" + ctx.repo
+	if ctx.environment != "" {
+		target += " (environment " + ctx.environment + ")"
+	}
+	var summary strings.Builder
+	summary.WriteString("# Variable Verification Report\n\n")
+	summary.WriteString(fmt.Sprintf("- **Target:** %s\n", target))
+	summary.WriteString(fmt.Sprintf("- **Generated:** %s\n\n", time.Now().UTC().Format(time.RFC3339)))
+	summary.WriteString("| Check | Status | Summary |\n|-------|--------|---------|\n")
+	for _, c := range checks {
+		summary.WriteString(fmt.Sprintf("| %s | %s | %s |\n", c.Name, c.Status, strings.ReplaceAll(c.Summary, "|", "\\|")))
+	}
+	files["summary.md"] = []byte(summary.String())
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	checksums := map[string]string{}
+	for _, name := range names {
+		sum := sha256.Sum256(files[name])
+		checksums[name] = hex.EncodeToString(sum[:])
+	}
+	manifest, err := json.MarshalIndent(map[string]interface{}{
+		"target":      target,
+		"generated":   time.Now().UTC().Format(time.RFC3339),
+		"sha256":      checksums,
+		"checks_pass": countChecks(checks, CheckPass),
+		"checks_fail": countChecks(checks, CheckFail),
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	archive := zip.NewWriter(out)
+	for _, name := range append(names, "manifest.json") {
+		data := files[name]
+		if name == "manifest.json" {
+			data = manifest
+		}
+		w, err := archive.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+func countChecks(checks []verifyCheck, status string) int {
+	n := 0
+	for _, c := range checks {
+		if c.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"runtime"
+	"runtime/debug"
+)
+
+// Build provenance, set at release time with
+// -ldflags "-X main.version=v1.2.3 -X main.commit=abc1234 -X main.buildDate=2024-01-02T15:04:05Z".
+// Builds without them fall back to what the Go toolchain recorded (module version and VCS stamp).
+var (
+	version   = ""
+	commit    = ""
+	buildDate = ""
+)
+
+// buildVersion is the version, commit, and build date of this binary, filled from the
+// ldflags or, failing those, the build info
+type buildVersion struct {
+	Version   string
+	Commit    string
+	BuildDate string
+	Modified  bool // Built from a working tree with uncommitted changes
+}
+
+// currentBuild resolves the provenance of the running binary
+func currentBuild() buildVersion {
+	build := buildVersion{Version: version, Commit: commit, BuildDate: buildDate}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if build.Version == "" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			build.Version = info.Main.Version
+		}
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if build.Commit == "" {
+					build.Commit = setting.Value
+				}
+			case "vcs.time":
+				if build.BuildDate == "" {
+					build.BuildDate = setting.Value
+				}
+			case "vcs.modified":
+				build.Modified = commit == "" && setting.Value == "true"
+			}
+		}
+	}
+	if build.Version == "" {
+		build.Version = "dev"
+	}
+	if len(build.Commit) > 12 {
+		build.Commit = build.Commit[:12]
+	}
+	if build.Modified {
+		build.Commit += "-dirty"
+	}
+	return build
+}
+
+// printVersion writes the --version / version output
+func printVersion() {
+	build := currentBuild()
+	fmt.Printf("sync-github-variable %s\n", build.Version)
+	fmt.Printf("  commit:  %s\n", orUnknown(build.Commit))
+	fmt.Printf("  built:   %s\n", orUnknown(build.BuildDate))
+	fmt.Printf("  go:      %s %s/%s\n", runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
+
+// userAgent identifies this client in GitHub's and GHES's request logs, e.g.
+// "sync-github-variable/v1.2.3 (abc1234; go1.21.5; linux/amd64)"
+func userAgent() string {
+	build := currentBuild()
+	commit := build.Commit
+	if commit == "" {
+		commit = "unknown"
+	}
+	return fmt.Sprintf("sync-github-variable/%s (%s; %s; %s/%s)", build.Version, commit, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}
+
+// userAgentTransport sets the User-Agent on requests to GitHub (the API and the device
+// flow's web host); other services keep Go's default
+type userAgentTransport struct {
+	base  http.RoundTripper
+	agent string
+}
+
+func (t *userAgentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	web, _ := url.Parse(githubWebURL())
+	if isGitHubAPIRequest(req) || (web != nil && req.URL.Host == web.Host) {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", t.agent)
+	}
+	return t.base.RoundTrip(req)
+}
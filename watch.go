@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// watchStatus is the state of the watch loop served on the health endpoint
+type watchStatus struct {
+	mu          sync.Mutex
+	Target      string      `json:"target"`
+	Interval    string      `json:"interval"`
+	Runs        int         `json:"runs"`
+	LastRun     time.Time   `json:"last_run,omitempty"`
+	LastSuccess time.Time   `json:"last_success,omitempty"`
+	LastError   string      `json:"last_error,omitempty"`
+	Drift       *SyncReport `json:"drift,omitempty"` // Differences found by the last successful check
+	Remediated  int         `json:"remediated"`      // Variables written by --remediate since start
+}
+
+// handleWatch re-runs the diff on an interval, reporting (and optionally fixing) drift
+func handleWatch(args []string, token, owner, repo, environment string) {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	every := fs.Duration("every", 15*time.Minute, "Interval between drift checks")
+	remediate := fs.Bool("remediate", false, "Create and update drifted variables automatically (never deletes)")
+	listen := fs.String("listen", "", "Serve GET /healthz on this address (e.g. :8080)")
+	fs.Parse(args)
+
+	if *every < time.Minute {
+		fatal(exitValidation, "--every must be at least 1m")
+	}
+
+	status := &watchStatus{Target: newSyncReport(owner, repo, environment, "diff").Target(), Interval: every.String()}
+	if *listen != "" {
+		wrapTransport(func(base http.RoundTripper) http.RoundTripper {
+			return &metricsTransport{base: base}
+		})
+		go serveWatchHealth(*listen, status, *every)
+		fmt.Printf("🩺 Health and metrics on http://%s/healthz and /metrics\n", *listen)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	fmt.Printf("👀 Watching %s every %v", status.Target, *every)
+	if *remediate {
+		fmt.Print(" (auto-remediation on)")
+	}
+	fmt.Println()
+
+	lastDrift := ""
+	for {
+		drift, err := watchCheck(token, owner, repo, environment, *remediate, status)
+		recordCheckMetrics(status.Target, drift, err)
+
+		status.mu.Lock()
+		status.Runs++
+		status.LastRun = time.Now()
+		if err != nil {
+			status.LastError = err.Error()
+		} else {
+			status.LastError = ""
+			status.LastSuccess = status.LastRun
+			status.Drift = drift
+		}
+		status.mu.Unlock()
+
+		if err != nil {
+			watchLog("❌ Check failed: %v", err)
+		} else if drift.HasDrift() {
+			// Notify only when the drift changes, not on every tick while it persists
+			if key := driftKey(drift); key != lastDrift {
+				sendNotifications(drift)
+				lastDrift = key
+			}
+		} else {
+			lastDrift = ""
+		}
+
+		select {
+		case <-ctx.Done():
+			watchLog("👋 Stopping watch")
+			exit(0)
+		case <-time.After(*every):
+		}
+	}
+}
+
+// watchCheck runs one diff and, with remediate set, writes the drifted variables back.
+// It returns the drift that remains afterwards.
+func watchCheck(token, owner, repo, environment string, remediate bool, status *watchStatus) (*SyncReport, error) {
+	local, _, err := LoadDesiredVariables(token, owner, repo, environment)
+	if err != nil {
+		return nil, err
+	}
+	remote, err := FetchGitHubVariables(token, owner, repo, environment)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := CompareSets(local, remote)
+	drift := driftReport(owner, repo, environment, diff)
+	if !drift.HasDrift() {
+		watchLog("✅ No drift (%d variables in sync)", len(diff.Unchanged))
+		return drift, nil
+	}
+	watchLog("⚠️  Drift: %d missing, %d changed, %d only in GitHub",
+		len(drift.Created), len(drift.Updated), len(drift.RemoteOnly))
+	if !remediate || len(drift.Created)+len(drift.Updated) == 0 {
+		return drift, nil
+	}
+	if missing, ok := checkApproval(token, owner, repo, environment, syncPlan(diff, nil), approvalCodes()).(*approvalError); ok {
+		watchLog("🔑 Approval request (for approve --request): %s", missing.Request)
+		return drift, fmt.Errorf("not remediating: %w", missing)
+	}
+
+	if !*noBackup {
+		backupFile, err := BackupGitHubVariables(token, owner, repo, environment)
+		if err != nil {
+			return drift, fmt.Errorf("not remediating, backup failed: %w", err)
+		}
+		watchLog("💾 Backup saved: %s", backupFile)
+	}
+
+	report := applyDiff(newRESTClient(token), newSyncReport(owner, repo, environment, "sync"), diff, watchLog, nil)
+	watchLog("🔧 Remediated: created %d, updated %d, failed %d", len(report.Created), len(report.Updated), len(report.Failed))
+	recordSyncMetrics(report)
+	sendNotifications(report)
+
+	status.mu.Lock()
+	status.Remediated += len(report.Created) + len(report.Updated)
+	status.mu.Unlock()
+
+	// Only what couldn't be fixed (plus remote-only variables, which are never deleted) is still drift
+	remaining := newSyncReport(owner, repo, environment, "diff")
+	remaining.RemoteOnly = drift.RemoteOnly
+	for _, f := range report.Failed {
+		if containsString(drift.Created, f.Name) {
+			remaining.Created = append(remaining.Created, f.Name)
+		} else {
+			remaining.Updated = append(remaining.Updated, f.Name)
+		}
+	}
+	return remaining, nil
+}
+
+// driftKey identifies a drift state so unchanged drift isn't re-notified
+func driftKey(r *SyncReport) string {
+	parts := []string{}
+	for _, list := range [][]string{r.Created, r.Updated, r.RemoteOnly} {
+		sorted := append([]string{}, list...)
+		sort.Strings(sorted)
+		parts = append(parts, strings.Join(sorted, ","))
+	}
+	return strings.Join(parts, "|")
+}
+
+// serveWatchHealth serves the watch status and Prometheus metrics. /healthz answers 503 when the last
+// check failed or no check has succeeded for two intervals, so orchestrators can restart a stuck watcher.
+func serveWatchHealth(addr string, status *watchStatus, every time.Duration) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		status.mu.Lock()
+		defer status.mu.Unlock()
+
+		healthy := status.LastError == "" &&
+			(status.Runs == 0 || time.Since(status.LastSuccess) < 2*every)
+		w.Header().Set("Content-Type", "application/json")
+		if !healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(status)
+	})
+
+	err := http.ListenAndServe(addr, mux)
+	if err != nil {
+		watchLog("❌ Health endpoint: %v", err)
+	}
+}
+
+// watchLog prints a timestamped line
+func watchLog(format string, args ...interface{}) {
+	fmt.Printf("[%s] %s\n", time.Now().Format("2006-01-02 15:04:05"), fmt.Sprintf(format, args...))
+}
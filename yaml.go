@@ -0,0 +1,456 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// yamlLine is a single meaningful line of a YAML document
+type yamlLine struct {
+	indent int
+	text   string
+	num    int
+}
+
+// yamlParser parses the subset of YAML used by values and config files:
+// block mappings, block sequences, flow lists/maps of scalars, quoted and
+// plain scalars, and literal (|) / folded (>) block scalars
+type yamlParser struct {
+	lines      []yamlLine
+	raw        []string
+	pos        int
+	keepText   bool // Plain scalars stay the text they were written as
+	numberText bool // Plain numbers become json.Number of the text they were written as
+}
+
+// ParseYAML parses a YAML document into maps, slices, and scalar values
+func ParseYAML(data []byte) (interface{}, error) {
+	return parseYAMLDocument(data, false, false)
+}
+
+// ParseYAMLText parses a YAML document like ParseYAML, but keeps plain numbers and booleans
+// as the text they were written as. Variable values must reach GitHub unchanged: 1.10 is a
+// version, not 1.1, and 007 keeps its zeros.
+func ParseYAMLText(data []byte) (interface{}, error) {
+	return parseYAMLDocument(data, true, false)
+}
+
+// parseYAMLNumbers parses a YAML document like ParseYAML, but plain numbers become json.Number
+// of their text, so a file decoded through JSON gets 1.10 as written in its string fields
+// and as a number in its numeric ones
+func parseYAMLNumbers(data []byte) (interface{}, error) {
+	return parseYAMLDocument(data, false, true)
+}
+
+func parseYAMLDocument(data []byte, keepText, numberText bool) (interface{}, error) {
+	p := &yamlParser{raw: strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n"), keepText: keepText, numberText: numberText}
+	for i, line := range p.raw {
+		trimmed := strings.TrimSpace(stripYAMLComment(line))
+		if trimmed == "" || trimmed == "---" || trimmed == "..." {
+			continue
+		}
+		if strings.HasPrefix(line, "\t") {
+			return nil, fmt.Errorf("line %d: tabs are not allowed for indentation", i+1)
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		p.lines = append(p.lines, yamlLine{indent: indent, text: trimmed, num: i + 1})
+	}
+
+	if len(p.lines) == 0 {
+		return map[string]interface{}{}, nil
+	}
+
+	value, err := p.parseBlock(p.lines[0].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, fmt.Errorf("line %d: unexpected content", p.lines[p.pos].num)
+	}
+	return value, nil
+}
+
+// parseBlock parses a mapping or sequence whose entries start at indent
+func (p *yamlParser) parseBlock(indent int) (interface{}, error) {
+	if strings.HasPrefix(p.lines[p.pos].text, "- ") || p.lines[p.pos].text == "-" {
+		return p.parseSequence(indent)
+	}
+	return p.parseMapping(indent)
+}
+
+func (p *yamlParser) parseSequence(indent int) (interface{}, error) {
+	items := []interface{}{}
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", line.num)
+		}
+		if !strings.HasPrefix(line.text, "- ") && line.text != "-" {
+			break
+		}
+
+		rest := strings.TrimSpace(strings.TrimPrefix(line.text, "-"))
+		if rest == "" {
+			// Nested block on the following lines
+			p.pos++
+			if p.pos < len(p.lines) && p.lines[p.pos].indent > indent {
+				value, err := p.parseBlock(p.lines[p.pos].indent)
+				if err != nil {
+					return nil, err
+				}
+				items = append(items, value)
+			} else {
+				items = append(items, nil)
+			}
+			continue
+		}
+
+		if key, _, ok := splitYAMLKey(rest); ok && key != "" {
+			// "- key: value" starts a mapping indented past the dash
+			itemIndent := indent + (len(line.text) - len(rest))
+			p.lines[p.pos] = yamlLine{indent: itemIndent, text: rest, num: line.num}
+			value, err := p.parseMapping(itemIndent)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, value)
+			continue
+		}
+
+		p.pos++
+		value, err := p.parseScalarValue(rest, indent, line.num)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, value)
+	}
+	return items, nil
+}
+
+func (p *yamlParser) parseMapping(indent int) (interface{}, error) {
+	result := map[string]interface{}{}
+	for p.pos < len(p.lines) {
+		line := p.lines[p.pos]
+		if line.indent < indent {
+			break
+		}
+		if line.indent > indent {
+			return nil, fmt.Errorf("line %d: unexpected indentation", line.num)
+		}
+
+		key, rest, ok := splitYAMLKey(line.text)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected 'key: value'", line.num)
+		}
+		if _, exists := result[key]; exists {
+			return nil, fmt.Errorf("line %d: duplicate key %q", line.num, key)
+		}
+		p.pos++
+
+		if rest == "" {
+			// Value is a nested block (or null when nothing follows)
+			if p.pos < len(p.lines) && (p.lines[p.pos].indent > indent ||
+				(p.lines[p.pos].indent == indent && strings.HasPrefix(p.lines[p.pos].text, "- "))) {
+				value, err := p.parseBlock(p.lines[p.pos].indent)
+				if err != nil {
+					return nil, err
+				}
+				result[key] = value
+			} else {
+				result[key] = nil
+			}
+			continue
+		}
+
+		value, err := p.parseScalarValue(rest, indent, line.num)
+		if err != nil {
+			return nil, err
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+// parseScalarValue parses an inline value, consuming following lines for block scalars
+func (p *yamlParser) parseScalarValue(text string, indent, num int) (interface{}, error) {
+	if strings.HasPrefix(text, "|") || strings.HasPrefix(text, ">") {
+		return p.parseBlockScalar(text, indent, num)
+	}
+	if strings.HasPrefix(text, "[") {
+		return p.parseFlowList(text, num)
+	}
+	if strings.HasPrefix(text, "{") {
+		return p.parseFlowMap(text, num)
+	}
+	return p.parseScalar(text, num)
+}
+
+// parseScalar parses a plain or quoted scalar, keeping plain numbers and booleans as text as
+// the parser is set to
+func (p *yamlParser) parseScalar(text string, num int) (interface{}, error) {
+	value, err := parseYAMLScalar(text, num)
+	switch value.(type) {
+	case bool, int, float64:
+		if p.keepText {
+			return text, nil
+		}
+	}
+	switch value.(type) {
+	case int, float64:
+		if p.numberText && json.Valid([]byte(text)) {
+			return json.Number(text), nil
+		}
+	}
+	return value, err
+}
+
+// parseBlockScalar reads a literal (|) or folded (>) block scalar from the raw lines
+func (p *yamlParser) parseBlockScalar(header string, indent, num int) (interface{}, error) {
+	folded := header[0] == '>'
+	chomp := ""
+	if strings.Contains(header, "-") {
+		chomp = "strip"
+	} else if strings.Contains(header, "+") {
+		chomp = "keep"
+	}
+
+	// Block content is every raw line after the header that is blank or indented past the parent
+	lines := []string{}
+	blockIndent := -1
+	i := num // raw index of the line after the header
+	for ; i < len(p.raw); i++ {
+		raw := p.raw[i]
+		if strings.TrimSpace(raw) == "" {
+			lines = append(lines, "")
+			continue
+		}
+		lineIndent := len(raw) - len(strings.TrimLeft(raw, " "))
+		if lineIndent <= indent {
+			break
+		}
+		if blockIndent < 0 {
+			blockIndent = lineIndent
+		}
+		if lineIndent < blockIndent {
+			break
+		}
+		lines = append(lines, raw[blockIndent:])
+	}
+
+	// Skip the consumed lines in the pre-split list
+	for p.pos < len(p.lines) && p.lines[p.pos].num <= i {
+		p.pos++
+	}
+
+	// Trailing blank lines belong to chomping, not content
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+
+	var value string
+	if folded {
+		var b strings.Builder
+		for idx, line := range lines {
+			if idx > 0 {
+				if line == "" || lines[idx-1] == "" {
+					b.WriteString("\n")
+				} else {
+					b.WriteString(" ")
+				}
+			}
+			b.WriteString(line)
+		}
+		value = b.String()
+	} else {
+		value = strings.Join(lines, "\n")
+	}
+
+	switch chomp {
+	case "strip":
+	case "keep":
+		value += "\n" + strings.Repeat("\n", trailing)
+	default:
+		if len(lines) > 0 {
+			value += "\n"
+		}
+	}
+	return value, nil
+}
+
+// splitYAMLKey splits "key: value" into its parts
+func splitYAMLKey(text string) (string, string, bool) {
+	if strings.HasPrefix(text, "\"") || strings.HasPrefix(text, "'") {
+		quote := text[0]
+		end := strings.IndexByte(text[1:], quote)
+		if end < 0 {
+			return "", "", false
+		}
+		key := text[1 : end+1]
+		rest := strings.TrimSpace(text[end+2:])
+		if !strings.HasPrefix(rest, ":") {
+			return "", "", false
+		}
+		return key, strings.TrimSpace(rest[1:]), true
+	}
+
+	idx := strings.Index(text, ": ")
+	if idx < 0 {
+		if strings.HasSuffix(text, ":") {
+			idx = len(text) - 1
+		} else {
+			return "", "", false
+		}
+	}
+	key := strings.TrimSpace(text[:idx])
+	if key == "" || strings.ContainsAny(key, "[]{}") {
+		return "", "", false
+	}
+	return key, strings.TrimSpace(text[idx+1:]), true
+}
+
+// stripYAMLComment removes a trailing "# comment" that is not inside quotes
+func stripYAMLComment(line string) string {
+	inSingle, inDouble := false, false
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\'':
+			if !inDouble {
+				inSingle = !inSingle
+			}
+		case '"':
+			if !inSingle && (i == 0 || line[i-1] != '\\') {
+				inDouble = !inDouble
+			}
+		case '#':
+			if !inSingle && !inDouble && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t') {
+				return line[:i]
+			}
+		}
+	}
+	return line
+}
+
+// parseYAMLScalar converts a plain or quoted scalar to a Go value
+func parseYAMLScalar(text string, num int) (interface{}, error) {
+	if strings.HasPrefix(text, "\"") {
+		if len(text) < 2 || !strings.HasSuffix(text, "\"") {
+			return nil, fmt.Errorf("line %d: unterminated double-quoted string", num)
+		}
+		value, err := strconv.Unquote(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid double-quoted string: %w", num, err)
+		}
+		return value, nil
+	}
+	if strings.HasPrefix(text, "'") {
+		if len(text) < 2 || !strings.HasSuffix(text, "'") {
+			return nil, fmt.Errorf("line %d: unterminated single-quoted string", num)
+		}
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	}
+
+	switch text {
+	case "~", "null", "Null", "NULL":
+		return nil, nil
+	case "true", "True", "TRUE":
+		return true, nil
+	case "false", "False", "FALSE":
+		return false, nil
+	}
+	if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return int(i), nil
+	}
+	if f, err := strconv.ParseFloat(text, 64); err == nil && !strings.ContainsAny(text, "xXnN") {
+		return f, nil
+	}
+	return text, nil
+}
+
+// splitYAMLFlow splits the inside of a flow collection on top-level commas
+func splitYAMLFlow(inner string) []string {
+	parts := []string{}
+	depth := 0
+	inSingle, inDouble := false, false
+	start := 0
+	for i := 0; i < len(inner); i++ {
+		switch inner[i] {
+		case '\'':
+			if !inDouble {
+				inSingle = !inSingle
+			}
+		case '"':
+			if !inSingle {
+				inDouble = !inDouble
+			}
+		case '[', '{':
+			if !inSingle && !inDouble {
+				depth++
+			}
+		case ']', '}':
+			if !inSingle && !inDouble {
+				depth--
+			}
+		case ',':
+			if depth == 0 && !inSingle && !inDouble {
+				parts = append(parts, strings.TrimSpace(inner[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if last := strings.TrimSpace(inner[start:]); last != "" {
+		parts = append(parts, last)
+	}
+	return parts
+}
+
+func (p *yamlParser) parseFlowList(text string, num int) (interface{}, error) {
+	if !strings.HasSuffix(text, "]") {
+		return nil, fmt.Errorf("line %d: unterminated flow sequence", num)
+	}
+	items := []interface{}{}
+	for _, part := range splitYAMLFlow(text[1 : len(text)-1]) {
+		value, err := p.parseInline(part, num)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, value)
+	}
+	return items, nil
+}
+
+func (p *yamlParser) parseFlowMap(text string, num int) (interface{}, error) {
+	if !strings.HasSuffix(text, "}") {
+		return nil, fmt.Errorf("line %d: unterminated flow mapping", num)
+	}
+	result := map[string]interface{}{}
+	for _, part := range splitYAMLFlow(text[1 : len(text)-1]) {
+		key, rest, ok := splitYAMLKey(part)
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected 'key: value' in flow mapping", num)
+		}
+		value, err := p.parseInline(rest, num)
+		if err != nil {
+			return nil, err
+		}
+		result[key] = value
+	}
+	return result, nil
+}
+
+func (p *yamlParser) parseInline(text string, num int) (interface{}, error) {
+	switch {
+	case strings.HasPrefix(text, "["):
+		return p.parseFlowList(text, num)
+	case strings.HasPrefix(text, "{"):
+		return p.parseFlowMap(text, num)
+	default:
+		return p.parseScalar(text, num)
+	}
+}